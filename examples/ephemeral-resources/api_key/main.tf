@@ -0,0 +1,19 @@
+# Mint an API key for the duration of the run and use it to configure a
+# second provider alias. The key is revoked when Terraform no longer needs
+# it and never appears in plan or state.
+ephemeral "truenas_api_key" "run" {
+  name       = "terraform-run"
+  username   = "automation"
+  expires_in = "1h"
+}
+
+provider "truenas" {
+  alias       = "automation"
+  host        = "nas.example.com"
+  auth_method = "websocket"
+
+  websocket {
+    username = "automation"
+    api_key  = ephemeral.truenas_api_key.run.key
+  }
+}
@@ -0,0 +1,13 @@
+# Generate a token that expires after five minutes and hand it to a provider
+# that talks to the TrueNAS REST API
+ephemeral "truenas_auth_token" "run" {
+  ttl = 300
+}
+
+provider "restapi" {
+  uri = "https://nas.example.com/api/v2.0"
+
+  headers = {
+    Authorization = "Token ${ephemeral.truenas_auth_token.run.token}"
+  }
+}
@@ -0,0 +1,3 @@
+output "next_backups" {
+  value = provider::truenas::cron_next_runs("0 3 * * mon-fri", timestamp(), 5)
+}
@@ -0,0 +1,8 @@
+locals {
+  db = provider::truenas::dataset_path(var.app_dataset, "db")
+}
+
+resource "truenas_dataset" "db" {
+  pool = local.db.pool
+  path = local.db.path
+}
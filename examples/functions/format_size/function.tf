@@ -0,0 +1,10 @@
+variable "quota_bytes" {
+  type    = number
+  default = 536870912000
+}
+
+resource "truenas_dataset" "share" {
+  pool  = "tank"
+  path  = "share"
+  quota = provider::truenas::format_size(var.quota_bytes) # "500GiB"
+}
@@ -0,0 +1,9 @@
+variable "mode" {
+  type    = string
+  default = "0750"
+}
+
+resource "truenas_host_path" "data" {
+  path = "/mnt/tank/data"
+  mode = provider::truenas::normalize_mode(var.mode) # "750"
+}
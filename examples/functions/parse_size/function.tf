@@ -0,0 +1,8 @@
+# Compare a configured quota against a limit
+locals {
+  quota_bytes = provider::truenas::parse_size("500GiB")
+}
+
+output "quota_fits" {
+  value = local.quota_bytes <= data.truenas_pool.main.available_bytes
+}
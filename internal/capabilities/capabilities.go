@@ -0,0 +1,222 @@
+// Package capabilities records which TrueNAS releases support each resource,
+// data source and version-dependent attribute, so that unsupported
+// configurations are reported at plan time instead of failing during apply.
+package capabilities
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Release identifies a TrueNAS release train by its major and minor version,
+// such as 24.10 or 25.04.
+type Release struct {
+	Major int
+	Minor int
+}
+
+// IsZero reports whether r is unset.
+func (r Release) IsZero() bool {
+	return r.Major == 0 && r.Minor == 0
+}
+
+// String returns the release as it is named by TrueNAS, such as "25.04".
+func (r Release) String() string {
+	return fmt.Sprintf("%d.%02d", r.Major, r.Minor)
+}
+
+// Range is the span of releases a feature is available in, both ends
+// included. A zero Min or Max leaves that end open.
+type Range struct {
+	Min Release
+	Max Release
+}
+
+// Contains reports whether v falls within the range. The zero Version, which
+// is reported before the version is detected, is always contained.
+func (r Range) Contains(v truenas.Version) bool {
+	if v.IsZero() {
+		return true
+	}
+	if !r.Min.IsZero() && !v.AtLeast(r.Min.Major, r.Min.Minor) {
+		return false
+	}
+	if !r.Max.IsZero() && v.AtLeast(r.Max.Major, r.Max.Minor+1) {
+		return false
+	}
+	return true
+}
+
+// String describes the range, such as "TrueNAS 25.04 or later", or
+// "TrueNAS 25.04" for a single release.
+func (r Range) String() string {
+	switch {
+	case !r.Min.IsZero() && r.Min == r.Max:
+		return fmt.Sprintf("TrueNAS %s", r.Min)
+	case !r.Min.IsZero() && !r.Max.IsZero():
+		return fmt.Sprintf("TrueNAS %s through %s", r.Min, r.Max)
+	case !r.Min.IsZero():
+		return fmt.Sprintf("TrueNAS %s or later", r.Min)
+	case !r.Max.IsZero():
+		return fmt.Sprintf("TrueNAS %s or earlier", r.Max)
+	default:
+		return "any TrueNAS version"
+	}
+}
+
+// Requirements declares the releases a resource or data source supports and
+// the attributes whose support is narrower than the type's.
+type Requirements struct {
+	Versions Range
+	// Attributes maps top-level attribute and block names to the releases
+	// that support them.
+	Attributes map[string]Range
+}
+
+// Releases in which features were introduced or removed.
+var (
+	// Electric Eel moved apps from Kubernetes charts to Docker Compose.
+	releaseElectricEel = Release{24, 10}
+	// Fangtooth added Incus containers (the virt namespace), registry
+	// logins for apps and secure boot for VMs, and replaced API key
+	// allowlists with per-user keys that can expire.
+	releaseFangtooth = Release{25, 4}
+)
+
+// registry declares the requirements of every resource, data source and
+// ephemeral resource, by type name. Types that work on every release have an
+// empty entry, so that the list doubles as documentation of the matrix.
+var registry = map[string]Requirements{
+	// Resources
+	"truenas_app":                   {Versions: Range{Min: releaseElectricEel}},
+	"truenas_app_registry":          {Versions: Range{Min: releaseFangtooth}},
+	"truenas_cloudsync_credentials": {},
+	"truenas_cloudsync_task":        {},
+	"truenas_cron_job":              {},
+	"truenas_dataset":               {},
+	"truenas_file":                  {},
+	"truenas_host_path":             {},
+	"truenas_snapshot":              {},
+	"truenas_virt_config":           {Versions: Range{Min: releaseFangtooth}},
+	"truenas_virt_instance":         {Versions: Range{Min: releaseFangtooth}},
+	"truenas_vm": {Attributes: map[string]Range{
+		"enable_secure_boot": {Min: releaseFangtooth},
+	}},
+	"truenas_zvol": {},
+
+	// Data sources
+	"data.truenas_cloudsync_credentials": {},
+	"data.truenas_dataset":               {},
+	"data.truenas_pool":                  {},
+	"data.truenas_snapshots":             {},
+	"data.truenas_virt_config":           {Versions: Range{Min: releaseFangtooth}},
+
+	// Ephemeral resources
+	"ephemeral.truenas_api_key": {Attributes: map[string]Range{
+		"allowlist":  {Max: releaseElectricEel},
+		"username":   {Min: releaseFangtooth},
+		"expires_in": {Min: releaseFangtooth},
+	}},
+	"ephemeral.truenas_auth_token": {Attributes: map[string]Range{
+		"single_use": {Min: releaseFangtooth},
+	}},
+}
+
+// DataSource returns the registry key of a data source type name, which
+// shares its type names with resources.
+func DataSource(typeName string) string {
+	return "data." + typeName
+}
+
+// EphemeralResource returns the registry key of an ephemeral resource type
+// name.
+func EphemeralResource(typeName string) string {
+	return "ephemeral." + typeName
+}
+
+// Lookup returns the requirements of the resource or data source key. Types
+// without an entry have no requirements.
+func Lookup(key string) Requirements {
+	return registry[key]
+}
+
+// Supported returns the keys of the resources and data sources that v
+// supports, sorted.
+func Supported(v truenas.Version) []string {
+	var keys []string
+	for key, req := range registry {
+		if req.Versions.Contains(v) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// displayName returns the name of key as written in configuration.
+func displayName(key string) string {
+	if name, ok := strings.CutPrefix(key, "data."); ok {
+		return "data source " + name
+	}
+	if name, ok := strings.CutPrefix(key, "ephemeral."); ok {
+		return "ephemeral resource " + name
+	}
+	return key
+}
+
+// Check adds an error diagnostic when v does not support key and reports
+// whether it is supported.
+func Check(key string, v truenas.Version, diags *diag.Diagnostics) bool {
+	r := Lookup(key).Versions
+	if r.Contains(v) {
+		return true
+	}
+	diags.AddError(
+		"Unsupported TrueNAS Version",
+		fmt.Sprintf("%s requires %s. Detected version: %s", displayName(key), r, v),
+	)
+	return false
+}
+
+// CheckAttributes adds an attribute error for every gated attribute of key
+// that is set in config but not supported by v.
+func CheckAttributes(key string, v truenas.Version, config tftypes.Value, diags *diag.Diagnostics) {
+	attributes := Lookup(key).Attributes
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		r := attributes[name]
+		if r.Contains(v) || !isSet(config, name) {
+			continue
+		}
+		diags.AddAttributeError(
+			path.Root(name),
+			"Unsupported TrueNAS Version",
+			fmt.Sprintf("%s of %s requires %s. Detected version: %s", name, displayName(key), r, v),
+		)
+	}
+}
+
+// isSet reports whether the top-level attribute name of config is known and
+// not null.
+func isSet(config tftypes.Value, name string) bool {
+	if config.IsNull() || !config.IsKnown() {
+		return false
+	}
+	got, _, err := tftypes.WalkAttributePath(config, tftypes.NewAttributePath().WithAttributeName(name))
+	if err != nil {
+		return false
+	}
+	value, ok := got.(tftypes.Value)
+	return ok && !value.IsNull()
+}
@@ -0,0 +1,141 @@
+package capabilities
+
+import (
+	"slices"
+	"testing"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func version(major, minor, patch int) truenas.Version {
+	return truenas.Version{Major: major, Minor: minor, Patch: patch}
+}
+
+func TestRange_Contains(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Range
+		v    truenas.Version
+		want bool
+	}{
+		{"open", Range{}, version(24, 4, 0), true},
+		{"below min", Range{Min: Release{25, 4}}, version(24, 10, 2), false},
+		{"at min", Range{Min: Release{25, 4}}, version(25, 4, 0), true},
+		{"above min", Range{Min: Release{25, 4}}, version(25, 10, 1), true},
+		{"patch of max", Range{Max: Release{25, 4}}, version(25, 4, 2), true},
+		{"above max", Range{Max: Release{25, 4}}, version(25, 10, 0), false},
+		{"within both", Range{Min: Release{24, 10}, Max: Release{25, 4}}, version(25, 4, 0), true},
+		{"undetected", Range{Min: Release{25, 4}}, truenas.Version{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Contains(tt.v); got != tt.want {
+				t.Errorf("Contains(%v) = %v, want %v", tt.v, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRange_String(t *testing.T) {
+	tests := []struct {
+		r    Range
+		want string
+	}{
+		{Range{}, "any TrueNAS version"},
+		{Range{Min: Release{25, 4}}, "TrueNAS 25.04 or later"},
+		{Range{Max: Release{24, 10}}, "TrueNAS 24.10 or earlier"},
+		{Range{Min: Release{24, 10}, Max: Release{25, 4}}, "TrueNAS 24.10 through 25.04"},
+		{Range{Min: Release{25, 4}, Max: Release{25, 4}}, "TrueNAS 25.04"},
+	}
+	for _, tt := range tests {
+		if got := tt.r.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var diags diag.Diagnostics
+	if !Check("truenas_dataset", version(24, 4, 0), &diags) {
+		t.Errorf("expected truenas_dataset to be supported: %v", diags)
+	}
+	if !Check("truenas_unknown", version(24, 4, 0), &diags) {
+		t.Errorf("expected types without an entry to be supported: %v", diags)
+	}
+	if Check("truenas_virt_instance", version(24, 10, 2), &diags) {
+		t.Fatal("expected truenas_virt_instance to be unsupported on 24.10")
+	}
+	if Check(DataSource("truenas_virt_config"), version(24, 10, 2), &diags) {
+		t.Fatal("expected data source truenas_virt_config to be unsupported on 24.10")
+	}
+
+	if Check("truenas_app_registry", version(24, 10, 2), &diags) {
+		t.Fatal("expected truenas_app_registry to be unsupported on 24.10")
+	}
+
+	if !Check("truenas_virt_config", version(25, 10, 1), &diags) {
+		t.Fatal("expected truenas_virt_config to be supported on 25.10")
+	}
+
+	if diags.ErrorsCount() != 3 {
+		t.Fatalf("expected three errors, got %v", diags)
+	}
+	want := "data source truenas_virt_config requires TrueNAS 25.04 or later. Detected version: 24.10.2.0"
+	if got := diags.Errors()[1].Detail(); got != want {
+		t.Errorf("expected detail %q, got %q", want, got)
+	}
+}
+
+func TestCheckAttributes(t *testing.T) {
+	registry["truenas_test"] = Requirements{Attributes: map[string]Range{
+		"legacy":  {Max: Release{24, 10}},
+		"modern":  {Min: Release{25, 4}},
+		"omitted": {Min: Release{25, 4}},
+	}}
+	t.Cleanup(func() { delete(registry, "truenas_test") })
+
+	config := tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"legacy":  tftypes.String,
+		"modern":  tftypes.String,
+		"omitted": tftypes.String,
+	}}, map[string]tftypes.Value{
+		"legacy":  tftypes.NewValue(tftypes.String, "x"),
+		"modern":  tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		"omitted": tftypes.NewValue(tftypes.String, nil),
+	})
+
+	var diags diag.Diagnostics
+	CheckAttributes("truenas_test", version(24, 10, 2), config, &diags)
+	if diags.ErrorsCount() != 1 {
+		t.Fatalf("expected one error on 24.10, got %v", diags)
+	}
+	d, ok := diags.Errors()[0].(diag.DiagnosticWithPath)
+	if !ok || d.Path().String() != "modern" {
+		t.Errorf("expected error at modern, got %v", diags.Errors()[0])
+	}
+
+	diags = nil
+	CheckAttributes("truenas_test", version(25, 10, 0), config, &diags)
+	if diags.ErrorsCount() != 1 {
+		t.Fatalf("expected one error on 25.10, got %v", diags)
+	}
+	d, ok = diags.Errors()[0].(diag.DiagnosticWithPath)
+	if !ok || d.Path().String() != "legacy" {
+		t.Errorf("expected error at legacy, got %v", diags.Errors()[0])
+	}
+}
+
+func TestSupported(t *testing.T) {
+	got := Supported(version(24, 10, 2))
+	if !slices.Contains(got, "truenas_app") || !slices.Contains(got, "data.truenas_pool") {
+		t.Errorf("expected apps and pools on 24.10, got %v", got)
+	}
+	if slices.Contains(got, "truenas_virt_instance") {
+		t.Errorf("expected no virt resources on 24.10, got %v", got)
+	}
+	if all := Supported(version(25, 10, 1)); len(all) != len(registry) {
+		t.Errorf("expected every type on 25.10, got %v", all)
+	}
+}
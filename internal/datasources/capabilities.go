@@ -0,0 +1,21 @@
+package datasources
+
+import (
+	"github.com/deevus/terraform-provider-truenas/internal/capabilities"
+	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+)
+
+// checkCapabilities reports whether the detected TrueNAS version supports the
+// data source typeName and its configured attributes, adding an error to resp
+// when it does not. Data sources are read during plan, so this surfaces the
+// problem before apply.
+func checkCapabilities(typeName string, s *services.TrueNASServices, req datasource.ReadRequest, resp *datasource.ReadResponse) bool {
+	key := capabilities.DataSource(typeName)
+	version := s.Version()
+	if !capabilities.Check(key, version, &resp.Diagnostics) {
+		return false
+	}
+	capabilities.CheckAttributes(key, version, req.Config.Raw, &resp.Diagnostics)
+	return !resp.Diagnostics.HasError()
+}
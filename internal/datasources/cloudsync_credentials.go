@@ -74,6 +74,10 @@ func (d *CloudSyncCredentialsDataSource) Configure(ctx context.Context, req data
 }
 
 func (d *CloudSyncCredentialsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkCapabilities("truenas_cloudsync_credentials", d.services, req, resp) {
+		return
+	}
+
 	var data CloudSyncCredentialsDataSourceModel
 
 	// Read Terraform configuration data into the model
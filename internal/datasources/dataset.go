@@ -93,6 +93,10 @@ func (d *DatasetDataSource) Configure(ctx context.Context, req datasource.Config
 }
 
 func (d *DatasetDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkCapabilities("truenas_dataset", d.services, req, resp) {
+		return
+	}
+
 	var data DatasetDataSourceModel
 
 	// Read Terraform configuration data into the model
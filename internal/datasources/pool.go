@@ -88,6 +88,10 @@ func (d *PoolDataSource) Configure(ctx context.Context, req datasource.Configure
 }
 
 func (d *PoolDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkCapabilities("truenas_pool", d.services, req, resp) {
+		return
+	}
+
 	var data PoolDataSourceModel
 
 	// Read Terraform configuration data into the model
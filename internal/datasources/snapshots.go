@@ -118,6 +118,10 @@ func (d *SnapshotsDataSource) Configure(ctx context.Context, req datasource.Conf
 }
 
 func (d *SnapshotsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkCapabilities("truenas_snapshots", d.services, req, resp) {
+		return
+	}
+
 	var data SnapshotsDataSourceModel
 
 	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
@@ -125,8 +129,17 @@ func (d *SnapshotsDataSource) Read(ctx context.Context, req datasource.ReadReque
 		return
 	}
 
-	// List all snapshots via the service
-	snapshots, err := d.services.Snapshot.List(ctx)
+	datasetID := data.DatasetID.ValueString()
+	recursive := !data.Recursive.IsNull() && data.Recursive.ValueBool()
+	namePattern := data.NamePattern.ValueString()
+
+	// Query only the snapshots of the dataset, and of its children when
+	// recursive, rather than every snapshot on the system
+	filter := []any{"dataset", "=", datasetID}
+	if recursive {
+		filter = []any{"OR", []any{filter, []any{"dataset", "^", datasetID + "/"}}}
+	}
+	snapshots, err := d.services.Snapshot.Query(ctx, [][]any{filter})
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Unable to Read Snapshots",
@@ -135,11 +148,6 @@ func (d *SnapshotsDataSource) Read(ctx context.Context, req datasource.ReadReque
 		return
 	}
 
-	// Filter by dataset
-	datasetID := data.DatasetID.ValueString()
-	recursive := !data.Recursive.IsNull() && data.Recursive.ValueBool()
-	namePattern := data.NamePattern.ValueString()
-
 	data.Snapshots = make([]SnapshotModel, 0, len(snapshots))
 	for _, snap := range snapshots {
 		// Filter by dataset ID
@@ -3,6 +3,7 @@ package datasources
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	truenas "github.com/deevus/truenas-go"
@@ -98,7 +99,7 @@ func TestSnapshotsDataSource_Read_Success(t *testing.T) {
 	ds := &SnapshotsDataSource{
 		services: &services.TrueNASServices{
 			Snapshot: &truenas.MockSnapshotService{
-				ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+				QueryFunc: func(ctx context.Context, filters [][]any) ([]truenas.Snapshot, error) {
 					return []truenas.Snapshot{
 						{
 							ID:           "tank/data@snap1",
@@ -169,7 +170,7 @@ func TestSnapshotsDataSource_Read_Empty(t *testing.T) {
 	ds := &SnapshotsDataSource{
 		services: &services.TrueNASServices{
 			Snapshot: &truenas.MockSnapshotService{
-				ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+				QueryFunc: func(ctx context.Context, filters [][]any) ([]truenas.Snapshot, error) {
 					return []truenas.Snapshot{}, nil
 				},
 			},
@@ -253,7 +254,7 @@ func TestSnapshotsDataSource_Read_APIError(t *testing.T) {
 	ds := &SnapshotsDataSource{
 		services: &services.TrueNASServices{
 			Snapshot: &truenas.MockSnapshotService{
-				ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+				QueryFunc: func(ctx context.Context, filters [][]any) ([]truenas.Snapshot, error) {
 					return nil, errors.New("connection refused")
 				},
 			},
@@ -297,10 +298,12 @@ func TestSnapshotsDataSource_Read_APIError(t *testing.T) {
 }
 
 func TestSnapshotsDataSource_Read_Recursive(t *testing.T) {
+	var gotFilters [][]any
 	ds := &SnapshotsDataSource{
 		services: &services.TrueNASServices{
 			Snapshot: &truenas.MockSnapshotService{
-				ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+				QueryFunc: func(ctx context.Context, filters [][]any) ([]truenas.Snapshot, error) {
+					gotFilters = filters
 					return []truenas.Snapshot{
 						{
 							ID:           "tank/data@snap1",
@@ -374,13 +377,19 @@ func TestSnapshotsDataSource_Read_Recursive(t *testing.T) {
 	if len(data.Snapshots) != 2 {
 		t.Errorf("expected 2 snapshots, got %d", len(data.Snapshots))
 	}
+
+	// The dataset filter is applied by the server
+	want := [][]any{{"OR", []any{[]any{"dataset", "=", "tank/data"}, []any{"dataset", "^", "tank/data/"}}}}
+	if !reflect.DeepEqual(gotFilters, want) {
+		t.Errorf("expected filters %v, got %v", want, gotFilters)
+	}
 }
 
 func TestSnapshotsDataSource_Read_NamePattern_Match(t *testing.T) {
 	ds := &SnapshotsDataSource{
 		services: &services.TrueNASServices{
 			Snapshot: &truenas.MockSnapshotService{
-				ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+				QueryFunc: func(ctx context.Context, filters [][]any) ([]truenas.Snapshot, error) {
 					return []truenas.Snapshot{
 						{
 							ID:           "tank/data@pre-upgrade-1",
@@ -460,7 +469,7 @@ func TestSnapshotsDataSource_Read_NamePattern_NoMatch(t *testing.T) {
 	ds := &SnapshotsDataSource{
 		services: &services.TrueNASServices{
 			Snapshot: &truenas.MockSnapshotService{
-				ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+				QueryFunc: func(ctx context.Context, filters [][]any) ([]truenas.Snapshot, error) {
 					return []truenas.Snapshot{
 						{
 							ID:           "tank/data@snap1",
@@ -523,7 +532,7 @@ func TestSnapshotsDataSource_Read_NamePattern_Invalid(t *testing.T) {
 	ds := &SnapshotsDataSource{
 		services: &services.TrueNASServices{
 			Snapshot: &truenas.MockSnapshotService{
-				ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+				QueryFunc: func(ctx context.Context, filters [][]any) ([]truenas.Snapshot, error) {
 					return []truenas.Snapshot{
 						{
 							ID:           "tank/data@snap1",
@@ -622,7 +631,7 @@ func TestSnapshotsDataSource_Read_FiltersOtherDatasets(t *testing.T) {
 	ds := &SnapshotsDataSource{
 		services: &services.TrueNASServices{
 			Snapshot: &truenas.MockSnapshotService{
-				ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+				QueryFunc: func(ctx context.Context, filters [][]any) ([]truenas.Snapshot, error) {
 					return []truenas.Snapshot{
 						{
 							ID:           "tank/data@snap1",
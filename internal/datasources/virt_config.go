@@ -78,6 +78,10 @@ func (d *VirtConfigDataSource) Configure(ctx context.Context, req datasource.Con
 }
 
 func (d *VirtConfigDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkCapabilities("truenas_virt_config", d.services, req, resp) {
+		return
+	}
+
 	var data VirtConfigDataSourceModel
 
 	// Read Terraform configuration data into the model
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
 	"github.com/deevus/terraform-provider-truenas/internal/services"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
@@ -371,6 +372,47 @@ func TestVirtConfigDataSource_Read_APIError(t *testing.T) {
 	}
 }
 
+func TestVirtConfigDataSource_Read_UnsupportedVersion(t *testing.T) {
+	called := false
+	ds := &VirtConfigDataSource{
+		services: &services.TrueNASServices{
+			Client: &client.MockClient{
+				VersionVal: truenas.Version{Major: 24, Minor: 10, Patch: 2, Raw: "TrueNAS-SCALE-24.10.2"},
+			},
+			Virt: &truenas.MockVirtService{
+				GetGlobalConfigFunc: func(ctx context.Context) (*truenas.VirtGlobalConfig, error) {
+					called = true
+					return &truenas.VirtGlobalConfig{}, nil
+				},
+			},
+		},
+	}
+
+	req := createVirtConfigTestReadRequest(t)
+
+	schemaReq := datasource.SchemaRequest{}
+	schemaResp := &datasource.SchemaResponse{}
+	ds.Schema(context.Background(), schemaReq, schemaResp)
+
+	resp := &datasource.ReadResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+		},
+	}
+
+	ds.Read(context.Background(), req, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for TrueNAS 24.10")
+	}
+	if got := resp.Diagnostics.Errors()[0].Summary(); got != "Unsupported TrueNAS Version" {
+		t.Errorf("expected version error, got %q", got)
+	}
+	if called {
+		t.Error("expected the API not to be queried on an unsupported version")
+	}
+}
+
 // Test that VirtConfigDataSource implements the DataSource interface
 func TestVirtConfigDataSource_ImplementsInterfaces(t *testing.T) {
 	ds := NewVirtConfigDataSource()
@@ -0,0 +1,248 @@
+package ephemeralresources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ ephemeral.EphemeralResource              = &APIKeyEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure = &APIKeyEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose     = &APIKeyEphemeralResource{}
+)
+
+// apiKeyPrivateID is the private data key holding the ID of the minted key,
+// so that Close can revoke it.
+const apiKeyPrivateID = "api_key_id"
+
+// APIKeyEphemeralResource mints an API key that is revoked when Terraform
+// no longer needs it.
+type APIKeyEphemeralResource struct {
+	BaseEphemeralResource
+}
+
+// APIKeyEphemeralResourceModel describes the ephemeral resource data model.
+type APIKeyEphemeralResourceModel struct {
+	Name      types.String          `tfsdk:"name"`
+	Username  types.String          `tfsdk:"username"`
+	Allowlist []APIKeyAllowlistItem `tfsdk:"allowlist"`
+	ExpiresIn types.String          `tfsdk:"expires_in"`
+	ID        types.Int64           `tfsdk:"id"`
+	Key       types.String          `tfsdk:"key"`
+	ExpiresAt types.String          `tfsdk:"expires_at"`
+}
+
+// APIKeyAllowlistItem is an entry of the allowlist of an API key.
+type APIKeyAllowlistItem struct {
+	Method   types.String `tfsdk:"method"`
+	Resource types.String `tfsdk:"resource"`
+}
+
+// apiKeyResponse is the result of api_key.create.
+type apiKeyResponse struct {
+	ID        int64           `json:"id"`
+	Key       string          `json:"key"`
+	ExpiresAt json.RawMessage `json:"expires_at"`
+}
+
+// NewAPIKeyEphemeralResource creates a new APIKeyEphemeralResource.
+func NewAPIKeyEphemeralResource() ephemeral.EphemeralResource {
+	return &APIKeyEphemeralResource{}
+}
+
+func (r *APIKeyEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_api_key"
+}
+
+func (r *APIKeyEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Mints a TrueNAS API key for the duration of a Terraform run and revokes it when the run " +
+			"no longer needs it. The key is never written to plan or state, so it can be passed to another " +
+			"provider, or to the websocket block of a second truenas provider alias.",
+		Attributes: map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				Description: "Name of the API key, as shown in the TrueNAS UI.",
+				Required:    true,
+			},
+			"username": schema.StringAttribute{
+				Description: "User the key authenticates as; the key has that user's privileges. " +
+					"Required on TrueNAS 25.04 and later, not supported before.",
+				Optional: true,
+			},
+			"allowlist": schema.ListNestedAttribute{
+				Description: "Methods and resources the key may access. TrueNAS 24.10 and earlier only; " +
+					"omit it for unrestricted access.",
+				Optional: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"method": schema.StringAttribute{
+							Description: "HTTP method (GET, POST, PUT, DELETE), CALL or SUBSCRIBE for " +
+								"WebSocket access, or * for any.",
+							Required: true,
+						},
+						"resource": schema.StringAttribute{
+							Description: "Resource or method name, such as pool.dataset.query, or * for any.",
+							Required:    true,
+						},
+					},
+				},
+			},
+			"expires_in": schema.StringAttribute{
+				Description: "How long the key stays valid if it is not revoked, as a Go duration such as " +
+					"\"1h\". TrueNAS 25.04 and later. The key is revoked at the end of the run regardless.",
+				Optional: true,
+			},
+			"id": schema.Int64Attribute{
+				Description: "ID of the API key.",
+				Computed:    true,
+			},
+			"key": schema.StringAttribute{
+				Description: "The API key.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "When the key expires, in RFC 3339 format. Null if it does not expire.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *APIKeyEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	if !r.checkWritable(&resp.Diagnostics) {
+		return
+	}
+
+	var data APIKeyEphemeralResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !r.checkCapabilities("truenas_api_key", req, resp) {
+		return
+	}
+
+	version := r.services.Version()
+	if version.AtLeast(25, 4) && data.Username.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("username"),
+			"Missing API Key Username",
+			fmt.Sprintf("TrueNAS %s creates API keys for a user. Set username to the user the key should act as.", version),
+		)
+		return
+	}
+
+	params := map[string]any{"name": data.Name.ValueString()}
+	if !data.Username.IsNull() {
+		params["username"] = data.Username.ValueString()
+	}
+	if data.Allowlist != nil {
+		allowlist := make([]map[string]any, len(data.Allowlist))
+		for i, item := range data.Allowlist {
+			allowlist[i] = map[string]any{
+				"method":   item.Method.ValueString(),
+				"resource": item.Resource.ValueString(),
+			}
+		}
+		params["allowlist"] = allowlist
+	}
+	if !data.ExpiresIn.IsNull() {
+		d, err := time.ParseDuration(data.ExpiresIn.ValueString())
+		if err != nil || d <= 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("expires_in"),
+				"Invalid Expiry",
+				fmt.Sprintf("expires_in must be a positive duration such as \"30m\" or \"1h\", got %q.", data.ExpiresIn.ValueString()),
+			)
+			return
+		}
+		params["expires_at"] = map[string]any{"$date": time.Now().Add(d).UnixMilli()}
+	}
+
+	result, err := r.services.Client.Call(ctx, "api_key.create", params)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Create API Key",
+			fmt.Sprintf("Unable to create API key %q: %s", data.Name.ValueString(), err.Error()),
+		)
+		return
+	}
+
+	var created apiKeyResponse
+	if err := json.Unmarshal(result, &created); err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Parse API Key",
+			fmt.Sprintf("Unable to parse the response of api_key.create: %s. Delete API key %q in the TrueNAS UI.",
+				err.Error(), data.Name.ValueString()),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, apiKeyPrivateID, []byte(strconv.FormatInt(created.ID, 10)))...)
+
+	data.ID = types.Int64Value(created.ID)
+	data.Key = types.StringValue(created.Key)
+	data.ExpiresAt = parseMiddlewareDate(created.ExpiresAt)
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+
+	// Terraform does not close an ephemeral resource whose Open failed, so
+	// the key would outlive the run
+	if resp.Diagnostics.HasError() {
+		r.revoke(ctx, created.ID, &resp.Diagnostics)
+	}
+}
+
+func (r *APIKeyEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	raw, diags := req.Private.GetKey(ctx, apiKeyPrivateID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || raw == nil {
+		return
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Revoke API Key",
+			fmt.Sprintf("Invalid API key ID %q in private data: %s", raw, err.Error()),
+		)
+		return
+	}
+
+	r.revoke(ctx, id, &resp.Diagnostics)
+}
+
+// revoke deletes the API key id.
+func (r *APIKeyEphemeralResource) revoke(ctx context.Context, id int64, diags *diag.Diagnostics) {
+	if _, err := r.services.Client.Call(ctx, "api_key.delete", id); err != nil {
+		diags.AddError(
+			"Unable to Revoke API Key",
+			fmt.Sprintf("Unable to delete API key %d: %s. Delete it in the TrueNAS UI.", id, err.Error()),
+		)
+	}
+}
+
+// parseMiddlewareDate converts a middleware datetime, encoded as
+// {"$date": milliseconds}, to an RFC 3339 string. Missing or null dates
+// become null.
+func parseMiddlewareDate(raw json.RawMessage) types.String {
+	var date struct {
+		Date *int64 `json:"$date"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &date) != nil || date.Date == nil {
+		return types.StringNull()
+	}
+	return types.StringValue(time.UnixMilli(*date.Date).UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,161 @@
+package ephemeralresources
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestAPIKeyEphemeralResource_Metadata(t *testing.T) {
+	r := NewAPIKeyEphemeralResource()
+
+	resp := &ephemeral.MetadataResponse{}
+	r.Metadata(context.Background(), ephemeral.MetadataRequest{ProviderTypeName: "truenas"}, resp)
+
+	if resp.TypeName != "truenas_api_key" {
+		t.Errorf("expected TypeName 'truenas_api_key', got %q", resp.TypeName)
+	}
+}
+
+func TestAPIKeyEphemeralResource_Schema(t *testing.T) {
+	r := NewAPIKeyEphemeralResource()
+
+	resp := &ephemeral.SchemaResponse{}
+	r.Schema(context.Background(), ephemeral.SchemaRequest{}, resp)
+
+	if resp.Schema.Description == "" {
+		t.Error("expected non-empty schema description")
+	}
+	if !resp.Schema.Attributes["name"].IsRequired() {
+		t.Error("expected 'name' attribute to be required")
+	}
+	key := resp.Schema.Attributes["key"]
+	if !key.IsComputed() || !key.IsSensitive() {
+		t.Error("expected 'key' attribute to be computed and sensitive")
+	}
+}
+
+func TestAPIKeyEphemeralResource_Configure(t *testing.T) {
+	r := NewAPIKeyEphemeralResource().(*APIKeyEphemeralResource)
+	svc := &services.TrueNASServices{}
+
+	resp := &ephemeral.ConfigureResponse{}
+	r.Configure(context.Background(), ephemeral.ConfigureRequest{ProviderData: svc}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if r.services != svc {
+		t.Error("expected services to be set")
+	}
+
+	resp = &ephemeral.ConfigureResponse{}
+	r.Configure(context.Background(), ephemeral.ConfigureRequest{ProviderData: "wrong"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for wrong provider data type")
+	}
+}
+
+func TestAPIKeyEphemeralResource_Open_RevokesKeyOnError(t *testing.T) {
+	var deleted any
+	r := &APIKeyEphemeralResource{BaseEphemeralResource{services: &services.TrueNASServices{
+		Client: &client.MockClient{
+			CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+				switch method {
+				case "api_key.create":
+					return json.RawMessage(`{"id": 7, "key": "7-secret", "expires_at": null}`), nil
+				case "api_key.delete":
+					deleted = params
+					return json.RawMessage(`true`), nil
+				}
+				t.Fatalf("unexpected call to %s", method)
+				return nil, nil
+			},
+		},
+	}}}
+
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(context.Background(), ephemeral.SchemaRequest{}, schemaResp)
+	config := tftypes.NewValue(schemaResp.Schema.Type().TerraformType(context.Background()), map[string]tftypes.Value{
+		"name":       tftypes.NewValue(tftypes.String, "terraform"),
+		"username":   tftypes.NewValue(tftypes.String, nil),
+		"allowlist":  tftypes.NewValue(schemaResp.Schema.Attributes["allowlist"].GetType().TerraformType(context.Background()), nil),
+		"expires_in": tftypes.NewValue(tftypes.String, nil),
+		"id":         tftypes.NewValue(tftypes.Number, nil),
+		"key":        tftypes.NewValue(tftypes.String, nil),
+		"expires_at": tftypes.NewValue(tftypes.String, nil),
+	})
+
+	req := ephemeral.OpenRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+	}
+	// A result schema without the attributes of the model makes Result.Set fail
+	resp := &ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schema.Schema{
+			Attributes: map[string]schema.Attribute{"name": schema.StringAttribute{Required: true}},
+		}},
+	}
+	// The framework creates the private data of a response, whose type is
+	// internal to it
+	private := reflect.ValueOf(&resp.Private).Elem()
+	private.Set(reflect.New(private.Type().Elem()))
+
+	r.Open(context.Background(), req, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected an error from Result.Set")
+	}
+	if deleted != int64(7) {
+		t.Errorf("expected API key 7 to be deleted, got %v", deleted)
+	}
+}
+
+func TestAPIKeyEphemeralResource_Open_ReadOnly(t *testing.T) {
+	r := &APIKeyEphemeralResource{BaseEphemeralResource{services: &services.TrueNASServices{
+		ReadOnly: true,
+		Client: &client.MockClient{
+			CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+				t.Fatalf("unexpected call to %s", method)
+				return nil, nil
+			},
+		},
+	}}}
+
+	resp := &ephemeral.OpenResponse{}
+	r.Open(context.Background(), ephemeral.OpenRequest{}, resp)
+
+	if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != "Provider Is Read-Only" {
+		t.Fatalf("expected a read-only error, got %v", resp.Diagnostics)
+	}
+}
+
+func TestParseMiddlewareDate(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`{"$date": 1767225600000}`, "2026-01-01T00:00:00Z"},
+		{`null`, ""},
+		{``, ""},
+		{`"garbage"`, ""},
+	}
+	for _, tt := range tests {
+		got := parseMiddlewareDate(json.RawMessage(tt.raw))
+		if tt.want == "" {
+			if !got.IsNull() {
+				t.Errorf("parseMiddlewareDate(%s) = %s, want null", tt.raw, got)
+			}
+			continue
+		}
+		if got.ValueString() != tt.want {
+			t.Errorf("parseMiddlewareDate(%s) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+}
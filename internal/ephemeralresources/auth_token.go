@@ -0,0 +1,131 @@
+package ephemeralresources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ ephemeral.EphemeralResource              = &AuthTokenEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure = &AuthTokenEphemeralResource{}
+)
+
+// defaultTokenTTL is the lifetime of a token when ttl is not set, matching
+// the middleware's default.
+const defaultTokenTTL = 600
+
+// AuthTokenEphemeralResource generates a short-lived authentication token
+// with auth.generate_token.
+type AuthTokenEphemeralResource struct {
+	BaseEphemeralResource
+}
+
+// AuthTokenEphemeralResourceModel describes the ephemeral resource data model.
+type AuthTokenEphemeralResourceModel struct {
+	TTL         types.Int64  `tfsdk:"ttl"`
+	MatchOrigin types.Bool   `tfsdk:"match_origin"`
+	SingleUse   types.Bool   `tfsdk:"single_use"`
+	Token       types.String `tfsdk:"token"`
+	ExpiresAt   types.String `tfsdk:"expires_at"`
+}
+
+// NewAuthTokenEphemeralResource creates a new AuthTokenEphemeralResource.
+func NewAuthTokenEphemeralResource() ephemeral.EphemeralResource {
+	return &AuthTokenEphemeralResource{}
+}
+
+func (r *AuthTokenEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_auth_token"
+}
+
+func (r *AuthTokenEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Generates a short-lived TrueNAS authentication token with auth.generate_token. The token " +
+			"authenticates as the provider's user until it expires and is never written to plan or state.",
+		Attributes: map[string]schema.Attribute{
+			"ttl": schema.Int64Attribute{
+				Description: fmt.Sprintf("Lifetime of the token in seconds. Defaults to %d.", defaultTokenTTL),
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"match_origin": schema.BoolAttribute{
+				Description: "Only accept the token from the address the provider connects from. Defaults to false.",
+				Optional:    true,
+			},
+			"single_use": schema.BoolAttribute{
+				Description: "Invalidate the token after its first use. TrueNAS 25.04 and later.",
+				Optional:    true,
+			},
+			"token": schema.StringAttribute{
+				Description: "The authentication token.",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "When the token expires, in RFC 3339 format.",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *AuthTokenEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	if !r.checkWritable(&resp.Diagnostics) {
+		return
+	}
+
+	var data AuthTokenEphemeralResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !r.checkCapabilities("truenas_auth_token", req, resp) {
+		return
+	}
+
+	ttl := int64(defaultTokenTTL)
+	if !data.TTL.IsNull() {
+		ttl = data.TTL.ValueInt64()
+	}
+	params := []any{ttl, map[string]any{}, data.MatchOrigin.ValueBool()}
+	if !data.SingleUse.IsNull() {
+		params = append(params, data.SingleUse.ValueBool())
+	}
+
+	issued := time.Now()
+	result, err := r.services.Client.Call(ctx, "auth.generate_token", params)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Generate Token",
+			fmt.Sprintf("Unable to generate an authentication token: %s", err.Error()),
+		)
+		return
+	}
+
+	var token string
+	if err := json.Unmarshal(result, &token); err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Parse Token",
+			fmt.Sprintf("Unable to parse the response of auth.generate_token: %s", err.Error()),
+		)
+		return
+	}
+
+	expiresAt := issued.Add(time.Duration(ttl) * time.Second)
+	data.Token = types.StringValue(token)
+	data.ExpiresAt = types.StringValue(expiresAt.UTC().Format(time.RFC3339))
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
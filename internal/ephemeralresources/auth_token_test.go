@@ -0,0 +1,59 @@
+package ephemeralresources
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+)
+
+func TestAuthTokenEphemeralResource_Metadata(t *testing.T) {
+	r := NewAuthTokenEphemeralResource()
+
+	resp := &ephemeral.MetadataResponse{}
+	r.Metadata(context.Background(), ephemeral.MetadataRequest{ProviderTypeName: "truenas"}, resp)
+
+	if resp.TypeName != "truenas_auth_token" {
+		t.Errorf("expected TypeName 'truenas_auth_token', got %q", resp.TypeName)
+	}
+}
+
+func TestAuthTokenEphemeralResource_Schema(t *testing.T) {
+	r := NewAuthTokenEphemeralResource()
+
+	resp := &ephemeral.SchemaResponse{}
+	r.Schema(context.Background(), ephemeral.SchemaRequest{}, resp)
+
+	if resp.Schema.Description == "" {
+		t.Error("expected non-empty schema description")
+	}
+	if !resp.Schema.Attributes["ttl"].IsOptional() {
+		t.Error("expected 'ttl' attribute to be optional")
+	}
+	token := resp.Schema.Attributes["token"]
+	if !token.IsComputed() || !token.IsSensitive() {
+		t.Error("expected 'token' attribute to be computed and sensitive")
+	}
+}
+
+func TestAuthTokenEphemeralResource_Open_ReadOnly(t *testing.T) {
+	r := &AuthTokenEphemeralResource{BaseEphemeralResource{services: &services.TrueNASServices{
+		ReadOnly: true,
+		Client: &client.MockClient{
+			CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+				t.Fatalf("unexpected call to %s", method)
+				return nil, nil
+			},
+		},
+	}}}
+
+	resp := &ephemeral.OpenResponse{}
+	r.Open(context.Background(), ephemeral.OpenRequest{}, resp)
+
+	if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != "Provider Is Read-Only" {
+		t.Fatalf("expected a read-only error, got %v", resp.Diagnostics)
+	}
+}
@@ -0,0 +1,66 @@
+// Package ephemeralresources implements Terraform ephemeral resources, which
+// produce short-lived values such as credentials that are never written to
+// plan or state.
+package ephemeralresources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deevus/terraform-provider-truenas/internal/capabilities"
+	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+)
+
+// BaseEphemeralResource provides shared Configure behavior for all ephemeral
+// resources. Embed this in ephemeral resource structs to inherit the
+// services field.
+type BaseEphemeralResource struct {
+	services *services.TrueNASServices
+}
+
+func (b *BaseEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	s, ok := req.ProviderData.(*services.TrueNASServices)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Ephemeral Resource Configure Type",
+			fmt.Sprintf("Expected *services.TrueNASServices, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	b.services = s
+}
+
+// checkWritable adds an error diagnostic and returns false when the provider
+// is read-only. Ephemeral resources mint credentials on the NAS, which
+// read-only mode refuses, so they fail before any call is made.
+func (b *BaseEphemeralResource) checkWritable(diags *diag.Diagnostics) bool {
+	if b.services == nil || !b.services.ReadOnly {
+		return true
+	}
+	diags.AddError(
+		"Provider Is Read-Only",
+		"The provider is configured with read_only = true and cannot create credentials, so ephemeral "+
+			"resources are unavailable. Open them with a provider alias that is not read-only.",
+	)
+	return false
+}
+
+// checkCapabilities reports whether the detected TrueNAS version supports the
+// ephemeral resource typeName and its configured attributes, adding an error
+// to resp when it does not.
+func (b *BaseEphemeralResource) checkCapabilities(typeName string, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) bool {
+	key := capabilities.EphemeralResource(typeName)
+	version := b.services.Version()
+	if !capabilities.Check(key, version, &resp.Diagnostics) {
+		return false
+	}
+	capabilities.CheckAttributes(key, version, req.Config.Raw, &resp.Diagnostics)
+	return !resp.Diagnostics.HasError()
+}
@@ -0,0 +1,122 @@
+package fakenas
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+func (s *Server) apiKeyTable() *table {
+	return s.table("api_key.query", "id")
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// registerAPIKey installs api_key.* and auth.generate_token. Minted keys are
+// accepted by auth.login_ex until they are deleted or expire. The key itself
+// is only returned by api_key.create, as the middleware does.
+func (s *Server) registerAPIKey() {
+	s.handle("api_key.query", func(r *Request) (any, error) {
+		return s.apiKeyTable().query(r)
+	})
+	s.handle("api_key.get_instance", func(r *Request) (any, error) {
+		return s.apiKeyTable().getInstance(r)
+	})
+
+	s.handle("api_key.create", func(r *Request) (any, error) {
+		params, err := decodeObject(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		name, _ := params["name"].(string)
+		if name == "" {
+			return nil, invalid("api_key_create.name", "Field required")
+		}
+		username, _ := params["username"].(string)
+		if username == "" {
+			username = s.username
+		}
+
+		id := s.apiKeyTable().newID()
+		row := Row{
+			"id":         float64(id),
+			"name":       name,
+			"username":   username,
+			"allowlist":  params["allowlist"],
+			"expires_at": params["expires_at"],
+			"revoked":    false,
+		}
+		if row["allowlist"] == nil {
+			row["allowlist"] = []any{}
+		}
+		s.apiKeyTable().insert(row)
+
+		key := fmt.Sprintf("%d-%s", id, randomHex(32))
+		s.apiKeys[key] = row
+
+		created := Row{"key": key}
+		for k, v := range row {
+			created[k] = v
+		}
+		return created, nil
+	})
+
+	s.handle("api_key.delete", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		if !s.apiKeyTable().remove(id) {
+			return nil, NotFound("API key %d does not exist", id)
+		}
+		for key, row := range s.apiKeys {
+			if equal(row["id"], float64(id)) {
+				delete(s.apiKeys, key)
+			}
+		}
+		return true, nil
+	})
+
+	s.handle("auth.generate_token", func(r *Request) (any, error) {
+		ttl := int64(600)
+		if err := r.Arg(0, &ttl); err != nil {
+			return nil, err
+		}
+		token := randomHex(32)
+		s.tokens[token] = time.Now().Add(time.Duration(ttl) * time.Second)
+		return token, nil
+	})
+}
+
+// validAPIKey reports whether key was minted by api_key.create for username
+// and has neither been deleted nor expired.
+func (s *Server) validAPIKey(username, key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.apiKeys[key]
+	if !ok || row["username"] != username {
+		return false
+	}
+	if expires, ok := row["expires_at"].(map[string]any); ok {
+		if ms, ok := expires["$date"].(float64); ok && time.Now().After(time.UnixMilli(int64(ms))) {
+			return false
+		}
+	}
+	return true
+}
+
+// Token returns the expiry of a token issued by auth.generate_token, and
+// whether it was issued.
+func (s *Server) Token(token string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires, ok := s.tokens[token]
+	return expires, ok
+}
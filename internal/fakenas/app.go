@@ -0,0 +1,314 @@
+package fakenas
+
+import (
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// App states reported by app.query.
+const (
+	AppRunning = "RUNNING"
+	AppStopped = "STOPPED"
+)
+
+var appNamePattern = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+
+func (s *Server) apps() *table {
+	return s.table("app.query", "name")
+}
+
+func (s *Server) registries() *table {
+	return s.table("app.registry.query", "id")
+}
+
+func (s *Server) registerApp() {
+	// app.query only includes the parsed compose config when asked to with
+	// extra.retrieve_config.
+	s.handle("app.query", func(r *Request) (any, error) {
+		var opts queryOptions
+		if err := r.Arg(1, &opts); err != nil {
+			return nil, err
+		}
+		withConfig, _ := opts.Extra["retrieve_config"].(bool)
+
+		rows := s.apps().all()
+		for i, row := range rows {
+			out := Row{}
+			for k, v := range row {
+				if k != "config" || withConfig {
+					out[k] = v
+				}
+			}
+			rows[i] = out
+		}
+		return query(rows, r.RawArg(0), r.RawArg(1))
+	})
+
+	s.handleJob("app.create", func(r *Request) (any, error) {
+		var args struct {
+			AppName       string `json:"app_name"`
+			CustomApp     bool   `json:"custom_app"`
+			ComposeString string `json:"custom_compose_config_string"`
+		}
+		if err := r.Arg(0, &args); err != nil {
+			return nil, err
+		}
+		switch {
+		case !appNamePattern.MatchString(args.AppName):
+			return nil, invalid("app_create.app_name", "Application name must start with an alphabetic character and can only contain lowercase alphanumeric characters and dashes")
+		case s.apps().get(args.AppName) != nil:
+			return nil, Invalid(ValidationError{Attribute: "app_create.app_name", Message: "Application with name " + args.AppName + " already exists", Errno: EEXIST})
+		case !args.CustomApp:
+			return nil, invalid("app_create.catalog_app", "Catalog apps are not supported")
+		}
+		config, err := parseCompose("app_create", args.ComposeString)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Progress(50, "Deploying "+args.AppName)
+		row := Row{
+			"id":                args.AppName,
+			"name":              args.AppName,
+			"state":             AppRunning,
+			"custom_app":        true,
+			"config":            config,
+			"version":           "1.0.0",
+			"human_version":     "1.0.0",
+			"latest_version":    "1.0.0",
+			"upgrade_available": false,
+			"migrated":          false,
+			"notes":             nil,
+			"portals":           Row{},
+			"metadata":          Row{"name": args.AppName, "train": "stable"},
+			"active_workloads": Row{
+				"containers":        float64(len(composeServices(config))),
+				"used_ports":        []any{},
+				"container_details": containerDetails(args.AppName, config, "running"),
+				"images":            []any{},
+				"networks":          []any{},
+				"volumes":           []any{},
+			},
+		}
+		s.apps().insert(row)
+		return row, nil
+	})
+
+	s.handleJob("app.update", func(r *Request) (any, error) {
+		row, err := s.appArg(r)
+		if err != nil {
+			return nil, err
+		}
+		var args struct {
+			ComposeString *string `json:"custom_compose_config_string"`
+		}
+		if err := r.Arg(1, &args); err != nil {
+			return nil, err
+		}
+		if args.ComposeString != nil {
+			config, err := parseCompose("app_update", *args.ComposeString)
+			if err != nil {
+				return nil, err
+			}
+			row["config"] = config
+			workloads := row["active_workloads"].(Row)
+			workloads["containers"] = float64(len(composeServices(config)))
+			workloads["container_details"] = containerDetails(row["name"].(string), config, containerState(row))
+		}
+		s.apps().replace(row)
+		return row, nil
+	})
+
+	s.handleJob("app.start", func(r *Request) (any, error) {
+		return s.setAppState(r, AppRunning)
+	})
+	s.handleJob("app.stop", func(r *Request) (any, error) {
+		return s.setAppState(r, AppStopped)
+	})
+	s.handleJob("app.redeploy", func(r *Request) (any, error) {
+		return s.setAppState(r, AppRunning)
+	})
+
+	s.handleJob("app.delete", func(r *Request) (any, error) {
+		row, err := s.appArg(r)
+		if err != nil {
+			return nil, err
+		}
+		s.apps().remove(row["name"])
+		return true, nil
+	})
+
+	s.handle("app.registry.query", func(r *Request) (any, error) {
+		return s.registries().query(r)
+	})
+	s.handle("app.registry.get_instance", func(r *Request) (any, error) {
+		return s.registries().getInstance(r)
+	})
+
+	s.handle("app.registry.create", func(r *Request) (any, error) {
+		params, err := decodeObject(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.validateRegistry("app_registry_create", params, nil); err != nil {
+			return nil, err
+		}
+		row := Row{"id": float64(s.registries().newID()), "description": nil}
+		for _, key := range []string{"name", "description", "username", "password", "uri"} {
+			if v, ok := params[key]; ok {
+				row[key] = v
+			}
+		}
+		if _, ok := row["uri"]; !ok {
+			row["uri"] = "https://index.docker.io/v1/"
+		}
+		s.registries().insert(row)
+		return row, nil
+	})
+
+	s.handle("app.registry.update", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		params, err := decodeObject(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		row := s.registries().get(id)
+		if row == nil {
+			return nil, NotFound("Registry %d does not exist", id)
+		}
+		if err := s.validateRegistry("app_registry_update", params, row); err != nil {
+			return nil, err
+		}
+		for _, key := range []string{"name", "description", "username", "password", "uri"} {
+			if v, ok := params[key]; ok {
+				row[key] = v
+			}
+		}
+		s.registries().replace(row)
+		return row, nil
+	})
+
+	s.handle("app.registry.delete", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		if !s.registries().remove(id) {
+			return nil, NotFound("Registry %d does not exist", id)
+		}
+		return true, nil
+	})
+}
+
+// appArg looks up the app named by the first argument.
+func (s *Server) appArg(r *Request) (Row, error) {
+	var name string
+	if err := r.Arg(0, &name); err != nil {
+		return nil, err
+	}
+	row := s.apps().get(name)
+	if row == nil {
+		return nil, NotFound("App %s does not exist", name)
+	}
+	return row, nil
+}
+
+func (s *Server) setAppState(r *Request, state string) (any, error) {
+	row, err := s.appArg(r)
+	if err != nil {
+		return nil, err
+	}
+	row["state"] = state
+	workloads := row["active_workloads"].(Row)
+	workloads["container_details"] = containerDetails(row["name"].(string), row["config"].(Row), containerState(row))
+	s.apps().replace(row)
+	return nil, nil
+}
+
+// validateRegistry checks app.registry create and update params. existing
+// is nil on create.
+func (s *Server) validateRegistry(schema string, params, existing Row) error {
+	var errs []ValidationError
+	if name, ok := params["name"].(string); ok {
+		for _, row := range s.registries().rows {
+			if row["name"] == name && (existing == nil || !equal(row["id"], existing["id"])) {
+				errs = append(errs, ValidationError{Attribute: schema + ".name", Message: "Name must be unique", Errno: EEXIST})
+			}
+		}
+	} else if existing == nil {
+		errs = append(errs, ValidationError{Attribute: schema + ".name", Message: "Field required"})
+	}
+	if existing == nil {
+		for _, key := range []string{"username", "password"} {
+			if _, ok := params[key].(string); !ok {
+				errs = append(errs, ValidationError{Attribute: schema + "." + key, Message: "Field required"})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return Invalid(errs...)
+	}
+	return nil
+}
+
+// parseCompose parses a compose file into the JSON form app.query returns.
+func parseCompose(schema, compose string) (Row, error) {
+	if compose == "" {
+		return nil, invalid(schema+".custom_compose_config_string", "This field is required for custom apps")
+	}
+	var config map[string]any
+	if err := yaml.Unmarshal([]byte(compose), &config); err != nil {
+		return nil, invalid(schema+".custom_compose_config_string", "Unable to parse compose file: %s", err)
+	}
+	if config == nil {
+		return nil, invalid(schema+".custom_compose_config_string", "Compose file must be a mapping")
+	}
+	if _, ok := config["services"].(map[string]any); !ok {
+		return nil, invalid(schema+".custom_compose_config_string", "Compose file must define services")
+	}
+	row, _ := toJSONValue(config).(map[string]any)
+	return row, nil
+}
+
+func composeServices(config Row) map[string]any {
+	services, _ := config["services"].(map[string]any)
+	return services
+}
+
+func containerState(app Row) string {
+	if app["state"] == AppRunning {
+		return "running"
+	}
+	return "exited"
+}
+
+func containerDetails(app string, config Row, state string) []any {
+	services := composeServices(config)
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	details := []any{}
+	for _, name := range names {
+		image := ""
+		if m, ok := services[name].(map[string]any); ok {
+			image, _ = m["image"].(string)
+		}
+		details = append(details, Row{
+			"id":            app + "-" + name + "-1",
+			"service_name":  name,
+			"image":         image,
+			"state":         state,
+			"port_config":   []any{},
+			"volume_mounts": []any{},
+		})
+	}
+	return details
+}
@@ -0,0 +1,105 @@
+package fakenas
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // RFC 6238 TOTP is defined over HMAC-SHA1
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// loginEx implements auth.login_ex for the API_KEY_PLAIN, PASSWORD_PLAIN and
+// OTP_TOKEN mechanisms. API_KEY_PLAIN accepts the configured key as well as
+// keys minted with api_key.create. With an OTP secret configured, a correct
+// password yields OTP_REQUIRED and the session is only authenticated once a
+// valid OTP_TOKEN follows.
+func (c *conn) loginEx(params []json.RawMessage) map[string]any {
+	var args struct {
+		Mechanism string `json:"mechanism"`
+		Username  string `json:"username"`
+		APIKey    string `json:"api_key"`
+		Password  string `json:"password"`
+		OTPToken  string `json:"otp_token"`
+	}
+	if len(params) > 0 {
+		_ = json.Unmarshal(params[0], &args)
+	}
+
+	s := c.server
+	c.mu.Lock()
+	awaitingOTP := c.awaitingOTP
+	c.awaitingOTP = false
+	c.mu.Unlock()
+
+	switch args.Mechanism {
+	case "API_KEY_PLAIN":
+		configured := s.apiKey != "" && args.Username == s.username && args.APIKey == s.apiKey
+		if !configured && !s.validAPIKey(args.Username, args.APIKey) {
+			return authResponse("AUTH_ERR")
+		}
+	case "PASSWORD_PLAIN":
+		if s.password == "" || args.Username != s.username || args.Password != s.password {
+			return authResponse("AUTH_ERR")
+		}
+		if s.otpSecret != "" {
+			c.mu.Lock()
+			c.awaitingOTP = true
+			c.mu.Unlock()
+			return authResponse("OTP_REQUIRED")
+		}
+	case "OTP_TOKEN":
+		if !awaitingOTP || !validTOTP(s.otpSecret, args.OTPToken, time.Now()) {
+			return authResponse("AUTH_ERR")
+		}
+	default:
+		return authResponse("AUTH_ERR")
+	}
+
+	c.mu.Lock()
+	c.authenticated = true
+	c.mu.Unlock()
+
+	s.connMu.Lock()
+	s.logins++
+	s.connMu.Unlock()
+
+	resp := authResponse("SUCCESS")
+	resp["authenticator"] = "LEVEL_1"
+	resp["user_info"] = map[string]any{"pw_name": s.username}
+	return resp
+}
+
+func authResponse(responseType string) map[string]any {
+	return map[string]any{"response_type": responseType}
+}
+
+// validTOTP checks token against the RFC 6238 codes of secret for the
+// current 30 second step and its neighbours, allowing for clock skew.
+func validTOTP(secret, token string, now time.Time) bool {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(
+		strings.TrimRight(strings.ToUpper(strings.ReplaceAll(secret, " ", "")), "="))
+	if err != nil {
+		return false
+	}
+	step := now.Unix() / 30
+	for _, counter := range []int64{step - 1, step, step + 1} {
+		if hmac.Equal([]byte(token), []byte(totp(key, counter))) {
+			return true
+		}
+	}
+	return false
+}
+
+func totp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1_000_000)
+}
@@ -0,0 +1,247 @@
+package fakenas
+
+import "strings"
+
+func (s *Server) credentials() *table {
+	return s.table("cloudsync.credentials.query", "id")
+}
+
+func (s *Server) cloudSyncTasks() *table {
+	return s.table("cloudsync.query", "id")
+}
+
+func (s *Server) registerCloudSync() {
+	s.handle("cloudsync.credentials.query", func(r *Request) (any, error) {
+		return s.credentials().query(r)
+	})
+	s.handle("cloudsync.credentials.get_instance", func(r *Request) (any, error) {
+		return s.credentials().getInstance(r)
+	})
+
+	// Credentials are stored as given, so both the 24.x form (provider
+	// string plus attributes) and the 25.x form (provider object) round-trip.
+	s.handle("cloudsync.credentials.create", func(r *Request) (any, error) {
+		params, err := decodeObject(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.validateCredential("cloud_sync_credentials_create", params, nil); err != nil {
+			return nil, err
+		}
+		row := Row{"id": float64(s.credentials().newID())}
+		for _, key := range []string{"name", "provider", "attributes"} {
+			if v, ok := params[key]; ok {
+				row[key] = v
+			}
+		}
+		s.credentials().insert(row)
+		return row, nil
+	})
+
+	s.handle("cloudsync.credentials.update", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		params, err := decodeObject(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		row := s.credentials().get(id)
+		if row == nil {
+			return nil, NotFound("Cloud Sync Credentials %d does not exist", id)
+		}
+		if err := s.validateCredential("cloud_sync_credentials_update", params, row); err != nil {
+			return nil, err
+		}
+		for _, key := range []string{"name", "provider", "attributes"} {
+			if v, ok := params[key]; ok {
+				row[key] = v
+			}
+		}
+		s.credentials().replace(row)
+		return row, nil
+	})
+
+	s.handle("cloudsync.credentials.delete", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		if s.credentials().get(id) == nil {
+			return nil, NotFound("Cloud Sync Credentials %d does not exist", id)
+		}
+		for _, task := range s.cloudSyncTasks().rows {
+			if ref, _ := task["credentials"].(Row); equal(ref["id"], normalizeKey(id)) {
+				return nil, Errorf(EFAULT, "This credential is used by cloud sync task %v", task["description"])
+			}
+		}
+		s.credentials().remove(id)
+		return true, nil
+	})
+
+	s.handle("cloudsync.query", func(r *Request) (any, error) {
+		return s.cloudSyncTasks().query(r)
+	})
+	s.handle("cloudsync.get_instance", func(r *Request) (any, error) {
+		return s.cloudSyncTasks().getInstance(r)
+	})
+
+	s.handle("cloudsync.create", func(r *Request) (any, error) {
+		params, err := decodeObject(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		row := Row{
+			"id":                    float64(s.cloudSyncTasks().newID()),
+			"description":           "",
+			"direction":             "PUSH",
+			"transfer_mode":         "COPY",
+			"encryption":            false,
+			"encryption_password":   "",
+			"encryption_salt":       "",
+			"snapshot":              false,
+			"transfers":             nil,
+			"bwlimit":               []any{},
+			"exclude":               []any{},
+			"include":               []any{},
+			"follow_symlinks":       false,
+			"create_empty_src_dirs": false,
+			"enabled":               true,
+			"schedule":              Row{"minute": "00", "hour": "*", "dom": "*", "month": "*", "dow": "*"},
+			"attributes":            Row{},
+			"args":                  "",
+			"pre_script":            "",
+			"post_script":           "",
+			"job":                   nil,
+			"locked":                false,
+		}
+		if err := s.applyCloudSyncParams("cloud_sync_create", row, params); err != nil {
+			return nil, err
+		}
+		s.cloudSyncTasks().insert(row)
+		return row, nil
+	})
+
+	s.handle("cloudsync.update", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		params, err := decodeObject(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		row := s.cloudSyncTasks().get(id)
+		if row == nil {
+			return nil, NotFound("Cloud Sync Task %d does not exist", id)
+		}
+		// Validate against a copy so a failed update leaves the task intact
+		next := Row{}
+		for k, v := range row {
+			next[k] = v
+		}
+		if err := s.applyCloudSyncParams("cloud_sync_update", next, params); err != nil {
+			return nil, err
+		}
+		s.cloudSyncTasks().replace(next)
+		return next, nil
+	})
+
+	s.handle("cloudsync.delete", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		if !s.cloudSyncTasks().remove(id) {
+			return nil, NotFound("Cloud Sync Task %d does not exist", id)
+		}
+		return true, nil
+	})
+
+	s.handleJob("cloudsync.sync", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		task := s.cloudSyncTasks().get(id)
+		if task == nil {
+			return nil, NotFound("Cloud Sync Task %d does not exist", id)
+		}
+		r.Progress(100, "Transferred 0 files")
+		task["job"] = Row{"id": float64(r.job.id), "state": JobSuccess, "progress": Row{"percent": float64(100)}}
+		s.cloudSyncTasks().replace(task)
+		return nil, nil
+	})
+}
+
+// validateCredential checks cloudsync.credentials create and update params.
+// existing is nil on create.
+func (s *Server) validateCredential(schema string, params, existing Row) error {
+	var errs []ValidationError
+	if name, ok := params["name"].(string); ok {
+		for _, row := range s.credentials().rows {
+			if row["name"] == name && (existing == nil || !equal(row["id"], existing["id"])) {
+				errs = append(errs, ValidationError{Attribute: schema + ".name", Message: "Name must be unique", Errno: EEXIST})
+			}
+		}
+	} else if existing == nil {
+		errs = append(errs, ValidationError{Attribute: schema + ".name", Message: "Field required"})
+	}
+	if existing == nil && params["provider"] == nil {
+		errs = append(errs, ValidationError{Attribute: schema + ".provider", Message: "Field required"})
+	}
+	if len(errs) > 0 {
+		return Invalid(errs...)
+	}
+	return nil
+}
+
+// applyCloudSyncParams validates and copies cloudsync create/update params
+// to row. The credentials ID is expanded to the {id, name} reference that
+// cloudsync.query returns.
+func (s *Server) applyCloudSyncParams(schema string, row, params Row) error {
+	var errs []ValidationError
+	if v, ok := params["credentials"]; ok {
+		cred := s.credentials().get(v)
+		if cred == nil {
+			errs = append(errs, ValidationError{Attribute: schema + ".credentials", Message: "Invalid credentials", Errno: ENOENT})
+		} else {
+			row["credentials"] = Row{"id": cred["id"], "name": cred["name"]}
+		}
+	} else if row["credentials"] == nil {
+		errs = append(errs, ValidationError{Attribute: schema + ".credentials", Message: "Field required"})
+	}
+	if v, ok := params["path"].(string); ok {
+		p := cleanPath(v)
+		if n, exists := s.files[p]; !strings.HasPrefix(p, "/mnt/") || !exists || !n.dir {
+			errs = append(errs, ValidationError{Attribute: schema + ".path", Message: "Directory " + v + " does not exist", Errno: ENOENT})
+		}
+	} else if row["path"] == nil {
+		errs = append(errs, ValidationError{Attribute: schema + ".path", Message: "Field required"})
+	}
+	if len(errs) > 0 {
+		return Invalid(errs...)
+	}
+
+	for key, v := range params {
+		switch key {
+		case "credentials":
+		case "schedule":
+			schedule := Row{}
+			prev, _ := row["schedule"].(Row)
+			for k, def := range prev {
+				schedule[k] = def
+			}
+			if next, ok := v.(map[string]any); ok {
+				for k, val := range next {
+					schedule[k] = val
+				}
+			}
+			row["schedule"] = schedule
+		default:
+			row[key] = v
+		}
+	}
+	return nil
+}
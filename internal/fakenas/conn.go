@@ -0,0 +1,198 @@
+package fakenas
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// JSON-RPC error codes used by the middleware.
+const (
+	codeMethodNotFound = -32601
+	codeCallError      = -32001
+)
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+	ID      json.RawMessage   `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int            `json:"code"`
+	Message string         `json:"message"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// conn is one WebSocket client connection.
+type conn struct {
+	ws     *websocket.Conn
+	server *Server
+
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	authenticated bool
+	awaitingOTP   bool              // password accepted, one-time password pending
+	subs          map[string]string // subscription ID -> collection name
+	nextSub       int
+}
+
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &conn{ws: ws, server: s, subs: make(map[string]string)}
+
+	s.connMu.Lock()
+	s.conns[c] = struct{}{}
+	s.connMu.Unlock()
+	defer func() {
+		s.connMu.Lock()
+		delete(s.conns, c)
+		s.connMu.Unlock()
+		_ = ws.Close()
+	}()
+
+	for {
+		var req rpcRequest
+		if err := ws.ReadJSON(&req); err != nil {
+			return
+		}
+		c.handle(req)
+	}
+}
+
+func (c *conn) write(v any) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.ws.WriteJSON(v)
+}
+
+func (c *conn) reply(id json.RawMessage, result any) {
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	c.write(rpcResponse{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (c *conn) fail(id json.RawMessage, err *Error) {
+	rpcErr := &rpcError{Code: codeCallError, Message: "Method call error", Data: err.errorData()}
+	if err == errMethodNotFound {
+		rpcErr = &rpcError{Code: codeMethodNotFound, Message: "Method not found"}
+	}
+	c.write(rpcResponse{JSONRPC: "2.0", ID: id, Error: rpcErr})
+}
+
+func (c *conn) handle(req rpcRequest) {
+	switch req.Method {
+	case "auth.login_ex":
+		c.reply(req.ID, c.loginEx(req.Params))
+		return
+	case "core.ping":
+		c.reply(req.ID, "pong")
+		return
+	}
+
+	c.mu.Lock()
+	authenticated := c.authenticated
+	c.mu.Unlock()
+	if !authenticated {
+		c.fail(req.ID, &Error{Errno: EACCES, Reason: "ENOTAUTHENTICATED: Not authenticated"})
+		return
+	}
+
+	switch req.Method {
+	case "core.subscribe":
+		var name string
+		if len(req.Params) > 0 {
+			_ = json.Unmarshal(req.Params[0], &name)
+		}
+		c.reply(req.ID, c.subscribe(name))
+		return
+	case "core.unsubscribe":
+		var id string
+		if len(req.Params) > 0 {
+			_ = json.Unmarshal(req.Params[0], &id)
+		}
+		c.mu.Lock()
+		delete(c.subs, id)
+		c.mu.Unlock()
+		c.reply(req.ID, nil)
+		return
+	}
+
+	result, err := c.server.dispatch(req.Method, req.Params)
+	if err != nil {
+		c.fail(req.ID, err)
+		return
+	}
+	c.reply(req.ID, result)
+}
+
+func (c *conn) subscribe(name string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextSub++
+	id := fmt.Sprintf("sub-%d", c.nextSub)
+	c.subs[id] = name
+	return id
+}
+
+// subscribed reports whether the connection receives events for collection.
+// Subscriptions with arguments ("collection:{...}") match their collection.
+func (c *conn) subscribed(collection string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range c.subs {
+		if name == collection || strings.HasPrefix(name, collection+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// publish sends a collection_update event to every subscribed connection.
+func (s *Server) publish(collection, msg string, id any, fields any) {
+	params := map[string]any{
+		"msg":        msg,
+		"collection": collection,
+		"id":         id,
+	}
+	if fields != nil {
+		params["fields"] = fields
+	}
+	event := rpcNotification{JSONRPC: "2.0", Method: "collection_update", Params: params}
+
+	s.connMu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.connMu.Unlock()
+
+	for _, c := range conns {
+		if c.subscribed(collection) {
+			c.write(event)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package fakenas
+
+func (s *Server) cronJobs() *table {
+	return s.table("cronjob.query", "id")
+}
+
+// defaultSchedule is the schedule cronjob.create fills in for omitted fields.
+var defaultSchedule = Row{"minute": "00", "hour": "*", "dom": "*", "month": "*", "dow": "*"}
+
+func (s *Server) registerCron() {
+	s.handle("cronjob.query", func(r *Request) (any, error) {
+		return s.cronJobs().query(r)
+	})
+	s.handle("cronjob.get_instance", func(r *Request) (any, error) {
+		return s.cronJobs().getInstance(r)
+	})
+
+	s.handle("cronjob.create", func(r *Request) (any, error) {
+		params, err := decodeObject(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		var errs []ValidationError
+		for _, key := range []string{"user", "command"} {
+			if v, _ := params[key].(string); v == "" {
+				errs = append(errs, ValidationError{Attribute: "cron_job_create." + key, Message: "Field required"})
+			}
+		}
+		if len(errs) > 0 {
+			return nil, Invalid(errs...)
+		}
+
+		row := Row{
+			"id":          float64(s.cronJobs().newID()),
+			"description": "",
+			"enabled":     true,
+			"stdout":      true,
+			"stderr":      false,
+		}
+		applyCronParams(row, params)
+		s.cronJobs().insert(row)
+		return row, nil
+	})
+
+	s.handle("cronjob.update", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		params, err := decodeObject(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		row := s.cronJobs().get(id)
+		if row == nil {
+			return nil, NotFound("CronJob %d does not exist", id)
+		}
+		applyCronParams(row, params)
+		s.cronJobs().replace(row)
+		return row, nil
+	})
+
+	s.handle("cronjob.delete", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		if !s.cronJobs().remove(id) {
+			return nil, NotFound("CronJob %d does not exist", id)
+		}
+		return true, nil
+	})
+
+	s.handleJob("cronjob.run", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		if s.cronJobs().get(id) == nil {
+			return nil, NotFound("CronJob %d does not exist", id)
+		}
+		return nil, nil
+	})
+}
+
+// applyCronParams copies cronjob create/update params to row, filling the
+// schedule from defaults and the previous schedule.
+func applyCronParams(row, params Row) {
+	for _, key := range []string{"user", "command", "description", "enabled", "stdout", "stderr"} {
+		if v, ok := params[key]; ok {
+			row[key] = v
+		}
+	}
+	schedule := Row{}
+	prev, _ := row["schedule"].(Row)
+	next, _ := params["schedule"].(map[string]any)
+	for key, def := range defaultSchedule {
+		switch {
+		case next[key] != nil:
+			schedule[key] = next[key]
+		case prev[key] != nil:
+			schedule[key] = prev[key]
+		default:
+			schedule[key] = def
+		}
+	}
+	row["schedule"] = schedule
+}
@@ -0,0 +1,111 @@
+package fakenas
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Errno values used by the middleware in call errors.
+const (
+	ENOENT    = 2
+	EACCES    = 13
+	EFAULT    = 14
+	EBUSY     = 16
+	EEXIST    = 17
+	EINVAL    = 22
+	ENOTEMPTY = 39
+)
+
+var errnoNames = map[int]string{
+	ENOENT:    "ENOENT",
+	EACCES:    "EACCES",
+	EFAULT:    "EFAULT",
+	EBUSY:     "EBUSY",
+	EEXIST:    "EEXIST",
+	EINVAL:    "EINVAL",
+	ENOTEMPTY: "ENOTEMPTY",
+}
+
+// Error is a middleware call error. It is sent to clients as a JSON-RPC
+// error whose data carries the errno, the "[ERRNAME] message" reason and,
+// for validation errors, the offending attributes in extra.
+type Error struct {
+	Errno  int
+	Reason string
+
+	// Extra holds [attribute, message, errno] triples for validation errors.
+	Extra [][]any
+}
+
+func (e *Error) Error() string {
+	return e.reason()
+}
+
+func (e *Error) reason() string {
+	name, ok := errnoNames[e.Errno]
+	if !ok {
+		name = "EFAULT"
+	}
+	return fmt.Sprintf("[%s] %s", name, e.Reason)
+}
+
+// errorData is the data member of a JSON-RPC error.
+func (e *Error) errorData() map[string]any {
+	extra := make([]any, len(e.Extra))
+	for i, x := range e.Extra {
+		extra[i] = x
+	}
+	return map[string]any{
+		"error":   e.Errno,
+		"errname": errnoNames[e.Errno],
+		"reason":  e.reason(),
+		"extra":   extra,
+		"trace":   nil,
+	}
+}
+
+// Errorf returns an Error with the given errno.
+func Errorf(errno int, format string, args ...any) *Error {
+	return &Error{Errno: errno, Reason: fmt.Sprintf(format, args...)}
+}
+
+// NotFound returns an ENOENT error.
+func NotFound(format string, args ...any) *Error {
+	return Errorf(ENOENT, format, args...)
+}
+
+// ValidationError describes one invalid attribute of a call.
+type ValidationError struct {
+	Attribute string
+	Message   string
+	Errno     int
+}
+
+// Invalid returns the error the middleware raises for ValidationErrors: an
+// EINVAL error listing every attribute in extra.
+func Invalid(errs ...ValidationError) *Error {
+	lines := make([]string, len(errs))
+	extra := make([][]any, len(errs))
+	for i, v := range errs {
+		errno := v.Errno
+		if errno == 0 {
+			errno = EINVAL
+		}
+		lines[i] = fmt.Sprintf("%s: %s", v.Attribute, v.Message)
+		extra[i] = []any{v.Attribute, v.Message, errno}
+	}
+	return &Error{Errno: EINVAL, Reason: strings.Join(lines, "\n"), Extra: extra}
+}
+
+// invalid is shorthand for a single-attribute validation error.
+func invalid(attribute, format string, args ...any) *Error {
+	return Invalid(ValidationError{Attribute: attribute, Message: fmt.Sprintf(format, args...)})
+}
+
+// asError converts any handler error into an *Error.
+func asError(err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return Errorf(EFAULT, "%s", err.Error())
+}
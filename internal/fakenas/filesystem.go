@@ -0,0 +1,186 @@
+package fakenas
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+func (s *Server) registerFilesystem() {
+	s.handle("filesystem.stat", func(r *Request) (any, error) {
+		var p string
+		if err := r.Arg(0, &p); err != nil {
+			return nil, err
+		}
+		return s.stat(p)
+	})
+
+	s.handle("filesystem.listdir", func(r *Request) (any, error) {
+		var p string
+		if err := r.Arg(0, &p); err != nil {
+			return nil, err
+		}
+		if n, ok := s.files[cleanPath(p)]; !ok || !n.dir {
+			return nil, NotFound("Directory %s does not exist", p)
+		}
+		entries := []Row{}
+		for _, child := range s.children(p) {
+			st, _ := s.stat(child)
+			st["name"] = child[strings.LastIndex(child, "/")+1:]
+			st["path"] = child
+			entries = append(entries, st)
+		}
+		return query(entries, r.RawArg(1), r.RawArg(2))
+	})
+
+	// filesystem.mkdir creates missing parents and accepts directories that
+	// already exist, matching how the clients' MkdirAll uses it.
+	s.handle("filesystem.mkdir", func(r *Request) (any, error) {
+		var args struct {
+			Path    string `json:"path"`
+			Options struct {
+				Mode string `json:"mode"`
+			} `json:"options"`
+		}
+		if err := r.Arg(0, &args); err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(cleanPath(args.Path), "/mnt/") {
+			return nil, invalid("filesystem.mkdir.path", "Path must be located within a pool mount")
+		}
+		mode := uint32(0o755)
+		if args.Options.Mode != "" {
+			m, err := parseOctal(args.Options.Mode)
+			if err != nil {
+				return nil, invalid("filesystem.mkdir.options.mode", "Invalid mode %q", args.Options.Mode)
+			}
+			mode = m
+		}
+		if err := s.mkdirAll(args.Path, mode); err != nil {
+			return nil, err
+		}
+		return s.stat(args.Path)
+	})
+
+	s.handle("filesystem.file_receive", func(r *Request) (any, error) {
+		var p, content string
+		var opts struct {
+			Mode *uint32 `json:"mode"`
+			UID  *int64  `json:"uid"`
+			GID  *int64  `json:"gid"`
+		}
+		if err := r.Arg(0, &p); err != nil {
+			return nil, err
+		}
+		if err := r.Arg(1, &content); err != nil {
+			return nil, err
+		}
+		if err := r.Arg(2, &opts); err != nil {
+			return nil, err
+		}
+		data, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, invalid("filesystem.file_receive.content", "Invalid base64 content")
+		}
+
+		mode, uid, gid := uint32(0o644), int64(0), int64(0)
+		if existing, ok := s.files[cleanPath(p)]; ok {
+			mode, uid, gid = existing.mode, existing.uid, existing.gid
+		}
+		if opts.Mode != nil {
+			mode = *opts.Mode & 0o7777
+		}
+		// -1 leaves ownership unchanged
+		if opts.UID != nil && *opts.UID >= 0 {
+			uid = *opts.UID
+		}
+		if opts.GID != nil && *opts.GID >= 0 {
+			gid = *opts.GID
+		}
+		if err := s.writeFile(p, data, mode, uid, gid); err != nil {
+			return nil, err
+		}
+		return true, nil
+	})
+
+	s.handleJob("filesystem.setperm", func(r *Request) (any, error) {
+		var args struct {
+			Path    string  `json:"path"`
+			Mode    *string `json:"mode"`
+			UID     *int64  `json:"uid"`
+			GID     *int64  `json:"gid"`
+			Options struct {
+				Recursive bool `json:"recursive"`
+				StripACL  bool `json:"stripacl"`
+			} `json:"options"`
+		}
+		if err := r.Arg(0, &args); err != nil {
+			return nil, err
+		}
+		var mode *uint32
+		if args.Mode != nil {
+			m, err := parseOctal(*args.Mode)
+			if err != nil {
+				return nil, invalid("filesystem.setperm.mode", "Invalid mode %q", *args.Mode)
+			}
+			mode = &m
+		}
+		return nil, s.setOwnership(args.Path, mode, args.UID, args.GID, args.Options.Recursive)
+	})
+
+	s.handleJob("filesystem.chown", func(r *Request) (any, error) {
+		var args struct {
+			Path    string `json:"path"`
+			UID     *int64 `json:"uid"`
+			GID     *int64 `json:"gid"`
+			Options struct {
+				Recursive bool `json:"recursive"`
+			} `json:"options"`
+		}
+		if err := r.Arg(0, &args); err != nil {
+			return nil, err
+		}
+		return nil, s.setOwnership(args.Path, nil, args.UID, args.GID, args.Options.Recursive)
+	})
+}
+
+// setOwnership applies a mode and owner to p, and optionally everything
+// below it. Negative IDs leave the owner unchanged. s.mu must be held.
+func (s *Server) setOwnership(p string, mode *uint32, uid, gid *int64, recursive bool) error {
+	p = cleanPath(p)
+	if _, ok := s.files[p]; !ok {
+		return NotFound("Path %s not found", p)
+	}
+	for name, n := range s.files {
+		if name != p && !(recursive && strings.HasPrefix(name, p+"/")) {
+			continue
+		}
+		if mode != nil {
+			n.mode = *mode
+		}
+		if uid != nil && *uid >= 0 {
+			n.uid = *uid
+		}
+		if gid != nil && *gid >= 0 {
+			n.gid = *gid
+		}
+	}
+	return nil
+}
+
+// readFile returns the content of a regular file. s.mu must be held.
+func (s *Server) readFile(p string) ([]byte, error) {
+	n, ok := s.files[cleanPath(p)]
+	if !ok {
+		return nil, NotFound("%s: file does not exist", p)
+	}
+	if n.dir {
+		return nil, Errorf(EINVAL, "%s: not a regular file", p)
+	}
+	return append([]byte(nil), n.data...), nil
+}
+
+func parseOctal(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 8, 32)
+	return uint32(v) & 0o7777, err
+}
@@ -0,0 +1,173 @@
+package fakenas
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/deevus/truenas-go/client"
+)
+
+// Job states reported on core.get_jobs.
+const (
+	JobWaiting = "WAITING"
+	JobRunning = "RUNNING"
+	JobSuccess = "SUCCESS"
+	JobFailed  = "FAILED"
+	JobAborted = "ABORTED"
+)
+
+const jobsCollection = "core.get_jobs"
+
+// job is a running or finished job. Its row is what core.get_jobs returns.
+type job struct {
+	id      int64
+	row     Row
+	aborted bool
+	done    chan struct{}
+}
+
+// startJob creates a job in the WAITING state. s.mu must be held.
+func (s *Server) startJob(name string, params []json.RawMessage) *job {
+	s.nextJobID++
+	args := make([]any, len(params))
+	for i, p := range params {
+		_ = json.Unmarshal(p, &args[i])
+	}
+	j := &job{
+		id:   s.nextJobID,
+		done: make(chan struct{}),
+		row: Row{
+			"id":           float64(s.nextJobID),
+			"method":       name,
+			"arguments":    args,
+			"state":        JobWaiting,
+			"progress":     Row{"percent": float64(0), "description": "", "extra": nil},
+			"result":       nil,
+			"error":        nil,
+			"exception":    nil,
+			"exc_info":     nil,
+			"abortable":    true,
+			"logs_path":    nil,
+			"logs_excerpt": nil,
+			"time_started": Row{"$date": float64(time.Now().UnixMilli())},
+		},
+	}
+	s.jobs[j.id] = j
+	s.jobsTable().insert(j.row)
+	s.publish(jobsCollection, "added", j.id, j.row)
+	return j
+}
+
+func (s *Server) jobsTable() *table {
+	return s.table(jobsCollection, "id")
+}
+
+// runJob executes a job's handler and publishes its terminal state.
+func (s *Server) runJob(j *job, h Handler, req *Request) {
+	defer s.jobWG.Done()
+	defer close(j.done)
+
+	s.mu.Lock()
+	j.row["state"] = JobRunning
+	s.publish(jobsCollection, "changed", j.id, j.row)
+	delay := s.jobDelay
+	s.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j.aborted {
+		s.finishJob(j, JobAborted, nil, &Error{Errno: EFAULT, Reason: "Job aborted"})
+		return
+	}
+
+	result, err := h(req)
+	if err != nil {
+		s.finishJob(j, JobFailed, nil, asError(err))
+		return
+	}
+	s.finishJob(j, JobSuccess, result, nil)
+}
+
+// finishJob records a terminal state. s.mu must be held.
+func (s *Server) finishJob(j *job, state string, result any, err *Error) {
+	j.row["state"] = state
+	j.row["time_finished"] = Row{"$date": float64(time.Now().UnixMilli())}
+	if err != nil {
+		j.row["error"] = err.reason()
+		j.row["exception"] = err.reason()
+		j.row["exc_info"] = Row{
+			"type":  "CallError",
+			"errno": float64(err.Errno),
+			"repr":  err.reason(),
+			"extra": err.errorData()["extra"],
+		}
+	} else {
+		j.row["result"] = toJSONValue(result)
+		j.row["progress"] = Row{"percent": float64(100), "description": "", "extra": nil}
+	}
+	s.publish(jobsCollection, "changed", j.id, j.row)
+}
+
+// setJobProgress updates and publishes a job's progress. s.mu must be held,
+// which is the case inside handlers.
+func (s *Server) setJobProgress(j *job, percent float64, description string) {
+	j.row["progress"] = Row{"percent": percent, "description": description, "extra": nil}
+	s.publish(jobsCollection, "changed", j.id, j.row)
+}
+
+// waitJob blocks until a job finishes and returns its result or error.
+func (s *Server) waitJob(ctx context.Context, id int64) (json.RawMessage, error) {
+	s.mu.Lock()
+	j := s.jobs[id]
+	s.mu.Unlock()
+	if j == nil {
+		return nil, NotFound("Job %d does not exist", id)
+	}
+
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.row["state"] != JobSuccess {
+		// Mirror the SSH client, which parses the job error
+		reason, _ := j.row["error"].(string)
+		return nil, client.ParseTrueNASError(reason)
+	}
+	return json.Marshal(j.row["result"])
+}
+
+// abortJob marks a job as aborted; it ends as ABORTED instead of running
+// if it has not started executing yet.
+func (s *Server) abortJob(id int64) error {
+	j := s.jobs[id]
+	if j == nil {
+		return NotFound("Job %d does not exist", id)
+	}
+	state := j.row["state"]
+	if state == JobSuccess || state == JobFailed || state == JobAborted {
+		return nil
+	}
+	j.aborted = true
+	return nil
+}
+
+// toJSONValue converts v to its decoded JSON form so rows never alias
+// handler values.
+func toJSONValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	var out any
+	_ = json.Unmarshal(mustJSON(v), &out)
+	return out
+}
@@ -0,0 +1,239 @@
+package fakenas
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+const datasetsCollection = "pool.dataset.query"
+
+func (s *Server) datasets() *table {
+	return s.table(datasetsCollection, "id")
+}
+
+func (s *Server) registerPool() {
+	s.handle("pool.query", func(r *Request) (any, error) {
+		return s.table("pool.query", "id").query(r)
+	})
+
+	s.handle("pool.dataset.query", func(r *Request) (any, error) {
+		return s.datasets().query(r)
+	})
+	s.handle("pool.dataset.get_instance", func(r *Request) (any, error) {
+		return s.datasets().getInstance(r)
+	})
+
+	s.handle("pool.dataset.create", func(r *Request) (any, error) {
+		params, err := decodeObject(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		name, _ := params["name"].(string)
+		kind, _ := params["type"].(string)
+		if kind == "" {
+			kind = "FILESYSTEM"
+		}
+
+		var errs []ValidationError
+		switch {
+		case name == "":
+			errs = append(errs, ValidationError{Attribute: "pool_dataset_create.name", Message: "Field required"})
+		case s.datasets().get(name) != nil:
+			errs = append(errs, ValidationError{Attribute: "pool_dataset_create.name", Message: "Path " + name + " already exists", Errno: EEXIST})
+		case !strings.Contains(name, "/"):
+			errs = append(errs, ValidationError{Attribute: "pool_dataset_create.name", Message: "You need a full name, e.g. pool/newdataset"})
+		case s.datasets().get(path.Dir(name)) == nil:
+			errs = append(errs, ValidationError{Attribute: "pool_dataset_create.name", Message: "Parent dataset " + path.Dir(name) + " does not exist", Errno: ENOENT})
+		}
+		switch kind {
+		case "FILESYSTEM":
+		case "VOLUME":
+			if _, ok := params["volsize"].(float64); !ok {
+				errs = append(errs, ValidationError{Attribute: "pool_dataset_create.volsize", Message: "This field is required for VOLUME"})
+			}
+		default:
+			errs = append(errs, ValidationError{Attribute: "pool_dataset_create.type", Message: "Invalid choice: " + kind})
+		}
+		if len(errs) > 0 {
+			return nil, Invalid(errs...)
+		}
+		return s.insertDataset(name, kind, params), nil
+	})
+
+	s.handle("pool.dataset.update", func(r *Request) (any, error) {
+		var id string
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		params, err := decodeObject(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		row := s.datasets().get(id)
+		if row == nil {
+			return nil, NotFound("%s does not exist", id)
+		}
+		if v, ok := params["volsize"].(float64); ok {
+			if row["type"] != "VOLUME" {
+				return nil, invalid("pool_dataset_update.volsize", "This field is not valid for FILESYSTEM")
+			}
+			if force, _ := params["force_size"].(bool); !force && int64(v) < lookupParsed(row, "volsize") {
+				return nil, invalid("pool_dataset_update.volsize", "You cannot shrink a zvol from GUI as this may lead to data loss.")
+			}
+		}
+		applyDatasetProperties(row, params)
+		s.datasets().replace(row)
+		return row, nil
+	})
+
+	s.handle("pool.dataset.delete", func(r *Request) (any, error) {
+		var id string
+		var opts struct {
+			Recursive bool `json:"recursive"`
+			Force     bool `json:"force"`
+		}
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		if err := r.Arg(1, &opts); err != nil {
+			return nil, err
+		}
+		row := s.datasets().get(id)
+		if row == nil {
+			return nil, NotFound("%s does not exist", id)
+		}
+		if !strings.Contains(id, "/") {
+			return nil, Errorf(EINVAL, "Root dataset %s cannot be deleted", id)
+		}
+
+		children := s.datasetChildren(id)
+		snapshots := s.snapshotsOf(id, true)
+		if !opts.Recursive && (len(children) > 0 || len(snapshots) > 0) {
+			return nil, Errorf(EFAULT, "Failed to delete dataset: cannot destroy '%s': filesystem has children\nuse '-r' to destroy the following datasets", id)
+		}
+		for _, snap := range snapshots {
+			if userRefs(snap) != "0" {
+				return nil, Errorf(EBUSY, "Failed to delete dataset: cannot destroy snapshot %s: dataset is busy", snap["id"])
+			}
+		}
+
+		for _, snap := range snapshots {
+			s.snapshots().remove(snap["id"])
+		}
+		for i := len(children) - 1; i >= 0; i-- {
+			s.removeDataset(children[i])
+		}
+		s.removeDataset(row)
+		return true, nil
+	})
+}
+
+// insertDataset creates a dataset row from pool.dataset.create params along
+// with its mountpoint directory. s.mu must be held.
+func (s *Server) insertDataset(name, kind string, params Row) Row {
+	pool := strings.SplitN(name, "/", 2)[0]
+	row := Row{
+		"id":          name,
+		"name":        name,
+		"pool":        pool,
+		"type":        kind,
+		"encrypted":   false,
+		"children":    []any{},
+		"mountpoint":  nil,
+		"comments":    propertyValue(""),
+		"compression": propertyValue("LZ4"),
+		"used":        sizeProperty(0),
+		"available":   sizeProperty(poolSize),
+		"origin":      propertyValue(""),
+		"readonly":    propertyValue("OFF"),
+	}
+	if kind == "FILESYSTEM" {
+		row["mountpoint"] = "/mnt/" + name
+		row["atime"] = propertyValue("OFF")
+		row["quota"] = sizeProperty(0)
+		row["refquota"] = sizeProperty(0)
+		row["sync"] = propertyValue("STANDARD")
+		row["recordsize"] = propertyValue("128K")
+	} else {
+		row["volsize"] = sizeProperty(0)
+		row["volblocksize"] = propertyValue("16K")
+		row["sparse"] = propertyValue("false")
+		if sparse, _ := params["sparse"].(bool); sparse {
+			row["sparse"] = propertyValue("true")
+		}
+		if v, ok := params["volblocksize"].(string); ok {
+			row["volblocksize"] = propertyValue(v)
+		}
+	}
+	applyDatasetProperties(row, params)
+
+	if kind == "FILESYSTEM" {
+		_ = s.mkdirAll(row["mountpoint"].(string), 0o755)
+	}
+	s.datasets().insert(row)
+	return row
+}
+
+// applyDatasetProperties copies updatable properties from params to row.
+func applyDatasetProperties(row, params Row) {
+	for _, key := range []string{"comments", "compression", "atime", "sync", "readonly", "recordsize"} {
+		if v, ok := params[key].(string); ok {
+			row[key] = propertyValue(v)
+		}
+	}
+	for _, key := range []string{"quota", "refquota", "volsize"} {
+		if v, ok := params[key].(float64); ok {
+			row[key] = sizeProperty(int64(v))
+		}
+	}
+}
+
+// removeDataset deletes a dataset and its mountpoint. s.mu must be held.
+func (s *Server) removeDataset(row Row) {
+	if mp, ok := row["mountpoint"].(string); ok {
+		s.removeTree(mp)
+	}
+	s.datasets().remove(row["id"])
+}
+
+// datasetChildren returns the descendants of name in creation order.
+func (s *Server) datasetChildren(name string) []Row {
+	var out []Row
+	for _, row := range s.datasets().rows {
+		if strings.HasPrefix(row["id"].(string), name+"/") {
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+// propertyValue returns a ZFS property in the form pool.dataset.query uses.
+func propertyValue(v string) Row {
+	return Row{
+		"value":    v,
+		"rawvalue": strings.ToLower(v),
+		"parsed":   v,
+		"source":   "LOCAL",
+	}
+}
+
+// sizeProperty returns a size property; zero means "none" for quotas.
+func sizeProperty(n int64) Row {
+	value := any(nil)
+	if n != 0 {
+		value = strconv.FormatInt(n, 10)
+	}
+	return Row{
+		"value":    value,
+		"rawvalue": strconv.FormatInt(n, 10),
+		"parsed":   float64(n),
+		"source":   "LOCAL",
+	}
+}
+
+func lookupParsed(row Row, key string) int64 {
+	v, _ := lookup(row, key+".parsed")
+	f, _ := v.(float64)
+	return int64(f)
+}
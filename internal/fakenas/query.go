@@ -0,0 +1,275 @@
+package fakenas
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Row is a stored object in its JSON form, exactly as query methods return it.
+type Row = map[string]any
+
+// queryOptions are the options accepted by the middleware's query methods.
+type queryOptions struct {
+	Get     bool           `json:"get"`
+	Count   bool           `json:"count"`
+	Limit   int            `json:"limit"`
+	Offset  int            `json:"offset"`
+	OrderBy []string       `json:"order_by"`
+	Select  []any          `json:"select"`
+	Extra   map[string]any `json:"extra"`
+}
+
+// query applies query-filters and query-options to rows, like the
+// middleware's generic CRUD query. Filters are [field, op, value] triples
+// or ["OR", [filters...]]; fields may be dotted paths into nested objects.
+func query(rows []Row, filtersArg, optionsArg json.RawMessage) (any, error) {
+	var filters []any
+	if len(filtersArg) > 0 && string(filtersArg) != "null" {
+		if err := json.Unmarshal(filtersArg, &filters); err != nil {
+			return nil, invalid("query-filters", "Not a list: %s", err)
+		}
+	}
+	var opts queryOptions
+	if len(optionsArg) > 0 && string(optionsArg) != "null" {
+		if err := json.Unmarshal(optionsArg, &opts); err != nil {
+			return nil, invalid("query-options", "Not an object: %s", err)
+		}
+	}
+
+	var matched []Row
+	for _, row := range rows {
+		ok, err := matchAll(row, filters)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, row)
+		}
+	}
+
+	if len(opts.OrderBy) > 0 {
+		sortRows(matched, opts.OrderBy)
+	}
+	if opts.Offset > 0 {
+		if opts.Offset >= len(matched) {
+			matched = nil
+		} else {
+			matched = matched[opts.Offset:]
+		}
+	}
+	if opts.Limit > 0 && opts.Limit < len(matched) {
+		matched = matched[:opts.Limit]
+	}
+
+	if opts.Count {
+		return len(matched), nil
+	}
+	if len(opts.Select) > 0 {
+		for i, row := range matched {
+			matched[i] = selectFields(row, opts.Select)
+		}
+	}
+	if opts.Get {
+		if len(matched) == 0 {
+			return nil, NotFound("Object does not exist")
+		}
+		return matched[0], nil
+	}
+	if matched == nil {
+		matched = []Row{}
+	}
+	return matched, nil
+}
+
+func matchAll(row Row, filters []any) (bool, error) {
+	for _, f := range filters {
+		ok, err := matchFilter(row, f)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchFilter(row Row, filter any) (bool, error) {
+	parts, ok := filter.([]any)
+	if !ok {
+		return false, invalid("query-filters", "Invalid filter %v", filter)
+	}
+
+	if len(parts) == 2 {
+		if op, _ := parts[0].(string); op == "OR" {
+			branches, ok := parts[1].([]any)
+			if !ok {
+				return false, invalid("query-filters", "Invalid OR filter %v", filter)
+			}
+			for _, b := range branches {
+				// Each branch is a filter or a list of filters joined by AND
+				if list, isList := b.([]any); isList && len(list) > 0 {
+					if _, nested := list[0].([]any); nested {
+						if ok, err := matchAll(row, list); err != nil || ok {
+							return ok, err
+						}
+						continue
+					}
+				}
+				if ok, err := matchFilter(row, b); err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+	}
+
+	if len(parts) != 3 {
+		return false, invalid("query-filters", "Invalid filter %v", filter)
+	}
+	field, ok := parts[0].(string)
+	if !ok {
+		return false, invalid("query-filters", "Invalid filter field %v", parts[0])
+	}
+	op, ok := parts[1].(string)
+	if !ok {
+		return false, invalid("query-filters", "Invalid filter operator %v", parts[1])
+	}
+
+	value, _ := lookup(row, field)
+	return compare(value, op, parts[2])
+}
+
+// lookup resolves a dotted path in row.
+func lookup(row Row, field string) (any, bool) {
+	var cur any = row
+	for _, part := range strings.Split(field, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func compare(value any, op string, want any) (bool, error) {
+	switch op {
+	case "=":
+		return equal(value, want), nil
+	case "!=":
+		return !equal(value, want), nil
+	case ">", ">=", "<", "<=":
+		a, aok := value.(float64)
+		b, bok := want.(float64)
+		if !aok || !bok {
+			as, bs := fmt.Sprint(value), fmt.Sprint(want)
+			return compareOrdered(strings.Compare(as, bs), op), nil
+		}
+		switch {
+		case a < b:
+			return compareOrdered(-1, op), nil
+		case a > b:
+			return compareOrdered(1, op), nil
+		default:
+			return compareOrdered(0, op), nil
+		}
+	case "in", "nin":
+		list, ok := want.([]any)
+		if !ok {
+			return false, invalid("query-filters", "%q requires a list", op)
+		}
+		found := false
+		for _, w := range list {
+			if equal(value, w) {
+				found = true
+				break
+			}
+		}
+		return found == (op == "in"), nil
+	case "rin", "rnin":
+		list, _ := value.([]any)
+		found := false
+		for _, v := range list {
+			if equal(v, want) {
+				found = true
+				break
+			}
+		}
+		return found == (op == "rin"), nil
+	case "^", "!^":
+		s, _ := value.(string)
+		w, _ := want.(string)
+		return strings.HasPrefix(s, w) == (op == "^"), nil
+	case "$", "!$":
+		s, _ := value.(string)
+		w, _ := want.(string)
+		return strings.HasSuffix(s, w) == (op == "$"), nil
+	case "~":
+		s, _ := value.(string)
+		w, _ := want.(string)
+		re, err := regexp.Compile(w)
+		if err != nil {
+			return false, invalid("query-filters", "Invalid regular expression %q", w)
+		}
+		return re.MatchString(s), nil
+	default:
+		return false, invalid("query-filters", "Invalid operator %q", op)
+	}
+}
+
+func compareOrdered(c int, op string) bool {
+	switch op {
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	default:
+		return c <= 0
+	}
+}
+
+// equal compares JSON-decoded values.
+func equal(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func sortRows(rows []Row, orderBy []string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, key := range orderBy {
+			desc := strings.HasPrefix(key, "-")
+			key = strings.TrimPrefix(key, "-")
+			a, _ := lookup(rows[i], key)
+			b, _ := lookup(rows[j], key)
+			if equal(a, b) {
+				continue
+			}
+			less, _ := compare(a, "<", b)
+			if desc {
+				return !less
+			}
+			return less
+		}
+		return false
+	})
+}
+
+func selectFields(row Row, fields []any) Row {
+	out := Row{}
+	for _, f := range fields {
+		name, ok := f.(string)
+		if !ok {
+			continue
+		}
+		if v, ok := lookup(row, name); ok {
+			out[name] = v
+		}
+	}
+	return out
+}
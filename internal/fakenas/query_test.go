@@ -0,0 +1,108 @@
+package fakenas
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func queryRows() []Row {
+	return []Row{
+		{"id": float64(1), "name": "tank/apps", "type": "FILESYSTEM", "used": Row{"parsed": float64(300)}},
+		{"id": float64(2), "name": "tank/media", "type": "FILESYSTEM", "used": Row{"parsed": float64(100)}},
+		{"id": float64(3), "name": "tank/vm-disk", "type": "VOLUME", "used": Row{"parsed": float64(200)}},
+	}
+}
+
+func names(t *testing.T, result any) []string {
+	t.Helper()
+	rows, ok := result.([]Row)
+	if !ok {
+		t.Fatalf("expected []Row, got %T", result)
+	}
+	out := make([]string, len(rows))
+	for i, row := range rows {
+		out[i], _ = row["name"].(string)
+	}
+	return out
+}
+
+func TestQuery_Filters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters string
+		want    []string
+	}{
+		{"none", `[]`, []string{"tank/apps", "tank/media", "tank/vm-disk"}},
+		{"equal", `[["type", "=", "VOLUME"]]`, []string{"tank/vm-disk"}},
+		{"not equal", `[["type", "!=", "VOLUME"]]`, []string{"tank/apps", "tank/media"}},
+		{"nested field", `[["used.parsed", ">=", 200]]`, []string{"tank/apps", "tank/vm-disk"}},
+		{"in", `[["id", "in", [1, 3]]]`, []string{"tank/apps", "tank/vm-disk"}},
+		{"prefix", `[["name", "^", "tank/m"]]`, []string{"tank/media"}},
+		{"suffix", `[["name", "$", "disk"]]`, []string{"tank/vm-disk"}},
+		{"regex", `[["name", "~", "^tank/(apps|media)$"]]`, []string{"tank/apps", "tank/media"}},
+		{"and", `[["type", "=", "FILESYSTEM"], ["used.parsed", "<", 200]]`, []string{"tank/media"}},
+		{"or", `[["OR", [["id", "=", 1], ["id", "=", 3]]]]`, []string{"tank/apps", "tank/vm-disk"}},
+		{"no match", `[["name", "=", "missing"]]`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := query(queryRows(), json.RawMessage(tt.filters), nil)
+			if err != nil {
+				t.Fatalf("query: %v", err)
+			}
+			got := names(t, result)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestQuery_Options(t *testing.T) {
+	result, err := query(queryRows(), nil, json.RawMessage(`{"order_by": ["-used.parsed"], "limit": 2}`))
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	got := names(t, result)
+	if len(got) != 2 || got[0] != "tank/apps" || got[1] != "tank/vm-disk" {
+		t.Errorf("unexpected order %v", got)
+	}
+
+	result, err = query(queryRows(), json.RawMessage(`[["type", "=", "FILESYSTEM"]]`), json.RawMessage(`{"count": true}`))
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if result != 2 {
+		t.Errorf("expected count 2, got %v", result)
+	}
+
+	result, err = query(queryRows(), json.RawMessage(`[["id", "=", 2]]`), json.RawMessage(`{"get": true, "select": ["name"]}`))
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	row, ok := result.(Row)
+	if !ok || len(row) != 1 || row["name"] != "tank/media" {
+		t.Errorf("unexpected row %v", result)
+	}
+}
+
+func TestQuery_GetNotFound(t *testing.T) {
+	_, err := query(queryRows(), json.RawMessage(`[["id", "=", 9]]`), json.RawMessage(`{"get": true}`))
+	e, ok := err.(*Error)
+	if !ok || e.Errno != ENOENT {
+		t.Fatalf("expected ENOENT, got %v", err)
+	}
+}
+
+func TestQuery_InvalidFilter(t *testing.T) {
+	_, err := query(queryRows(), json.RawMessage(`[["id", "="]]`), nil)
+	e, ok := err.(*Error)
+	if !ok || e.Errno != EINVAL {
+		t.Fatalf("expected EINVAL, got %v", err)
+	}
+}
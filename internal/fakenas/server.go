@@ -0,0 +1,459 @@
+// Package fakenas is an in-process stand-in for the TrueNAS middleware.
+//
+// A Server speaks the JSON-RPC 2.0 WebSocket protocol served at /api/current,
+// authenticates with auth.login_ex, keeps datasets, snapshots, apps, cron
+// jobs, cloud sync tasks, VMs and virt instances in memory, and runs long
+// operations as jobs whose progress is published on core.get_jobs. It lets
+// the provider be exercised end to end, through the real truenas-go clients,
+// without a TrueNAS system:
+//
+//	srv := fakenas.NewServer(t)
+//	c, _ := client.NewWebSocketClient(client.WebSocketConfig{
+//		Host: srv.Host, Port: srv.Port, InsecureSkipVerify: true,
+//		Username: srv.Username(), APIKey: srv.APIKey(),
+//	})
+//
+// ShellClient returns a client.Client that talks to the same state in
+// process, standing in for the SSH transport.
+package fakenas
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/gorilla/websocket"
+)
+
+// Defaults used when no Option overrides them.
+const (
+	DefaultVersion  = "TrueNAS-25.10.1"
+	DefaultUsername = "root"
+	DefaultAPIKey   = "1-fakenas-api-key"
+	DefaultPool     = "tank"
+)
+
+// Handler implements a middleware method.
+type Handler func(r *Request) (any, error)
+
+type method struct {
+	handler Handler
+	job     bool
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithVersion sets the version reported by system.version and system.info.
+func WithVersion(version string) Option {
+	return func(s *Server) { s.version = version }
+}
+
+// WithAPIKey sets the credentials accepted by the API_KEY_PLAIN mechanism.
+func WithAPIKey(username, apiKey string) Option {
+	return func(s *Server) {
+		s.username = username
+		s.apiKey = apiKey
+	}
+}
+
+// WithPassword sets the credentials accepted by the PASSWORD_PLAIN
+// mechanism. The user is the same as the one of the API key.
+func WithPassword(username, password string) Option {
+	return func(s *Server) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithOTPSecret enables two-factor authentication for password logins: the
+// base32 TOTP secret is used to check the OTP_TOKEN that must follow.
+func WithOTPSecret(secret string) Option {
+	return func(s *Server) { s.otpSecret = secret }
+}
+
+// WithClientCA makes the server require a client certificate issued by one
+// of the PEM certificates in caPEM, as a reverse proxy enforcing mutual TLS
+// would.
+func WithClientCA(caPEM []byte) Option {
+	return func(s *Server) {
+		s.clientCAs = x509.NewCertPool()
+		s.clientCAs.AppendCertsFromPEM(caPEM)
+	}
+}
+
+// WithPool sets the name of the pool the server starts with.
+func WithPool(name string) Option {
+	return func(s *Server) { s.pool = name }
+}
+
+// WithJobDelay keeps every job RUNNING for d before it executes.
+func WithJobDelay(d time.Duration) Option {
+	return func(s *Server) { s.jobDelay = d }
+}
+
+// Server is a fake TrueNAS middleware. Create one with NewServer.
+type Server struct {
+	// URL is the base URL of the server, e.g. https://127.0.0.1:41234.
+	URL string
+	// Host and Port are the address the server listens on.
+	Host string
+	Port int
+
+	version   string
+	username  string
+	apiKey    string
+	password  string
+	otpSecret string
+	pool      string
+	jobDelay  time.Duration
+	clientCAs *x509.CertPool
+
+	http *httptest.Server
+
+	mu        sync.Mutex
+	methods   map[string]method
+	tables    map[string]*table
+	files     map[string]*node
+	downloads map[string][]byte
+	failures  map[string][]*Error
+	calls     []string
+	jobs      map[int64]*job
+	nextJobID int64
+	jobWG     sync.WaitGroup
+	txg       int64
+	virt      Row
+	apiKeys   map[string]Row
+	tokens    map[string]time.Time
+
+	connMu sync.Mutex
+	conns  map[*conn]struct{}
+	logins int
+}
+
+// NewServer starts a Server on a local TLS listener. It is closed when the
+// test finishes.
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	s := newServer(opts...)
+	s.start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newServer(opts ...Option) *Server {
+	s := &Server{
+		version:   DefaultVersion,
+		username:  DefaultUsername,
+		apiKey:    DefaultAPIKey,
+		pool:      DefaultPool,
+		methods:   make(map[string]method),
+		tables:    make(map[string]*table),
+		files:     make(map[string]*node),
+		downloads: make(map[string][]byte),
+		failures:  make(map[string][]*Error),
+		jobs:      make(map[int64]*job),
+		conns:     make(map[*conn]struct{}),
+		apiKeys:   make(map[string]Row),
+		tokens:    make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.registerMethods()
+	s.seed()
+	return s
+}
+
+func (s *Server) start() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/current", s.serveWebSocket)
+	mux.HandleFunc("/_download/", s.serveDownload)
+	s.http = httptest.NewUnstartedServer(mux)
+	if s.clientCAs != nil {
+		s.http.TLS = &tls.Config{ClientCAs: s.clientCAs, ClientAuth: tls.RequireAndVerifyClientCert}
+	}
+	s.http.StartTLS()
+
+	s.URL = s.http.URL
+	host, port, _ := net.SplitHostPort(s.http.Listener.Addr().String())
+	s.Host = host
+	s.Port, _ = strconv.Atoi(port)
+}
+
+// Close disconnects all clients and stops the server.
+func (s *Server) Close() {
+	s.connMu.Lock()
+	for c := range s.conns {
+		_ = c.ws.Close()
+	}
+	s.connMu.Unlock()
+	if s.http != nil {
+		s.http.Close()
+	}
+	s.jobWG.Wait()
+}
+
+// Username returns the user accepted by the server.
+func (s *Server) Username() string { return s.username }
+
+// APIKey returns the API key accepted by the server.
+func (s *Server) APIKey() string { return s.apiKey }
+
+// CertificatePEM returns the server's self-signed TLS certificate.
+func (s *Server) CertificatePEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.http.Certificate().Raw})
+}
+
+// Password returns the password accepted by the server, if any.
+func (s *Server) Password() string { return s.password }
+
+// OTPSecret returns the TOTP secret required after a password login, if any.
+func (s *Server) OTPSecret() string { return s.otpSecret }
+
+// Logins returns the number of successful logins.
+func (s *Server) Logins() int {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	return s.logins
+}
+
+// ExpireSessions logs every connection out, as happens when a session
+// expires: later calls fail with ENOTAUTHENTICATED until the client logs in
+// again.
+func (s *Server) ExpireSessions() {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	for c := range s.conns {
+		c.mu.Lock()
+		c.authenticated = false
+		c.mu.Unlock()
+	}
+}
+
+// DropConnections closes every client connection, as happens when the
+// middleware restarts. Jobs keep running.
+func (s *Server) DropConnections() {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	for c := range s.conns {
+		_ = c.ws.Close()
+	}
+}
+
+// Version returns the parsed version the server reports.
+func (s *Server) Version() truenas.Version {
+	v, _ := truenas.ParseVersion(s.version)
+	return v
+}
+
+// Handle registers or replaces a method.
+func (s *Server) Handle(name string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[name] = method{handler: h}
+}
+
+// HandleJob registers or replaces a method that runs as a job: callers
+// receive a job ID and the result is published on core.get_jobs.
+func (s *Server) HandleJob(name string, h Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods[name] = method{handler: h, job: true}
+}
+
+// FailNext makes the next call to name fail with err. Calls queue up, so
+// FailNext can be used repeatedly to fail several consecutive calls.
+func (s *Server) FailNext(name string, err *Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[name] = append(s.failures[name], err)
+}
+
+// Calls returns the names of the methods called so far, in order.
+// Authentication, subscriptions and pings are not included.
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+// CallCount returns how many times name has been called.
+func (s *Server) CallCount(name string) int {
+	n := 0
+	for _, c := range s.Calls() {
+		if c == name {
+			n++
+		}
+	}
+	return n
+}
+
+// Request is a single method call.
+type Request struct {
+	Method string
+	Params []json.RawMessage
+
+	server *Server
+	job    *job
+}
+
+// Arg decodes positional argument i into v. Missing and null arguments
+// leave v unchanged.
+func (r *Request) Arg(i int, v any) error {
+	raw := r.RawArg(i)
+	if raw == nil {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return invalid(r.Method, "Invalid argument %d: %s", i+1, err)
+	}
+	return nil
+}
+
+// RawArg returns positional argument i, or nil when it is missing or null.
+func (r *Request) RawArg(i int) json.RawMessage {
+	if i >= len(r.Params) || string(r.Params[i]) == "null" {
+		return nil
+	}
+	return r.Params[i]
+}
+
+// Progress reports job progress. It is a no-op outside jobs.
+func (r *Request) Progress(percent float64, description string) {
+	if r.job != nil {
+		r.server.setJobProgress(r.job, percent, description)
+	}
+}
+
+// dispatch runs a call. For job methods it returns the job ID and starts the
+// job; the job's handler runs later, in the background.
+func (s *Server) dispatch(name string, params []json.RawMessage) (any, *Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !isSessionMethod(name) {
+		s.calls = append(s.calls, name)
+	}
+	if queued := s.failures[name]; len(queued) > 0 {
+		s.failures[name] = queued[1:]
+		return nil, queued[0]
+	}
+
+	m, ok := s.methods[name]
+	if !ok {
+		return nil, errMethodNotFound
+	}
+
+	req := &Request{Method: name, Params: params, server: s}
+	if m.job {
+		j := s.startJob(name, params)
+		req.job = j
+		s.jobWG.Add(1)
+		go s.runJob(j, m.handler, req)
+		return j.id, nil
+	}
+
+	result, err := m.handler(req)
+	if err != nil {
+		return nil, asError(err)
+	}
+	// Encode while locked: results often share maps with stored rows
+	return mustJSON(result), nil
+}
+
+// errMethodNotFound is mapped to the JSON-RPC "method not found" code.
+var errMethodNotFound = &Error{Errno: EINVAL, Reason: "Method does not exist"}
+
+// isSessionMethod reports whether name manages the connection rather than
+// the system, and is therefore not recorded by Calls.
+func isSessionMethod(name string) bool {
+	return strings.HasPrefix(name, "auth.") || name == "core.ping" ||
+		name == "core.subscribe" || name == "core.unsubscribe"
+}
+
+// Call invokes a method in process. params follows client.Client.Call: a
+// []any is passed as positional arguments, anything else as the only one.
+// Job methods return the job ID.
+func (s *Server) Call(ctx context.Context, name string, params any) (json.RawMessage, error) {
+	args, err := positionalParams(params)
+	if err != nil {
+		return nil, err
+	}
+	result, callErr := s.dispatch(name, args)
+	if callErr != nil {
+		return nil, callErr
+	}
+	return json.Marshal(result)
+}
+
+// CallAndWait invokes a method in process and, for job methods, waits for
+// the job to finish.
+func (s *Server) CallAndWait(ctx context.Context, name string, params any) (json.RawMessage, error) {
+	result, err := s.Call(ctx, name, params)
+	if err != nil {
+		return nil, err
+	}
+	var id int64
+	if json.Unmarshal(result, &id) != nil || !s.isJob(name) {
+		return result, nil
+	}
+	return s.waitJob(ctx, id)
+}
+
+func (s *Server) isJob(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.methods[name].job
+}
+
+// positionalParams converts client-style params into positional arguments.
+func positionalParams(params any) ([]json.RawMessage, error) {
+	if params == nil {
+		return nil, nil
+	}
+	list, ok := params.([]any)
+	if !ok {
+		list = []any{params}
+	}
+	args := make([]json.RawMessage, len(list))
+	for i, p := range list {
+		data, err := json.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = data
+	}
+	return args, nil
+}
+
+// mustJSON marshals values built by the server itself.
+func mustJSON(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// toRow converts v to its JSON object form.
+func toRow(v any) Row {
+	var row Row
+	if err := json.Unmarshal(mustJSON(v), &row); err != nil {
+		panic(err)
+	}
+	return row
+}
+
+var upgrader = websocket.Upgrader{}
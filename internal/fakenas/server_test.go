@@ -0,0 +1,306 @@
+package fakenas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+// connect returns a connected WebSocket client for srv.
+func connect(t *testing.T, srv *Server) *client.WebSocketClient {
+	t.Helper()
+	c, err := client.NewWebSocketClient(client.WebSocketConfig{
+		Host:               srv.Host,
+		Port:               srv.Port,
+		Username:           srv.Username(),
+		APIKey:             srv.APIKey(),
+		InsecureSkipVerify: true,
+		MaxRetries:         1,
+	})
+	if err != nil {
+		t.Fatalf("NewWebSocketClient: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	return c
+}
+
+func TestServer_Connect_DetectsVersion(t *testing.T) {
+	srv := NewServer(t, WithVersion("TrueNAS-25.04.2.4"))
+	c := connect(t, srv)
+
+	v := c.Version()
+	if v.Major != 25 || v.Minor != 4 || v.Patch != 2 || v.Build != 4 {
+		t.Errorf("unexpected version %v", v)
+	}
+}
+
+func TestServer_Connect_RejectsWrongAPIKey(t *testing.T) {
+	srv := NewServer(t)
+	c, err := client.NewWebSocketClient(client.WebSocketConfig{
+		Host:               srv.Host,
+		Port:               srv.Port,
+		Username:           srv.Username(),
+		APIKey:             "wrong",
+		InsecureSkipVerify: true,
+		MaxRetries:         1,
+	})
+	if err != nil {
+		t.Fatalf("NewWebSocketClient: %v", err)
+	}
+	defer c.Close()
+
+	_, err = c.Call(context.Background(), "system.info", nil)
+	if err == nil || !strings.Contains(err.Error(), "AUTH_ERR") {
+		t.Fatalf("expected authentication error, got %v", err)
+	}
+}
+
+func TestServer_Call_UnknownMethod(t *testing.T) {
+	srv := NewServer(t)
+	c := connect(t, srv)
+
+	_, err := c.Call(context.Background(), "no.such.method", nil)
+	var rpcErr *client.JSONRPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected JSONRPCError, got %v", err)
+	}
+	if rpcErr.Code != codeMethodNotFound {
+		t.Errorf("expected code %d, got %d", codeMethodNotFound, rpcErr.Code)
+	}
+}
+
+func TestServer_Call_ErrorCarriesErrno(t *testing.T) {
+	srv := NewServer(t)
+	c := connect(t, srv)
+
+	_, err := c.Call(context.Background(), "cronjob.get_instance", 99)
+	var rpcErr *client.JSONRPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected JSONRPCError, got %v", err)
+	}
+	if rpcErr.Data == nil || rpcErr.Data.Error != ENOENT {
+		t.Fatalf("expected errno ENOENT, got %+v", rpcErr.Data)
+	}
+	if !strings.HasPrefix(rpcErr.Data.Reason, "[ENOENT]") {
+		t.Errorf("expected reason to start with [ENOENT], got %q", rpcErr.Data.Reason)
+	}
+}
+
+func TestServer_CallAndWait_Job(t *testing.T) {
+	srv := NewServer(t)
+	srv.HandleJob("test.job", func(r *Request) (any, error) {
+		var n int
+		if err := r.Arg(0, &n); err != nil {
+			return nil, err
+		}
+		r.Progress(50, "halfway")
+		return map[string]any{"double": n * 2}, nil
+	})
+	c := connect(t, srv)
+
+	result, err := c.CallAndWait(context.Background(), "test.job", 21)
+	if err != nil {
+		t.Fatalf("CallAndWait: %v", err)
+	}
+	if string(result) != `{"double":42}` {
+		t.Errorf("unexpected result %s", result)
+	}
+}
+
+func TestServer_CallAndWait_JobFailure(t *testing.T) {
+	srv := NewServer(t)
+	srv.HandleJob("test.job", func(r *Request) (any, error) {
+		return nil, Errorf(EBUSY, "resource is busy")
+	})
+	c := connect(t, srv)
+
+	_, err := c.CallAndWait(context.Background(), "test.job", nil)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	var tnErr *client.TrueNASError
+	if !errors.As(err, &tnErr) {
+		t.Fatalf("expected TrueNASError, got %T: %v", err, err)
+	}
+	if tnErr.Code != "EBUSY" {
+		t.Errorf("expected code EBUSY, got %q", tnErr.Code)
+	}
+}
+
+func TestServer_FailNext(t *testing.T) {
+	srv := NewServer(t)
+	srv.FailNext("system.info", Errorf(EFAULT, "injected"))
+	c := connect(t, srv)
+
+	if _, err := c.Call(context.Background(), "system.info", nil); err == nil || !strings.Contains(err.Error(), "injected") {
+		t.Fatalf("expected injected failure, got %v", err)
+	}
+	if _, err := c.Call(context.Background(), "system.info", nil); err != nil {
+		t.Fatalf("expected second call to succeed, got %v", err)
+	}
+	if n := srv.CallCount("system.info"); n != 2 {
+		t.Errorf("expected 2 calls, got %d", n)
+	}
+}
+
+func TestServer_JobAbort(t *testing.T) {
+	srv := NewServer(t, WithJobDelay(200*time.Millisecond))
+	srv.HandleJob("test.job", func(r *Request) (any, error) {
+		t.Error("aborted job should not run")
+		return nil, nil
+	})
+	c := connect(t, srv)
+	ctx := context.Background()
+
+	result, err := c.Call(ctx, "test.job", nil)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	var id int64
+	if err := json.Unmarshal(result, &id); err != nil {
+		t.Fatalf("expected job ID, got %s", result)
+	}
+	if _, err := c.Call(ctx, "core.job_abort", id); err != nil {
+		t.Fatalf("core.job_abort: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		result, err := c.Call(ctx, "core.get_jobs", []any{[]any{[]any{"id", "=", id}}})
+		if err != nil {
+			t.Fatalf("core.get_jobs: %v", err)
+		}
+		var jobs []client.Job
+		if err := json.Unmarshal(result, &jobs); err != nil {
+			t.Fatalf("parse jobs: %v", err)
+		}
+		if len(jobs) == 1 && jobs[0].State == JobAborted {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("job was not aborted")
+}
+
+func TestServer_Subscribe_PublishesCollectionEvents(t *testing.T) {
+	srv := NewServer(t)
+	c := connect(t, srv)
+	ctx := context.Background()
+
+	sub, err := c.Subscribe(ctx, "cronjob.query", nil)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	cron := truenas.NewCronService(c, c.Version())
+	job, err := cron.Create(ctx, truenas.CreateCronJobOpts{
+		User:     "root",
+		Command:  "true",
+		Schedule: truenas.Schedule{Minute: "0", Hour: "*", Dom: "*", Month: "*", Dow: "*"},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	select {
+	case event := <-sub.C:
+		var fields struct {
+			ID      int64  `json:"id"`
+			Command string `json:"command"`
+		}
+		if err := json.Unmarshal(event, &fields); err != nil {
+			t.Fatalf("parse event: %v (%s)", err, event)
+		}
+		if fields.ID != job.ID || fields.Command != "true" {
+			t.Errorf("unexpected event %s", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+}
+
+func TestServer_Call_InProcess(t *testing.T) {
+	srv := NewServer(t)
+
+	result, err := srv.Call(context.Background(), "system.version", nil)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if string(result) != `"`+DefaultVersion+`"` {
+		t.Errorf("unexpected result %s", result)
+	}
+	if calls := srv.Calls(); len(calls) != 1 || calls[0] != "system.version" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
+
+func TestServer_APIKey_MintAndRevoke(t *testing.T) {
+	srv := NewServer(t)
+	ctx := context.Background()
+
+	result, err := srv.Call(ctx, "api_key.create", map[string]any{"name": "ci", "username": srv.Username()})
+	if err != nil {
+		t.Fatalf("api_key.create: %v", err)
+	}
+	var created struct {
+		ID  int64  `json:"id"`
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(result, &created); err != nil || created.Key == "" {
+		t.Fatalf("unexpected api_key.create result %s: %v", result, err)
+	}
+
+	login := func() error {
+		c, err := client.NewWebSocketClient(client.WebSocketConfig{
+			Host:               srv.Host,
+			Port:               srv.Port,
+			Username:           srv.Username(),
+			APIKey:             created.Key,
+			InsecureSkipVerify: true,
+			MaxRetries:         1,
+		})
+		if err != nil {
+			t.Fatalf("NewWebSocketClient: %v", err)
+		}
+		defer c.Close()
+		_, err = c.Call(ctx, "system.info", nil)
+		return err
+	}
+	if err := login(); err != nil {
+		t.Fatalf("expected the minted key to log in: %v", err)
+	}
+
+	if _, err := srv.Call(ctx, "api_key.delete", []any{created.ID}); err != nil {
+		t.Fatalf("api_key.delete: %v", err)
+	}
+	if err := login(); err == nil || !strings.Contains(err.Error(), "AUTH_ERR") {
+		t.Fatalf("expected the deleted key to be rejected, got %v", err)
+	}
+}
+
+func TestServer_GenerateToken(t *testing.T) {
+	srv := NewServer(t)
+
+	result, err := srv.Call(context.Background(), "auth.generate_token", []any{300, map[string]any{}, false})
+	if err != nil {
+		t.Fatalf("auth.generate_token: %v", err)
+	}
+	var token string
+	if err := json.Unmarshal(result, &token); err != nil || token == "" {
+		t.Fatalf("unexpected token %s: %v", result, err)
+	}
+	expires, ok := srv.Token(token)
+	if !ok || time.Until(expires) > 300*time.Second || time.Until(expires) < 290*time.Second {
+		t.Errorf("expected the token to expire in 300s, got %v (issued %v)", time.Until(expires), ok)
+	}
+}
@@ -0,0 +1,409 @@
+package fakenas
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+func TestDatasetService_RoundTrip(t *testing.T) {
+	srv := NewServer(t)
+	c := connect(t, srv)
+	ctx := context.Background()
+	svc := truenas.NewDatasetService(c, c.Version())
+
+	created, err := svc.CreateDataset(ctx, truenas.CreateDatasetOpts{Name: "tank/apps", Compression: "LZ4", Quota: 1 << 30})
+	if err != nil {
+		t.Fatalf("CreateDataset: %v", err)
+	}
+	if created.Mountpoint != "/mnt/tank/apps" || created.Compression != "LZ4" || created.Quota != 1<<30 {
+		t.Errorf("unexpected dataset %+v", created)
+	}
+
+	if _, err := svc.CreateDataset(ctx, truenas.CreateDatasetOpts{Name: "tank/apps"}); err == nil {
+		t.Error("expected duplicate create to fail")
+	}
+
+	comments := "updated"
+	updated, err := svc.UpdateDataset(ctx, "tank/apps", truenas.UpdateDatasetOpts{Comments: &comments})
+	if err != nil {
+		t.Fatalf("UpdateDataset: %v", err)
+	}
+	if updated.Comments != "updated" {
+		t.Errorf("expected comments to be updated, got %q", updated.Comments)
+	}
+
+	if err := svc.DeleteDataset(ctx, "tank/apps", false); err != nil {
+		t.Fatalf("DeleteDataset: %v", err)
+	}
+	got, err := svc.GetDataset(ctx, "tank/apps")
+	if err != nil {
+		t.Fatalf("GetDataset: %v", err)
+	}
+	if got != nil {
+		t.Errorf("expected dataset to be deleted, got %+v", got)
+	}
+}
+
+func TestDatasetService_Zvol(t *testing.T) {
+	srv := NewServer(t)
+	c := connect(t, srv)
+	ctx := context.Background()
+	svc := truenas.NewDatasetService(c, c.Version())
+
+	zvol, err := svc.CreateZvol(ctx, truenas.CreateZvolOpts{Name: "tank/disk", Volsize: 2 << 30})
+	if err != nil {
+		t.Fatalf("CreateZvol: %v", err)
+	}
+	if zvol.Volsize != 2<<30 || zvol.Volblocksize != "16K" {
+		t.Errorf("unexpected zvol %+v", zvol)
+	}
+
+	smaller := int64(1 << 30)
+	if _, err := svc.UpdateZvol(ctx, "tank/disk", truenas.UpdateZvolOpts{Volsize: &smaller}); err == nil {
+		t.Error("expected shrinking without force_size to fail")
+	}
+	zvol, err = svc.UpdateZvol(ctx, "tank/disk", truenas.UpdateZvolOpts{Volsize: &smaller, ForceSize: true})
+	if err != nil {
+		t.Fatalf("UpdateZvol: %v", err)
+	}
+	if zvol.Volsize != smaller {
+		t.Errorf("expected volsize %d, got %d", smaller, zvol.Volsize)
+	}
+}
+
+func TestSnapshotService_Lifecycle(t *testing.T) {
+	for _, version := range []string{"TrueNAS-25.04.2", DefaultVersion} {
+		t.Run(version, func(t *testing.T) {
+			srv := NewServer(t, WithVersion(version))
+			c := connect(t, srv)
+			ctx := context.Background()
+			datasets := truenas.NewDatasetService(c, c.Version())
+			svc := truenas.NewSnapshotService(c, c.Version())
+
+			if _, err := datasets.CreateDataset(ctx, truenas.CreateDatasetOpts{Name: "tank/data"}); err != nil {
+				t.Fatalf("CreateDataset: %v", err)
+			}
+			snap, err := svc.Create(ctx, truenas.CreateSnapshotOpts{Dataset: "tank/data", Name: "daily"})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if snap.ID != "tank/data@daily" || snap.HasHold {
+				t.Errorf("unexpected snapshot %+v", snap)
+			}
+
+			if err := svc.Hold(ctx, snap.ID); err != nil {
+				t.Fatalf("Hold: %v", err)
+			}
+			if err := svc.Delete(ctx, snap.ID); err == nil {
+				t.Error("expected deleting a held snapshot to fail")
+			}
+			if err := svc.Release(ctx, snap.ID); err != nil {
+				t.Fatalf("Release: %v", err)
+			}
+
+			if err := svc.Clone(ctx, snap.ID, "tank/clone"); err != nil {
+				t.Fatalf("Clone: %v", err)
+			}
+			if ds, err := datasets.GetDataset(ctx, "tank/clone"); err != nil || ds == nil {
+				t.Fatalf("expected clone dataset, got %v, %v", ds, err)
+			}
+
+			if err := svc.Delete(ctx, snap.ID); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			got, err := svc.Get(ctx, snap.ID)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got != nil {
+				t.Errorf("expected snapshot to be deleted, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestAppService_Lifecycle(t *testing.T) {
+	srv := NewServer(t)
+	c := connect(t, srv)
+	ctx := context.Background()
+	svc := truenas.NewAppService(c, c.Version())
+
+	compose := "services:\n  web:\n    image: nginx:latest\n"
+	app, err := svc.CreateApp(ctx, truenas.CreateAppOpts{Name: "web", CustomApp: true, CustomComposeConfig: compose})
+	if err != nil {
+		t.Fatalf("CreateApp: %v", err)
+	}
+	if app.State != "RUNNING" || !app.CustomApp {
+		t.Errorf("unexpected app %+v", app)
+	}
+
+	withConfig, err := svc.GetAppWithConfig(ctx, "web")
+	if err != nil {
+		t.Fatalf("GetAppWithConfig: %v", err)
+	}
+	if _, ok := withConfig.Config["services"]; !ok {
+		t.Errorf("expected compose config, got %v", withConfig.Config)
+	}
+
+	if err := svc.StopApp(ctx, "web"); err != nil {
+		t.Fatalf("StopApp: %v", err)
+	}
+	app, err = svc.GetApp(ctx, "web")
+	if err != nil {
+		t.Fatalf("GetApp: %v", err)
+	}
+	if app.State != "STOPPED" {
+		t.Errorf("expected STOPPED, got %s", app.State)
+	}
+
+	if err := svc.DeleteApp(ctx, "web"); err != nil {
+		t.Fatalf("DeleteApp: %v", err)
+	}
+	if app, err := svc.GetApp(ctx, "web"); err != nil || app != nil {
+		t.Errorf("expected app to be deleted, got %+v, %v", app, err)
+	}
+}
+
+func TestVMService_Devices(t *testing.T) {
+	srv := NewServer(t)
+	c := connect(t, srv)
+	ctx := context.Background()
+	svc := truenas.NewVMService(c, c.Version())
+
+	vm, err := svc.CreateVM(ctx, truenas.CreateVMOpts{Name: "web", VCPUs: 2, Cores: 1, Threads: 1, Memory: 2048, Bootloader: "UEFI"})
+	if err != nil {
+		t.Fatalf("CreateVM: %v", err)
+	}
+	if vm.State != VMStopped {
+		t.Errorf("expected STOPPED, got %s", vm.State)
+	}
+
+	dev, err := svc.CreateDevice(ctx, truenas.CreateVMDeviceOpts{
+		VM:         vm.ID,
+		DeviceType: truenas.DeviceTypeNIC,
+		NIC:        &truenas.NICDevice{Type: "VIRTIO", NICAttach: "br0"},
+	})
+	if err != nil {
+		t.Fatalf("CreateDevice: %v", err)
+	}
+	if dev.NIC == nil || dev.NIC.Type != "VIRTIO" || dev.NIC.NICAttach != "br0" {
+		t.Errorf("unexpected device %+v", dev)
+	}
+
+	if err := svc.StartVM(ctx, vm.ID); err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+	if err := svc.StopVM(ctx, vm.ID, truenas.StopVMOpts{Force: true}); err != nil {
+		t.Fatalf("StopVM: %v", err)
+	}
+
+	if err := svc.DeleteVM(ctx, vm.ID); err != nil {
+		t.Fatalf("DeleteVM: %v", err)
+	}
+	devices, err := svc.ListDevices(ctx, vm.ID)
+	if err != nil {
+		t.Fatalf("ListDevices: %v", err)
+	}
+	if len(devices) != 0 {
+		t.Errorf("expected devices to be deleted with the VM, got %d", len(devices))
+	}
+}
+
+func TestVirtService_Instance(t *testing.T) {
+	srv := NewServer(t)
+	c := connect(t, srv)
+	ctx := context.Background()
+	svc := truenas.NewVirtService(c, c.Version())
+
+	_, err := svc.CreateInstance(ctx, truenas.CreateVirtInstanceOpts{Name: "box", Image: "debian/bookworm"})
+	if err == nil || !strings.Contains(err.Error(), "No pool") {
+		t.Fatalf("expected an unconfigured pool error, got %v", err)
+	}
+
+	pool := DefaultPool
+	cfg, err := svc.UpdateGlobalConfig(ctx, truenas.UpdateVirtGlobalConfigOpts{Pool: &pool})
+	if err != nil {
+		t.Fatalf("UpdateGlobalConfig: %v", err)
+	}
+	if cfg.Pool != DefaultPool {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	inst, err := svc.CreateInstance(ctx, truenas.CreateVirtInstanceOpts{
+		Name:      "box",
+		Image:     "debian/bookworm",
+		Autostart: true,
+		Devices:   []truenas.VirtDeviceOpts{{DevType: "DISK", Source: "/mnt/tank", Destination: "/data"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateInstance: %v", err)
+	}
+	if inst.Status != VirtRunning || inst.StoragePool != DefaultPool {
+		t.Errorf("unexpected instance %+v", inst)
+	}
+
+	if err := svc.AddDevice(ctx, "box", truenas.VirtDeviceOpts{DevType: "PROXY", SourceProto: "TCP", SourcePort: 8080, DestProto: "TCP", DestPort: 80}); err != nil {
+		t.Fatalf("AddDevice: %v", err)
+	}
+	devices, err := svc.ListDevices(ctx, "box")
+	if err != nil {
+		t.Fatalf("ListDevices: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(devices))
+	}
+	if err := svc.DeleteDevice(ctx, "box", devices[0].Name); err != nil {
+		t.Fatalf("DeleteDevice: %v", err)
+	}
+
+	if err := svc.DeleteInstance(ctx, "box"); err != nil {
+		t.Fatalf("DeleteInstance: %v", err)
+	}
+	if inst, err := svc.GetInstance(ctx, "box"); err != nil || inst != nil {
+		t.Errorf("expected instance to be deleted, got %+v, %v", inst, err)
+	}
+}
+
+func TestCloudSyncService_CredentialInUse(t *testing.T) {
+	srv := NewServer(t)
+	c := connect(t, srv)
+	ctx := context.Background()
+	svc := truenas.NewCloudSyncService(c, c.Version())
+
+	cred, err := svc.CreateCredential(ctx, truenas.CreateCredentialOpts{
+		Name:         "b2",
+		ProviderType: "B2",
+		Attributes:   map[string]string{"account": "id", "key": "secret"},
+	})
+	if err != nil {
+		t.Fatalf("CreateCredential: %v", err)
+	}
+
+	task, err := svc.CreateTask(ctx, truenas.CreateCloudSyncTaskOpts{
+		Description:  "backup",
+		Path:         "/mnt/tank",
+		CredentialID: cred.ID,
+		Direction:    "PUSH",
+		TransferMode: "SYNC",
+		Enabled:      true,
+		Schedule:     truenas.Schedule{Minute: "0", Hour: "3", Dom: "*", Month: "*", Dow: "*"},
+		Attributes:   map[string]any{"bucket": "backups"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if task.CredentialID != cred.ID || task.Schedule.Hour != "3" {
+		t.Errorf("unexpected task %+v", task)
+	}
+
+	if err := svc.DeleteCredential(ctx, cred.ID); err == nil {
+		t.Error("expected deleting a credential in use to fail")
+	}
+	if err := svc.Sync(ctx, task.ID); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if err := svc.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if err := svc.DeleteCredential(ctx, cred.ID); err != nil {
+		t.Fatalf("DeleteCredential: %v", err)
+	}
+}
+
+func TestFilesystem_WebSocketFallback(t *testing.T) {
+	srv := NewServer(t)
+	ws := connect(t, srv)
+	ctx := context.Background()
+	fallback := transport.NewAPIFallbackClient(transport.APIFallbackConfig{
+		Host:               srv.Host,
+		Port:               srv.Port,
+		InsecureSkipVerify: true,
+	})
+	fallback.Attach(ws)
+
+	if err := ws.MkdirAll(ctx, "/mnt/tank/app/config", 0o750); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := ws.WriteFile(ctx, "/mnt/tank/app/config/app.yaml", truenas.WriteFileParams{Content: []byte("key: value\n"), Mode: 0o600}); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	fsSvc := truenas.NewFilesystemService(ws, ws.Version())
+	st, err := fsSvc.Stat(ctx, "/mnt/tank/app/config/app.yaml")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if st.Mode != 0o600 {
+		t.Errorf("expected mode 0600, got %o", st.Mode)
+	}
+
+	data, err := fallback.ReadFile(ctx, "/mnt/tank/app/config/app.yaml")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "key: value\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	exists, err := ws.FileExists(ctx, "/mnt/tank/missing")
+	if err != nil {
+		t.Fatalf("FileExists: %v", err)
+	}
+	if exists {
+		t.Error("expected missing file not to exist")
+	}
+}
+
+func TestShellClient_FileOperations(t *testing.T) {
+	srv := NewServer(t)
+	c := srv.ShellClient()
+	ctx := context.Background()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	if err := c.MkdirAll(ctx, "/mnt/tank/app", 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := c.WriteFile(ctx, "/mnt/tank/app/file", truenas.WriteFileParams{Content: []byte("hello"), Mode: 0o644}); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := c.Chown(ctx, "/mnt/tank/app/file", 568, 568); err != nil {
+		t.Fatalf("Chown: %v", err)
+	}
+	st, err := truenas.NewFilesystemService(c, c.Version()).Stat(ctx, "/mnt/tank/app/file")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if st.UID != 568 || st.GID != 568 {
+		t.Errorf("expected owner 568:568, got %d:%d", st.UID, st.GID)
+	}
+
+	if err := c.RemoveDir(ctx, "/mnt/tank/app"); err == nil {
+		t.Error("expected removing a non-empty directory to fail")
+	}
+	if err := c.DeleteFile(ctx, "/mnt/tank/app/file"); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	if _, err := c.ReadFile(ctx, "/mnt/tank/app/file"); err == nil {
+		t.Error("expected reading a deleted file to fail")
+	}
+	if err := c.RemoveDir(ctx, "/mnt/tank/app"); err != nil {
+		t.Fatalf("RemoveDir: %v", err)
+	}
+
+	_, err = c.Call(ctx, "cronjob.get_instance", 42)
+	if err == nil || !strings.HasPrefix(err.Error(), "Process exited with status 1") {
+		t.Fatalf("expected SSH-style error, got %v", err)
+	}
+	var tnErr *client.TrueNASError
+	if errors.As(client.ParseTrueNASError(err.Error()), &tnErr); tnErr == nil || tnErr.Code != "ENOENT" {
+		t.Errorf("expected parseable ENOENT error, got %v", err)
+	}
+}
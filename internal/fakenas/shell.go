@@ -0,0 +1,200 @@
+package fakenas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+// ShellClient returns a client.Client that operates on the server's state in
+// process, standing in for the SSH transport. Middleware calls are
+// dispatched like "midclt call" over SSH, and shell operations (rm, rmdir,
+// cat) act on the in-memory filesystem.
+func (s *Server) ShellClient() client.Client {
+	return &shellClient{server: s}
+}
+
+var _ client.Client = (*shellClient)(nil)
+
+type shellClient struct {
+	server  *Server
+	version truenas.Version
+}
+
+func (c *shellClient) Connect(ctx context.Context) error {
+	v, err := truenas.ParseVersion(c.server.version)
+	if err != nil {
+		return err
+	}
+	c.version = v
+	return nil
+}
+
+func (c *shellClient) Version() truenas.Version {
+	return c.version
+}
+
+// Call mirrors the SSH client, whose errors carry midclt's output after the
+// process exit status.
+func (c *shellClient) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := c.server.Call(ctx, method, params)
+	if err != nil {
+		return nil, shellError(err)
+	}
+	return result, nil
+}
+
+func (c *shellClient) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := c.server.CallAndWait(ctx, method, params)
+	if err != nil {
+		if _, ok := err.(*Error); ok {
+			return nil, shellError(err)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+func shellError(err error) error {
+	return fmt.Errorf("Process exited with status 1: %s", err.Error())
+}
+
+func (c *shellClient) WriteFile(ctx context.Context, p string, params truenas.WriteFileParams) error {
+	return truenas.NewFilesystemService(c, c.version).WriteFile(ctx, p, params)
+}
+
+func (c *shellClient) ReadFile(ctx context.Context, p string) ([]byte, error) {
+	data, err := c.server.cat(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", p, shellError(err))
+	}
+	return data, nil
+}
+
+func (c *shellClient) DeleteFile(ctx context.Context, p string) error {
+	if err := c.server.rm(p); err != nil {
+		return fmt.Errorf("failed to delete file %q: %w", p, shellError(err))
+	}
+	return nil
+}
+
+func (c *shellClient) RemoveDir(ctx context.Context, p string) error {
+	if err := c.server.rmdir(p); err != nil {
+		return fmt.Errorf("failed to remove directory %q: %w", p, shellError(err))
+	}
+	return nil
+}
+
+func (c *shellClient) RemoveAll(ctx context.Context, p string) error {
+	if err := c.server.rmRecursive(p); err != nil {
+		return fmt.Errorf("failed to remove directory %q: %w", p, shellError(err))
+	}
+	return nil
+}
+
+func (c *shellClient) FileExists(ctx context.Context, p string) (bool, error) {
+	s := c.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.files[cleanPath(p)]
+	return ok, nil
+}
+
+func (c *shellClient) Chown(ctx context.Context, p string, uid, gid int) error {
+	_, err := c.CallAndWait(ctx, "filesystem.chown", map[string]any{"path": p, "uid": uid, "gid": gid})
+	if err != nil {
+		return fmt.Errorf("failed to change ownership of %q: %w", p, err)
+	}
+	return nil
+}
+
+func (c *shellClient) ChmodRecursive(ctx context.Context, p string, mode fs.FileMode) error {
+	params := map[string]any{
+		"path":    p,
+		"mode":    fmt.Sprintf("%04o", mode),
+		"options": map[string]any{"recursive": true},
+	}
+	if _, err := c.CallAndWait(ctx, "filesystem.setperm", params); err != nil {
+		return fmt.Errorf("failed to chmod %q: %w", p, err)
+	}
+	return nil
+}
+
+func (c *shellClient) MkdirAll(ctx context.Context, p string, mode fs.FileMode) error {
+	params := map[string]any{
+		"path":    p,
+		"options": map[string]any{"mode": fmt.Sprintf("%04o", mode)},
+	}
+	if _, err := c.Call(ctx, "filesystem.mkdir", params); err != nil {
+		return fmt.Errorf("failed to create directory %q: %w", p, err)
+	}
+	return nil
+}
+
+func (c *shellClient) Subscribe(ctx context.Context, collection string, params any) (*truenas.Subscription[json.RawMessage], error) {
+	return nil, client.ErrUnsupportedOperation
+}
+
+func (c *shellClient) Close() error {
+	return nil
+}
+
+// The shell commands the SSH transport runs with sudo. Errors carry the
+// message the command prints on stderr.
+
+func (s *Server) cat(p string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.readFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("cat: %s: No such file or directory", p)
+	}
+	return data, nil
+}
+
+func (s *Server) rm(p string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.files[cleanPath(p)]
+	switch {
+	case !ok:
+		return fmt.Errorf("rm: cannot remove '%s': No such file or directory", p)
+	case n.dir:
+		return fmt.Errorf("rm: cannot remove '%s': Is a directory", p)
+	}
+	delete(s.files, cleanPath(p))
+	return nil
+}
+
+func (s *Server) rmdir(p string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, ok := s.files[cleanPath(p)]
+	switch {
+	case !ok:
+		return fmt.Errorf("rmdir: failed to remove '%s': No such file or directory", p)
+	case !n.dir:
+		return fmt.Errorf("rmdir: failed to remove '%s': Not a directory", p)
+	case len(s.children(p)) > 0:
+		return fmt.Errorf("rmdir: failed to remove '%s': Directory not empty", p)
+	case s.isMountpoint(cleanPath(p)):
+		return fmt.Errorf("rmdir: failed to remove '%s': Device or resource busy", p)
+	}
+	delete(s.files, cleanPath(p))
+	return nil
+}
+
+func (s *Server) rmRecursive(p string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if path.Clean(p) == "/" || cleanPath(p) == "/mnt" {
+		return fmt.Errorf("rm: refusing to remove '%s'", p)
+	}
+	s.removeTree(p)
+	return nil
+}
@@ -0,0 +1,181 @@
+package fakenas
+
+import (
+	"strconv"
+	"strings"
+)
+
+// snapshotPrefix returns the snapshot namespace for the server's version:
+// pool.snapshot from 25.10, zfs.snapshot before.
+func (s *Server) snapshotPrefix() string {
+	if s.Version().AtLeast(25, 10) {
+		return "pool.snapshot"
+	}
+	return "zfs.snapshot"
+}
+
+func (s *Server) snapshots() *table {
+	return s.table(s.snapshotPrefix()+".query", "id")
+}
+
+func (s *Server) registerSnapshot() {
+	prefix := s.snapshotPrefix()
+
+	s.handle(prefix+".query", func(r *Request) (any, error) {
+		return s.snapshots().query(r)
+	})
+	s.handle(prefix+".get_instance", func(r *Request) (any, error) {
+		return s.snapshots().getInstance(r)
+	})
+
+	s.handle(prefix+".create", func(r *Request) (any, error) {
+		var args struct {
+			Dataset   string `json:"dataset"`
+			Name      string `json:"name"`
+			Recursive bool   `json:"recursive"`
+		}
+		if err := r.Arg(0, &args); err != nil {
+			return nil, err
+		}
+		attr := strings.ReplaceAll(prefix, ".", "_") + "_create"
+		if args.Name == "" {
+			return nil, invalid(attr+".name", "Field required")
+		}
+		if s.datasets().get(args.Dataset) == nil {
+			return nil, invalid(attr+".dataset", "Dataset %s does not exist", args.Dataset)
+		}
+		id := args.Dataset + "@" + args.Name
+		if s.snapshots().get(id) != nil {
+			return nil, Errorf(EEXIST, "Failed to snapshot %s: dataset already exists", id)
+		}
+
+		row := s.insertSnapshot(args.Dataset, args.Name)
+		if args.Recursive {
+			for _, child := range s.datasetChildren(args.Dataset) {
+				s.insertSnapshot(child["id"].(string), args.Name)
+			}
+		}
+		return row, nil
+	})
+
+	s.handle(prefix+".delete", func(r *Request) (any, error) {
+		snap, err := s.snapshotArg(r)
+		if err != nil {
+			return nil, err
+		}
+		if userRefs(snap) != "0" {
+			return nil, Errorf(EBUSY, "cannot destroy snapshot %s: dataset is busy", snap["id"])
+		}
+		s.snapshots().remove(snap["id"])
+		return true, nil
+	})
+
+	s.handle(prefix+".hold", func(r *Request) (any, error) {
+		snap, err := s.snapshotArg(r)
+		if err != nil {
+			return nil, err
+		}
+		setUserRefs(snap, "1")
+		s.snapshots().replace(snap)
+		return nil, nil
+	})
+
+	s.handle(prefix+".release", func(r *Request) (any, error) {
+		snap, err := s.snapshotArg(r)
+		if err != nil {
+			return nil, err
+		}
+		setUserRefs(snap, "0")
+		s.snapshots().replace(snap)
+		return nil, nil
+	})
+
+	s.handle(prefix+".rollback", func(r *Request) (any, error) {
+		_, err := s.snapshotArg(r)
+		return nil, err
+	})
+
+	s.handle(prefix+".clone", func(r *Request) (any, error) {
+		var args struct {
+			Snapshot   string `json:"snapshot"`
+			DatasetDst string `json:"dataset_dst"`
+		}
+		if err := r.Arg(0, &args); err != nil {
+			return nil, err
+		}
+		attr := strings.ReplaceAll(prefix, ".", "_") + "_clone"
+		if s.snapshots().get(args.Snapshot) == nil {
+			return nil, NotFound("Snapshot %s does not exist", args.Snapshot)
+		}
+		if s.datasets().get(args.DatasetDst) != nil {
+			return nil, invalid(attr+".dataset_dst", "%s already exists", args.DatasetDst)
+		}
+		row := s.insertDataset(args.DatasetDst, "FILESYSTEM", Row{})
+		row["origin"] = propertyValue(args.Snapshot)
+		return true, nil
+	})
+}
+
+// snapshotArg looks up the snapshot named by the first argument.
+func (s *Server) snapshotArg(r *Request) (Row, error) {
+	var id string
+	if err := r.Arg(0, &id); err != nil {
+		return nil, err
+	}
+	snap := s.snapshots().get(id)
+	if snap == nil {
+		return nil, NotFound("Snapshot %s does not exist", id)
+	}
+	return snap, nil
+}
+
+// insertSnapshot records a snapshot of dataset. s.mu must be held.
+func (s *Server) insertSnapshot(dataset, name string) Row {
+	s.txg++
+	used := lookupParsed(s.datasets().get(dataset), "used")
+	row := Row{
+		"id":            dataset + "@" + name,
+		"name":          dataset + "@" + name,
+		"snapshot_name": name,
+		"dataset":       dataset,
+		"pool":          strings.SplitN(dataset, "/", 2)[0],
+		"type":          "SNAPSHOT",
+		"holds":         Row{},
+		"properties": Row{
+			"createtxg":  propertyValue(strconv.FormatInt(s.txg, 10)),
+			"used":       sizeProperty(0),
+			"referenced": sizeProperty(used),
+			"userrefs":   Row{"value": "0", "rawvalue": "0", "parsed": "0", "source": "NONE"},
+		},
+	}
+	s.snapshots().insert(row)
+	return row
+}
+
+// snapshotsOf returns the snapshots of dataset, and of its descendants when
+// recursive is set.
+func (s *Server) snapshotsOf(dataset string, recursive bool) []Row {
+	var out []Row
+	for _, row := range s.snapshots().rows {
+		ds := row["dataset"].(string)
+		if ds == dataset || (recursive && strings.HasPrefix(ds, dataset+"/")) {
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+func userRefs(snap Row) any {
+	v, _ := lookup(snap, "properties.userrefs.parsed")
+	return v
+}
+
+func setUserRefs(snap Row, refs string) {
+	props := snap["properties"].(Row)
+	props["userrefs"] = Row{"value": refs, "rawvalue": refs, "parsed": refs, "source": "NONE"}
+	holds := Row{}
+	if refs != "0" {
+		holds["truenas"] = float64(1)
+	}
+	snap["holds"] = holds
+}
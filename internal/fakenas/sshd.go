@@ -0,0 +1,492 @@
+package fakenas
+
+import (
+	"bufio"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHServer is an SSH server in front of a Server, as sshd is on TrueNAS.
+// Each session runs a single command: "midclt call [-j] method args..." or
+// one of the shell commands the SSH transport uses (cat, rm, rmdir), either
+// optionally prefixed with sudo. Users other than root are refused unless
+// they use sudo, as with root login disabled on TrueNAS. A bastion created
+// with NewBastion serves no commands and forwards TCP connections instead.
+type SSHServer struct {
+	// Host and Port are the address the server listens on.
+	Host string
+	Port int
+
+	server       *Server // nil for a bastion
+	hostKey      ssh.Signer
+	user         string
+	sudoPassword string
+	authorized   []ssh.PublicKey
+	listener     net.Listener
+
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	commands []string
+	tunnels  int
+	wg       sync.WaitGroup
+}
+
+// SSHOption configures an SSHServer.
+type SSHOption func(*SSHServer)
+
+// WithAuthorizedKey accepts key for public key authentication. It can be
+// given several times, and at least once: without authorized keys every
+// login is rejected.
+func WithAuthorizedKey(key ssh.PublicKey) SSHOption {
+	return func(s *SSHServer) { s.authorized = append(s.authorized, key) }
+}
+
+// WithSSHUser sets the only user that can log in. Defaults to root.
+func WithSSHUser(user string) SSHOption {
+	return func(s *SSHServer) { s.user = user }
+}
+
+// WithSudoPassword makes sudo ask for password, which it reads from stdin
+// with -S. By default sudo does not ask for a password.
+func WithSudoPassword(password string) SSHOption {
+	return func(s *SSHServer) { s.sudoPassword = password }
+}
+
+// WithHostKey sets the host key. By default a new ed25519 key is generated.
+func WithHostKey(key ssh.Signer) SSHOption {
+	return func(s *SSHServer) { s.hostKey = key }
+}
+
+// ServeSSH starts an SSHServer for s on a local listener. It is closed when
+// the test finishes.
+func (s *Server) ServeSSH(t testing.TB, opts ...SSHOption) *SSHServer {
+	t.Helper()
+	return startSSH(t, s, opts)
+}
+
+// NewBastion starts an SSH jump host that forwards connections to any
+// address. It is closed when the test finishes.
+func NewBastion(t testing.TB, opts ...SSHOption) *SSHServer {
+	t.Helper()
+	return startSSH(t, nil, opts)
+}
+
+func startSSH(t testing.TB, srv *Server, opts []SSHOption) *SSHServer {
+	s := &SSHServer{server: srv, user: DefaultUsername, conns: make(map[net.Conn]struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.hostKey == nil {
+		_, s.hostKey = GenerateSSHKey(t)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("fakenas: listen: %v", err)
+	}
+	s.listener = l
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	s.Host = host
+	s.Port, _ = strconv.Atoi(port)
+
+	s.wg.Add(1)
+	go s.accept()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// GenerateSSHKey returns a new ed25519 key as an OpenSSH private key and as
+// a signer, whose PublicKey can be passed to WithAuthorizedKey.
+func GenerateSSHKey(t testing.TB) (privateKeyPEM string, signer ssh.Signer) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("fakenas: generate key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("fakenas: marshal key: %v", err)
+	}
+	signer, err = ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("fakenas: signer: %v", err)
+	}
+	return string(pem.EncodeToMemory(block)), signer
+}
+
+// HostKey returns the server's public host key.
+func (s *SSHServer) HostKey() ssh.PublicKey { return s.hostKey.PublicKey() }
+
+// Fingerprint returns the SHA256 fingerprint of the host key, in the form
+// printed by ssh-keygen -lf.
+func (s *SSHServer) Fingerprint() string { return ssh.FingerprintSHA256(s.HostKey()) }
+
+// Commands returns the commands run so far, in order.
+func (s *SSHServer) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// Tunnels returns the number of connections a bastion has forwarded.
+func (s *SSHServer) Tunnels() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tunnels
+}
+
+// Close disconnects all clients and stops the server.
+func (s *SSHServer) Close() {
+	_ = s.listener.Close()
+	s.mu.Lock()
+	for c := range s.conns {
+		_ = c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *SSHServer) accept() {
+	defer s.wg.Done()
+	config := &ssh.ServerConfig{PublicKeyCallback: s.checkKey}
+	config.AddHostKey(s.hostKey)
+
+	for {
+		nc, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[nc] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, nc)
+				s.mu.Unlock()
+				_ = nc.Close()
+			}()
+			s.serveConn(nc, config)
+		}()
+	}
+}
+
+func (s *SSHServer) checkKey(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	if meta.User() != s.user {
+		return nil, fmt.Errorf("unknown user %q", meta.User())
+	}
+	for _, k := range s.authorized {
+		if string(k.Marshal()) == string(key.Marshal()) {
+			return nil, nil
+		}
+	}
+	return nil, errors.New("key not authorized")
+}
+
+func (s *SSHServer) serveConn(nc net.Conn, config *ssh.ServerConfig) {
+	sc, chans, reqs, err := ssh.NewServerConn(nc, config)
+	if err != nil {
+		return
+	}
+	defer func() { _ = sc.Close() }()
+	go ssh.DiscardRequests(reqs)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for nch := range chans {
+		switch {
+		case nch.ChannelType() == "session" && s.server != nil:
+			ch, reqs, err := nch.Accept()
+			if err != nil {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.serveSession(ch, reqs)
+			}()
+		case nch.ChannelType() == "direct-tcpip" && s.server == nil:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.forward(nch)
+			}()
+		default:
+			_ = nch.Reject(ssh.Prohibited, "not supported")
+		}
+	}
+}
+
+// forward tunnels a direct-tcpip channel, as ssh -J uses.
+func (s *SSHServer) forward(nch ssh.NewChannel) {
+	var dest struct {
+		Host     string
+		Port     uint32
+		OrigHost string
+		OrigPort uint32
+	}
+	if err := ssh.Unmarshal(nch.ExtraData(), &dest); err != nil {
+		_ = nch.Reject(ssh.ConnectionFailed, "malformed request")
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(dest.Host, strconv.Itoa(int(dest.Port))))
+	if err != nil {
+		_ = nch.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	defer func() { _ = target.Close() }()
+	ch, reqs, err := nch.Accept()
+	if err != nil {
+		return
+	}
+	defer func() { _ = ch.Close() }()
+	go ssh.DiscardRequests(reqs)
+
+	s.mu.Lock()
+	s.tunnels++
+	s.mu.Unlock()
+
+	done := make(chan struct{}, 2)
+	go func() { _, _ = io.Copy(target, ch); done <- struct{}{} }()
+	go func() { _, _ = io.Copy(ch, target); done <- struct{}{} }()
+	<-done
+}
+
+func (s *SSHServer) serveSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer func() { _ = ch.Close() }()
+	for req := range reqs {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		var payload struct{ Command string }
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+
+		s.mu.Lock()
+		s.commands = append(s.commands, payload.Command)
+		s.mu.Unlock()
+
+		status := s.exec(payload.Command, ch, ch, ch.Stderr())
+		_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+		return
+	}
+}
+
+// exec runs a command line and returns its exit status.
+func (s *SSHServer) exec(command string, stdin io.Reader, stdout, stderr io.Writer) uint32 {
+	args, err := splitCommand(command)
+	if err != nil {
+		fmt.Fprintf(stderr, "sh: 1: %s\n", err)
+		return 2
+	}
+	elevated := s.user == DefaultUsername
+	if len(args) > 0 && args[0] == "sudo" {
+		if args, err = s.sudo(args[1:], stdin); err != nil {
+			fmt.Fprintf(stderr, "sudo: %s\n", err)
+			return 1
+		}
+		elevated = true
+	}
+	if len(args) == 0 {
+		return 0
+	}
+	if !elevated {
+		switch args[0] {
+		case "midclt":
+			fmt.Fprintln(stderr, "[EPERM] Not authorized")
+			return 1
+		case "cat", "rm", "rmdir":
+			fmt.Fprintf(stderr, "%s: %s: Permission denied\n", args[0], args[len(args)-1])
+			return 1
+		}
+	}
+
+	var out []byte
+	switch args[0] {
+	case "midclt":
+		out, err = s.midclt(args[1:])
+	case "cat":
+		if len(args) != 2 {
+			err = errors.New("usage: cat FILE")
+			break
+		}
+		out, err = s.server.cat(args[1])
+	case "rm":
+		switch {
+		case len(args) == 3 && args[1] == "-rf":
+			err = s.server.rmRecursive(args[2])
+		case len(args) == 2:
+			err = s.server.rm(args[1])
+		default:
+			err = errors.New("usage: rm [-rf] FILE")
+		}
+	case "rmdir":
+		if len(args) != 2 {
+			err = errors.New("usage: rmdir DIRECTORY")
+			break
+		}
+		err = s.server.rmdir(args[1])
+	default:
+		fmt.Fprintf(stderr, "sh: 1: %s: not found\n", args[0])
+		return 127
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	_, _ = stdout.Write(out)
+	return 0
+}
+
+// sudo parses the options of sudo that the SSH transport uses and checks the
+// password when one is required. It returns the command to run.
+func (s *SSHServer) sudo(args []string, stdin io.Reader) ([]string, error) {
+	nonInteractive, readStdin := false, false
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-n":
+			nonInteractive = true
+		case "-S":
+			readStdin = true
+		case "-p":
+			if len(args) < 2 {
+				return nil, errors.New("option requires an argument -- 'p'")
+			}
+			args = args[1:]
+		case "--":
+			return args[1:], nil
+		default:
+			return nil, fmt.Errorf("invalid option -- '%s'", strings.TrimLeft(args[0], "-"))
+		}
+		args = args[1:]
+	}
+	if s.sudoPassword == "" {
+		return args, nil
+	}
+
+	switch {
+	case readStdin:
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return nil, errors.New("no password was provided")
+		}
+		if strings.TrimSuffix(line, "\n") != s.sudoPassword {
+			return nil, errors.New("1 incorrect password attempt")
+		}
+		return args, nil
+	case nonInteractive:
+		return nil, errors.New("a password is required")
+	default:
+		return nil, errors.New("a terminal is required to read the password; " +
+			"either use the -S option to read from standard input or configure an askpass helper")
+	}
+}
+
+// midclt runs "midclt call [-j] method args...". Like midclt, it prints
+// string results as they are and anything else as JSON.
+func (s *SSHServer) midclt(args []string) ([]byte, error) {
+	if len(args) < 2 || args[0] != "call" {
+		return nil, errors.New("usage: midclt call [-j] method [args...]")
+	}
+	args = args[1:]
+	wait := args[0] == "-j"
+	if wait {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, errors.New("usage: midclt call [-j] method [args...]")
+	}
+
+	params := make([]any, len(args)-1)
+	for i, arg := range args[1:] {
+		params[i] = json.RawMessage(arg)
+	}
+
+	var result json.RawMessage
+	var err error
+	if wait {
+		result, err = s.server.CallAndWait(context.Background(), args[0], params)
+	} else {
+		result, err = s.server.Call(context.Background(), args[0], params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var str string
+	if json.Unmarshal(result, &str) == nil {
+		return []byte(str + "\n"), nil
+	}
+	return append(result, '\n'), nil
+}
+
+// splitCommand splits a command line into words, following the quoting
+// rules of the POSIX shell for single quotes, double quotes and backslashes.
+func splitCommand(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(command); i++ {
+		ch := command[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case ch == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated quoted string")
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case ch == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`", command[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(command[i])
+			}
+			if i >= len(command) {
+				return nil, errors.New("unterminated quoted string")
+			}
+			inWord = true
+		case ch == '\\' && i+1 < len(command):
+			i++
+			word.WriteByte(command[i])
+			inWord = true
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,93 @@
+package fakenas
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{`sudo midclt call system.version`, []string{"sudo", "midclt", "call", "system.version"}},
+		{`midclt call pool.dataset.create '{"name":"tank/a b"}'`, []string{"midclt", "call", "pool.dataset.create", `{"name":"tank/a b"}`}},
+		{`cat '/mnt/it'"'"'s'`, []string{"cat", "/mnt/it's"}},
+		{`rm "a \"b\"" c\ d`, []string{"rm", `a "b"`, "c d"}},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.command, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.command, got, tt.want)
+		}
+	}
+
+	if _, err := splitCommand(`cat 'unterminated`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+// runSSH runs command on sshd and returns its output and exit error.
+func runSSH(t *testing.T, sshd *SSHServer, key ssh.Signer, command string) (string, error) {
+	t.Helper()
+	c, err := ssh.Dial("tcp", sshd.listener.Addr().String(), &ssh.ClientConfig{
+		User:            DefaultUsername,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(key)},
+		HostKeyCallback: ssh.FixedHostKey(sshd.HostKey()),
+	})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.Close()
+	session, err := c.NewSession()
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	defer session.Close()
+	out, err := session.CombinedOutput(command)
+	return string(out), err
+}
+
+func TestSSHServer_Midclt(t *testing.T) {
+	_, key := GenerateSSHKey(t)
+	srv := NewServer(t)
+	sshd := srv.ServeSSH(t, WithAuthorizedKey(key.PublicKey()))
+
+	out, err := runSSH(t, sshd, key, "sudo midclt call system.version")
+	if err != nil || out != DefaultVersion+"\n" {
+		t.Fatalf("system.version: %q, %v", out, err)
+	}
+
+	out, err = runSSH(t, sshd, key, `sudo midclt call filesystem.stat '"/mnt/missing"'`)
+	if err == nil || !strings.Contains(out, "[ENOENT]") {
+		t.Fatalf("expected ENOENT, got %q, %v", out, err)
+	}
+
+	if _, err := runSSH(t, sshd, key, "reboot"); err == nil {
+		t.Fatal("expected unknown command to fail")
+	}
+	if got := sshd.Commands(); len(got) != 3 || got[2] != "reboot" {
+		t.Errorf("unexpected commands: %q", got)
+	}
+}
+
+func TestSSHServer_RejectsUnknownKey(t *testing.T) {
+	_, key := GenerateSSHKey(t)
+	_, other := GenerateSSHKey(t)
+	sshd := NewServer(t).ServeSSH(t, WithAuthorizedKey(key.PublicKey()))
+
+	_, err := ssh.Dial("tcp", sshd.listener.Addr().String(), &ssh.ClientConfig{
+		User:            DefaultUsername,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(other)},
+		HostKeyCallback: ssh.FixedHostKey(sshd.HostKey()),
+	})
+	if err == nil {
+		t.Fatal("expected authentication to fail")
+	}
+}
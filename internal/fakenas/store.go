@@ -0,0 +1,271 @@
+package fakenas
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+)
+
+// table is an ordered in-memory collection of rows keyed by one field.
+// Tables named after a query method ("vm.query") publish changes to
+// subscribers of that collection.
+type table struct {
+	server *Server
+	name   string
+	key    string
+	nextID int64
+	rows   []Row
+}
+
+// table returns the named table, creating it on first use. s.mu must be held.
+func (s *Server) table(name, key string) *table {
+	t, ok := s.tables[name]
+	if !ok {
+		t = &table{server: s, name: name, key: key}
+		s.tables[name] = t
+	}
+	return t
+}
+
+// newID allocates the next integer ID.
+func (t *table) newID() int64 {
+	t.nextID++
+	return t.nextID
+}
+
+func (t *table) index(id any) int {
+	for i, row := range t.rows {
+		if equal(row[t.key], id) {
+			return i
+		}
+	}
+	return -1
+}
+
+// get returns the row with the given key, or nil.
+func (t *table) get(id any) Row {
+	if i := t.index(normalizeKey(id)); i >= 0 {
+		return t.rows[i]
+	}
+	return nil
+}
+
+func (t *table) insert(row Row) {
+	t.rows = append(t.rows, row)
+	t.notify("added", row)
+}
+
+// replace stores row in place of the existing row with the same key.
+func (t *table) replace(row Row) {
+	if i := t.index(row[t.key]); i >= 0 {
+		t.rows[i] = row
+		t.notify("changed", row)
+	}
+}
+
+func (t *table) remove(id any) bool {
+	i := t.index(normalizeKey(id))
+	if i < 0 {
+		return false
+	}
+	row := t.rows[i]
+	t.rows = append(t.rows[:i], t.rows[i+1:]...)
+	if t.publishes() {
+		t.server.publish(t.name, "removed", row[t.key], nil)
+	}
+	return true
+}
+
+// all returns every row. The slice is a copy; rows are shared.
+func (t *table) all() []Row {
+	return append([]Row(nil), t.rows...)
+}
+
+func (t *table) publishes() bool {
+	return t.name != jobsCollection && strings.HasSuffix(t.name, ".query")
+}
+
+func (t *table) notify(msg string, row Row) {
+	if t.publishes() {
+		t.server.publish(t.name, msg, row[t.key], row)
+	}
+}
+
+// normalizeKey converts Go integer keys to their JSON-decoded form.
+func normalizeKey(id any) any {
+	switch v := id.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return id
+}
+
+// queryTable implements a CRUD query method over t.
+func (t *table) query(r *Request) (any, error) {
+	return query(t.all(), r.RawArg(0), r.RawArg(1))
+}
+
+// getInstance implements a CRUD get_instance method over t.
+func (t *table) getInstance(r *Request) (any, error) {
+	var id any
+	if err := r.Arg(0, &id); err != nil {
+		return nil, err
+	}
+	row := t.get(id)
+	if row == nil {
+		return nil, NotFound("%v does not exist", id)
+	}
+	return row, nil
+}
+
+// node is a file or directory in the in-memory filesystem.
+type node struct {
+	dir  bool
+	data []byte
+	mode uint32
+	uid  int64
+	gid  int64
+}
+
+// S_IFDIR and S_IFREG are the file type bits reported by filesystem.stat.
+const (
+	sIFDIR = 0o040000
+	sIFREG = 0o100000
+)
+
+func cleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// mkdir creates a single directory whose parent must exist. s.mu must be held.
+func (s *Server) mkdir(p string, mode uint32) error {
+	p = cleanPath(p)
+	if n, ok := s.files[p]; ok {
+		if n.dir {
+			return Errorf(EEXIST, "File exists: '%s'", p)
+		}
+		return Errorf(EEXIST, "File exists: '%s'", p)
+	}
+	parent, ok := s.files[path.Dir(p)]
+	if !ok || !parent.dir {
+		return NotFound("No such file or directory: '%s'", path.Dir(p))
+	}
+	s.files[p] = &node{dir: true, mode: mode}
+	return nil
+}
+
+// mkdirAll creates p and any missing parents. s.mu must be held.
+func (s *Server) mkdirAll(p string, mode uint32) error {
+	p = cleanPath(p)
+	if n, ok := s.files[p]; ok {
+		if !n.dir {
+			return Errorf(EEXIST, "Not a directory: '%s'", p)
+		}
+		return nil
+	}
+	if p != "/" {
+		if err := s.mkdirAll(path.Dir(p), mode); err != nil {
+			return err
+		}
+	}
+	s.files[p] = &node{dir: true, mode: mode}
+	return nil
+}
+
+// writeFile creates or replaces a regular file. s.mu must be held.
+func (s *Server) writeFile(p string, data []byte, mode uint32, uid, gid int64) error {
+	p = cleanPath(p)
+	parent, ok := s.files[path.Dir(p)]
+	if !ok || !parent.dir {
+		return NotFound("No such file or directory: '%s'", path.Dir(p))
+	}
+	if n, ok := s.files[p]; ok && n.dir {
+		return Errorf(EINVAL, "Is a directory: '%s'", p)
+	}
+	s.files[p] = &node{data: append([]byte(nil), data...), mode: mode, uid: uid, gid: gid}
+	return nil
+}
+
+// children returns the paths directly below p. s.mu must be held.
+func (s *Server) children(p string) []string {
+	p = cleanPath(p)
+	var out []string
+	for name := range s.files {
+		if name != p && path.Dir(name) == p {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// removeTree deletes p and everything below it. s.mu must be held.
+func (s *Server) removeTree(p string) {
+	p = cleanPath(p)
+	for name := range s.files {
+		if name == p || strings.HasPrefix(name, p+"/") {
+			delete(s.files, name)
+		}
+	}
+}
+
+// stat returns the filesystem.stat result for p. s.mu must be held.
+func (s *Server) stat(p string) (Row, error) {
+	p = cleanPath(p)
+	n, ok := s.files[p]
+	if !ok {
+		return nil, NotFound("Path %s not found", p)
+	}
+	kind, mode, size := "FILE", sIFREG|n.mode, len(n.data)
+	if n.dir {
+		kind, mode, size = "DIRECTORY", sIFDIR|n.mode, 4096
+	}
+	return Row{
+		"realpath":      p,
+		"type":          kind,
+		"size":          float64(size),
+		"mode":          float64(mode),
+		"uid":           float64(n.uid),
+		"gid":           float64(n.gid),
+		"user":          userName(n.uid),
+		"group":         userName(n.gid),
+		"acl":           false,
+		"is_mountpoint": s.isMountpoint(p),
+	}, nil
+}
+
+func userName(id int64) any {
+	switch id {
+	case 0:
+		return "root"
+	case 568:
+		return "apps"
+	}
+	return nil
+}
+
+// isMountpoint reports whether p is the mountpoint of a dataset.
+func (s *Server) isMountpoint(p string) bool {
+	for _, row := range s.table("pool.dataset.query", "id").rows {
+		if row["mountpoint"] == p {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeObject decodes argument i as a JSON object.
+func decodeObject(r *Request, i int) (Row, error) {
+	row := Row{}
+	raw := r.RawArg(i)
+	if raw == nil {
+		return row, nil
+	}
+	if err := json.Unmarshal(raw, &row); err != nil {
+		return nil, invalid(r.Method, "Argument %d must be an object", i+1)
+	}
+	return row, nil
+}
@@ -0,0 +1,135 @@
+package fakenas
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// registerMethods installs the built-in method handlers.
+func (s *Server) registerMethods() {
+	s.registerSystem()
+	s.registerPool()
+	s.registerSnapshot()
+	s.registerApp()
+	s.registerCron()
+	s.registerCloudSync()
+	s.registerVM()
+	s.registerVirt()
+	s.registerFilesystem()
+	s.registerAPIKey()
+}
+
+// handle registers a built-in plain method. Unlike Handle it does not lock,
+// as it only runs while the server is being constructed.
+func (s *Server) handle(name string, h Handler) {
+	s.methods[name] = method{handler: h}
+}
+
+// handleJob registers a built-in job method.
+func (s *Server) handleJob(name string, h Handler) {
+	s.methods[name] = method{handler: h, job: true}
+}
+
+// seed creates the initial pool, its root dataset and the directories
+// backing them.
+func (s *Server) seed() {
+	s.files["/"] = &node{dir: true, mode: 0o755}
+	_ = s.mkdirAll("/mnt", 0o755)
+
+	pools := s.table("pool.query", "id")
+	pools.insert(Row{
+		"id":        float64(pools.newID()),
+		"name":      s.pool,
+		"guid":      "1234567890",
+		"path":      "/mnt/" + s.pool,
+		"status":    "ONLINE",
+		"healthy":   true,
+		"size":      float64(poolSize),
+		"allocated": float64(0),
+		"free":      float64(poolSize),
+	})
+	s.insertDataset(s.pool, "FILESYSTEM", Row{})
+}
+
+// poolSize is the capacity reported for the seeded pool: 1 TiB.
+const poolSize int64 = 1 << 40
+
+func (s *Server) registerSystem() {
+	s.handle("system.version", func(r *Request) (any, error) {
+		return s.version, nil
+	})
+	s.handle("system.info", func(r *Request) (any, error) {
+		return Row{
+			"version":        s.version,
+			"hostname":       "truenas",
+			"physmem":        float64(16 << 30),
+			"model":          "Fake CPU",
+			"cores":          float64(4),
+			"physical_cores": float64(4),
+			"uptime_seconds": float64(3600),
+			"system_product": "fakenas",
+			"timezone":       "UTC",
+		}, nil
+	})
+
+	s.handle("core.get_jobs", func(r *Request) (any, error) {
+		return s.jobsTable().query(r)
+	})
+	s.handle("core.job_abort", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		return nil, s.abortJob(id)
+	})
+
+	// core.download runs a method whose output is served over HTTP, as
+	// used to read files with filesystem.get.
+	s.handle("core.download", func(r *Request) (any, error) {
+		var name string
+		var args []string
+		if err := r.Arg(0, &name); err != nil {
+			return nil, err
+		}
+		if err := r.Arg(1, &args); err != nil {
+			return nil, err
+		}
+		if name != "filesystem.get" || len(args) != 1 {
+			return nil, Errorf(EINVAL, "%s is not supported by core.download", name)
+		}
+		data, err := s.readFile(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		s.nextJobID++
+		id := s.nextJobID
+		token := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("download-%d", id)))
+		s.downloads[token] = data
+		return []any{id, fmt.Sprintf("/_download/%d?auth_token=%s", id, token)}, nil
+	})
+}
+
+// serveDownload serves the content prepared by core.download. Each token
+// can be used once.
+func (s *Server) serveDownload(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/_download/") {
+		http.NotFound(w, r)
+		return
+	}
+	token := r.URL.Query().Get("auth_token")
+
+	s.mu.Lock()
+	data, ok := s.downloads[token]
+	delete(s.downloads, token)
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "invalid auth token", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,317 @@
+package fakenas
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Virt instance states reported by virt.instance.query.
+const (
+	VirtRunning = "RUNNING"
+	VirtStopped = "STOPPED"
+)
+
+var virtNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{0,62}$`)
+
+func (s *Server) instances() *table {
+	return s.table("virt.instance.query", "id")
+}
+
+// virtGlobal returns the virt.global configuration, creating the
+// unconfigured default on first use. s.mu must be held.
+func (s *Server) virtGlobal() Row {
+	if s.virt == nil {
+		s.virt = Row{
+			"id":            float64(1),
+			"pool":          nil,
+			"dataset":       nil,
+			"storage_pools": []any{},
+			"bridge":        nil,
+			"v4_network":    nil,
+			"v6_network":    nil,
+			"state":         "NO_POOL",
+		}
+	}
+	return s.virt
+}
+
+func (s *Server) registerVirt() {
+	s.handle("virt.global.config", func(r *Request) (any, error) {
+		return s.virtGlobal(), nil
+	})
+
+	s.handle("virt.global.update", func(r *Request) (any, error) {
+		params, err := decodeObject(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		cfg := s.virtGlobal()
+		if v, ok := params["pool"]; ok {
+			pool, _ := v.(string)
+			switch {
+			case pool == "":
+				cfg["pool"], cfg["dataset"], cfg["storage_pools"], cfg["state"] = nil, nil, []any{}, "NO_POOL"
+			case s.poolByName(pool) == nil:
+				return nil, invalid("virt_global_update.pool", "Pool %s does not exist", pool)
+			default:
+				cfg["pool"], cfg["dataset"], cfg["storage_pools"], cfg["state"] = pool, pool+"/.ix-virt", []any{pool}, "INITIALIZED"
+			}
+		}
+		for _, key := range []string{"bridge", "v4_network", "v6_network"} {
+			if v, ok := params[key]; ok {
+				if str, _ := v.(string); str == "" {
+					v = nil
+				}
+				cfg[key] = v
+			}
+		}
+		return cfg, nil
+	})
+
+	s.handle("virt.instance.query", func(r *Request) (any, error) {
+		return s.instances().query(r)
+	})
+	s.handle("virt.instance.get_instance", func(r *Request) (any, error) {
+		var id string
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		row := s.instances().get(id)
+		if row == nil {
+			return nil, NotFound("Instance %s does not exist", id)
+		}
+		return row, nil
+	})
+
+	s.handleJob("virt.instance.create", func(r *Request) (any, error) {
+		var args struct {
+			Name         string  `json:"name"`
+			InstanceType string  `json:"instance_type"`
+			Image        string  `json:"image"`
+			CPU          string  `json:"cpu"`
+			Memory       int64   `json:"memory"`
+			Autostart    *bool   `json:"autostart"`
+			Environment  Row     `json:"environment"`
+			Devices      []Row   `json:"devices"`
+			StoragePool  *string `json:"storage_pool"`
+		}
+		if err := r.Arg(0, &args); err != nil {
+			return nil, err
+		}
+
+		var errs []ValidationError
+		if !virtNamePattern.MatchString(args.Name) {
+			errs = append(errs, ValidationError{Attribute: "virt_instance_create.name", Message: "Invalid instance name"})
+		} else if s.instances().get(args.Name) != nil {
+			errs = append(errs, ValidationError{Attribute: "virt_instance_create.name", Message: "Instance " + args.Name + " already exists", Errno: EEXIST})
+		}
+		if !strings.Contains(args.Image, "/") {
+			errs = append(errs, ValidationError{Attribute: "virt_instance_create.image", Message: "Image must be in the form name/release"})
+		}
+		pool, _ := s.virtGlobal()["pool"].(string)
+		if args.StoragePool != nil && *args.StoragePool != "" {
+			pool = *args.StoragePool
+		}
+		if pool == "" {
+			errs = append(errs, ValidationError{Attribute: "virt_instance_create.storage_pool", Message: "No pool has been configured for virtualization"})
+		} else if s.poolByName(pool) == nil {
+			errs = append(errs, ValidationError{Attribute: "virt_instance_create.storage_pool", Message: "Pool " + pool + " does not exist", Errno: ENOENT})
+		}
+		if len(errs) > 0 {
+			return nil, Invalid(errs...)
+		}
+
+		kind := args.InstanceType
+		if kind == "" {
+			kind = "CONTAINER"
+		}
+		autostart := true
+		if args.Autostart != nil {
+			autostart = *args.Autostart
+		}
+		env := args.Environment
+		if env == nil {
+			env = Row{}
+		}
+		image := strings.SplitN(args.Image, "/", 2)
+		row := Row{
+			"id":           args.Name,
+			"name":         args.Name,
+			"type":         kind,
+			"status":       VirtRunning,
+			"cpu":          nil,
+			"memory":       nil,
+			"autostart":    autostart,
+			"environment":  env,
+			"aliases":      []any{Row{"type": "INET", "address": "10.0.0.10", "netmask": float64(24)}},
+			"storage_pool": pool,
+			"image": Row{
+				"architecture": "amd64",
+				"description":  fmt.Sprintf("%s %s amd64 (default)", image[0], image[1]),
+				"os":           image[0],
+				"release":      image[1],
+				"variant":      "default",
+				"serial":       "",
+				"type":         strings.ToLower(kind),
+			},
+			"raw":         nil,
+			"vnc_enabled": false,
+			"vnc_port":    nil,
+			"secure_boot": false,
+			"devices":     []any{},
+		}
+		if args.CPU != "" {
+			row["cpu"] = args.CPU
+		}
+		if args.Memory != 0 {
+			row["memory"] = float64(args.Memory)
+		}
+		for i, dev := range args.Devices {
+			if err := addVirtDevice(row, dev, i); err != nil {
+				return nil, err
+			}
+		}
+		r.Progress(50, "Creating instance")
+		s.instances().insert(row)
+		return row, nil
+	})
+
+	s.handleJob("virt.instance.update", func(r *Request) (any, error) {
+		row, err := s.instanceArg(r)
+		if err != nil {
+			return nil, err
+		}
+		params, err := decodeObject(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range []string{"autostart", "environment", "cpu", "memory", "vnc_enabled", "vnc_port"} {
+			if v, ok := params[key]; ok {
+				row[key] = v
+			}
+		}
+		s.instances().replace(row)
+		return row, nil
+	})
+
+	s.handleJob("virt.instance.delete", func(r *Request) (any, error) {
+		row, err := s.instanceArg(r)
+		if err != nil {
+			return nil, err
+		}
+		s.instances().remove(row["id"])
+		return true, nil
+	})
+
+	s.handleJob("virt.instance.start", func(r *Request) (any, error) {
+		return s.setInstanceStatus(r, VirtRunning)
+	})
+	s.handleJob("virt.instance.stop", func(r *Request) (any, error) {
+		return s.setInstanceStatus(r, VirtStopped)
+	})
+	s.handleJob("virt.instance.restart", func(r *Request) (any, error) {
+		return s.setInstanceStatus(r, VirtRunning)
+	})
+
+	s.handle("virt.instance.device_list", func(r *Request) (any, error) {
+		row, err := s.instanceArg(r)
+		if err != nil {
+			return nil, err
+		}
+		return row["devices"], nil
+	})
+
+	s.handleJob("virt.instance.device_add", func(r *Request) (any, error) {
+		row, err := s.instanceArg(r)
+		if err != nil {
+			return nil, err
+		}
+		dev, err := decodeObject(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := addVirtDevice(row, dev, len(row["devices"].([]any))); err != nil {
+			return nil, err
+		}
+		s.instances().replace(row)
+		return true, nil
+	})
+
+	s.handleJob("virt.instance.device_delete", func(r *Request) (any, error) {
+		row, err := s.instanceArg(r)
+		if err != nil {
+			return nil, err
+		}
+		var name string
+		if err := r.Arg(1, &name); err != nil {
+			return nil, err
+		}
+		devices := row["devices"].([]any)
+		for i, dev := range devices {
+			if dev.(Row)["name"] == name {
+				row["devices"] = append(devices[:i:i], devices[i+1:]...)
+				s.instances().replace(row)
+				return true, nil
+			}
+		}
+		return nil, NotFound("Device %s does not exist on instance %s", name, row["name"])
+	})
+}
+
+// instanceArg looks up the virt instance named by the first argument.
+func (s *Server) instanceArg(r *Request) (Row, error) {
+	var id string
+	if err := r.Arg(0, &id); err != nil {
+		return nil, err
+	}
+	row := s.instances().get(id)
+	if row == nil {
+		return nil, NotFound("Instance %s does not exist", id)
+	}
+	return row, nil
+}
+
+func (s *Server) setInstanceStatus(r *Request, status string) (any, error) {
+	row, err := s.instanceArg(r)
+	if err != nil {
+		return nil, err
+	}
+	row["status"] = status
+	s.instances().replace(row)
+	return true, nil
+}
+
+// poolByName returns the pool row with the given name, or nil.
+func (s *Server) poolByName(name string) Row {
+	for _, row := range s.table("pool.query", "id").rows {
+		if row["name"] == name {
+			return row
+		}
+	}
+	return nil
+}
+
+// addVirtDevice appends dev to the instance's devices, naming it after its
+// type when no name is given.
+func addVirtDevice(row, dev Row, index int) error {
+	devType, _ := dev["dev_type"].(string)
+	if devType == "" {
+		return invalid("virt_device.dev_type", "Field required")
+	}
+	out := Row{"readonly": false, "description": nil}
+	for k, v := range dev {
+		out[k] = v
+	}
+	if name, _ := out["name"].(string); name == "" {
+		out["name"] = fmt.Sprintf("%s%d", strings.ToLower(devType), index)
+	}
+	devices := row["devices"].([]any)
+	for _, existing := range devices {
+		if existing.(Row)["name"] == out["name"] {
+			return invalid("virt_device.name", "Device %s already exists", out["name"])
+		}
+	}
+	row["devices"] = append(devices, out)
+	return nil
+}
@@ -0,0 +1,325 @@
+package fakenas
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// VM states reported in vm.query status.
+const (
+	VMRunning = "RUNNING"
+	VMStopped = "STOPPED"
+)
+
+var vmNamePattern = regexp.MustCompile(`^[a-zA-Z_0-9]+$`)
+
+func (s *Server) vms() *table {
+	return s.table("vm.query", "id")
+}
+
+func (s *Server) vmDevices() *table {
+	return s.table("vm.device.query", "id")
+}
+
+func (s *Server) registerVM() {
+	s.handle("vm.query", func(r *Request) (any, error) {
+		return query(s.vmRows(), r.RawArg(0), r.RawArg(1))
+	})
+	s.handle("vm.get_instance", func(r *Request) (any, error) {
+		row, err := s.vmArg(r)
+		if err != nil {
+			return nil, err
+		}
+		return s.vmWithDevices(row), nil
+	})
+
+	s.handle("vm.create", func(r *Request) (any, error) {
+		params, err := decodeObject(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.validateVM("vm_create", params, nil); err != nil {
+			return nil, err
+		}
+		row := Row{
+			"id":                    float64(s.vms().newID()),
+			"description":           "",
+			"vcpus":                 float64(1),
+			"cores":                 float64(1),
+			"threads":               float64(1),
+			"memory":                float64(512),
+			"min_memory":            nil,
+			"autostart":             true,
+			"time":                  "LOCAL",
+			"bootloader":            "UEFI",
+			"bootloader_ovmf":       "OVMF_CODE.fd",
+			"cpu_mode":              "CUSTOM",
+			"cpu_model":             nil,
+			"shutdown_timeout":      float64(90),
+			"command_line_args":     "",
+			"hide_from_msr":         false,
+			"ensure_display_device": true,
+			"hyperv_enlightenments": false,
+			"status":                vmStatus(VMStopped),
+		}
+		if s.Version().AtLeast(25, 4) {
+			row["enable_secure_boot"] = false
+		}
+		applyParams(row, params, "devices")
+		s.vms().insert(row)
+		return s.vmWithDevices(row), nil
+	})
+
+	s.handle("vm.update", func(r *Request) (any, error) {
+		row, err := s.vmArg(r)
+		if err != nil {
+			return nil, err
+		}
+		params, err := decodeObject(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.validateVM("vm_update", params, row); err != nil {
+			return nil, err
+		}
+		applyParams(row, params, "devices", "status")
+		s.vms().replace(row)
+		return s.vmWithDevices(row), nil
+	})
+
+	s.handle("vm.delete", func(r *Request) (any, error) {
+		row, err := s.vmArg(r)
+		if err != nil {
+			return nil, err
+		}
+		for _, dev := range s.devicesOf(row["id"]) {
+			s.vmDevices().remove(dev["id"])
+		}
+		s.vms().remove(row["id"])
+		return true, nil
+	})
+
+	s.handle("vm.start", func(r *Request) (any, error) {
+		row, err := s.vmArg(r)
+		if err != nil {
+			return nil, err
+		}
+		if lookupString(row, "status.state") == VMRunning {
+			return nil, Errorf(EINVAL, "%s is already running", row["name"])
+		}
+		row["status"] = vmStatus(VMRunning)
+		s.vms().replace(row)
+		return nil, nil
+	})
+
+	s.handleJob("vm.stop", func(r *Request) (any, error) {
+		row, err := s.vmArg(r)
+		if err != nil {
+			return nil, err
+		}
+		row["status"] = vmStatus(VMStopped)
+		s.vms().replace(row)
+		return nil, nil
+	})
+
+	s.handle("vm.device.query", func(r *Request) (any, error) {
+		return s.vmDevices().query(r)
+	})
+	s.handle("vm.device.get_instance", func(r *Request) (any, error) {
+		return s.vmDevices().getInstance(r)
+	})
+
+	s.handle("vm.device.create", func(r *Request) (any, error) {
+		params, err := decodeObject(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		if s.vms().get(params["vm"]) == nil {
+			return nil, Invalid(ValidationError{Attribute: "vm_device_create.vm", Message: fmt.Sprintf("VM %v does not exist", params["vm"]), Errno: ENOENT})
+		}
+		attrs, _ := params["attributes"].(map[string]any)
+		if _, ok := attrs["dtype"].(string); !ok {
+			return nil, invalid("vm_device_create.attributes.dtype", "Field required")
+		}
+
+		id := s.vmDevices().newID()
+		order, ok := params["order"]
+		if !ok || order == nil {
+			order = float64(1000 + id)
+		}
+		row := Row{
+			"id":         float64(id),
+			"vm":         params["vm"],
+			"order":      order,
+			"attributes": deviceDefaults(id, attrs),
+		}
+		s.vmDevices().insert(row)
+		return row, nil
+	})
+
+	s.handle("vm.device.update", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		params, err := decodeObject(r, 1)
+		if err != nil {
+			return nil, err
+		}
+		row := s.vmDevices().get(id)
+		if row == nil {
+			return nil, NotFound("VM device %d does not exist", id)
+		}
+		if v, ok := params["order"]; ok && v != nil {
+			row["order"] = v
+		}
+		if attrs, ok := params["attributes"].(map[string]any); ok {
+			row["attributes"] = deviceDefaults(id, attrs)
+		}
+		s.vmDevices().replace(row)
+		return row, nil
+	})
+
+	s.handle("vm.device.delete", func(r *Request) (any, error) {
+		var id int64
+		if err := r.Arg(0, &id); err != nil {
+			return nil, err
+		}
+		if !s.vmDevices().remove(id) {
+			return nil, NotFound("VM device %d does not exist", id)
+		}
+		return true, nil
+	})
+}
+
+// vmArg looks up the VM whose ID is the first argument.
+func (s *Server) vmArg(r *Request) (Row, error) {
+	var id int64
+	if err := r.Arg(0, &id); err != nil {
+		return nil, err
+	}
+	row := s.vms().get(id)
+	if row == nil {
+		return nil, NotFound("VM %d does not exist", id)
+	}
+	return row, nil
+}
+
+func (s *Server) validateVM(schema string, params, existing Row) error {
+	var errs []ValidationError
+	if name, ok := params["name"].(string); ok {
+		if !vmNamePattern.MatchString(name) {
+			errs = append(errs, ValidationError{Attribute: schema + ".name", Message: "Only alphanumeric characters are allowed."})
+		}
+		for _, row := range s.vms().rows {
+			if row["name"] == name && (existing == nil || !equal(row["id"], existing["id"])) {
+				errs = append(errs, ValidationError{Attribute: schema + ".name", Message: "This name already exists.", Errno: EEXIST})
+			}
+		}
+	} else if existing == nil {
+		errs = append(errs, ValidationError{Attribute: schema + ".name", Message: "Field required"})
+	}
+	if v, ok := params["memory"].(float64); ok && v < 20 {
+		errs = append(errs, ValidationError{Attribute: schema + ".memory", Message: "Memory must be at least 20 MiB"})
+	}
+	if _, ok := params["enable_secure_boot"]; ok && !s.Version().AtLeast(25, 4) {
+		errs = append(errs, ValidationError{Attribute: schema + ".enable_secure_boot", Message: "Extra inputs are not permitted"})
+	}
+	if len(errs) > 0 {
+		return Invalid(errs...)
+	}
+	return nil
+}
+
+// vmRows returns every VM with its devices, as vm.query does.
+func (s *Server) vmRows() []Row {
+	rows := s.vms().all()
+	for i, row := range rows {
+		rows[i] = s.vmWithDevices(row)
+	}
+	return rows
+}
+
+func (s *Server) vmWithDevices(row Row) Row {
+	out := Row{}
+	for k, v := range row {
+		out[k] = v
+	}
+	devices := []any{}
+	for _, dev := range s.devicesOf(row["id"]) {
+		devices = append(devices, dev)
+	}
+	out["devices"] = devices
+	return out
+}
+
+func (s *Server) devicesOf(vm any) []Row {
+	var out []Row
+	for _, dev := range s.vmDevices().rows {
+		if equal(dev["vm"], vm) {
+			out = append(out, dev)
+		}
+	}
+	return out
+}
+
+func vmStatus(state string) Row {
+	status := Row{"state": state, "pid": nil, "domain_state": "SHUTOFF"}
+	if state == VMRunning {
+		status["pid"] = float64(4242)
+		status["domain_state"] = "RUNNING"
+	}
+	return status
+}
+
+// deviceDefaults fills in the attributes the middleware defaults per device
+// type.
+func deviceDefaults(id int64, attrs map[string]any) Row {
+	out := Row{}
+	for k, v := range attrs {
+		out[k] = v
+	}
+	setDefault := func(key string, v any) {
+		if _, ok := out[key]; !ok {
+			out[key] = v
+		}
+	}
+	switch out["dtype"] {
+	case "DISK", "RAW":
+		setDefault("type", "AHCI")
+		setDefault("serial", fmt.Sprintf("fakenas%08d", id))
+		if out["dtype"] == "RAW" {
+			setDefault("boot", false)
+		}
+	case "NIC":
+		setDefault("type", "E1000")
+		setDefault("trust_guest_rx_filters", false)
+	case "DISPLAY":
+		setDefault("type", "SPICE")
+		setDefault("bind", "127.0.0.1")
+		setDefault("resolution", "1024x768")
+		setDefault("web_port", float64(5800+id))
+	case "USB":
+		setDefault("controller_type", "nec-xhci")
+	}
+	return out
+}
+
+// applyParams copies params into row, skipping the given keys.
+func applyParams(row, params Row, skip ...string) {
+	for k, v := range params {
+		skipped := false
+		for _, s := range skip {
+			skipped = skipped || k == s
+		}
+		if !skipped {
+			row[k] = v
+		}
+	}
+}
+
+func lookupString(row Row, field string) string {
+	v, _ := lookup(row, field)
+	s, _ := v.(string)
+	return s
+}
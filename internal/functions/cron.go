@@ -0,0 +1,159 @@
+package functions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit bounds the search for the next run, so that schedules that
+// never fire, such as the 30th of February, fail instead of looping forever.
+const cronSearchLimit = 8 * 366 * 24 * time.Hour
+
+// cronField describes one of the five fields of a cron schedule.
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names []string
+}
+
+var (
+	cronMinute = cronField{name: "minute", min: 0, max: 59}
+	cronHour   = cronField{name: "hour", min: 0, max: 23}
+	cronDom    = cronField{name: "dom", min: 1, max: 31}
+	cronMonth  = cronField{name: "month", min: 1, max: 12, names: []string{
+		"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec",
+	}}
+	// Day of week 7 is accepted as Sunday, as cron does.
+	cronDow = cronField{name: "dow", min: 0, max: 7, names: []string{
+		"sun", "mon", "tue", "wed", "thu", "fri", "sat",
+	}}
+)
+
+// cronSchedule is a parsed cron schedule, in the form of the schedule block
+// of truenas_cron_job and truenas_cloudsync_task.
+type cronSchedule struct {
+	minute, hour, dom, month, dow []bool
+	// domStar and dowStar record whether the day fields are unrestricted.
+	// When both are restricted a day matches if either one does.
+	domStar, dowStar bool
+}
+
+// parseCronSchedule parses a five-field schedule such as "0 3 * * mon-fri".
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour dom month dow), got %d", len(fields))
+	}
+
+	s := &cronSchedule{
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}
+	var err error
+	for i, dst := range []*[]bool{&s.minute, &s.hour, &s.dom, &s.month, &s.dow} {
+		field := []cronField{cronMinute, cronHour, cronDom, cronMonth, cronDow}[i]
+		if *dst, err = field.parse(fields[i]); err != nil {
+			return nil, err
+		}
+	}
+	if s.dow[7] {
+		s.dow[0] = true
+	}
+	return s, nil
+}
+
+// parse returns the values of the field matched by expr, indexed by value.
+func (f cronField) parse(expr string) ([]bool, error) {
+	set := make([]bool, f.max+1)
+	for _, item := range strings.Split(expr, ",") {
+		rng, step := item, 1
+		if before, after, ok := strings.Cut(item, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid step %q in %s field", after, f.name)
+			}
+			rng, step = before, n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return nil, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return nil, err
+			}
+			if lo > hi {
+				return nil, fmt.Errorf("invalid range %q in %s field", rng, f.name)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return nil, err
+			}
+			// A single value with a step runs from that value to the maximum
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// value parses a single number or name of the field.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field: must be between %d and %d", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// matchesDay reports whether the schedule runs on the day of t.
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dom, dow := s.dom[t.Day()], s.dow[int(t.Weekday())]
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// next returns the first time after t at which the schedule runs, in the
+// location of t.
+func (s *cronSchedule) next(t time.Time) (time.Time, error) {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		switch {
+		case !s.month[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("the schedule does not run within %d years", int(cronSearchLimit.Hours()/24/366))
+}
@@ -0,0 +1,91 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ function.Function = &CronNextRunsFunction{}
+
+// maxCronRuns caps the number of runs cron_next_runs returns.
+const maxCronRuns = 1000
+
+// CronNextRunsFunction lists the upcoming runs of a cron schedule.
+type CronNextRunsFunction struct{}
+
+// NewCronNextRunsFunction creates a new CronNextRunsFunction.
+func NewCronNextRunsFunction() function.Function {
+	return &CronNextRunsFunction{}
+}
+
+func (f *CronNextRunsFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "cron_next_runs"
+}
+
+func (f *CronNextRunsFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "List the next runs of a cron schedule",
+		Description: "Returns the next count times, as RFC 3339 timestamps, at which a five-field cron schedule " +
+			"(\"minute hour dom month dow\", the fields of the schedule block of truenas_cron_job and " +
+			"truenas_cloudsync_task) runs after from. Fields accept numbers, names (jan-dec, sun-sat), " +
+			"ranges, lists and steps. When both dom and dow are restricted, a day matches if either does. " +
+			"Times are computed in the UTC offset of from; pass a timestamp in the NAS's offset to match its clock.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "schedule",
+				Description: "The cron schedule, such as \"0 3 * * mon-fri\".",
+			},
+			function.StringParameter{
+				Name:        "from",
+				Description: "The RFC 3339 timestamp to start after, such as the result of timestamp().",
+			},
+			function.Int64Parameter{
+				Name:        "count",
+				Description: fmt.Sprintf("The number of runs to return, from 1 to %d.", maxCronRuns),
+			},
+		},
+		Return: function.ListReturn{
+			ElementType: types.StringType,
+		},
+	}
+}
+
+func (f *CronNextRunsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var expr, from string
+	var count int64
+
+	resp.Error = req.Arguments.Get(ctx, &expr, &from, &count)
+	if resp.Error != nil {
+		return
+	}
+
+	schedule, err := parseCronSchedule(expr)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid schedule %q: %s.", expr, err.Error()))
+		return
+	}
+	t, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Invalid timestamp %q: must be in RFC 3339 format.", from))
+		return
+	}
+	if count < 1 || count > maxCronRuns {
+		resp.Error = function.NewArgumentFuncError(2, fmt.Sprintf("Count must be between 1 and %d.", maxCronRuns))
+		return
+	}
+
+	runs := make([]string, 0, count)
+	for range count {
+		if t, err = schedule.next(t); err != nil {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid schedule %q: %s.", expr, err.Error()))
+			return
+		}
+		runs = append(runs, t.Format(time.RFC3339))
+	}
+
+	resp.Error = resp.Result.Set(ctx, runs)
+}
@@ -0,0 +1,59 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCronNextRunsFunction_Run(t *testing.T) {
+	got, err := runFunction(t, NewCronNextRunsFunction(),
+		types.StringValue("0 3 * * *"),
+		types.StringValue("2026-03-01T03:00:00+11:00"),
+		types.Int64Value(3),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := types.ListValueMust(types.StringType, []attr.Value{
+		types.StringValue("2026-03-02T03:00:00+11:00"),
+		types.StringValue("2026-03-03T03:00:00+11:00"),
+		types.StringValue("2026-03-04T03:00:00+11:00"),
+	})
+	if !got.Equal(want) {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestCronNextRunsFunction_Run_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule string
+		from     string
+		count    int64
+		wantArg  int64
+	}{
+		{"schedule", "0 3 * *", "2026-03-01T00:00:00Z", 1, 0},
+		{"never runs", "0 0 31 4 *", "2026-03-01T00:00:00Z", 1, 0},
+		{"timestamp", "0 3 * * *", "yesterday", 1, 1},
+		{"zero count", "0 3 * * *", "2026-03-01T00:00:00Z", 0, 2},
+		{"large count", "0 3 * * *", "2026-03-01T00:00:00Z", maxCronRuns + 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runFunction(t, NewCronNextRunsFunction(),
+				types.StringValue(tt.schedule),
+				types.StringValue(tt.from),
+				types.Int64Value(tt.count),
+			)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if err.FunctionArgument == nil || *err.FunctionArgument != tt.wantArg {
+				t.Errorf("expected the error to point at argument %d, got %v", tt.wantArg, err)
+			}
+		})
+	}
+}
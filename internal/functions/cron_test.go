@@ -0,0 +1,69 @@
+package functions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+	} {
+		if _, err := parseCronSchedule(expr); err == nil {
+			t.Errorf("expected %q to be rejected", expr)
+		}
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	from := time.Date(2026, time.January, 30, 22, 15, 30, 0, time.UTC) // a Friday
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "2026-01-30T22:16:00Z"},
+		{"*/20 * * * *", "2026-01-30T22:20:00Z"},
+		{"0 3 * * *", "2026-01-31T03:00:00Z"},
+		{"0 3 * * mon-fri", "2026-02-02T03:00:00Z"},
+		{"30 8 1 * *", "2026-02-01T08:30:00Z"},
+		{"0 0 29 2 *", "2028-02-29T00:00:00Z"},
+		{"0 0 * * 7", "2026-02-01T00:00:00Z"},
+		{"0 12 15 * sat", "2026-01-31T12:00:00Z"},
+		{"0 0 1 JAN,jul *", "2026-07-01T00:00:00Z"},
+		{"10/15 22 * * *", "2026-01-30T22:25:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := parseCronSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := s.next(from)
+			if err != nil {
+				t.Fatalf("next: %v", err)
+			}
+			if got.Format(time.RFC3339) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestCronSchedule_Next_Never(t *testing.T) {
+	s, err := parseCronSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, err := s.next(time.Now()); err == nil {
+		t.Fatal("expected an error for a schedule that never runs")
+	}
+}
@@ -0,0 +1,111 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ function.Function = &DatasetPathFunction{}
+
+// datasetPathAttrTypes are the attributes of the object dataset_path returns.
+var datasetPathAttrTypes = map[string]attr.Type{
+	"id":     types.StringType,
+	"pool":   types.StringType,
+	"path":   types.StringType,
+	"parent": types.StringType,
+	"name":   types.StringType,
+}
+
+// DatasetPathFunction joins and splits dataset identifiers.
+type DatasetPathFunction struct{}
+
+// NewDatasetPathFunction creates a new DatasetPathFunction.
+func NewDatasetPathFunction() function.Function {
+	return &DatasetPathFunction{}
+}
+
+func (f *DatasetPathFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "dataset_path"
+}
+
+func (f *DatasetPathFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Join or split a dataset identifier",
+		Description: "Joins its arguments into a dataset identifier and returns its parts, so that " +
+			"dataset_path(\"tank\", \"apps/db\") and dataset_path(\"tank/apps/db\") both return " +
+			"{id = \"tank/apps/db\", pool = \"tank\", path = \"apps/db\", parent = \"tank/apps\", name = \"db\"}. " +
+			"pool and path match the attributes of truenas_dataset and truenas_zvol, and parent and name the " +
+			"parent-relative form. path and parent are null for a pool's root dataset.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "id",
+				Description: "The dataset identifier, or its first segments such as the pool name.",
+			},
+		},
+		VariadicParameter: function.StringParameter{
+			Name:        "segments",
+			Description: "Further path segments to append.",
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: datasetPathAttrTypes,
+		},
+	}
+}
+
+func (f *DatasetPathFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var id string
+	var segments []string
+
+	resp.Error = req.Arguments.Get(ctx, &id, &segments)
+	if resp.Error != nil {
+		return
+	}
+
+	var parts []string
+	for i, arg := range append([]string{id}, segments...) {
+		for _, part := range strings.Split(strings.Trim(arg, "/"), "/") {
+			if err := validateDatasetSegment(part); err != nil {
+				resp.Error = function.NewArgumentFuncError(int64(i), fmt.Sprintf("Invalid dataset path %q: %s.", arg, err.Error()))
+				return
+			}
+			parts = append(parts, part)
+		}
+	}
+
+	path, parent := types.StringNull(), types.StringNull()
+	if len(parts) > 1 {
+		path = types.StringValue(strings.Join(parts[1:], "/"))
+		parent = types.StringValue(strings.Join(parts[:len(parts)-1], "/"))
+	}
+
+	result, diags := types.ObjectValue(datasetPathAttrTypes, map[string]attr.Value{
+		"id":     types.StringValue(strings.Join(parts, "/")),
+		"pool":   types.StringValue(parts[0]),
+		"path":   path,
+		"parent": parent,
+		"name":   types.StringValue(parts[len(parts)-1]),
+	})
+	resp.Error = function.FuncErrorFromDiags(ctx, diags)
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, result)
+}
+
+// validateDatasetSegment rejects a single component of a dataset name that
+// ZFS would not accept.
+func validateDatasetSegment(segment string) error {
+	if segment == "" {
+		return fmt.Errorf("segments must not be empty")
+	}
+	if strings.ContainsAny(segment, "@#") {
+		return fmt.Errorf("snapshot and bookmark names are not dataset paths")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// segments builds the variadic argument of dataset_path.
+func segments(values ...string) attr.Value {
+	elemTypes := make([]attr.Type, len(values))
+	elems := make([]attr.Value, len(values))
+	for i, v := range values {
+		elemTypes[i] = types.StringType
+		elems[i] = types.StringValue(v)
+	}
+	return types.TupleValueMust(elemTypes, elems)
+}
+
+func TestDatasetPathFunction_Run(t *testing.T) {
+	nested := map[string]attr.Value{
+		"id":     types.StringValue("tank/apps/db"),
+		"pool":   types.StringValue("tank"),
+		"path":   types.StringValue("apps/db"),
+		"parent": types.StringValue("tank/apps"),
+		"name":   types.StringValue("db"),
+	}
+	tests := []struct {
+		name string
+		id   string
+		rest []string
+		want map[string]attr.Value
+	}{
+		{"split", "tank/apps/db", nil, nested},
+		{"join pool and path", "tank", []string{"apps/db"}, nested},
+		{"join parent and name", "tank/apps", []string{"db"}, nested},
+		{"join segments", "tank", []string{"apps", "db"}, nested},
+		{"trims slashes", "tank/", []string{"/apps/db/"}, nested},
+		{"pool", "tank", nil, map[string]attr.Value{
+			"id":     types.StringValue("tank"),
+			"pool":   types.StringValue("tank"),
+			"path":   types.StringNull(),
+			"parent": types.StringNull(),
+			"name":   types.StringValue("tank"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runFunction(t, NewDatasetPathFunction(), types.StringValue(tt.id), segments(tt.rest...))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := types.ObjectValueMust(datasetPathAttrTypes, tt.want)
+			if !got.Equal(want) {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+func TestDatasetPathFunction_Run_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		rest    []string
+		wantArg int64
+	}{
+		{"empty", "", nil, 0},
+		{"double slash", "tank//db", nil, 0},
+		{"snapshot", "tank/db@daily", nil, 0},
+		{"empty segment", "tank", []string{"apps", ""}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runFunction(t, NewDatasetPathFunction(), types.StringValue(tt.id), segments(tt.rest...))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if err.FunctionArgument == nil || *err.FunctionArgument != tt.wantArg {
+				t.Errorf("expected the error to point at argument %d, got %v", tt.wantArg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package functions
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &FormatSizeFunction{}
+
+// sizeUnits are the binary units format_size chooses from, largest first.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"EiB", 1 << 60},
+	{"PiB", 1 << 50},
+	{"TiB", 1 << 40},
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+}
+
+// FormatSizeFunction converts bytes to a size string.
+type FormatSizeFunction struct{}
+
+// NewFormatSizeFunction creates a new FormatSizeFunction.
+func NewFormatSizeFunction() function.Function {
+	return &FormatSizeFunction{}
+}
+
+func (f *FormatSizeFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "format_size"
+}
+
+func (f *FormatSizeFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Convert bytes to a size string",
+		Description: "Formats a number of bytes in the largest binary unit that represents it exactly, " +
+			"such as \"10GiB\" for 10737418240. Sizes that are not a whole number of KiB are returned in bytes. " +
+			"The result parses back to the same number with parse_size.",
+		Parameters: []function.Parameter{
+			function.Int64Parameter{
+				Name:        "bytes",
+				Description: "The size in bytes. Must not be negative.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *FormatSizeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var bytes int64
+
+	resp.Error = req.Arguments.Get(ctx, &bytes)
+	if resp.Error != nil {
+		return
+	}
+
+	if bytes < 0 {
+		resp.Error = function.NewArgumentFuncError(0, "Size must not be negative.")
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, formatSize(bytes))
+}
+
+// formatSize returns bytes in the largest unit that divides it exactly.
+func formatSize(bytes int64) string {
+	if bytes == 0 {
+		return "0"
+	}
+	for _, unit := range sizeUnits {
+		if bytes%unit.bytes == 0 {
+			return strconv.FormatInt(bytes/unit.bytes, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
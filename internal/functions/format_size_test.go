@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"testing"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFormatSizeFunction_Run(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0"},
+		{1000, "1000"},
+		{1024, "1KiB"},
+		{1536, "1536"},
+		{10 << 30, "10GiB"},
+		{1536 << 20, "1536MiB"},
+		{3 << 40, "3TiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := runFunction(t, NewFormatSizeFunction(), types.Int64Value(tt.bytes))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(types.StringValue(tt.want)) {
+				t.Errorf("expected %q, got %s", tt.want, got)
+			}
+
+			// The result must parse back to the same size
+			parsed, parseErr := truenas.ParseSize(tt.want)
+			if parseErr != nil || parsed != tt.bytes {
+				t.Errorf("ParseSize(%q) = %d, %v; want %d", tt.want, parsed, parseErr, tt.bytes)
+			}
+		})
+	}
+}
+
+func TestFormatSizeFunction_Run_Negative(t *testing.T) {
+	if _, err := runFunction(t, NewFormatSizeFunction(), types.Int64Value(-1)); err == nil {
+		t.Fatal("expected an error for a negative size")
+	}
+}
@@ -0,0 +1,29 @@
+package functions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// runFunction calls f with args and returns its result and error.
+func runFunction(t *testing.T, f function.Function, args ...attr.Value) (attr.Value, *function.FuncError) {
+	t.Helper()
+
+	defResp := &function.DefinitionResponse{}
+	f.Definition(context.Background(), function.DefinitionRequest{}, defResp)
+	if defResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected definition errors: %v", defResp.Diagnostics)
+	}
+
+	result, funcErr := defResp.Definition.Return.NewResultData(context.Background())
+	if funcErr != nil {
+		t.Fatalf("unexpected result data error: %v", funcErr)
+	}
+
+	resp := &function.RunResponse{Result: result}
+	f.Run(context.Background(), function.RunRequest{Arguments: function.NewArgumentsData(args)}, resp)
+	return resp.Result.Value(), resp.Error
+}
@@ -0,0 +1,56 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/deevus/terraform-provider-truenas/internal/resources"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &NormalizeModeFunction{}
+
+// NormalizeModeFunction converts a Unix mode to the form TrueNAS reports.
+type NormalizeModeFunction struct{}
+
+// NewNormalizeModeFunction creates a new NormalizeModeFunction.
+func NewNormalizeModeFunction() function.Function {
+	return &NormalizeModeFunction{}
+}
+
+func (f *NormalizeModeFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "normalize_mode"
+}
+
+func (f *NormalizeModeFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Normalize a Unix mode",
+		Description: "Parses an octal Unix mode such as \"0755\" with the same rules as the mode attributes of " +
+			"truenas_file, truenas_host_path and truenas_dataset, and returns it as TrueNAS reports it, " +
+			"without leading zeros (\"755\"). Modes outside 0000-7777 are rejected.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "mode",
+				Description: "The mode to normalize, in octal.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *NormalizeModeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var mode string
+
+	resp.Error = req.Arguments.Get(ctx, &mode)
+	if resp.Error != nil {
+		return
+	}
+
+	m, err := resources.ParseMode(mode)
+	if err != nil || m > 0o7777 {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid mode %q: must be an octal number between 0000 and 7777.", mode))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, fmt.Sprintf("%o", uint32(m)))
+}
@@ -0,0 +1,42 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestNormalizeModeFunction_Run(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{"0755", "755"},
+		{"755", "755"},
+		{"0644", "644"},
+		{"00600", "600"},
+		{"4755", "4755"},
+		{"0", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			got, err := runFunction(t, NewNormalizeModeFunction(), types.StringValue(tt.mode))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(types.StringValue(tt.want)) {
+				t.Errorf("expected %q, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeModeFunction_Run_Invalid(t *testing.T) {
+	for _, mode := range []string{"", "rwxr-xr-x", "0789", "17777"} {
+		t.Run(mode, func(t *testing.T) {
+			if _, err := runFunction(t, NewNormalizeModeFunction(), types.StringValue(mode)); err == nil {
+				t.Fatalf("expected an error for mode %q", mode)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+var _ function.Function = &ParseSizeFunction{}
+
+// ParseSizeFunction converts a size string to bytes.
+type ParseSizeFunction struct{}
+
+// NewParseSizeFunction creates a new ParseSizeFunction.
+func NewParseSizeFunction() function.Function {
+	return &ParseSizeFunction{}
+}
+
+func (f *ParseSizeFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_size"
+}
+
+func (f *ParseSizeFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Convert a size string to bytes",
+		Description: "Parses a size such as \"10GB\", \"500MiB\" or \"1024\" into a number of bytes, " +
+			"using the same rules as size attributes like truenas_dataset.quota. " +
+			"Decimal units (KB, MB, GB) are powers of 1000 and binary units (KiB, MiB, GiB) are powers of 1024.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "size",
+				Description: "The size to parse.",
+			},
+		},
+		Return: function.Int64Return{},
+	}
+}
+
+func (f *ParseSizeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var size string
+
+	resp.Error = req.Arguments.Get(ctx, &size)
+	if resp.Error != nil {
+		return
+	}
+
+	bytes, err := truenas.ParseSize(size)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid size %q: %s", size, err.Error()))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, bytes)
+}
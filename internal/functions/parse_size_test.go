@@ -0,0 +1,41 @@
+package functions
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestParseSizeFunction_Run(t *testing.T) {
+	tests := []struct {
+		size string
+		want int64
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"10GB", 10_000_000_000},
+		{"10GiB", 10 * 1 << 30},
+		{" 2T ", 2_000_000_000_000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := runFunction(t, NewParseSizeFunction(), types.StringValue(tt.size))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(types.Int64Value(tt.want)) {
+				t.Errorf("expected %d, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseSizeFunction_Run_Invalid(t *testing.T) {
+	_, err := runFunction(t, NewParseSizeFunction(), types.StringValue("ten gigs"))
+	if err == nil {
+		t.Fatal("expected an error for an invalid size")
+	}
+	if err.FunctionArgument == nil || *err.FunctionArgument != 0 {
+		t.Errorf("expected the error to point at the size argument, got %v", err)
+	}
+}
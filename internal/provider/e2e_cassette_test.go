@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/fakenas"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// cassetteSession creates, updates and destroys a dataset and a file,
+// starting a new run before every command as Terraform does.
+func cassetteSession(t *testing.T, h *e2eHarness) {
+	t.Helper()
+	dataset := h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "data", "compression": "LZ4"})
+	h.newRun()
+	file := h.apply("truenas_file", map[string]any{"path": "/mnt/tank/app.conf", "content": "password = 1\n"})
+
+	h.newRun()
+	h.update(dataset, map[string]any{"pool": "tank", "path": "data", "compression": "ZSTD"})
+	h.newRun()
+	h.update(file, map[string]any{"path": "/mnt/tank/app.conf", "content": "password = 2\n", "mode": "0600"})
+	if got := dataset.attr(t, "compression"); got != "ZSTD" {
+		t.Errorf("expected compression ZSTD, got %q", got)
+	}
+
+	h.newRun()
+	h.destroyAll()
+}
+
+func TestE2E_ReplaysRecordedSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	srv := fakenas.NewServer(t)
+	recording := startE2EHarness(t, &RecordingClientFactory{Inner: newFakeNASFactory(t, srv), Path: path}, fakeNASProviderConfig(srv))
+	recording.srv = srv
+	cassetteSession(t, recording)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"password = 1\n", "password = 2\n"} {
+		if strings.Contains(string(data), content) || strings.Contains(string(data), base64.StdEncoding.EncodeToString([]byte(content))) {
+			t.Errorf("expected file contents %q to be left out of the cassette", content)
+		}
+	}
+
+	// The replay has no server to reach and no credentials to log in with
+	replaying := startE2EHarness(t, &ReplayClientFactory{Path: path}, map[string]any{
+		"host":        "nas.example.com",
+		"auth_method": "websocket",
+	})
+	cassetteSession(t, replaying)
+}
+
+// cassetteProviderFactories serves a new provider for every Terraform
+// command, as Terraform does, with clients from factory.
+func cassetteProviderFactories(factory ClientFactory) map[string]func() (tfprotov6.ProviderServer, error) {
+	return map[string]func() (tfprotov6.ProviderServer, error){
+		"truenas": func() (tfprotov6.ProviderServer, error) {
+			return providerserver.NewProtocol6WithError(&TrueNASProvider{version: "test", factory: factory})()
+		},
+	}
+}
+
+// cassetteTestCase creates and updates a dataset with Terraform, which
+// destroys it when the test case ends.
+func cassetteTestCase(factory ClientFactory, providerConfig string) resource.TestCase {
+	dataset := func(compression string) string {
+		return providerConfig + fmt.Sprintf(`
+resource "truenas_dataset" "data" {
+  pool        = "tank"
+  path        = "data"
+  compression = %q
+}
+`, compression)
+	}
+
+	return resource.TestCase{
+		ProtoV6ProviderFactories: cassetteProviderFactories(factory),
+		Steps: []resource.TestStep{
+			{
+				Config: dataset("LZ4"),
+				Check:  resource.TestCheckResourceAttr("truenas_dataset.data", "compression", "LZ4"),
+			},
+			{
+				Config: dataset("ZSTD"),
+				Check:  resource.TestCheckResourceAttr("truenas_dataset.data", "compression", "ZSTD"),
+			},
+		},
+	}
+}
+
+func TestAcc_ReplaysRecordedSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	srv := fakenas.NewServer(t)
+	resource.Test(t, cassetteTestCase(&RecordingClientFactory{Inner: newFakeNASFactory(t, srv), Path: path}, fmt.Sprintf(`
+provider "truenas" {
+  host        = %q
+  auth_method = "websocket"
+  rate_limit  = 0
+
+  websocket {
+    username             = %q
+    api_key              = %q
+    port                 = %d
+    insecure_skip_verify = true
+  }
+}
+`, srv.Host, srv.Username(), srv.APIKey(), srv.Port)))
+
+	// The replay has no server to reach and no credentials to log in with
+	resource.Test(t, cassetteTestCase(&ReplayClientFactory{Path: path}, `
+provider "truenas" {
+  host        = "nas.example.com"
+  auth_method = "websocket"
+}
+`))
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// openEphemeral opens an ephemeral resource and returns its response, whose
+// diagnostics are left for the caller to check.
+func (h *e2eHarness) openEphemeral(typeName string, config map[string]any) *tfprotov6.OpenEphemeralResourceResponse {
+	h.t.Helper()
+	schema, ok := h.ephemeralSchemas[typeName]
+	if !ok {
+		h.t.Fatalf("unknown ephemeral resource type %s", typeName)
+	}
+	dv := dynamicValue(h.t, schema.ValueType(), config)
+	resp, err := h.server.OpenEphemeralResource(h.ctx, &tfprotov6.OpenEphemeralResourceRequest{
+		TypeName: typeName,
+		Config:   &dv,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: OpenEphemeralResource: %v", typeName, err)
+	}
+	return resp
+}
+
+// closeEphemeral closes an ephemeral resource opened by openEphemeral.
+func (h *e2eHarness) closeEphemeral(typeName string, opened *tfprotov6.OpenEphemeralResourceResponse) {
+	h.t.Helper()
+	resp, err := h.server.CloseEphemeralResource(h.ctx, &tfprotov6.CloseEphemeralResourceRequest{
+		TypeName: typeName,
+		Private:  opened.Private,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: CloseEphemeralResource: %v", typeName, err)
+	}
+	checkDiagnostics(h.t, typeName+": close", resp.Diagnostics)
+}
+
+// ephemeralAttr returns a top-level string attribute of the result of an
+// ephemeral resource.
+func (h *e2eHarness) ephemeralAttr(typeName string, opened *tfprotov6.OpenEphemeralResourceResponse, name string) string {
+	h.t.Helper()
+	v, err := opened.Result.Unmarshal(h.ephemeralSchemas[typeName].ValueType())
+	if err != nil {
+		h.t.Fatalf("%s: failed to decode result: %v", typeName, err)
+	}
+	var attrs map[string]tftypes.Value
+	if err := v.As(&attrs); err != nil {
+		h.t.Fatalf("%s: result is not an object: %v", typeName, err)
+	}
+	var s string
+	if err := attrs[name].As(&s); err != nil {
+		h.t.Fatalf("%s: attribute %s is not a string: %v", typeName, name, err)
+	}
+	return s
+}
+
+// expectDiagnostic fails the test unless diags contain an error with summary.
+func expectDiagnostic(t *testing.T, diags []*tfprotov6.Diagnostic, summary string) {
+	t.Helper()
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError && d.Summary == summary {
+			return
+		}
+	}
+	t.Fatalf("expected error %q, got %v", summary, diags)
+}
+
+func TestE2E_EphemeralAPIKey(t *testing.T) {
+	h := newE2EHarness(t)
+
+	opened := h.openEphemeral("truenas_api_key", map[string]any{
+		"name":       "terraform-run",
+		"username":   h.srv.Username(),
+		"expires_in": "1h",
+	})
+	checkDiagnostics(t, "open", opened.Diagnostics)
+
+	key := h.ephemeralAttr("truenas_api_key", opened, "key")
+	expiresAt, err := time.Parse(time.RFC3339, h.ephemeralAttr("truenas_api_key", opened, "expires_at"))
+	if err != nil {
+		t.Fatalf("expires_at: %v", err)
+	}
+	if d := time.Until(expiresAt); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("expected the key to expire in 1h, got %v", d)
+	}
+
+	login := func() error {
+		c, err := client.NewWebSocketClient(client.WebSocketConfig{
+			Host:               h.srv.Host,
+			Port:               h.srv.Port,
+			Username:           h.srv.Username(),
+			APIKey:             key,
+			InsecureSkipVerify: true,
+			MaxRetries:         1,
+		})
+		if err != nil {
+			t.Fatalf("NewWebSocketClient: %v", err)
+		}
+		defer c.Close()
+		_, err = c.Call(h.ctx, "system.info", nil)
+		return err
+	}
+	if err := login(); err != nil {
+		t.Fatalf("expected the minted key to log in: %v", err)
+	}
+
+	h.closeEphemeral("truenas_api_key", opened)
+	if err := login(); err == nil || !strings.Contains(err.Error(), "AUTH_ERR") {
+		t.Fatalf("expected the revoked key to be rejected, got %v", err)
+	}
+}
+
+func TestE2E_EphemeralAPIKey_MissingUsername(t *testing.T) {
+	h := newE2EHarness(t)
+
+	opened := h.openEphemeral("truenas_api_key", map[string]any{"name": "terraform-run"})
+	expectDiagnostic(t, opened.Diagnostics, "Missing API Key Username")
+}
+
+func TestE2E_EphemeralAPIKey_UnsupportedAttribute(t *testing.T) {
+	h := newE2EHarness(t)
+
+	opened := h.openEphemeral("truenas_api_key", map[string]any{
+		"name":     "terraform-run",
+		"username": h.srv.Username(),
+		"allowlist": []any{
+			map[string]any{"method": "CALL", "resource": "system.info"},
+		},
+	})
+	expectDiagnostic(t, opened.Diagnostics, "Unsupported TrueNAS Version")
+}
+
+func TestE2E_EphemeralAuthToken(t *testing.T) {
+	h := newE2EHarness(t)
+
+	opened := h.openEphemeral("truenas_auth_token", map[string]any{"ttl": 300})
+	checkDiagnostics(t, "open", opened.Diagnostics)
+
+	expires, ok := h.srv.Token(h.ephemeralAttr("truenas_auth_token", opened, "token"))
+	if !ok {
+		t.Fatalf("expected the token to be issued by the server")
+	}
+	if d := time.Until(expires); d < 290*time.Second || d > 300*time.Second {
+		t.Errorf("expected the token to expire in 300s, got %v", d)
+	}
+}
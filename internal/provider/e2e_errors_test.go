@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// applyFailing creates a resource from config, expecting the apply to fail,
+// and returns its diagnostics.
+func (h *e2eHarness) applyFailing(typeName string, config map[string]any) []*tfprotov6.Diagnostic {
+	h.t.Helper()
+	schema := h.schema(typeName)
+	r := &e2eResource{typeName: typeName, config: config}
+	r.state = tftypes.NewValue(schema.ValueType(), nil)
+
+	h.validate(r)
+	planned, private := h.plan(r, config)
+	priorDV := h.dynamic(r.typeName, r.state)
+	plannedDV := h.dynamic(r.typeName, planned)
+	configDV := dynamicValue(h.t, schema.ValueType(), config)
+	resp, err := h.server.ApplyResourceChange(h.ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       r.typeName,
+		PriorState:     &priorDV,
+		PlannedState:   &plannedDV,
+		Config:         &configDV,
+		PlannedPrivate: private,
+	})
+	if err != nil {
+		h.t.Fatalf("ApplyResourceChange: %v", err)
+	}
+	return resp.Diagnostics
+}
+
+func TestE2E_ValidationErrorOnAttribute(t *testing.T) {
+	h := newE2EHarness(t)
+
+	diags := h.applyFailing("truenas_app", map[string]any{
+		"name":           "1web",
+		"custom_app":     true,
+		"compose_config": "services:\n  web:\n    image: nginx:latest\n",
+	})
+	expectDiagnostic(t, diags, "Unable to Create App")
+
+	want := tftypes.NewAttributePath().WithAttributeName("name")
+	for _, d := range diags {
+		if d.Summary == "Unable to Create App" && !want.Equal(d.Attribute) {
+			t.Errorf("expected the error on %s, got %s", want, d.Attribute)
+		}
+	}
+}
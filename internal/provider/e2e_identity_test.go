@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// identityType returns the type of the resource identity of typeName.
+func (h *e2eHarness) identityType(typeName string) tftypes.Type {
+	h.t.Helper()
+	resp, err := h.server.GetResourceIdentitySchemas(h.ctx, &tfprotov6.GetResourceIdentitySchemasRequest{})
+	if err != nil {
+		h.t.Fatalf("GetResourceIdentitySchemas: %v", err)
+	}
+	schema, ok := resp.IdentitySchemas[typeName]
+	if !ok {
+		h.t.Fatalf("%s: resource has no identity schema", typeName)
+	}
+	return schema.ValueType()
+}
+
+// importByIdentity imports an object the way an import block with an
+// identity argument does, reads it and returns its state.
+func (h *e2eHarness) importByIdentity(typeName string, identity map[string]any) *e2eResource {
+	h.t.Helper()
+	identityDV := dynamicValue(h.t, h.identityType(typeName), identity)
+	importResp, err := h.server.ImportResourceState(h.ctx, &tfprotov6.ImportResourceStateRequest{
+		TypeName: typeName,
+		Identity: &tfprotov6.ResourceIdentityData{IdentityData: &identityDV},
+	})
+	if err != nil {
+		h.t.Fatalf("%s: ImportResourceState: %v", typeName, err)
+	}
+	checkDiagnostics(h.t, typeName+" import", importResp.Diagnostics)
+	if len(importResp.ImportedResources) != 1 {
+		h.t.Fatalf("%s: expected 1 imported resource, got %d", typeName, len(importResp.ImportedResources))
+	}
+	imported := importResp.ImportedResources[0]
+
+	readResp, err := h.server.ReadResource(h.ctx, &tfprotov6.ReadResourceRequest{
+		TypeName:        typeName,
+		CurrentState:    imported.State,
+		CurrentIdentity: imported.Identity,
+		Private:         imported.Private,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: ReadResource: %v", typeName, err)
+	}
+	checkDiagnostics(h.t, typeName+" read", readResp.Diagnostics)
+	state := h.unmarshal(typeName, readResp.NewState)
+	if state.IsNull() {
+		h.t.Fatalf("%s: imported resource was not found", typeName)
+	}
+	return &e2eResource{typeName: typeName, state: state}
+}
+
+func TestE2E_ImportByIdentity(t *testing.T) {
+	h := newE2EHarness(t)
+	dataset := h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "media"})
+	zvol := h.apply("truenas_zvol", map[string]any{"pool": "tank", "path": "disk", "volsize": "1G"})
+	snapshot := h.apply("truenas_snapshot", map[string]any{"dataset_id": "tank/media", "name": "daily"})
+	hostPath := h.apply("truenas_host_path", map[string]any{"path": "/mnt/tank/share", "mode": "755", "uid": 0, "gid": 0})
+	cron := h.apply("truenas_cron_job", map[string]any{
+		"user":     "root",
+		"command":  "echo hello",
+		"schedule": map[string]any{"minute": "0", "hour": "*"},
+	})
+	registry := h.apply("truenas_app_registry", map[string]any{
+		"name": "ghcr", "username": "user", "password": "token", "uri": "https://ghcr.io",
+	})
+
+	cases := []struct {
+		applied  *e2eResource
+		identity map[string]any
+	}{
+		{dataset, map[string]any{"pool": "tank", "path": "media"}},
+		{zvol, map[string]any{"pool": "tank", "path": "disk"}},
+		{snapshot, map[string]any{"dataset": "tank/media", "name": "daily"}},
+		{hostPath, map[string]any{"path": "/mnt/tank/share"}},
+		{cron, map[string]any{"id": cron.intID(t)}},
+		{registry, map[string]any{"id": registry.intID(t)}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.applied.typeName, func(t *testing.T) {
+			imported := h.importByIdentity(tc.applied.typeName, tc.identity)
+			if got, want := imported.attr(t, "id"), tc.applied.attr(t, "id"); got != want {
+				t.Errorf("expected to import %q, got %q", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package provider
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// list runs a list resource, as "terraform query" does, and returns its
+// results after checking their diagnostics.
+func (h *e2eHarness) list(typeName string, config map[string]any, includeResource bool, limit int64) []tfprotov6.ListResourceResult {
+	h.t.Helper()
+	schemaResp, err := h.server.GetProviderSchema(h.ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		h.t.Fatalf("GetProviderSchema: %v", err)
+	}
+	schema, ok := schemaResp.ListResourceSchemas[typeName]
+	if !ok {
+		h.t.Fatalf("unknown list resource type %s", typeName)
+	}
+
+	server, ok := h.server.(tfprotov6.ProviderServerWithListResource)
+	if !ok {
+		h.t.Fatalf("provider server does not support list resources")
+	}
+	if config == nil {
+		config = map[string]any{}
+	}
+	dv := dynamicValue(h.t, schema.ValueType(), config)
+	stream, err := server.ListResource(h.ctx, &tfprotov6.ListResourceRequest{
+		TypeName:        typeName,
+		Config:          &dv,
+		IncludeResource: includeResource,
+		Limit:           limit,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: ListResource: %v", typeName, err)
+	}
+
+	var results []tfprotov6.ListResourceResult
+	for result := range stream.Results {
+		checkDiagnostics(h.t, typeName+" list", result.Diagnostics)
+		results = append(results, result)
+	}
+	return results
+}
+
+// identity decodes the identity of a list result into Go values: strings
+// for string attributes and int64 for numbers.
+func (h *e2eHarness) identity(typeName string, result tfprotov6.ListResourceResult) map[string]any {
+	h.t.Helper()
+	if result.Identity == nil || result.Identity.IdentityData == nil {
+		h.t.Fatalf("%s: list result %q has no identity", typeName, result.DisplayName)
+	}
+	v, err := result.Identity.IdentityData.Unmarshal(h.identityType(typeName))
+	if err != nil {
+		h.t.Fatalf("%s: failed to decode identity: %v", typeName, err)
+	}
+	var attrs map[string]tftypes.Value
+	if err := v.As(&attrs); err != nil {
+		h.t.Fatalf("%s: identity is not an object: %v", typeName, err)
+	}
+
+	out := make(map[string]any, len(attrs))
+	for name, attr := range attrs {
+		switch {
+		case attr.Type().Is(tftypes.String):
+			var s string
+			_ = attr.As(&s)
+			out[name] = s
+		case attr.Type().Is(tftypes.Number):
+			var n big.Float
+			_ = attr.As(&n)
+			i, _ := n.Int64()
+			out[name] = i
+		}
+	}
+	return out
+}
+
+// listedResource returns the resource object of a list result as the state
+// of an e2eResource.
+func (h *e2eHarness) listedResource(typeName string, result tfprotov6.ListResourceResult) *e2eResource {
+	h.t.Helper()
+	if result.Resource == nil {
+		h.t.Fatalf("%s: list result %q has no resource", typeName, result.DisplayName)
+	}
+	return &e2eResource{typeName: typeName, state: h.unmarshal(typeName, result.Resource)}
+}
+
+func TestE2E_ListDatasets(t *testing.T) {
+	h := newE2EHarness(t)
+	parent := h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "media", "compression": "LZ4"})
+	h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "media/photos"})
+	h.apply("truenas_zvol", map[string]any{"pool": "tank", "path": "disk", "volsize": "1G"})
+
+	results := h.list("truenas_dataset", map[string]any{"pool": "tank"}, true, 0)
+	found := make(map[string]bool)
+	for _, result := range results {
+		identity := h.identity("truenas_dataset", result)
+		if identity["pool"] != "tank" {
+			t.Errorf("dataset %q: expected pool tank, got %v", result.DisplayName, identity["pool"])
+		}
+		if identity["path"] == "disk" {
+			t.Errorf("zvol listed as a dataset")
+		}
+		found[identity["path"].(string)] = true
+
+		listed := h.listedResource("truenas_dataset", result)
+		if got := listed.attr(t, "id"); got != result.DisplayName {
+			t.Errorf("expected resource %q for %q", result.DisplayName, got)
+		}
+		if identity["path"] == "media" {
+			if diffs, _ := parent.state.Diff(listed.state); len(diffs) > 0 {
+				t.Errorf("listed dataset differs from applied state:\n%s", formatDiffs(diffs))
+			}
+		}
+	}
+	for _, path := range []string{"media", "media/photos"} {
+		if !found[path] {
+			t.Errorf("expected dataset tank/%s to be listed, got %v", path, found)
+		}
+	}
+
+	if got := len(h.list("truenas_dataset", map[string]any{"pool": "tank"}, false, 1)); got != 1 {
+		t.Errorf("expected limit to return 1 result, got %d", got)
+	}
+	if got := len(h.list("truenas_dataset", map[string]any{"pool": "other"}, false, 0)); got != 0 {
+		t.Errorf("expected no datasets in another pool, got %d", got)
+	}
+}
+
+func TestE2E_ListZvolsAndSnapshots(t *testing.T) {
+	h := newE2EHarness(t)
+	h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "snapped"})
+	h.apply("truenas_zvol", map[string]any{"pool": "tank", "path": "disk", "volsize": "1G"})
+	h.apply("truenas_snapshot", map[string]any{"dataset_id": "tank/snapped", "name": "daily"})
+
+	zvols := h.list("truenas_zvol", nil, true, 0)
+	if len(zvols) != 1 {
+		t.Fatalf("expected 1 zvol, got %d", len(zvols))
+	}
+	if identity := h.identity("truenas_zvol", zvols[0]); identity["pool"] != "tank" || identity["path"] != "disk" {
+		t.Errorf("unexpected zvol identity %v", identity)
+	}
+	if got := h.listedResource("truenas_zvol", zvols[0]).attr(t, "id"); got != "tank/disk" {
+		t.Errorf("expected zvol tank/disk, got %q", got)
+	}
+
+	snapshots := h.list("truenas_snapshot", map[string]any{"dataset_id": "tank/snapped"}, true, 0)
+	if len(snapshots) != 1 {
+		t.Fatalf("expected 1 snapshot, got %d", len(snapshots))
+	}
+	if identity := h.identity("truenas_snapshot", snapshots[0]); identity["dataset"] != "tank/snapped" || identity["name"] != "daily" {
+		t.Errorf("unexpected snapshot identity %v", identity)
+	}
+	if got := h.listedResource("truenas_snapshot", snapshots[0]).attr(t, "id"); got != "tank/snapped@daily" {
+		t.Errorf("expected snapshot tank/snapped@daily, got %q", got)
+	}
+}
+
+func TestE2E_ListCronJobs(t *testing.T) {
+	h := newE2EHarness(t)
+	r := h.apply("truenas_cron_job", map[string]any{
+		"user":        "root",
+		"command":     "echo hello",
+		"description": "hourly hello",
+		"schedule":    map[string]any{"minute": "0", "hour": "*"},
+	})
+
+	results := h.list("truenas_cron_job", nil, true, 0)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 cron job, got %d", len(results))
+	}
+	if results[0].DisplayName != "hourly hello" {
+		t.Errorf("expected display name %q, got %q", "hourly hello", results[0].DisplayName)
+	}
+	if identity := h.identity("truenas_cron_job", results[0]); identity["id"] != int64(r.intID(t)) {
+		t.Errorf("expected identity id %d, got %v", r.intID(t), identity["id"])
+	}
+	listed := h.listedResource("truenas_cron_job", results[0])
+	if diffs, _ := r.state.Diff(listed.state); len(diffs) > 0 {
+		t.Errorf("listed cron job differs from applied state:\n%s", formatDiffs(diffs))
+	}
+}
@@ -0,0 +1,219 @@
+package provider
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+const e2eProviderAddress = "registry.terraform.io/deevus/truenas"
+
+// rawState encodes the state of r as Terraform stores it, with extra
+// attributes added and the attributes in drop removed.
+func rawState(t *testing.T, r *e2eResource, extra map[string]any, drop ...string) *tfprotov6.RawState {
+	t.Helper()
+	m, _ := jsonValue(t, r.state).(map[string]any)
+	for k, v := range extra {
+		m[k] = v
+	}
+	for _, k := range drop {
+		delete(m, k)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("failed to encode state: %v", err)
+	}
+	return &tfprotov6.RawState{JSON: b}
+}
+
+// jsonValue converts v to the value encoding/json writes for it.
+func jsonValue(t *testing.T, v tftypes.Value) any {
+	t.Helper()
+	if v.IsNull() {
+		return nil
+	}
+	switch {
+	case v.Type().Is(tftypes.String):
+		var s string
+		_ = v.As(&s)
+		return s
+	case v.Type().Is(tftypes.Bool):
+		var b bool
+		_ = v.As(&b)
+		return b
+	case v.Type().Is(tftypes.Number):
+		var n big.Float
+		_ = v.As(&n)
+		return json.Number(n.Text('f', -1))
+	case v.Type().Is(tftypes.Object{}), v.Type().Is(tftypes.Map{}):
+		var attrs map[string]tftypes.Value
+		_ = v.As(&attrs)
+		out := make(map[string]any, len(attrs))
+		for k, a := range attrs {
+			out[k] = jsonValue(t, a)
+		}
+		return out
+	default:
+		var elems []tftypes.Value
+		if err := v.As(&elems); err != nil {
+			t.Fatalf("unsupported value %v: %v", v, err)
+		}
+		out := make([]any, len(elems))
+		for i, e := range elems {
+			out[i] = jsonValue(t, e)
+		}
+		return out
+	}
+}
+
+// move asks targetType to take over the state of source, as a moved block
+// does, and returns the response for the caller to check.
+func (h *e2eHarness) move(providerAddress string, source *e2eResource, targetType string) *tfprotov6.MoveResourceStateResponse {
+	h.t.Helper()
+	resp, err := h.server.MoveResourceState(h.ctx, &tfprotov6.MoveResourceStateRequest{
+		SourceProviderAddress: providerAddress,
+		SourceTypeName:        source.typeName,
+		SourceSchemaVersion:   1,
+		SourceState:           rawState(h.t, source, nil),
+		TargetTypeName:        targetType,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: MoveResourceState: %v", targetType, err)
+	}
+	return resp
+}
+
+// refresh reads r, as the refresh after a move does, and returns the
+// response for the caller to check.
+func (h *e2eHarness) refresh(r *e2eResource) *tfprotov6.ReadResourceResponse {
+	h.t.Helper()
+	current := h.dynamic(r.typeName, r.state)
+	resp, err := h.server.ReadResource(h.ctx, &tfprotov6.ReadResourceRequest{
+		TypeName:     r.typeName,
+		CurrentState: &current,
+		Private:      r.private,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: ReadResource: %v", r.typeName, err)
+	}
+	return resp
+}
+
+// moved returns the target of a successful move, refreshed.
+func (h *e2eHarness) moved(targetType string, resp *tfprotov6.MoveResourceStateResponse) *e2eResource {
+	h.t.Helper()
+	checkDiagnostics(h.t, targetType+" move", resp.Diagnostics)
+	r := &e2eResource{typeName: targetType, state: h.unmarshal(targetType, resp.TargetState), private: resp.TargetPrivate}
+	readResp := h.refresh(r)
+	checkDiagnostics(h.t, targetType+" read", readResp.Diagnostics)
+	r.state, r.private = h.unmarshal(targetType, readResp.NewState), readResp.Private
+	if r.state.IsNull() {
+		h.t.Fatalf("%s: moved resource was not found", targetType)
+	}
+	return r
+}
+
+// hostPathState returns the state of a truenas_host_path for path.
+func (h *e2eHarness) hostPathState(path string) *e2eResource {
+	h.t.Helper()
+	return &e2eResource{
+		typeName: "truenas_host_path",
+		state: tfValue(h.t, h.schema("truenas_host_path").ValueType(), map[string]any{
+			"id": path, "path": path, "mode": "755", "uid": 0, "gid": 0,
+		}),
+	}
+}
+
+func TestE2E_MoveHostPathToDataset(t *testing.T) {
+	h := newE2EHarness(t)
+	dataset := h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "media"})
+	mountPath := dataset.attr(t, "mount_path")
+
+	moved := h.moved("truenas_dataset", h.move(e2eProviderAddress, h.hostPathState(mountPath), "truenas_dataset"))
+	if got := moved.attr(t, "id"); got != "tank/media" {
+		t.Errorf("expected dataset tank/media, got %q", got)
+	}
+	if got := moved.attr(t, "mode"); got != "755" {
+		t.Errorf("expected mode of the host path to be kept, got %q", got)
+	}
+
+	if got := moved.attr(t, "compression"); got == "" {
+		t.Errorf("expected the refresh to fill in the dataset")
+	}
+	if got := h.refresh(moved); len(got.Diagnostics) > 0 {
+		t.Errorf("expected the mountpoint to be checked only once, got %v", got.Diagnostics)
+	}
+
+	// A plain directory moves, but the refresh finds no dataset there.
+	resp := h.move(e2eProviderAddress, h.hostPathState("/mnt/tank/plain"), "truenas_dataset")
+	checkDiagnostics(t, "move", resp.Diagnostics)
+	plain := &e2eResource{typeName: "truenas_dataset", state: h.unmarshal("truenas_dataset", resp.TargetState), private: resp.TargetPrivate}
+	expectDiagnostic(t, h.refresh(plain).Diagnostics, "Moved Host Path Is Not a Dataset")
+
+	resp = h.move(e2eProviderAddress, h.hostPathState("/srv/apps"), "truenas_dataset")
+	expectDiagnostic(t, resp.Diagnostics, "Unable to Move Host Path")
+}
+
+func TestE2E_MoveHostPathToDataset_ParentForm(t *testing.T) {
+	h := newE2EHarness(t)
+	dataset := h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "media"})
+
+	// The moved state names the dataset by pool and path; configurations
+	// that name it by parent plan no replacement.
+	moved := h.moved("truenas_dataset", h.move(e2eProviderAddress, h.hostPathState(dataset.attr(t, "mount_path")), "truenas_dataset"))
+	h.plan(moved, map[string]any{"parent": "tank", "name": "media"})
+	h.update(moved, map[string]any{"parent": "tank", "path": "media"})
+	if got := moved.attr(t, "id"); got != "tank/media" {
+		t.Errorf("expected dataset tank/media, got %q", got)
+	}
+	h.expectNoChanges(moved)
+}
+
+func TestE2E_MoveDatasetToHostPath(t *testing.T) {
+	h := newE2EHarness(t)
+	dataset := h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "media"})
+
+	moved := h.moved("truenas_host_path", h.move(e2eProviderAddress, dataset, "truenas_host_path"))
+	if got, want := moved.attr(t, "path"), dataset.attr(t, "mount_path"); got != want {
+		t.Errorf("expected host path %q, got %q", want, got)
+	}
+}
+
+func TestE2E_MoveFromRenamedProvider(t *testing.T) {
+	h := newE2EHarness(t)
+	dataset := h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "media"})
+
+	moved := h.moved("truenas_dataset", h.move("registry.terraform.io/someone/truenas", dataset, "truenas_dataset"))
+	if diffs, _ := dataset.state.Diff(moved.state); len(diffs) > 0 {
+		t.Errorf("moved state differs from applied state:\n%s", formatDiffs(diffs))
+	}
+
+	resp := h.move("registry.terraform.io/hashicorp/other", dataset, "truenas_dataset")
+	expectDiagnostic(t, resp.Diagnostics, "Unable to Move Resource State")
+}
+
+func TestE2E_UpgradeStateVersion0(t *testing.T) {
+	h := newE2EHarness(t)
+	dataset := h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "media"})
+
+	// A version 0 state with an attribute this schema no longer has and
+	// without one it added.
+	resp, err := h.server.UpgradeResourceState(h.ctx, &tfprotov6.UpgradeResourceStateRequest{
+		TypeName: "truenas_dataset",
+		Version:  0,
+		RawState: rawState(t, dataset, map[string]any{"legacy": "x"}, "snapshot_id"),
+	})
+	if err != nil {
+		t.Fatalf("UpgradeResourceState: %v", err)
+	}
+	checkDiagnostics(t, "upgrade", resp.Diagnostics)
+
+	upgraded := &e2eResource{typeName: "truenas_dataset", state: h.unmarshal("truenas_dataset", resp.UpgradedState)}
+	if got := upgraded.attr(t, "id"); got != "tank/media" {
+		t.Errorf("expected dataset tank/media, got %q", got)
+	}
+	h.read(upgraded)
+}
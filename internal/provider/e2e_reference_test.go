@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// planCreate plans the creation of a resource from config and returns the
+// diagnostics of the plan for the caller to check.
+func (h *e2eHarness) planCreate(typeName string, config map[string]any) []*tfprotov6.Diagnostic {
+	h.t.Helper()
+	schema := h.schema(typeName)
+	prior := tftypes.NewValue(schema.ValueType(), nil)
+	configValue := tfValue(h.t, schema.ValueType(), config)
+
+	priorDV := h.dynamic(typeName, prior)
+	proposedDV := h.dynamic(typeName, proposedNewState(schema.Block, prior, configValue))
+	configDV := h.dynamic(typeName, configValue)
+	resp, err := h.server.PlanResourceChange(h.ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       &priorDV,
+		ProposedNewState: &proposedDV,
+		Config:           &configDV,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: PlanResourceChange: %v", typeName, err)
+	}
+	return resp.Diagnostics
+}
+
+// expectWarning checks that diags contain a warning with summary.
+func expectWarning(t *testing.T, diags []*tfprotov6.Diagnostic, summary string) {
+	t.Helper()
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityWarning && d.Summary == summary {
+			return
+		}
+	}
+	t.Fatalf("expected warning %q, got %v", summary, diags)
+}
+
+func TestE2E_ReferenceChecks(t *testing.T) {
+	h := newE2EHarness(t)
+
+	expectDiagnostic(t, h.planCreate("truenas_dataset", map[string]any{"pool": "nope", "path": "media"}), "Pool Not Found")
+	expectDiagnostic(t, h.planCreate("truenas_zvol", map[string]any{"parent": "nope/vms", "path": "disk", "volsize": "1G"}), "Pool Not Found")
+	expectWarning(t, h.planCreate("truenas_dataset", map[string]any{"pool": "tank", "path": "missing/child"}), "Parent Dataset Not Found")
+	expectWarning(t, h.planCreate("truenas_dataset", map[string]any{"parent": "tank/missing", "path": "child"}), "Parent Dataset Not Found")
+	checkDiagnostics(t, "dataset plan", h.planCreate("truenas_dataset", map[string]any{"pool": "tank", "path": "media"}))
+
+	expectDiagnostic(t, h.planCreate("truenas_cloudsync_task", map[string]any{
+		"credentials": 999,
+		"path":        "/mnt/tank",
+		"direction":   "push",
+		"b2":          map[string]any{"bucket": "backups"},
+		"schedule":    map[string]any{"minute": "0", "hour": "3"},
+	}), "Cloud Sync Credentials Not Found")
+
+	instance := map[string]any{
+		"name":          "box",
+		"image_name":    "debian",
+		"image_version": "bookworm",
+		"storage_pool":  "tank",
+	}
+	expectWarning(t, h.planCreate("truenas_virt_instance", instance), "Storage Pool Not Configured")
+	h.apply("truenas_virt_config", map[string]any{"pool": "tank"})
+	if diags := h.planCreate("truenas_virt_instance", instance); len(diags) > 0 {
+		t.Errorf("expected no diagnostics once the pool is configured, got %v", diags)
+	}
+}
+
+func TestE2E_SkipReferenceChecks(t *testing.T) {
+	h := newE2EHarness(t)
+	t.Setenv(EnvSkipReferenceChecks, "true")
+	h.newRun()
+
+	if diags := h.planCreate("truenas_dataset", map[string]any{"pool": "nope", "path": "media"}); len(diags) > 0 {
+		t.Errorf("expected references not to be checked, got %v", diags)
+	}
+}
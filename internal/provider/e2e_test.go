@@ -0,0 +1,833 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/fakenas"
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// End-to-end tests drive the provider over the plugin protocol, the way
+// Terraform does, against an in-process fake TrueNAS. Each resource is
+// created, checked for drift, updated, checked again and destroyed.
+
+// fakeNASFactory connects to a fakenas.Server: the real WebSocket client
+// talks to its listener and the SSH client is replaced by its shell client.
+type fakeNASFactory struct {
+	srv     *fakenas.Server
+	clients []client.Client
+}
+
+func (f *fakeNASFactory) NewSSHClient(cfg transport.SSHConfig) (client.Client, error) {
+	return f.srv.ShellClient(), nil
+}
+
+func (f *fakeNASFactory) NewWebSocketClient(cfg transport.WebSocketConfig) (client.Client, error) {
+	c, err := transport.NewWebSocketClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	f.clients = append(f.clients, c)
+	return c, nil
+}
+
+// e2eHarness is a configured provider server plus the resources it has
+// created, which are destroyed in reverse order when the test ends.
+type e2eHarness struct {
+	t       *testing.T
+	ctx     context.Context
+	srv     *fakenas.Server
+	server  tfprotov6.ProviderServer
+	schemas map[string]*tfprotov6.Schema
+	// ephemeralSchemas are the schemas of the ephemeral resources.
+	ephemeralSchemas map[string]*tfprotov6.Schema
+	created          []*e2eResource
+
+	providerConfig tfprotov6.DynamicValue
+}
+
+// e2eResource is the state of a resource managed by an e2eHarness.
+type e2eResource struct {
+	typeName  string
+	config    map[string]any
+	state     tftypes.Value
+	private   []byte
+	destroyed bool
+}
+
+func newE2EHarness(t *testing.T, opts ...fakenas.Option) *e2eHarness {
+	t.Helper()
+	srv := fakenas.NewServer(t, opts...)
+	h := startE2EHarness(t, newFakeNASFactory(t, srv), fakeNASProviderConfig(srv))
+	h.srv = srv
+	return h
+}
+
+// newFakeNASFactory returns a fakeNASFactory for srv whose clients are
+// closed when the test ends.
+func newFakeNASFactory(t *testing.T, srv *fakenas.Server) *fakeNASFactory {
+	factory := &fakeNASFactory{srv: srv}
+	t.Cleanup(func() {
+		for _, c := range factory.clients {
+			_ = c.Close()
+		}
+	})
+	return factory
+}
+
+// fakeNASProviderConfig returns a provider configuration for srv.
+func fakeNASProviderConfig(srv *fakenas.Server) map[string]any {
+	websocket := map[string]any{
+		"username":             srv.Username(),
+		"port":                 srv.Port,
+		"insecure_skip_verify": true,
+		"max_retries":          1,
+	}
+	if srv.Password() != "" {
+		websocket["password"] = srv.Password()
+		if srv.OTPSecret() != "" {
+			websocket["otp_secret"] = srv.OTPSecret()
+		}
+	} else {
+		websocket["api_key"] = srv.APIKey()
+	}
+
+	return map[string]any{
+		"host":        srv.Host,
+		"auth_method": "websocket",
+		"websocket":   websocket,
+		"rate_limit":  0,
+
+		"rate_limit_weights": map[string]any{"pool.dataset.query": 2},
+		"ssh": map[string]any{
+			"private_key":          "unused",
+			"host_key_fingerprint": "unused",
+		},
+	}
+}
+
+// startE2EHarness configures a provider server whose clients come from
+// factory.
+func startE2EHarness(t *testing.T, factory ClientFactory, providerConfig map[string]any) *e2eHarness {
+	t.Helper()
+	ctx := context.Background()
+
+	p := &TrueNASProvider{version: "test", factory: factory}
+	server, err := providerserver.NewProtocol6WithError(p)()
+	if err != nil {
+		t.Fatalf("failed to create provider server: %v", err)
+	}
+
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("GetProviderSchema: %v", err)
+	}
+	checkDiagnostics(t, "GetProviderSchema", schemaResp.Diagnostics)
+
+	h := &e2eHarness{
+		t:       t,
+		ctx:     ctx,
+		server:  server,
+		schemas: schemaResp.ResourceSchemas,
+
+		ephemeralSchemas: schemaResp.EphemeralResourceSchemas,
+		providerConfig:   dynamicValue(t, schemaResp.Provider.ValueType(), providerConfig),
+	}
+	h.newRun()
+
+	t.Cleanup(h.destroyAll)
+	return h
+}
+
+// newRun configures the provider afresh, as Terraform does when a new plan
+// or apply starts the provider, so that nothing is cached from earlier
+// steps.
+func (h *e2eHarness) newRun() {
+	h.t.Helper()
+	resp, err := h.server.ConfigureProvider(h.ctx, &tfprotov6.ConfigureProviderRequest{
+		TerraformVersion: "1.11.0",
+		Config:           &h.providerConfig,
+	})
+	if err != nil {
+		h.t.Fatalf("ConfigureProvider: %v", err)
+	}
+	checkDiagnostics(h.t, "ConfigureProvider", resp.Diagnostics)
+}
+
+// apply creates a resource from config and checks that a second plan is
+// empty.
+func (h *e2eHarness) apply(typeName string, config map[string]any) *e2eResource {
+	h.t.Helper()
+	schema := h.schema(typeName)
+	r := &e2eResource{typeName: typeName, config: config}
+	r.state = tftypes.NewValue(schema.ValueType(), nil)
+
+	h.validate(r)
+	planned, private := h.plan(r, config)
+	h.applyPlan(r, planned, private)
+	h.created = append(h.created, r)
+	h.read(r)
+	h.expectNoChanges(r)
+	return r
+}
+
+// update changes the configuration of r in place and checks that a second
+// plan is empty.
+func (h *e2eHarness) update(r *e2eResource, config map[string]any) {
+	h.t.Helper()
+	r.config = config
+	h.validate(r)
+	planned, private := h.plan(r, config)
+	if planned.Equal(r.state) {
+		h.t.Fatalf("%s: expected changes to be planned", r.typeName)
+	}
+	h.applyPlan(r, planned, private)
+	h.read(r)
+	h.expectNoChanges(r)
+}
+
+// destroy deletes r.
+func (h *e2eHarness) destroy(r *e2eResource) {
+	h.t.Helper()
+	typ := h.schema(r.typeName).ValueType()
+	null := dynamicValue(h.t, typ, nil)
+	prior := h.dynamic(r.typeName, r.state)
+
+	planResp, err := h.server.PlanResourceChange(h.ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         r.typeName,
+		PriorState:       &prior,
+		ProposedNewState: &null,
+		Config:           &null,
+		PriorPrivate:     r.private,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: PlanResourceChange: %v", r.typeName, err)
+	}
+	checkDiagnostics(h.t, r.typeName+" destroy plan", planResp.Diagnostics)
+
+	applyResp, err := h.server.ApplyResourceChange(h.ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       r.typeName,
+		PriorState:     &prior,
+		PlannedState:   &null,
+		Config:         &null,
+		PlannedPrivate: r.private,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: ApplyResourceChange: %v", r.typeName, err)
+	}
+	checkDiagnostics(h.t, r.typeName+" destroy", applyResp.Diagnostics)
+	r.destroyed = true
+}
+
+// expectGone checks that reading r after it was deleted outside Terraform
+// removes it from state.
+func (h *e2eHarness) expectGone(r *e2eResource) {
+	h.t.Helper()
+	current := h.dynamic(r.typeName, r.state)
+	resp, err := h.server.ReadResource(h.ctx, &tfprotov6.ReadResourceRequest{
+		TypeName:     r.typeName,
+		CurrentState: &current,
+		Private:      r.private,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: ReadResource: %v", r.typeName, err)
+	}
+	checkDiagnostics(h.t, r.typeName+" read", resp.Diagnostics)
+	state := h.unmarshal(r.typeName, resp.NewState)
+	if !state.IsNull() {
+		h.t.Errorf("%s: expected resource to be removed from state, got %v", r.typeName, state)
+	}
+}
+
+// destroyAll destroys every resource that is still live, newest first.
+func (h *e2eHarness) destroyAll() {
+	for i := len(h.created) - 1; i >= 0; i-- {
+		if r := h.created[i]; !r.destroyed {
+			h.destroy(r)
+		}
+	}
+}
+
+func (h *e2eHarness) validate(r *e2eResource) {
+	h.t.Helper()
+	config := dynamicValue(h.t, h.schema(r.typeName).ValueType(), r.config)
+	resp, err := h.server.ValidateResourceConfig(h.ctx, &tfprotov6.ValidateResourceConfigRequest{
+		TypeName: r.typeName,
+		Config:   &config,
+		ClientCapabilities: &tfprotov6.ValidateResourceConfigClientCapabilities{
+			WriteOnlyAttributesAllowed: true,
+		},
+	})
+	if err != nil {
+		h.t.Fatalf("%s: ValidateResourceConfig: %v", r.typeName, err)
+	}
+	checkDiagnostics(h.t, r.typeName+" validate", resp.Diagnostics)
+}
+
+func (h *e2eHarness) plan(r *e2eResource, configMap map[string]any) (tftypes.Value, []byte) {
+	h.t.Helper()
+	schema := h.schema(r.typeName)
+	config := tfValue(h.t, schema.ValueType(), configMap)
+	proposed := proposedNewState(schema.Block, r.state, config)
+
+	priorDV := h.dynamic(r.typeName, r.state)
+	proposedDV := h.dynamic(r.typeName, proposed)
+	configDV := h.dynamic(r.typeName, config)
+	resp, err := h.server.PlanResourceChange(h.ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         r.typeName,
+		PriorState:       &priorDV,
+		ProposedNewState: &proposedDV,
+		Config:           &configDV,
+		PriorPrivate:     r.private,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: PlanResourceChange: %v", r.typeName, err)
+	}
+	checkDiagnostics(h.t, r.typeName+" plan", resp.Diagnostics)
+	if !r.state.IsNull() && len(resp.RequiresReplace) > 0 {
+		h.t.Fatalf("%s: unexpected replacement of %v", r.typeName, resp.RequiresReplace)
+	}
+	return h.unmarshal(r.typeName, resp.PlannedState), resp.PlannedPrivate
+}
+
+func (h *e2eHarness) applyPlan(r *e2eResource, planned tftypes.Value, private []byte) {
+	h.t.Helper()
+	schema := h.schema(r.typeName)
+	priorDV := h.dynamic(r.typeName, r.state)
+	plannedDV := h.dynamic(r.typeName, planned)
+	configDV := dynamicValue(h.t, schema.ValueType(), r.config)
+	resp, err := h.server.ApplyResourceChange(h.ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       r.typeName,
+		PriorState:     &priorDV,
+		PlannedState:   &plannedDV,
+		Config:         &configDV,
+		PlannedPrivate: private,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: ApplyResourceChange: %v", r.typeName, err)
+	}
+	checkDiagnostics(h.t, r.typeName+" apply", resp.Diagnostics)
+	state := h.unmarshal(r.typeName, resp.NewState)
+	if !state.IsFullyKnown() {
+		h.t.Fatalf("%s: applied state has unknown values: %v", r.typeName, state)
+	}
+	r.state, r.private = state, resp.Private
+}
+
+func (h *e2eHarness) read(r *e2eResource) {
+	h.t.Helper()
+	current := h.dynamic(r.typeName, r.state)
+	resp, err := h.server.ReadResource(h.ctx, &tfprotov6.ReadResourceRequest{
+		TypeName:     r.typeName,
+		CurrentState: &current,
+		Private:      r.private,
+	})
+	if err != nil {
+		h.t.Fatalf("%s: ReadResource: %v", r.typeName, err)
+	}
+	checkDiagnostics(h.t, r.typeName+" read", resp.Diagnostics)
+	state := h.unmarshal(r.typeName, resp.NewState)
+	if state.IsNull() {
+		h.t.Fatalf("%s: resource disappeared after apply", r.typeName)
+	}
+	if diffs, _ := r.state.Diff(state); len(diffs) > 0 {
+		h.t.Errorf("%s: read state differs from applied state:\n%s", r.typeName, formatDiffs(diffs))
+	}
+	r.state, r.private = state, resp.Private
+}
+
+func (h *e2eHarness) expectNoChanges(r *e2eResource) {
+	h.t.Helper()
+	planned, _ := h.plan(r, r.config)
+	if diffs, _ := r.state.Diff(planned); len(diffs) > 0 {
+		h.t.Errorf("%s: expected an empty plan, got changes:\n%s", r.typeName, formatDiffs(diffs))
+	}
+}
+
+func (h *e2eHarness) schema(typeName string) *tfprotov6.Schema {
+	h.t.Helper()
+	schema, ok := h.schemas[typeName]
+	if !ok {
+		h.t.Fatalf("unknown resource type %s", typeName)
+	}
+	return schema
+}
+
+func (h *e2eHarness) dynamic(typeName string, v tftypes.Value) tfprotov6.DynamicValue {
+	h.t.Helper()
+	dv, err := tfprotov6.NewDynamicValue(h.schema(typeName).ValueType(), v)
+	if err != nil {
+		h.t.Fatalf("%s: failed to encode value: %v", typeName, err)
+	}
+	return dv
+}
+
+func (h *e2eHarness) unmarshal(typeName string, dv *tfprotov6.DynamicValue) tftypes.Value {
+	h.t.Helper()
+	typ := h.schema(typeName).ValueType()
+	if dv == nil {
+		return tftypes.NewValue(typ, nil)
+	}
+	v, err := dv.Unmarshal(typ)
+	if err != nil {
+		h.t.Fatalf("%s: failed to decode value: %v", typeName, err)
+	}
+	return v
+}
+
+// attr returns a top-level string attribute of r's state.
+func (r *e2eResource) attr(t *testing.T, name string) string {
+	t.Helper()
+	var attrs map[string]tftypes.Value
+	if err := r.state.As(&attrs); err != nil {
+		t.Fatalf("%s: state is not an object: %v", r.typeName, err)
+	}
+	var s string
+	if err := attrs[name].As(&s); err != nil {
+		t.Fatalf("%s: attribute %s is not a string: %v", r.typeName, name, err)
+	}
+	return s
+}
+
+// proposedNewState merges prior state into config the way Terraform does
+// before planning: computed attributes left unset in config keep their prior
+// values.
+func proposedNewState(block *tfprotov6.SchemaBlock, prior, config tftypes.Value) tftypes.Value {
+	if prior.IsNull() || config.IsNull() || !prior.IsKnown() {
+		return config
+	}
+	var priorAttrs, configAttrs map[string]tftypes.Value
+	if prior.As(&priorAttrs) != nil || config.As(&configAttrs) != nil {
+		return config
+	}
+
+	out := make(map[string]tftypes.Value, len(configAttrs))
+	for name, v := range configAttrs {
+		out[name] = v
+	}
+	for _, attr := range block.Attributes {
+		if attr.Computed && configAttrs[attr.Name].IsNull() {
+			out[attr.Name] = priorAttrs[attr.Name]
+		}
+	}
+	for _, nested := range block.BlockTypes {
+		name := nested.TypeName
+		switch nested.Nesting {
+		case tfprotov6.SchemaNestedBlockNestingModeSingle:
+			out[name] = proposedNewState(nested.Block, priorAttrs[name], configAttrs[name])
+		case tfprotov6.SchemaNestedBlockNestingModeList:
+			var priorElems, configElems []tftypes.Value
+			if priorAttrs[name].As(&priorElems) != nil || configAttrs[name].As(&configElems) != nil {
+				continue
+			}
+			elems := make([]tftypes.Value, len(configElems))
+			for i, elem := range configElems {
+				elems[i] = elem
+				if i < len(priorElems) {
+					elems[i] = proposedNewState(nested.Block, priorElems[i], elem)
+				}
+			}
+			out[name] = tftypes.NewValue(configAttrs[name].Type(), elems)
+		}
+	}
+	return tftypes.NewValue(config.Type(), out)
+}
+
+// tfValue converts v to a value of typ. Objects are given as maps and
+// missing attributes are null.
+func tfValue(t *testing.T, typ tftypes.Type, v any) tftypes.Value {
+	t.Helper()
+	if v == nil {
+		return tftypes.NewValue(typ, nil)
+	}
+	switch typ := typ.(type) {
+	case tftypes.Object:
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("expected map for %s, got %T", typ, v)
+		}
+		for name := range m {
+			if _, ok := typ.AttributeTypes[name]; !ok {
+				t.Fatalf("unknown attribute %q", name)
+			}
+		}
+		attrs := make(map[string]tftypes.Value, len(typ.AttributeTypes))
+		for name, attrType := range typ.AttributeTypes {
+			attrs[name] = tfValue(t, attrType, m[name])
+		}
+		return tftypes.NewValue(typ, attrs)
+	case tftypes.List:
+		return tftypes.NewValue(typ, tfElems(t, typ.ElementType, v))
+	case tftypes.Set:
+		return tftypes.NewValue(typ, tfElems(t, typ.ElementType, v))
+	case tftypes.Map:
+		m, ok := v.(map[string]any)
+		if !ok {
+			t.Fatalf("expected map for %s, got %T", typ, v)
+		}
+		elems := make(map[string]tftypes.Value, len(m))
+		for k, elem := range m {
+			elems[k] = tfValue(t, typ.ElementType, elem)
+		}
+		return tftypes.NewValue(typ, elems)
+	}
+	return tftypes.NewValue(typ, v)
+}
+
+func tfElems(t *testing.T, elemType tftypes.Type, v any) []tftypes.Value {
+	t.Helper()
+	var items []any
+	switch v := v.(type) {
+	case []any:
+		items = v
+	case []map[string]any:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	case []string:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	default:
+		t.Fatalf("expected list, got %T", v)
+	}
+	elems := make([]tftypes.Value, len(items))
+	for i, item := range items {
+		elems[i] = tfValue(t, elemType, item)
+	}
+	return elems
+}
+
+func dynamicValue(t *testing.T, typ tftypes.Type, v any) tfprotov6.DynamicValue {
+	t.Helper()
+	dv, err := tfprotov6.NewDynamicValue(typ, tfValue(t, typ, v))
+	if err != nil {
+		t.Fatalf("failed to encode value: %v", err)
+	}
+	return dv
+}
+
+func checkDiagnostics(t *testing.T, step string, diags []*tfprotov6.Diagnostic) {
+	t.Helper()
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("%s: %s: %s", step, d.Summary, d.Detail)
+		}
+	}
+}
+
+func formatDiffs(diffs []tftypes.ValueDiff) string {
+	lines := make([]string, len(diffs))
+	for i, d := range diffs {
+		lines[i] = fmt.Sprintf("  %s: %v => %v", d.Path, d.Value1, d.Value2)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// e2eCases exercises every resource the provider registers. setup creates
+// prerequisites and adds attributes referring to them to config.
+var e2eCases = []struct {
+	typeName string
+	setup    func(t *testing.T, h *e2eHarness, config map[string]any)
+	create   map[string]any
+	update   map[string]any
+}{
+	{
+		typeName: "truenas_dataset",
+		create:   map[string]any{"pool": "tank", "path": "data", "compression": "LZ4"},
+		update:   map[string]any{"pool": "tank", "path": "data", "compression": "ZSTD", "quota": "10G"},
+	},
+	{
+		typeName: "truenas_zvol",
+		create:   map[string]any{"pool": "tank", "path": "disk", "volsize": "1G"},
+		update:   map[string]any{"pool": "tank", "path": "disk", "volsize": "2G", "comments": "grown"},
+	},
+	{
+		typeName: "truenas_host_path",
+		create:   map[string]any{"path": "/mnt/tank/share", "mode": "755", "uid": 0, "gid": 0},
+		update:   map[string]any{"path": "/mnt/tank/share", "mode": "750", "uid": 568, "gid": 568},
+	},
+	{
+		typeName: "truenas_file",
+		create:   map[string]any{"path": "/mnt/tank/app.conf", "content": "key = 1\n"},
+		update:   map[string]any{"path": "/mnt/tank/app.conf", "content": "key = 2\n", "mode": "0600"},
+	},
+	{
+		typeName: "truenas_snapshot",
+		setup: func(t *testing.T, h *e2eHarness, config map[string]any) {
+			h.apply("truenas_dataset", map[string]any{"pool": "tank", "path": "snapped"})
+		},
+		create: map[string]any{"dataset_id": "tank/snapped", "name": "daily"},
+		update: map[string]any{"dataset_id": "tank/snapped", "name": "daily", "hold": true},
+	},
+	{
+		typeName: "truenas_cloudsync_credentials",
+		create: map[string]any{
+			"name": "b2",
+			"b2":   map[string]any{"account": "account-id", "key": "secret"},
+		},
+		update: map[string]any{
+			"name": "b2",
+			"b2":   map[string]any{"account": "account-id", "key": "rotated"},
+		},
+	},
+	{
+		typeName: "truenas_cloudsync_task",
+		setup: func(t *testing.T, h *e2eHarness, config map[string]any) {
+			creds := h.apply("truenas_cloudsync_credentials", map[string]any{
+				"name": "b2",
+				"b2":   map[string]any{"account": "account-id", "key": "secret"},
+			})
+			var id int
+			if _, err := fmt.Sscan(creds.attr(t, "id"), &id); err != nil {
+				t.Fatalf("credentials ID: %v", err)
+			}
+			config["credentials"] = id
+		},
+		create: map[string]any{
+			"description": "backup",
+			"path":        "/mnt/tank",
+			"direction":   "push",
+			"b2":          map[string]any{"bucket": "backups"},
+			"schedule":    map[string]any{"minute": "0", "hour": "3"},
+		},
+		update: map[string]any{
+			"description": "nightly backup",
+			"path":        "/mnt/tank",
+			"direction":   "push",
+			"b2":          map[string]any{"bucket": "backups", "folder": "nas"},
+			"schedule":    map[string]any{"minute": "30", "hour": "2"},
+		},
+	},
+	{
+		typeName: "truenas_cron_job",
+		create: map[string]any{
+			"user":     "root",
+			"command":  "echo hello",
+			"schedule": map[string]any{"minute": "0", "hour": "*"},
+		},
+		update: map[string]any{
+			"user":        "root",
+			"command":     "echo goodbye",
+			"description": "farewell",
+			"schedule":    map[string]any{"minute": "15", "hour": "4", "dow": "1"},
+		},
+	},
+	{
+		typeName: "truenas_virt_config",
+		create:   map[string]any{"pool": "tank"},
+		update:   map[string]any{"pool": "tank", "v4_network": "10.10.0.1/24"},
+	},
+	{
+		typeName: "truenas_virt_instance",
+		setup: func(t *testing.T, h *e2eHarness, config map[string]any) {
+			h.apply("truenas_virt_config", map[string]any{"pool": "tank"})
+		},
+		create: map[string]any{
+			"name":          "box",
+			"image_name":    "debian",
+			"image_version": "bookworm",
+			"storage_pool":  "tank",
+			"disk":          []map[string]any{{"name": "data", "source": "/mnt/tank", "destination": "/data", "readonly": false}},
+		},
+		update: map[string]any{
+			"name":          "box",
+			"image_name":    "debian",
+			"image_version": "bookworm",
+			"storage_pool":  "tank",
+			"desired_state": "STOPPED",
+			"disk":          []map[string]any{{"name": "data", "source": "/mnt/tank", "destination": "/data", "readonly": false}},
+			"proxy":         []map[string]any{{"name": "http", "source_proto": "TCP", "source_port": 8080, "dest_proto": "TCP", "dest_port": 80}},
+		},
+	},
+	{
+		typeName: "truenas_app_registry",
+		create:   map[string]any{"name": "ghcr", "username": "user", "password": "token", "uri": "https://ghcr.io"},
+		update:   map[string]any{"name": "ghcr", "username": "user", "password": "token", "uri": "https://ghcr.io", "description": "GitHub"},
+	},
+	{
+		typeName: "truenas_vm",
+		create: map[string]any{
+			"name":   "web",
+			"memory": 1024,
+			"nic":    []map[string]any{{"type": "VIRTIO", "nic_attach": "br0"}},
+		},
+		update: map[string]any{
+			"name":   "web",
+			"memory": 2048,
+			"vcpus":  2,
+			"nic":    []map[string]any{{"type": "VIRTIO", "nic_attach": "br0"}},
+			"disk":   []map[string]any{{"path": "/dev/zvol/tank/web"}},
+		},
+	},
+	{
+		typeName: "truenas_app",
+		create: map[string]any{
+			"name":           "web",
+			"custom_app":     true,
+			"compose_config": "services:\n  web:\n    image: nginx:latest\n",
+		},
+		update: map[string]any{
+			"name":           "web",
+			"custom_app":     true,
+			"compose_config": "services:\n  web:\n    image: nginx:1.27\n",
+			"desired_state":  "stopped",
+		},
+	},
+}
+
+func TestE2E_Resources(t *testing.T) {
+	for _, tc := range e2eCases {
+		t.Run(tc.typeName, func(t *testing.T) {
+			h := newE2EHarness(t)
+			create, update := copyConfig(tc.create), copyConfig(tc.update)
+			if tc.setup != nil {
+				extra := map[string]any{}
+				tc.setup(t, h, extra)
+				for k, v := range extra {
+					create[k], update[k] = v, v
+				}
+			}
+
+			r := h.apply(tc.typeName, create)
+			h.update(r, update)
+			h.destroy(r)
+		})
+	}
+}
+
+func TestE2E_CoversAllResources(t *testing.T) {
+	h := newE2EHarness(t)
+	covered := map[string]bool{}
+	for _, tc := range e2eCases {
+		covered[tc.typeName] = true
+	}
+	for typeName := range h.schemas {
+		if !covered[typeName] {
+			t.Errorf("resource %s has no end-to-end test case", typeName)
+		}
+	}
+}
+
+// deletesOutsideTerraform delete the object of a resource the way the
+// TrueNAS UI would. The file and host path resources are deleted over SSH
+// and virt_config cannot be deleted.
+var deletesOutsideTerraform = map[string]func(h *e2eHarness, r *e2eResource) error{
+	"truenas_dataset": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.Call(h.ctx, "pool.dataset.delete", []any{r.attr(h.t, "id")})
+		return err
+	},
+	"truenas_zvol": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.Call(h.ctx, "pool.dataset.delete", []any{r.attr(h.t, "id")})
+		return err
+	},
+	"truenas_snapshot": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.Call(h.ctx, "pool.snapshot.delete", []any{r.attr(h.t, "id")})
+		return err
+	},
+	"truenas_cloudsync_credentials": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.Call(h.ctx, "cloudsync.credentials.delete", []any{r.intID(h.t)})
+		return err
+	},
+	"truenas_cloudsync_task": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.Call(h.ctx, "cloudsync.delete", []any{r.intID(h.t)})
+		return err
+	},
+	"truenas_cron_job": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.Call(h.ctx, "cronjob.delete", []any{r.intID(h.t)})
+		return err
+	},
+	"truenas_virt_instance": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.CallAndWait(h.ctx, "virt.instance.delete", []any{r.attr(h.t, "name")})
+		return err
+	},
+	"truenas_app_registry": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.Call(h.ctx, "app.registry.delete", []any{r.intID(h.t)})
+		return err
+	},
+	"truenas_vm": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.Call(h.ctx, "vm.delete", []any{r.intID(h.t)})
+		return err
+	},
+	"truenas_app": func(h *e2eHarness, r *e2eResource) error {
+		_, err := h.srv.CallAndWait(h.ctx, "app.delete", []any{r.attr(h.t, "name")})
+		return err
+	},
+}
+
+// intID returns the numeric ID of r.
+func (r *e2eResource) intID(t *testing.T) int {
+	t.Helper()
+	var id int
+	if _, err := fmt.Sscan(r.attr(t, "id"), &id); err != nil {
+		t.Fatalf("%s: ID: %v", r.typeName, err)
+	}
+	return id
+}
+
+// TestE2E_ResourceDeletedOutsideTerraform checks that a refresh removes a
+// resource whose object was deleted outside Terraform, and that destroying
+// it anyway succeeds.
+func TestE2E_ResourceDeletedOutsideTerraform(t *testing.T) {
+	for _, tc := range e2eCases {
+		deleteOutside, ok := deletesOutsideTerraform[tc.typeName]
+		if !ok {
+			continue
+		}
+		t.Run(tc.typeName, func(t *testing.T) {
+			h := newE2EHarness(t)
+			create := copyConfig(tc.create)
+			if tc.setup != nil {
+				tc.setup(t, h, create)
+			}
+
+			r := h.apply(tc.typeName, create)
+			if err := deleteOutside(h, r); err != nil {
+				t.Fatalf("delete outside Terraform: %v", err)
+			}
+			h.newRun()
+			h.expectGone(r)
+			h.destroy(r)
+		})
+	}
+}
+
+func TestE2E_PasswordAuthentication_SessionExpiresMidApply(t *testing.T) {
+	h := newE2EHarness(t,
+		fakenas.WithPassword("breakglass", "hunter2"),
+		fakenas.WithOTPSecret("JBSWY3DPEHPK3PXP"),
+	)
+	r := h.apply("truenas_cron_job", map[string]any{
+		"user":     "root",
+		"command":  "echo hello",
+		"schedule": map[string]any{"minute": "0", "hour": "*"},
+	})
+
+	logins := h.srv.Logins()
+	h.srv.ExpireSessions()
+	h.update(r, map[string]any{
+		"user":     "root",
+		"command":  "echo goodbye",
+		"schedule": map[string]any{"minute": "0", "hour": "*"},
+	})
+	if h.srv.Logins() <= logins {
+		t.Errorf("expected the provider to log in again after the session expired")
+	}
+}
+
+func copyConfig(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
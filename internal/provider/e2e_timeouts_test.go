@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/deevus/terraform-provider-truenas/internal/fakenas"
+)
+
+func TestE2E_Timeouts_RoundTrip(t *testing.T) {
+	h := newE2EHarness(t)
+
+	r := h.apply("truenas_dataset", map[string]any{
+		"pool":     "tank",
+		"path":     "data",
+		"timeouts": map[string]any{"create": "10m", "delete": "1h"},
+	})
+	h.update(r, map[string]any{
+		"pool":     "tank",
+		"path":     "data",
+		"timeouts": map[string]any{"create": "10m", "update": "45s", "delete": "1h"},
+	})
+}
+
+func TestE2E_Timeouts_CreateAbortsJob(t *testing.T) {
+	h := newE2EHarness(t, fakenas.WithJobDelay(time.Second))
+
+	diags := h.applyFailing("truenas_app", map[string]any{
+		"name":           "web",
+		"custom_app":     true,
+		"compose_config": "services:\n  web:\n    image: nginx:latest\n",
+		"timeouts":       map[string]any{"create": "100ms"},
+	})
+	expectDiagnostic(t, diags, "Unable to Create App")
+
+	if n := h.srv.CallCount("core.job_abort"); n != 1 {
+		t.Fatalf("expected the app.create job to be aborted, got %d core.job_abort calls", n)
+	}
+
+	// The aborted job finishes after the delay without creating the app
+	time.Sleep(1500 * time.Millisecond)
+	apps, err := h.srv.Call(context.Background(), "app.query", []any{[]any{[]any{"name", "=", "web"}}})
+	if err != nil {
+		t.Fatalf("app.query: %v", err)
+	}
+	if string(apps) != "[]" {
+		t.Errorf("expected no app after the abort, got %s", apps)
+	}
+}
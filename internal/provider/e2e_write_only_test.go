@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// registryPassword returns the password TrueNAS holds for the registry r.
+func (h *e2eHarness) registryPassword(r *e2eResource) string {
+	h.t.Helper()
+	result, err := h.srv.Call(h.ctx, "app.registry.query", []any{})
+	if err != nil {
+		h.t.Fatalf("app.registry.query: %v", err)
+	}
+	var registries []struct {
+		ID       json.Number `json:"id"`
+		Password string      `json:"password"`
+	}
+	if err := json.Unmarshal(result, &registries); err != nil {
+		h.t.Fatalf("failed to decode registries: %v", err)
+	}
+	for _, reg := range registries {
+		if reg.ID.String() == r.attr(h.t, "id") {
+			return reg.Password
+		}
+	}
+	h.t.Fatalf("registry %s not found", r.attr(h.t, "id"))
+	return ""
+}
+
+// expectNullAttr fails the test unless the top-level attribute name of r's
+// state is null.
+func expectNullAttr(t *testing.T, r *e2eResource, name string) {
+	t.Helper()
+	var attrs map[string]tftypes.Value
+	if err := r.state.As(&attrs); err != nil {
+		t.Fatalf("%s: state is not an object: %v", r.typeName, err)
+	}
+	if !attrs[name].IsNull() {
+		t.Errorf("%s: expected %s to be null in state, got %v", r.typeName, name, attrs[name])
+	}
+}
+
+func TestE2E_WriteOnlySecret(t *testing.T) {
+	h := newE2EHarness(t)
+
+	r := h.apply("truenas_app_registry", map[string]any{
+		"name":                "ghcr",
+		"username":            "user",
+		"password_wo":         "token",
+		"password_wo_version": 1,
+		"uri":                 "https://ghcr.io",
+	})
+	expectNullAttr(t, r, "password")
+	expectNullAttr(t, r, "password_wo")
+	if got := h.registryPassword(r); got != "token" {
+		t.Errorf("expected TrueNAS to hold password %q, got %q", "token", got)
+	}
+
+	// Changing the secret alone plans nothing; bumping the version sends it
+	r.config["password_wo"] = "rotated"
+	h.expectNoChanges(r)
+	h.update(r, map[string]any{
+		"name":                "ghcr",
+		"username":            "user",
+		"password_wo":         "rotated",
+		"password_wo_version": 2,
+		"uri":                 "https://ghcr.io",
+	})
+	expectNullAttr(t, r, "password_wo")
+	if got := h.registryPassword(r); got != "rotated" {
+		t.Errorf("expected TrueNAS to hold password %q, got %q", "rotated", got)
+	}
+}
+
+func TestE2E_WriteOnlyFileContent(t *testing.T) {
+	h := newE2EHarness(t)
+
+	r := h.apply("truenas_file", map[string]any{
+		"path":               "/mnt/tank/secret.env",
+		"content_wo":         "TOKEN=1\n",
+		"content_wo_version": 1,
+	})
+	expectNullAttr(t, r, "content_wo")
+	expectNullAttr(t, r, "checksum")
+
+	h.update(r, map[string]any{
+		"path":               "/mnt/tank/secret.env",
+		"content_wo":         "TOKEN=2\n",
+		"content_wo_version": 2,
+	})
+	expectNullAttr(t, r, "content_wo")
+}
+
+// cloudSyncSalt returns the encryption salt TrueNAS holds for the cloud sync
+// task r.
+func (h *e2eHarness) cloudSyncSalt(r *e2eResource) string {
+	h.t.Helper()
+	result, err := h.srv.Call(h.ctx, "cloudsync.get_instance", []any{r.intID(h.t)})
+	if err != nil {
+		h.t.Fatalf("cloudsync.get_instance: %v", err)
+	}
+	var task struct {
+		EncryptionSalt string `json:"encryption_salt"`
+	}
+	if err := json.Unmarshal(result, &task); err != nil {
+		h.t.Fatalf("failed to decode cloud sync task: %v", err)
+	}
+	return task.EncryptionSalt
+}
+
+func TestE2E_WriteOnlyCloudSyncSalt(t *testing.T) {
+	h := newE2EHarness(t)
+
+	creds := h.apply("truenas_cloudsync_credentials", map[string]any{
+		"name": "b2",
+		"b2":   map[string]any{"account": "account-id", "key": "secret"},
+	})
+	config := func(salt string, version int) map[string]any {
+		return map[string]any{
+			"description": "secure backup",
+			"credentials": creds.intID(t),
+			"path":        "/mnt/tank",
+			"direction":   "push",
+			"b2":          map[string]any{"bucket": "backups"},
+			"schedule":    map[string]any{"minute": "0", "hour": "3"},
+			"encryption": map[string]any{
+				"password":        "passphrase",
+				"salt_wo":         salt,
+				"salt_wo_version": version,
+			},
+		}
+	}
+
+	r := h.apply("truenas_cloudsync_task", config("pepper", 1))
+	if got := h.cloudSyncSalt(r); got != "pepper" {
+		t.Errorf("expected TrueNAS to hold salt %q, got %q", "pepper", got)
+	}
+
+	h.update(r, config("cumin", 2))
+	if got := h.cloudSyncSalt(r); got != "cumin" {
+		t.Errorf("expected TrueNAS to hold salt %q, got %q", "cumin", got)
+	}
+}
@@ -0,0 +1,220 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Environment variables consulted when the matching provider attribute is
+// not set in configuration. Values in HCL always take precedence.
+const (
+	EnvHost       = "TRUENAS_HOST"
+	EnvAuthMethod = "TRUENAS_AUTH_METHOD"
+	EnvRateLimit  = "TRUENAS_RATE_LIMIT"
+	EnvMaxRetries = "TRUENAS_MAX_RETRIES"
+	EnvReadOnly   = "TRUENAS_READ_ONLY"
+
+	EnvSkipReferenceChecks = "TRUENAS_SKIP_REFERENCE_CHECKS"
+
+	EnvSSHPort               = "TRUENAS_SSH_PORT"
+	EnvSSHUser               = "TRUENAS_SSH_USER"
+	EnvSSHPrivateKey         = "TRUENAS_SSH_PRIVATE_KEY"
+	EnvSSHPrivateKeyFile     = "TRUENAS_SSH_PRIVATE_KEY_FILE"
+	EnvSSHUseAgent           = "TRUENAS_SSH_USE_AGENT"
+	EnvSSHHostKeyFingerprint = "TRUENAS_SSH_HOST_KEY_FINGERPRINT"
+	EnvSSHKnownHostsFile     = "TRUENAS_SSH_KNOWN_HOSTS_FILE"
+	EnvSSHMaxSessions        = "TRUENAS_SSH_MAX_SESSIONS"
+	EnvSSHSudo               = "TRUENAS_SSH_SUDO"
+	EnvSSHSudoPassword       = "TRUENAS_SSH_SUDO_PASSWORD"
+
+	EnvUsername           = "TRUENAS_USERNAME"
+	EnvAPIKey             = "TRUENAS_API_KEY"
+	EnvPassword           = "TRUENAS_PASSWORD"
+	EnvOTPSecret          = "TRUENAS_OTP_SECRET"
+	EnvWebSocketPort      = "TRUENAS_WEBSOCKET_PORT"
+	EnvInsecureSkipVerify = "TRUENAS_INSECURE_SKIP_VERIFY"
+	EnvCACertFile         = "TRUENAS_CA_CERT_FILE"
+	EnvTLSFingerprint     = "TRUENAS_TLS_FINGERPRINT"
+	EnvClientCertFile     = "TRUENAS_CLIENT_CERT_FILE"
+	EnvClientKeyFile      = "TRUENAS_CLIENT_KEY_FILE"
+)
+
+// missingValueDetail describes where a required value was expected from.
+func missingValueDetail(attr, envVar string) string {
+	return fmt.Sprintf("Set %s in the provider configuration or the %s environment variable.", attr, envVar)
+}
+
+// applyEnvironment fills unset provider attributes from environment variables.
+// Blocks that are absent from configuration are created when any of their
+// attributes is supplied through the environment, so a provider block can be
+// left completely empty in CI.
+func applyEnvironment(config *TrueNASProviderModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	envString(&config.Host, EnvHost)
+	envString(&config.AuthMethod, EnvAuthMethod)
+	diags.Append(envInt64(&config.RateLimit, EnvRateLimit, path.Root("rate_limit"))...)
+	diags.Append(envInt64(&config.MaxRetries, EnvMaxRetries, path.Root("max_retries"))...)
+	diags.Append(envBool(&config.ReadOnly, EnvReadOnly, path.Root("read_only"))...)
+	diags.Append(envBool(&config.SkipReferenceChecks, EnvSkipReferenceChecks, path.Root("skip_reference_checks"))...)
+
+	if config.SSH == nil && anyEnvSet(EnvSSHPort, EnvSSHUser, EnvSSHPrivateKey, EnvSSHPrivateKeyFile, EnvSSHUseAgent,
+		EnvSSHHostKeyFingerprint, EnvSSHKnownHostsFile, EnvSSHMaxSessions, EnvSSHSudo, EnvSSHSudoPassword) {
+		config.SSH = &SSHBlockModel{
+			Port:                types.Int64Null(),
+			User:                types.StringNull(),
+			PrivateKey:          types.StringNull(),
+			PrivateKeyFile:      types.StringNull(),
+			UseAgent:            types.BoolNull(),
+			HostKeyFingerprint:  types.StringNull(),
+			HostKeyFingerprints: types.ListNull(types.StringType),
+			KnownHostsFile:      types.StringNull(),
+			MaxSessions:         types.Int64Null(),
+			Sudo:                types.BoolNull(),
+			SudoPassword:        types.StringNull(),
+		}
+	}
+	if config.SSH != nil {
+		sshPath := path.Root("ssh")
+		diags.Append(envInt64(&config.SSH.Port, EnvSSHPort, sshPath.AtName("port"))...)
+		envString(&config.SSH.User, EnvSSHUser)
+		// A key configured inline or as a file is not joined by the other
+		// form from the environment, which would make them conflict.
+		privateKeyConfigured := !config.SSH.PrivateKey.IsNull()
+		if config.SSH.PrivateKeyFile.IsNull() {
+			envString(&config.SSH.PrivateKey, EnvSSHPrivateKey)
+		}
+		if !privateKeyConfigured {
+			envString(&config.SSH.PrivateKeyFile, EnvSSHPrivateKeyFile)
+		}
+		diags.Append(envBool(&config.SSH.UseAgent, EnvSSHUseAgent, sshPath.AtName("use_agent"))...)
+		envString(&config.SSH.HostKeyFingerprint, EnvSSHHostKeyFingerprint)
+		envString(&config.SSH.KnownHostsFile, EnvSSHKnownHostsFile)
+		diags.Append(envInt64(&config.SSH.MaxSessions, EnvSSHMaxSessions, sshPath.AtName("max_sessions"))...)
+		diags.Append(envBool(&config.SSH.Sudo, EnvSSHSudo, sshPath.AtName("sudo"))...)
+		envString(&config.SSH.SudoPassword, EnvSSHSudoPassword)
+	}
+
+	if config.WebSocket == nil && anyEnvSet(EnvUsername, EnvAPIKey, EnvPassword, EnvOTPSecret, EnvWebSocketPort, EnvInsecureSkipVerify,
+		EnvCACertFile, EnvTLSFingerprint, EnvClientCertFile, EnvClientKeyFile) {
+		config.WebSocket = &WebSocketBlockModel{
+			Username:           types.StringNull(),
+			APIKey:             types.StringNull(),
+			Password:           types.StringNull(),
+			OTPSecret:          types.StringNull(),
+			Port:               types.Int64Null(),
+			InsecureSkipVerify: types.BoolNull(),
+			CACertPEM:          types.StringNull(),
+			CACertFile:         types.StringNull(),
+			TLSFingerprint:     types.StringNull(),
+			ClientCertPEM:      types.StringNull(),
+			ClientCertFile:     types.StringNull(),
+			ClientKeyPEM:       types.StringNull(),
+			ClientKeyFile:      types.StringNull(),
+			MaxConcurrent:      types.Int64Null(),
+			ConnectTimeout:     types.Int64Null(),
+			MaxRetries:         types.Int64Null(),
+		}
+	}
+	if config.WebSocket != nil {
+		wsPath := path.Root("websocket")
+		envString(&config.WebSocket.Username, EnvUsername)
+		// A credential set in configuration is not joined by the other
+		// kind from the environment, which would make them conflict.
+		apiKeyConfigured := !config.WebSocket.APIKey.IsNull()
+		if config.WebSocket.Password.IsNull() {
+			envString(&config.WebSocket.APIKey, EnvAPIKey)
+		}
+		if !apiKeyConfigured {
+			envString(&config.WebSocket.Password, EnvPassword)
+			envString(&config.WebSocket.OTPSecret, EnvOTPSecret)
+		}
+		diags.Append(envInt64(&config.WebSocket.Port, EnvWebSocketPort, wsPath.AtName("port"))...)
+		diags.Append(envBool(&config.WebSocket.InsecureSkipVerify, EnvInsecureSkipVerify, wsPath.AtName("insecure_skip_verify"))...)
+		// Files from the environment only fill in when the inline PEM
+		// alternative is not configured.
+		if config.WebSocket.CACertPEM.IsNull() {
+			envString(&config.WebSocket.CACertFile, EnvCACertFile)
+		}
+		envString(&config.WebSocket.TLSFingerprint, EnvTLSFingerprint)
+		if config.WebSocket.ClientCertPEM.IsNull() {
+			envString(&config.WebSocket.ClientCertFile, EnvClientCertFile)
+		}
+		if config.WebSocket.ClientKeyPEM.IsNull() {
+			envString(&config.WebSocket.ClientKeyFile, EnvClientKeyFile)
+		}
+	}
+
+	return diags
+}
+
+// anyEnvSet reports whether at least one of the named variables is non-empty.
+func anyEnvSet(names ...string) bool {
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// envString sets v from the environment when v is null.
+func envString(v *types.String, name string) {
+	if !v.IsNull() {
+		return
+	}
+	if s := os.Getenv(name); s != "" {
+		*v = types.StringValue(s)
+	}
+}
+
+// envInt64 sets v from the environment when v is null.
+func envInt64(v *types.Int64, name string, attrPath path.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !v.IsNull() {
+		return diags
+	}
+	s := os.Getenv(name)
+	if s == "" {
+		return diags
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		diags.AddAttributeError(
+			attrPath,
+			"Invalid Environment Variable",
+			fmt.Sprintf("%s must be an integer, got %q.", name, s),
+		)
+		return diags
+	}
+	*v = types.Int64Value(n)
+	return diags
+}
+
+// envBool sets v from the environment when v is null.
+func envBool(v *types.Bool, name string, attrPath path.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !v.IsNull() {
+		return diags
+	}
+	s := os.Getenv(name)
+	if s == "" {
+		return diags
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		diags.AddAttributeError(
+			attrPath,
+			"Invalid Environment Variable",
+			fmt.Sprintf("%s must be a boolean, got %q.", name, s),
+		)
+		return diags
+	}
+	*v = types.BoolValue(b)
+	return diags
+}
@@ -0,0 +1,299 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// createEmptyConfigureRequest creates a provider.ConfigureRequest with every
+// attribute and block null, as for an empty `provider "truenas" {}` block.
+func createEmptyConfigureRequest(t *testing.T) provider.ConfigureRequest {
+	t.Helper()
+
+	p := New("1.0.0")()
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(context.Background(), provider.SchemaRequest{}, schemaResp)
+
+	objType, ok := schemaResp.Schema.Type().TerraformType(context.Background()).(tftypes.Object)
+	if !ok {
+		t.Fatal("expected provider schema to be an object type")
+	}
+
+	values := make(map[string]tftypes.Value, len(objType.AttributeTypes))
+	for name, typ := range objType.AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+
+	return provider.ConfigureRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(objType, values),
+		},
+	}
+}
+
+func TestApplyEnvironment_FillsNullValues(t *testing.T) {
+	t.Setenv(EnvHost, "nas.example.com")
+	t.Setenv(EnvAuthMethod, "websocket")
+	t.Setenv(EnvRateLimit, "120")
+	t.Setenv(EnvSSHPrivateKey, testPrivateKey)
+	t.Setenv(EnvSSHHostKeyFingerprint, testHostKeyFingerprint)
+	t.Setenv(EnvSSHPort, "2222")
+	t.Setenv(EnvUsername, "terraform")
+	t.Setenv(EnvAPIKey, "1-secret")
+	t.Setenv(EnvInsecureSkipVerify, "true")
+
+	config := TrueNASProviderModel{
+		Host:       types.StringNull(),
+		AuthMethod: types.StringNull(),
+		RateLimit:  types.Int64Null(),
+		MaxRetries: types.Int64Null(),
+	}
+
+	diags := applyEnvironment(&config)
+	if diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+
+	if config.Host.ValueString() != "nas.example.com" {
+		t.Errorf("expected host from environment, got %q", config.Host.ValueString())
+	}
+	if config.AuthMethod.ValueString() != "websocket" {
+		t.Errorf("expected auth_method from environment, got %q", config.AuthMethod.ValueString())
+	}
+	if config.RateLimit.ValueInt64() != 120 {
+		t.Errorf("expected rate_limit 120, got %d", config.RateLimit.ValueInt64())
+	}
+	if !config.MaxRetries.IsNull() {
+		t.Errorf("expected max_retries to stay null, got %v", config.MaxRetries)
+	}
+	if config.SSH == nil {
+		t.Fatal("expected ssh block to be created from environment")
+	}
+	if config.SSH.PrivateKey.ValueString() != testPrivateKey {
+		t.Error("expected ssh.private_key from environment")
+	}
+	if config.SSH.HostKeyFingerprint.ValueString() != testHostKeyFingerprint {
+		t.Error("expected ssh.host_key_fingerprint from environment")
+	}
+	if config.SSH.Port.ValueInt64() != 2222 {
+		t.Errorf("expected ssh.port 2222, got %d", config.SSH.Port.ValueInt64())
+	}
+	if config.WebSocket == nil {
+		t.Fatal("expected websocket block to be created from environment")
+	}
+	if config.WebSocket.Username.ValueString() != "terraform" {
+		t.Errorf("expected websocket.username from environment, got %q", config.WebSocket.Username.ValueString())
+	}
+	if config.WebSocket.APIKey.ValueString() != "1-secret" {
+		t.Error("expected websocket.api_key from environment")
+	}
+	if !config.WebSocket.InsecureSkipVerify.ValueBool() {
+		t.Error("expected websocket.insecure_skip_verify from environment")
+	}
+}
+
+func TestApplyEnvironment_ConfigTakesPrecedence(t *testing.T) {
+	t.Setenv(EnvHost, "from-env")
+	t.Setenv(EnvSSHUser, "env-user")
+
+	config := TrueNASProviderModel{
+		Host:       types.StringValue("from-config"),
+		AuthMethod: types.StringNull(),
+		RateLimit:  types.Int64Null(),
+		MaxRetries: types.Int64Null(),
+		SSH: &SSHBlockModel{
+			Port:               types.Int64Null(),
+			User:               types.StringValue("config-user"),
+			PrivateKey:         types.StringNull(),
+			HostKeyFingerprint: types.StringNull(),
+			MaxSessions:        types.Int64Null(),
+		},
+	}
+
+	diags := applyEnvironment(&config)
+	if diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+
+	if config.Host.ValueString() != "from-config" {
+		t.Errorf("expected configured host to win, got %q", config.Host.ValueString())
+	}
+	if config.SSH.User.ValueString() != "config-user" {
+		t.Errorf("expected configured ssh.user to win, got %q", config.SSH.User.ValueString())
+	}
+	if config.WebSocket != nil {
+		t.Error("expected websocket block to stay nil without websocket environment variables")
+	}
+}
+
+func TestApplyEnvironment_ConfiguredCredentialExcludesOtherKind(t *testing.T) {
+	t.Setenv(EnvAPIKey, "1-from-env")
+	t.Setenv(EnvPassword, "from-env")
+	t.Setenv(EnvOTPSecret, "JBSWY3DPEHPK3PXP")
+
+	config := TrueNASProviderModel{
+		Host:       types.StringNull(),
+		AuthMethod: types.StringNull(),
+		RateLimit:  types.Int64Null(),
+		MaxRetries: types.Int64Null(),
+		WebSocket: &WebSocketBlockModel{
+			Username:  types.StringValue("breakglass"),
+			APIKey:    types.StringNull(),
+			Password:  types.StringValue("from-config"),
+			OTPSecret: types.StringNull(),
+		},
+	}
+
+	diags := applyEnvironment(&config)
+	if diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+
+	if !config.WebSocket.APIKey.IsNull() {
+		t.Errorf("expected api_key to stay null next to a configured password, got %q", config.WebSocket.APIKey.ValueString())
+	}
+	if config.WebSocket.Password.ValueString() != "from-config" {
+		t.Errorf("expected configured password to win, got %q", config.WebSocket.Password.ValueString())
+	}
+	if config.WebSocket.OTPSecret.ValueString() != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("expected otp_secret from environment, got %q", config.WebSocket.OTPSecret.ValueString())
+	}
+}
+
+func TestApplyEnvironment_InvalidInteger(t *testing.T) {
+	t.Setenv(EnvSSHPort, "twenty-two")
+
+	config := TrueNASProviderModel{
+		Host:       types.StringNull(),
+		AuthMethod: types.StringNull(),
+		RateLimit:  types.Int64Null(),
+		MaxRetries: types.Int64Null(),
+	}
+
+	diags := applyEnvironment(&config)
+	if !diags.HasError() {
+		t.Fatal("expected error for non-integer TRUENAS_SSH_PORT")
+	}
+	if !strings.Contains(diags[0].Detail(), EnvSSHPort) {
+		t.Errorf("expected detail to name %s, got %q", EnvSSHPort, diags[0].Detail())
+	}
+}
+
+func TestApplyEnvironment_InvalidBool(t *testing.T) {
+	t.Setenv(EnvInsecureSkipVerify, "maybe")
+
+	config := TrueNASProviderModel{
+		Host:       types.StringNull(),
+		AuthMethod: types.StringNull(),
+		RateLimit:  types.Int64Null(),
+		MaxRetries: types.Int64Null(),
+	}
+
+	diags := applyEnvironment(&config)
+	if !diags.HasError() {
+		t.Fatal("expected error for non-boolean TRUENAS_INSECURE_SKIP_VERIFY")
+	}
+}
+
+func TestProvider_Configure_FromEnvironment(t *testing.T) {
+	t.Setenv(EnvHost, "nas.example.com")
+	t.Setenv(EnvSSHPrivateKey, testPrivateKey)
+	t.Setenv(EnvSSHHostKeyFingerprint, testHostKeyFingerprint)
+	t.Setenv(EnvSSHUser, "terraform")
+
+	var gotConfig *transport.SSHConfig
+	mock := newTestMockClient(truenas.Version{Major: 25, Minor: 4})
+	factory := &capturingClientFactory{
+		mockClientFactory: mockClientFactory{sshClient: mock},
+		onSSH:             func(cfg transport.SSHConfig) { gotConfig = &cfg },
+	}
+
+	p := &TrueNASProvider{version: "1.0.0", factory: factory}
+
+	resp := &provider.ConfigureResponse{}
+	p.Configure(context.Background(), createEmptyConfigureRequest(t), resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+	if gotConfig == nil {
+		t.Fatal("expected SSH client to be created")
+	}
+	if gotConfig.Host != "nas.example.com" {
+		t.Errorf("expected host from environment, got %q", gotConfig.Host)
+	}
+	if gotConfig.User != "terraform" {
+		t.Errorf("expected user from environment, got %q", gotConfig.User)
+	}
+	if gotConfig.PrivateKey != testPrivateKey {
+		t.Error("expected private key from environment")
+	}
+}
+
+func TestProvider_Configure_MissingHost_NamesEnvironmentVariable(t *testing.T) {
+	p := &TrueNASProvider{version: "1.0.0"}
+
+	resp := &provider.ConfigureResponse{}
+	p.Configure(context.Background(), createEmptyConfigureRequest(t), resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for missing host")
+	}
+
+	d := resp.Diagnostics[0]
+	if !strings.Contains(d.Detail(), EnvHost) {
+		t.Errorf("expected detail to mention %s, got %q", EnvHost, d.Detail())
+	}
+	withPath, ok := d.(interface{ Path() path.Path })
+	if !ok || !withPath.Path().Equal(path.Root("host")) {
+		t.Error("expected diagnostic to be attached to the host attribute")
+	}
+}
+
+func TestProvider_Configure_MissingPrivateKey_NamesEnvironmentVariable(t *testing.T) {
+	t.Setenv(EnvHost, "nas.example.com")
+	t.Setenv(EnvSSHHostKeyFingerprint, testHostKeyFingerprint)
+
+	p := &TrueNASProvider{version: "1.0.0"}
+
+	resp := &provider.ConfigureResponse{}
+	p.Configure(context.Background(), createEmptyConfigureRequest(t), resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for missing private key")
+	}
+
+	found := false
+	for _, d := range resp.Diagnostics {
+		if strings.Contains(d.Detail(), EnvSSHPrivateKey) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a diagnostic mentioning %s, got %v", EnvSSHPrivateKey, resp.Diagnostics)
+	}
+}
+
+// capturingClientFactory records the SSH config passed to NewSSHClient.
+type capturingClientFactory struct {
+	mockClientFactory
+	onSSH func(cfg transport.SSHConfig)
+}
+
+func (f *capturingClientFactory) NewSSHClient(cfg transport.SSHConfig) (client.Client, error) {
+	if f.onSSH != nil {
+		f.onSSH(cfg)
+	}
+	return f.mockClientFactory.NewSSHClient(cfg)
+}
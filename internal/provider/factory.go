@@ -1,20 +1,156 @@
 package provider
 
-import "github.com/deevus/truenas-go/client"
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	"github.com/deevus/truenas-go/client"
+)
+
+// Environment variables selecting a cassette-backed client factory, to rerun
+// a recorded session without a server.
+const (
+	// EnvCassette is the path of the cassette file to record to or replay from.
+	EnvCassette = "TRUENAS_CASSETTE"
+	// EnvCassetteMode is "record" or "replay". Recording requires a reachable
+	// TrueNAS server; replay needs the host but no credentials.
+	EnvCassetteMode = "TRUENAS_CASSETTE_MODE"
+)
+
+// Transport labels used in cassettes.
+const (
+	transportSSH       = "ssh"
+	transportWebSocket = "websocket"
+)
 
 // ClientFactory abstracts client creation for testability.
 type ClientFactory interface {
-	NewSSHClient(cfg *client.SSHConfig) (client.Client, error)
-	NewWebSocketClient(cfg client.WebSocketConfig) (client.Client, error)
+	NewSSHClient(cfg transport.SSHConfig) (client.Client, error)
+	NewWebSocketClient(cfg transport.WebSocketConfig) (client.Client, error)
 }
 
 // DefaultClientFactory creates real clients for production use.
 type DefaultClientFactory struct{}
 
-func (f *DefaultClientFactory) NewSSHClient(cfg *client.SSHConfig) (client.Client, error) {
-	return client.NewSSHClient(cfg, client.WithLogger(TFLogAdapter{}))
+func (f *DefaultClientFactory) NewSSHClient(cfg transport.SSHConfig) (client.Client, error) {
+	cfg.Logger = TFLogAdapter{}
+	c, err := transport.NewSSHClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (f *DefaultClientFactory) NewWebSocketClient(cfg transport.WebSocketConfig) (client.Client, error) {
+	c, err := transport.NewWebSocketClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// RecordingClientFactory wraps the clients of another factory so that every
+// operation is appended to a cassette file. Its clients share one writer.
+type RecordingClientFactory struct {
+	Inner ClientFactory
+	Path  string
+
+	once   sync.Once
+	writer *transport.CassetteWriter
+}
+
+func (f *RecordingClientFactory) cassetteWriter() *transport.CassetteWriter {
+	f.once.Do(func() { f.writer = transport.NewCassetteWriter(f.Path) })
+	return f.writer
+}
+
+func (f *RecordingClientFactory) NewSSHClient(cfg transport.SSHConfig) (client.Client, error) {
+	c, err := f.Inner.NewSSHClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return transport.NewRecorder(c, transportSSH, f.cassetteWriter()), nil
+}
+
+func (f *RecordingClientFactory) NewWebSocketClient(cfg transport.WebSocketConfig) (client.Client, error) {
+	c, err := f.Inner.NewWebSocketClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return transport.NewRecorder(c, transportWebSocket, f.cassetteWriter()), nil
 }
 
-func (f *DefaultClientFactory) NewWebSocketClient(cfg client.WebSocketConfig) (client.Client, error) {
-	return client.NewWebSocketClient(cfg)
+// ReplayClientFactory creates clients that answer from a recorded cassette
+// instead of contacting a server. Connection settings are ignored. Its
+// clients share the position in the cassette, so a provider configured
+// again for each Terraform command continues where the last one stopped.
+type ReplayClientFactory struct {
+	Path string
+
+	mu    sync.Mutex
+	state *transport.ReplayState
+}
+
+// replayState loads the cassette on first use.
+func (f *ReplayClientFactory) replayState() (*transport.ReplayState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.state == nil {
+		state, err := transport.LoadReplayState(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		f.state = state
+	}
+	return f.state, nil
+}
+
+func (f *ReplayClientFactory) NewSSHClient(cfg transport.SSHConfig) (client.Client, error) {
+	state, err := f.replayState()
+	if err != nil {
+		return nil, err
+	}
+	return transport.NewReplayer(transportSSH, state), nil
+}
+
+func (f *ReplayClientFactory) NewWebSocketClient(cfg transport.WebSocketConfig) (client.Client, error) {
+	state, err := f.replayState()
+	if err != nil {
+		return nil, err
+	}
+	return transport.NewReplayer(transportWebSocket, state), nil
+}
+
+// newClient returns the client of authMethod, replaying the interactions
+// recorded for its transport.
+func (f *ReplayClientFactory) newClient(authMethod string) (client.Client, error) {
+	if authMethod == "websocket" {
+		return f.NewWebSocketClient(transport.WebSocketConfig{})
+	}
+	return f.NewSSHClient(transport.SSHConfig{})
+}
+
+// clientFactoryFromEnv returns the factory selected by TRUENAS_CASSETTE_MODE,
+// or DefaultClientFactory when no cassette mode is set.
+func clientFactoryFromEnv() (ClientFactory, error) {
+	mode := os.Getenv(EnvCassetteMode)
+	if mode == "" {
+		return &DefaultClientFactory{}, nil
+	}
+
+	path := os.Getenv(EnvCassette)
+	if path == "" {
+		return nil, fmt.Errorf("%s is set to %q but %s is empty", EnvCassetteMode, mode, EnvCassette)
+	}
+
+	switch mode {
+	case "record":
+		return &RecordingClientFactory{Inner: &DefaultClientFactory{}, Path: path}, nil
+	case "replay":
+		return &ReplayClientFactory{Path: path}, nil
+	default:
+		return nil, fmt.Errorf("%s must be 'record' or 'replay', got %q", EnvCassetteMode, mode)
+	}
 }
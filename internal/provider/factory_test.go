@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+)
+
+func TestClientFactoryFromEnv_Default(t *testing.T) {
+	t.Setenv(EnvCassetteMode, "")
+
+	f, err := clientFactoryFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := f.(*DefaultClientFactory); !ok {
+		t.Errorf("expected *DefaultClientFactory, got %T", f)
+	}
+}
+
+func TestClientFactoryFromEnv_Record(t *testing.T) {
+	t.Setenv(EnvCassetteMode, "record")
+	t.Setenv(EnvCassette, "/tmp/cassette.jsonl")
+
+	f, err := clientFactoryFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rf, ok := f.(*RecordingClientFactory)
+	if !ok {
+		t.Fatalf("expected *RecordingClientFactory, got %T", f)
+	}
+	if rf.Path != "/tmp/cassette.jsonl" {
+		t.Errorf("unexpected path %q", rf.Path)
+	}
+	if _, ok := rf.Inner.(*DefaultClientFactory); !ok {
+		t.Errorf("expected recording to wrap *DefaultClientFactory, got %T", rf.Inner)
+	}
+}
+
+func TestClientFactoryFromEnv_Replay(t *testing.T) {
+	t.Setenv(EnvCassetteMode, "replay")
+	t.Setenv(EnvCassette, "/tmp/cassette.jsonl")
+
+	f, err := clientFactoryFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := f.(*ReplayClientFactory); !ok {
+		t.Errorf("expected *ReplayClientFactory, got %T", f)
+	}
+}
+
+func TestClientFactoryFromEnv_MissingPath(t *testing.T) {
+	t.Setenv(EnvCassetteMode, "replay")
+	t.Setenv(EnvCassette, "")
+
+	if _, err := clientFactoryFromEnv(); err == nil {
+		t.Fatal("expected error when cassette path is missing")
+	}
+}
+
+func TestClientFactoryFromEnv_InvalidMode(t *testing.T) {
+	t.Setenv(EnvCassetteMode, "rewind")
+	t.Setenv(EnvCassette, "/tmp/cassette.jsonl")
+
+	if _, err := clientFactoryFromEnv(); err == nil {
+		t.Fatal("expected error for invalid mode")
+	}
+}
+
+func TestRecordingClientFactory_WrapsClients(t *testing.T) {
+	mock := newTestMockClient(truenas.Version{Major: 25, Minor: 4, Raw: "TrueNAS-25.04.0"})
+	f := &RecordingClientFactory{
+		Inner: &mockClientFactory{sshClient: mock, wsClient: mock},
+		Path:  filepath.Join(t.TempDir(), "cassette.jsonl"),
+	}
+
+	ssh, err := f.NewSSHClient(transport.SSHConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := ssh.(*transport.Recorder); !ok {
+		t.Errorf("expected *transport.Recorder, got %T", ssh)
+	}
+}
+
+func TestProvider_Configure_ReplaysCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	if err := os.WriteFile(path, []byte(`{"transport":"ssh","operation":"connect","result":"TrueNAS-25.04.2"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvCassetteMode, "replay")
+	t.Setenv(EnvCassette, path)
+	// No SSH key or host key fingerprint is needed to replay
+	t.Setenv(EnvHost, "nas.example.com")
+
+	p := &TrueNASProvider{version: "1.0.0"}
+
+	resp := &provider.ConfigureResponse{}
+	p.Configure(context.Background(), createEmptyConfigureRequest(t), resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+	if resp.ResourceData == nil {
+		t.Fatal("expected resource data to be set")
+	}
+}
+
+func TestProvider_Configure_ReplaysWebSocketCassetteWithoutCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	if err := os.WriteFile(path, []byte(`{"transport":"websocket","operation":"connect","result":"TrueNAS-25.04.2"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(EnvCassetteMode, "replay")
+	t.Setenv(EnvCassette, path)
+	t.Setenv(EnvHost, "nas.example.com")
+	t.Setenv(EnvAuthMethod, "websocket")
+
+	p := &TrueNASProvider{version: "1.0.0"}
+
+	resp := &provider.ConfigureResponse{}
+	p.Configure(context.Background(), createEmptyConfigureRequest(t), resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+	if resp.ResourceData == nil {
+		t.Fatal("expected resource data to be set")
+	}
+}
+
+func TestProvider_Configure_InvalidCassetteMode(t *testing.T) {
+	t.Setenv(EnvCassetteMode, "rewind")
+	t.Setenv(EnvCassette, "/tmp/cassette.jsonl")
+	t.Setenv(EnvHost, "nas.example.com")
+
+	p := &TrueNASProvider{version: "1.0.0"}
+
+	resp := &provider.ConfigureResponse{}
+	p.Configure(context.Background(), createEmptyConfigureRequest(t), resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for invalid cassette mode")
+	}
+}
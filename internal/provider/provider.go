@@ -8,16 +8,30 @@ import (
 	truenas "github.com/deevus/truenas-go"
 	"github.com/deevus/truenas-go/client"
 	"github.com/deevus/terraform-provider-truenas/internal/datasources"
+	"github.com/deevus/terraform-provider-truenas/internal/ephemeralresources"
+	"github.com/deevus/terraform-provider-truenas/internal/functions"
 	"github.com/deevus/terraform-provider-truenas/internal/resources"
 	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var _ provider.Provider = &TrueNASProvider{}
+var _ provider.ProviderWithFunctions = &TrueNASProvider{}
+var _ provider.ProviderWithEphemeralResources = &TrueNASProvider{}
+var _ provider.ProviderWithListResources = &TrueNASProvider{}
 
 // TrueNASProviderModel describes the provider data model.
 type TrueNASProviderModel struct {
@@ -27,23 +41,52 @@ type TrueNASProviderModel struct {
 	WebSocket  *WebSocketBlockModel `tfsdk:"websocket"`
 	RateLimit  types.Int64          `tfsdk:"rate_limit"`
 	MaxRetries types.Int64          `tfsdk:"max_retries"`
+	ReadOnly   types.Bool           `tfsdk:"read_only"`
+
+	RateLimitWeights    types.Map  `tfsdk:"rate_limit_weights"`
+	SkipReferenceChecks types.Bool `tfsdk:"skip_reference_checks"`
 }
 
 // SSHBlockModel describes the SSH configuration block.
 type SSHBlockModel struct {
+	Port                types.Int64      `tfsdk:"port"`
+	User                types.String     `tfsdk:"user"`
+	PrivateKey          types.String     `tfsdk:"private_key"`
+	PrivateKeyFile      types.String     `tfsdk:"private_key_file"`
+	UseAgent            types.Bool       `tfsdk:"use_agent"`
+	HostKeyFingerprint  types.String     `tfsdk:"host_key_fingerprint"`
+	HostKeyFingerprints types.List       `tfsdk:"host_key_fingerprints"`
+	KnownHostsFile      types.String     `tfsdk:"known_hosts_file"`
+	MaxSessions         types.Int64      `tfsdk:"max_sessions"`
+	Sudo                types.Bool       `tfsdk:"sudo"`
+	SudoPassword        types.String     `tfsdk:"sudo_password"`
+	Bastion             *SSHBastionModel `tfsdk:"bastion"`
+}
+
+// SSHBastionModel describes the jump host of the SSH configuration block.
+type SSHBastionModel struct {
+	Host               types.String `tfsdk:"host"`
 	Port               types.Int64  `tfsdk:"port"`
 	User               types.String `tfsdk:"user"`
 	PrivateKey         types.String `tfsdk:"private_key"`
 	HostKeyFingerprint types.String `tfsdk:"host_key_fingerprint"`
-	MaxSessions        types.Int64  `tfsdk:"max_sessions"`
 }
 
 // WebSocketBlockModel describes the WebSocket configuration block.
 type WebSocketBlockModel struct {
 	Username           types.String `tfsdk:"username"`
 	APIKey             types.String `tfsdk:"api_key"`
+	Password           types.String `tfsdk:"password"`
+	OTPSecret          types.String `tfsdk:"otp_secret"`
 	Port               types.Int64  `tfsdk:"port"`
 	InsecureSkipVerify types.Bool   `tfsdk:"insecure_skip_verify"`
+	CACertPEM          types.String `tfsdk:"ca_cert_pem"`
+	CACertFile         types.String `tfsdk:"ca_cert_file"`
+	TLSFingerprint     types.String `tfsdk:"tls_fingerprint"`
+	ClientCertPEM      types.String `tfsdk:"client_cert_pem"`
+	ClientCertFile     types.String `tfsdk:"client_cert_file"`
+	ClientKeyPEM       types.String `tfsdk:"client_key_pem"`
+	ClientKeyFile      types.String `tfsdk:"client_key_file"`
 	MaxConcurrent      types.Int64  `tfsdk:"max_concurrent"`
 	ConnectTimeout     types.Int64  `tfsdk:"connect_timeout"`
 	MaxRetries         types.Int64  `tfsdk:"max_retries"`
@@ -72,21 +115,49 @@ func (p *TrueNASProvider) Schema(ctx context.Context, req provider.SchemaRequest
 		Description: "Terraform provider for TrueNAS SCALE and Community Edition.",
 		Attributes: map[string]schema.Attribute{
 			"host": schema.StringAttribute{
-				Description: "TrueNAS server hostname or IP address.",
-				Required:    true,
+				Description: "TrueNAS server hostname or IP address. " +
+					"Can also be set with the TRUENAS_HOST environment variable.",
+				Optional: true,
 			},
 			"auth_method": schema.StringAttribute{
-				Description: "Authentication method: 'ssh' or 'websocket'. WebSocket requires both websocket and ssh blocks (ssh is used for fallback operations).",
-				Required:    true,
+				Description: "Authentication method: 'ssh' or 'websocket'. Defaults to 'ssh'. " +
+					"WebSocket requires the websocket block; the ssh block is optional and, when present, " +
+					"is used for operations that need a shell (deleting files and directories). " +
+					"Can also be set with the TRUENAS_AUTH_METHOD environment variable.",
+				Optional: true,
 			},
 			"rate_limit": schema.Int64Attribute{
-				Description: "Maximum API calls per minute. Default: 300 (5 per second). " +
-					"Set to 0 to disable rate limiting.",
+				Description: "Maximum API calls per minute, for both transports. Default: 300 (5 per second). " +
+					"Set to 0 to disable rate limiting. " +
+					"Can also be set with the TRUENAS_RATE_LIMIT environment variable.",
 				Optional: true,
 			},
+			"rate_limit_weights": schema.MapAttribute{
+				Description: "Number of calls a call to an API method counts as against rate_limit, keyed by method, " +
+					"for expensive methods such as pool.dataset.query. Methods not listed count as one call.",
+				ElementType: types.Int64Type,
+				Optional:    true,
+				Validators: []validator.Map{
+					mapvalidator.ValueInt64sAre(int64validator.AtLeast(1)),
+				},
+			},
 			"max_retries": schema.Int64Attribute{
-				Description: "Maximum retry attempts for transient connection errors. Default: 3. " +
-					"Set to 0 to disable retries.",
+				Description: "Maximum retry attempts for transient errors, such as connection resets and busy datasets. Default: 3. " +
+					"Set to 0 to disable retries; the WebSocket transport still retries once. The websocket block's max_retries takes precedence for the WebSocket transport. " +
+					"Can also be set with the TRUENAS_MAX_RETRIES environment variable.",
+				Optional: true,
+			},
+			"read_only": schema.BoolAttribute{
+				Description: "Reject every API call that could change the NAS, for audit pipelines. " +
+					"Plans, refreshes, data sources and imports keep working; applying a change fails, and ephemeral resources, " +
+					"which create credentials on the NAS, are unavailable. Defaults to false. " +
+					"Can also be set with the TRUENAS_READ_ONLY environment variable.",
+				Optional: true,
+			},
+			"skip_reference_checks": schema.BoolAttribute{
+				Description: "Skip the plan-time lookups that check that the pools, parent datasets, cloud sync credentials " +
+					"and virt storage pools resources refer to exist on the NAS. Mistakes then only fail during apply. Defaults to false. " +
+					"Can also be set with the TRUENAS_SKIP_REFERENCE_CHECKS environment variable.",
 				Optional: true,
 			},
 		},
@@ -95,50 +166,178 @@ func (p *TrueNASProvider) Schema(ctx context.Context, req provider.SchemaRequest
 				Description: "SSH connection configuration.",
 				Attributes: map[string]schema.Attribute{
 					"port": schema.Int64Attribute{
-						Description: "SSH port. Defaults to 22.",
-						Optional:    true,
+						Description: "SSH port. Defaults to 22. " +
+							"Can also be set with the TRUENAS_SSH_PORT environment variable.",
+						Optional: true,
 					},
 					"user": schema.StringAttribute{
-						Description: "SSH username. Defaults to 'root'.",
-						Optional:    true,
+						Description: "SSH username. Defaults to 'root'. " +
+							"Can also be set with the TRUENAS_SSH_USER environment variable.",
+						Optional: true,
 					},
 					"private_key": schema.StringAttribute{
-						Description: "SSH private key content.",
-						Required:    true,
-						Sensitive:   true,
+						Description: "SSH private key content. Conflicts with private_key_file. " +
+							"Can also be set with the TRUENAS_SSH_PRIVATE_KEY environment variable.",
+						Optional:  true,
+						Sensitive: true,
+					},
+					"private_key_file": schema.StringAttribute{
+						Description: "Path to the SSH private key. Conflicts with private_key. " +
+							"Can also be set with the TRUENAS_SSH_PRIVATE_KEY_FILE environment variable.",
+						Optional: true,
+					},
+					"use_agent": schema.BoolAttribute{
+						Description: "Authenticate with the keys of the ssh-agent at SSH_AUTH_SOCK, such as hardware keys. " +
+							"A configured private key is offered first. Defaults to false. " +
+							"Can also be set with the TRUENAS_SSH_USE_AGENT environment variable.",
+						Optional: true,
 					},
 					"host_key_fingerprint": schema.StringAttribute{
 						Description: "SHA256 fingerprint of the TrueNAS server's SSH host key. " +
-							"Get it with: ssh-keyscan <host> 2>/dev/null | ssh-keygen -lf -",
-						Required:  true,
+							"Get it with: ssh-keyscan <host> 2>/dev/null | ssh-keygen -lf - " +
+							"Can also be set with the TRUENAS_SSH_HOST_KEY_FINGERPRINT environment variable.",
+						Optional:  true,
 						Sensitive: false,
 					},
+					"host_key_fingerprints": schema.ListAttribute{
+						Description: "Additional accepted SHA256 host key fingerprints. " +
+							"List the old and the new key while rotating host keys.",
+						ElementType: types.StringType,
+						Optional:    true,
+					},
+					"known_hosts_file": schema.StringAttribute{
+						Description: "Path to an OpenSSH known_hosts file. Host keys it lists are accepted in addition " +
+							"to the configured fingerprints, for both the TrueNAS server and the bastion. " +
+							"One of host_key_fingerprint, host_key_fingerprints and known_hosts_file is required. " +
+							"Can also be set with the TRUENAS_SSH_KNOWN_HOSTS_FILE environment variable.",
+						Optional: true,
+					},
 					"max_sessions": schema.Int64Attribute{
 						Description: "Maximum concurrent SSH sessions. Defaults to 5. " +
-							"Increase for large deployments, decrease if you see connection errors.",
+							"Increase for large deployments, decrease if you see connection errors. " +
+							"Can also be set with the TRUENAS_SSH_MAX_SESSIONS environment variable.",
 						Optional: true,
 					},
+					"sudo": schema.BoolAttribute{
+						Description: "Run midclt and file operations with sudo, so that root login can stay disabled. " +
+							"Defaults to true when user is not root or sudo_password is set. Without sudo_password, the user needs a NOPASSWD sudo rule. " +
+							"Can also be set with the TRUENAS_SSH_SUDO environment variable.",
+						Optional: true,
+					},
+					"sudo_password": schema.StringAttribute{
+						Description: "Password sudo asks for. Requires sudo. " +
+							"Can also be set with the TRUENAS_SSH_SUDO_PASSWORD environment variable.",
+						Optional:  true,
+						Sensitive: true,
+					},
+				},
+				Blocks: map[string]schema.Block{
+					"bastion": schema.SingleNestedBlock{
+						Description: "Jump host the SSH connection is tunnelled through, like ssh -J.",
+						Attributes: map[string]schema.Attribute{
+							"host": schema.StringAttribute{
+								Description: "Bastion hostname or IP address.",
+								Optional:    true,
+							},
+							"port": schema.Int64Attribute{
+								Description: "Bastion SSH port. Defaults to 22.",
+								Optional:    true,
+							},
+							"user": schema.StringAttribute{
+								Description: "Bastion username. Defaults to ssh.user.",
+								Optional:    true,
+							},
+							"private_key": schema.StringAttribute{
+								Description: "Private key for the bastion. Defaults to the key of the TrueNAS connection. " +
+									"The ssh-agent is offered as well when use_agent is set.",
+								Optional:  true,
+								Sensitive: true,
+							},
+							"host_key_fingerprint": schema.StringAttribute{
+								Description: "SHA256 fingerprint of the bastion's SSH host key. " +
+									"Required unless known_hosts_file lists the bastion.",
+								Optional: true,
+							},
+						},
+					},
 				},
 			},
 			"websocket": schema.SingleNestedBlock{
 				Description: "WebSocket connection configuration. Required when auth_method is 'websocket'.",
 				Attributes: map[string]schema.Attribute{
 					"username": schema.StringAttribute{
-						Description: "TrueNAS username associated with the API key. Usually 'root'.",
-						Optional:    true,
+						Description: "TrueNAS username to authenticate as, the owner of the API key or password. Usually 'root'. " +
+							"Can also be set with the TRUENAS_USERNAME environment variable.",
+						Optional: true,
 					},
 					"api_key": schema.StringAttribute{
-						Description: "TrueNAS API key for authentication.",
-						Optional:    true,
-						Sensitive:   true,
+						Description: "TrueNAS API key for authentication. Exactly one of api_key and password is required. " +
+							"Can also be set with the TRUENAS_API_KEY environment variable.",
+						Optional:  true,
+						Sensitive: true,
+					},
+					"password": schema.StringAttribute{
+						Description: "Password of the user, for accounts without API keys such as a local break-glass account. " +
+							"Exactly one of api_key and password is required. " +
+							"Can also be set with the TRUENAS_PASSWORD environment variable.",
+						Optional:  true,
+						Sensitive: true,
+					},
+					"otp_secret": schema.StringAttribute{
+						Description: "Base32 TOTP secret of the user, used to generate the one-time password when " +
+							"two-factor authentication is enabled. Requires password. " +
+							"Can also be set with the TRUENAS_OTP_SECRET environment variable.",
+						Optional:  true,
+						Sensitive: true,
 					},
 					"port": schema.Int64Attribute{
-						Description: "WebSocket port. Defaults to 443.",
-						Optional:    true,
+						Description: "WebSocket port. Defaults to 443. " +
+							"Can also be set with the TRUENAS_WEBSOCKET_PORT environment variable.",
+						Optional: true,
 					},
 					"insecure_skip_verify": schema.BoolAttribute{
-						Description: "Skip TLS certificate verification. Defaults to false.",
+						Description: "Skip TLS certificate verification. Defaults to false. " +
+							"Cannot be combined with a CA certificate or tls_fingerprint. " +
+							"Can also be set with the TRUENAS_INSECURE_SKIP_VERIFY environment variable.",
+						Optional: true,
+					},
+					"ca_cert_pem": schema.StringAttribute{
+						Description: "PEM encoded CA certificate, or the NAS's self-signed certificate, trusted " +
+							"instead of the system roots. Conflicts with ca_cert_file.",
+						Optional: true,
+					},
+					"ca_cert_file": schema.StringAttribute{
+						Description: "Path to a PEM encoded CA certificate trusted instead of the system roots. " +
+							"Conflicts with ca_cert_pem. " +
+							"Can also be set with the TRUENAS_CA_CERT_FILE environment variable.",
+						Optional: true,
+					},
+					"tls_fingerprint": schema.StringAttribute{
+						Description: "SHA-256 fingerprint of the server certificate in hex, as printed by " +
+							"`openssl x509 -noout -fingerprint -sha256`. Without a CA certificate, a certificate " +
+							"matching the pin is trusted even if self-signed; with one, both must hold. " +
+							"Can also be set with the TRUENAS_TLS_FINGERPRINT environment variable.",
+						Optional: true,
+					},
+					"client_cert_pem": schema.StringAttribute{
+						Description: "PEM encoded client certificate for mutual TLS, e.g. through a reverse proxy. " +
+							"Requires client_key_pem or client_key_file. Conflicts with client_cert_file.",
+						Optional: true,
+					},
+					"client_cert_file": schema.StringAttribute{
+						Description: "Path to a PEM encoded client certificate for mutual TLS. Conflicts with client_cert_pem. " +
+							"Can also be set with the TRUENAS_CLIENT_CERT_FILE environment variable.",
+						Optional: true,
+					},
+					"client_key_pem": schema.StringAttribute{
+						Description: "PEM encoded private key of the client certificate. Conflicts with client_key_file.",
 						Optional:    true,
+						Sensitive:   true,
+					},
+					"client_key_file": schema.StringAttribute{
+						Description: "Path to the PEM encoded private key of the client certificate. Conflicts with client_key_pem. " +
+							"Can also be set with the TRUENAS_CLIENT_KEY_FILE environment variable.",
+						Optional: true,
 					},
 					"max_concurrent": schema.Int64Attribute{
 						Description: "Maximum concurrent in-flight requests. Defaults to 20.",
@@ -167,100 +366,160 @@ func (p *TrueNASProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
-	// Resolve factory (use default if not set)
-	factory := p.factory
-	if factory == nil {
-		factory = &DefaultClientFactory{}
+	// Fill unset attributes from the environment, then validate the result
+	resp.Diagnostics.Append(applyEnvironment(&config)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	var finalClient client.Client
+	if config.Host.ValueString() == "" {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("host"),
+			"Missing TrueNAS Host",
+			missingValueDetail("host", EnvHost),
+		)
+		return
+	}
 
-	switch config.AuthMethod.ValueString() {
-	case "websocket":
-		// Validate websocket block
-		if config.WebSocket == nil {
+	// Resolve factory (use default, or a cassette factory to record or replay)
+	factory := p.factory
+	if factory == nil {
+		var err error
+		factory, err = clientFactoryFromEnv()
+		if err != nil {
 			resp.Diagnostics.AddError(
-				"Missing WebSocket Configuration",
-				"WebSocket block is required when auth_method is 'websocket'.",
+				"Invalid Cassette Configuration",
+				err.Error(),
 			)
 			return
 		}
+		// Keep it, so that configuring again continues the cassette
+		p.factory = factory
+	}
 
-		// Validate required websocket attributes
-		if config.WebSocket.Username.IsNull() || config.WebSocket.Username.ValueString() == "" {
-			resp.Diagnostics.AddError(
-				"Missing WebSocket Username",
-				"websocket.username is required when auth_method is 'websocket'.",
-			)
-			return
-		}
-		if config.WebSocket.APIKey.IsNull() || config.WebSocket.APIKey.ValueString() == "" {
-			resp.Diagnostics.AddError(
-				"Missing WebSocket API Key",
-				"websocket.api_key is required when auth_method is 'websocket'.",
-			)
+	// Rate limiting applies to both transports
+	rateLimit := transport.DefaultRateLimit
+	if !config.RateLimit.IsNull() {
+		rateLimit = int(config.RateLimit.ValueInt64())
+	}
+	var weights map[string]int
+	if !config.RateLimitWeights.IsNull() {
+		resp.Diagnostics.Append(config.RateLimitWeights.ElementsAs(ctx, &weights, false)...)
+		if resp.Diagnostics.HasError() {
 			return
 		}
+	}
 
-		// Validate SSH block (needed for fallback)
-		if config.SSH == nil {
-			resp.Diagnostics.AddError(
-				"Missing SSH Configuration",
-				"SSH block is required for fallback operations when auth_method is 'websocket'.",
-			)
-			return
-		}
+	var finalClient client.Client
 
-		// Create SSH client for fallback
-		sshConfig := &client.SSHConfig{
-			Host:               config.Host.ValueString(),
-			PrivateKey:         config.SSH.PrivateKey.ValueString(),
-			HostKeyFingerprint: config.SSH.HostKeyFingerprint.ValueString(),
-		}
-		if !config.SSH.Port.IsNull() {
-			sshConfig.Port = int(config.SSH.Port.ValueInt64())
+	replay, replaying := factory.(*ReplayClientFactory)
+	authMethod := config.AuthMethod.ValueString()
+	switch {
+	case replaying && (authMethod == "websocket" || authMethod == "ssh" || authMethod == ""):
+		// The cassette answers every call, so no connection settings or
+		// credentials are needed
+		replayClient, err := replay.newClient(authMethod)
+		if err == nil {
+			err = replayClient.Connect(ctx)
 		}
-		if !config.SSH.User.IsNull() {
-			sshConfig.User = config.SSH.User.ValueString()
-		}
-		if !config.SSH.MaxSessions.IsNull() {
-			sshConfig.MaxSessions = int(config.SSH.MaxSessions.ValueInt64())
-		}
-
-		sshClient, err := factory.NewSSHClient(sshConfig)
 		if err != nil {
 			resp.Diagnostics.AddError(
-				"Unable to Create SSH Client",
+				"Unable to Replay Cassette",
 				err.Error(),
 			)
 			return
 		}
+		finalClient = replayClient
 
-		// Connect SSH client to detect version
-		if err := sshClient.Connect(ctx); err != nil {
+	case authMethod == "websocket":
+		// Validate websocket block
+		if config.WebSocket == nil {
 			resp.Diagnostics.AddError(
-				"Unable to Connect to TrueNAS",
-				err.Error(),
+				"Missing WebSocket Configuration",
+				fmt.Sprintf("WebSocket block is required when auth_method is 'websocket'. "+
+					"Configure a websocket block or set the %s and %s environment variables.", EnvUsername, EnvAPIKey),
 			)
 			return
 		}
 
-		// Validate version for WebSocket mode
-		if !sshClient.Version().AtLeast(25, 0) {
-			resp.Diagnostics.AddError(
-				"WebSocket Transport Requires TrueNAS 25.0+",
-				fmt.Sprintf("Detected version %s. Use auth_method = \"ssh\" instead.",
-					sshClient.Version().Raw),
+		// Validate required websocket attributes
+		if config.WebSocket.Username.ValueString() == "" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("websocket").AtName("username"),
+				"Missing WebSocket Username",
+				"websocket.username is required when auth_method is 'websocket'. "+
+					missingValueDetail("websocket.username", EnvUsername),
 			)
 			return
 		}
+		if !validateWebSocketCredentials(config.WebSocket, &resp.Diagnostics) {
+			return
+		}
+		tlsConfig, ok := websocketTLSConfig(config.WebSocket, &resp.Diagnostics)
+		if !ok {
+			return
+		}
+
+		// The ssh block is optional in WebSocket mode. When present, SSH
+		// detects the version and serves operations that need a shell.
+		// Without it, the provider runs in API-only mode.
+		var fallback client.Client
+		var apiFallback *transport.APIFallbackClient
+		if config.SSH != nil {
+			// Create SSH client for fallback
+			sshConfig, ok := sshTransportConfig(ctx, config.Host.ValueString(), config.SSH, &resp.Diagnostics)
+			if !ok {
+				return
+			}
+
+			sshClient, err := factory.NewSSHClient(sshConfig)
+			if err != nil {
+				resp.Diagnostics.AddError(
+					"Unable to Create SSH Client",
+					err.Error(),
+				)
+				return
+			}
+
+			// Connect SSH client to detect version
+			if err := sshClient.Connect(ctx); err != nil {
+				resp.Diagnostics.AddError(
+					"Unable to Connect to TrueNAS",
+					err.Error(),
+				)
+				return
+			}
+
+			// Validate version for WebSocket mode
+			if !sshClient.Version().AtLeast(25, 0) {
+				resp.Diagnostics.AddError(
+					"WebSocket Transport Requires TrueNAS 25.0+",
+					fmt.Sprintf("Detected version %s. Use auth_method = \"ssh\" instead.",
+						sshClient.Version().Raw),
+				)
+				return
+			}
+
+			fallback = sshClient
+		} else {
+			apiFallback = transport.NewAPIFallbackClient(transport.APIFallbackConfig{
+				Host:               config.Host.ValueString(),
+				Port:               int(config.WebSocket.Port.ValueInt64()),
+				InsecureSkipVerify: config.WebSocket.InsecureSkipVerify.ValueBool(),
+				TLSConfig:          tlsConfig,
+			})
+			fallback = apiFallback
+		}
 
 		// Create WebSocket client
-		wsConfig := client.WebSocketConfig{
-			Host:     config.Host.ValueString(),
-			Username: config.WebSocket.Username.ValueString(),
-			APIKey:   config.WebSocket.APIKey.ValueString(),
-			Fallback: sshClient,
+		wsConfig := transport.WebSocketConfig{
+			Host:      config.Host.ValueString(),
+			Username:  config.WebSocket.Username.ValueString(),
+			APIKey:    config.WebSocket.APIKey.ValueString(),
+			Password:  config.WebSocket.Password.ValueString(),
+			OTPSecret: config.WebSocket.OTPSecret.ValueString(),
+			TLSConfig: tlsConfig,
+			Fallback:  fallback,
 		}
 		if !config.WebSocket.Port.IsNull() {
 			wsConfig.Port = int(config.WebSocket.Port.ValueInt64())
@@ -274,8 +533,17 @@ func (p *TrueNASProvider) Configure(ctx context.Context, req provider.ConfigureR
 		if !config.WebSocket.ConnectTimeout.IsNull() {
 			wsConfig.ConnectTimeout = time.Duration(config.WebSocket.ConnectTimeout.ValueInt64()) * time.Second
 		}
+		// The WebSocket client sends a query again when its connection
+		// drops; 0 in its config means the default, so none is -1
+		maxRetries := config.MaxRetries
 		if !config.WebSocket.MaxRetries.IsNull() {
-			wsConfig.MaxRetries = int(config.WebSocket.MaxRetries.ValueInt64())
+			maxRetries = config.WebSocket.MaxRetries
+		}
+		if !maxRetries.IsNull() {
+			wsConfig.MaxRetries = int(maxRetries.ValueInt64())
+			if wsConfig.MaxRetries == 0 {
+				wsConfig.MaxRetries = -1
+			}
 		}
 
 		wsClient, err := factory.NewWebSocketClient(wsConfig)
@@ -286,8 +554,12 @@ func (p *TrueNASProvider) Configure(ctx context.Context, req provider.ConfigureR
 			)
 			return
 		}
+		if apiFallback != nil {
+			apiFallback.Attach(wsClient)
+		}
 
-		// Connect WebSocket client (caches version from fallback)
+		// Connect WebSocket client (caches version from fallback, which
+		// detects it over SSH or with system.info in API-only mode)
 		if err := wsClient.Connect(ctx); err != nil {
 			resp.Diagnostics.AddError(
 				"Unable to Connect WebSocket Client",
@@ -296,34 +568,42 @@ func (p *TrueNASProvider) Configure(ctx context.Context, req provider.ConfigureR
 			return
 		}
 
-		finalClient = wsClient
+		if apiFallback != nil && !wsClient.Version().AtLeast(25, 0) {
+			resp.Diagnostics.AddError(
+				"WebSocket Transport Requires TrueNAS 25.0+",
+				fmt.Sprintf("Detected version %s. Use auth_method = \"ssh\" instead.",
+					wsClient.Version().Raw),
+			)
+			return
+		}
+
+		retries := 3
+		if !maxRetries.IsNull() {
+			retries = int(maxRetries.ValueInt64())
+		}
 
-	case "ssh", "":
+		// Wrap client with rate limiting and retry
+		finalClient = transport.NewRateLimitedClient(wsClient, transport.RateLimitConfig{
+			CallsPerMinute: rateLimit,
+			Weights:        weights,
+			MaxRetries:     retries,
+			Classifier:     &transport.RetryClassifier{},
+		})
+
+	case authMethod == "ssh" || authMethod == "":
 		// Validate SSH block is provided
 		if config.SSH == nil {
 			resp.Diagnostics.AddError(
 				"Missing SSH Configuration",
-				"SSH block is required when auth_method is 'ssh'.",
+				fmt.Sprintf("SSH block is required when auth_method is 'ssh'. "+
+					"Configure an ssh block or set the %s and %s environment variables.", EnvSSHPrivateKey, EnvSSHHostKeyFingerprint),
 			)
 			return
 		}
-
 		// Build SSH config with values from provider configuration
-		sshConfig := &client.SSHConfig{
-			Host:               config.Host.ValueString(),
-			PrivateKey:         config.SSH.PrivateKey.ValueString(),
-			HostKeyFingerprint: config.SSH.HostKeyFingerprint.ValueString(),
-		}
-
-		// Set optional values if provided
-		if !config.SSH.Port.IsNull() {
-			sshConfig.Port = int(config.SSH.Port.ValueInt64())
-		}
-		if !config.SSH.User.IsNull() {
-			sshConfig.User = config.SSH.User.ValueString()
-		}
-		if !config.SSH.MaxSessions.IsNull() {
-			sshConfig.MaxSessions = int(config.SSH.MaxSessions.ValueInt64())
+		sshConfig, ok := sshTransportConfig(ctx, config.Host.ValueString(), config.SSH, &resp.Diagnostics)
+		if !ok {
+			return
 		}
 
 		// Create SSH client (validates config and applies defaults)
@@ -345,37 +625,52 @@ func (p *TrueNASProvider) Configure(ctx context.Context, req provider.ConfigureR
 			return
 		}
 
-		// Apply rate limiting defaults
-		rateLimit := 0 // 0 means use default (18)
-		if !config.RateLimit.IsNull() {
-			rateLimit = int(config.RateLimit.ValueInt64())
-		}
-
-		maxRetries := -1 // -1 means use default (3)
+		maxRetries := 3
 		if !config.MaxRetries.IsNull() {
 			maxRetries = int(config.MaxRetries.ValueInt64())
 		}
 
 		// Wrap client with rate limiting and retry
-		finalClient = client.NewRateLimitedClient(
-			sshClient,
-			rateLimit,
-			maxRetries,
-			&client.SSHRetryClassifier{},
-		)
+		finalClient = transport.NewRateLimitedClient(sshClient, transport.RateLimitConfig{
+			CallsPerMinute: rateLimit,
+			Weights:        weights,
+			MaxRetries:     maxRetries,
+			Classifier:     &transport.RetryClassifier{},
+		})
 
 	default:
-		resp.Diagnostics.AddError(
+		resp.Diagnostics.AddAttributeError(
+			path.Root("auth_method"),
 			"Invalid Authentication Method",
-			fmt.Sprintf("auth_method must be 'ssh' or 'websocket', got '%s'.", config.AuthMethod.ValueString()),
+			fmt.Sprintf("auth_method must be 'ssh' or 'websocket', got '%s'. "+
+				"The value is read from the provider configuration or the %s environment variable.",
+				config.AuthMethod.ValueString(), EnvAuthMethod),
 		)
 		return
 	}
 
+	// In read-only mode, mutations are rejected before they reach the wire
+	readOnly := config.ReadOnly.ValueBool()
+	if readOnly {
+		finalClient = transport.NewReadOnlyClient(finalClient)
+	}
+
+	// Calls on objects deleted outside Terraform fail with a typed
+	// not-found error
+	finalClient = services.NewNotFoundClient(finalClient)
+
+	// Lookups of datasets and snapshots are batched and cached for the
+	// rest of the operation
+	finalClient = services.NewQueryCache(finalClient)
+
 	// Build service registry
 	version := finalClient.Version()
 	svc := &services.TrueNASServices{
-		Client:     finalClient,
+		Client:   finalClient,
+		ReadOnly: readOnly,
+
+		SkipReferenceChecks: config.SkipReferenceChecks.ValueBool(),
+
 		App:        truenas.NewAppService(finalClient, version),
 		CloudSync:  truenas.NewCloudSyncService(finalClient, version),
 		Cron:       truenas.NewCronService(finalClient, version),
@@ -388,6 +683,49 @@ func (p *TrueNASProvider) Configure(ctx context.Context, req provider.ConfigureR
 
 	resp.DataSourceData = svc
 	resp.ResourceData = svc
+	resp.EphemeralResourceData = svc
+	resp.ListResourceData = svc
+}
+
+// validateWebSocketCredentials checks that exactly one of api_key and
+// password is set, and that otp_secret accompanies a password. It reports
+// whether the credentials are valid.
+func validateWebSocketCredentials(ws *WebSocketBlockModel, diags *diag.Diagnostics) bool {
+	wsPath := path.Root("websocket")
+	apiKey := ws.APIKey.ValueString()
+	password := ws.Password.ValueString()
+	otpSecret := ws.OTPSecret.ValueString()
+
+	switch {
+	case apiKey == "" && password == "":
+		diags.AddAttributeError(
+			wsPath.AtName("api_key"),
+			"Missing WebSocket Credentials",
+			"One of websocket.api_key or websocket.password is required when auth_method is 'websocket'. "+
+				fmt.Sprintf("Set one in the provider configuration or the %s or %s environment variable.", EnvAPIKey, EnvPassword),
+		)
+	case apiKey != "" && password != "":
+		diags.AddAttributeError(
+			wsPath.AtName("password"),
+			"Conflicting WebSocket Credentials",
+			"Only one of websocket.api_key and websocket.password can be set.",
+		)
+	case otpSecret != "" && password == "":
+		diags.AddAttributeError(
+			wsPath.AtName("otp_secret"),
+			"Invalid WebSocket OTP Secret",
+			"websocket.otp_secret is only used with password authentication; API keys are not subject to two-factor authentication.",
+		)
+	case otpSecret != "":
+		if err := transport.ValidateOTPSecret(otpSecret); err != nil {
+			diags.AddAttributeError(
+				wsPath.AtName("otp_secret"),
+				"Invalid WebSocket OTP Secret",
+				fmt.Sprintf("websocket.otp_secret must be the base32 secret shown when two-factor authentication was set up: %s.", err),
+			)
+		}
+	}
+	return !diags.HasError()
 }
 
 func (p *TrueNASProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
@@ -417,3 +755,33 @@ func (p *TrueNASProvider) Resources(ctx context.Context) []func() resource.Resou
 		resources.NewZvolResource,
 	}
 }
+
+func (p *TrueNASProvider) ListResources(ctx context.Context) []func() list.ListResource {
+	return []func() list.ListResource{
+		resources.NewDatasetListResource,
+		resources.NewZvolListResource,
+		resources.NewSnapshotListResource,
+		resources.NewAppListResource,
+		resources.NewCronJobListResource,
+		resources.NewCloudSyncTaskListResource,
+		resources.NewVMListResource,
+		resources.NewVirtInstanceListResource,
+	}
+}
+
+func (p *TrueNASProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		ephemeralresources.NewAPIKeyEphemeralResource,
+		ephemeralresources.NewAuthTokenEphemeralResource,
+	}
+}
+
+func (p *TrueNASProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		functions.NewParseSizeFunction,
+		functions.NewFormatSizeFunction,
+		functions.NewNormalizeModeFunction,
+		functions.NewDatasetPathFunction,
+		functions.NewCronNextRunsFunction,
+	}
+}
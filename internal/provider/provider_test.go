@@ -2,13 +2,19 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
 	truenas "github.com/deevus/truenas-go"
 	"github.com/deevus/truenas-go/client"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -25,14 +31,14 @@ type mockClientFactory struct {
 	wsErr     error
 }
 
-func (f *mockClientFactory) NewSSHClient(cfg *client.SSHConfig) (client.Client, error) {
+func (f *mockClientFactory) NewSSHClient(cfg transport.SSHConfig) (client.Client, error) {
 	if f.sshErr != nil {
 		return nil, f.sshErr
 	}
 	return f.sshClient, nil
 }
 
-func (f *mockClientFactory) NewWebSocketClient(cfg client.WebSocketConfig) (client.Client, error) {
+func (f *mockClientFactory) NewWebSocketClient(cfg transport.WebSocketConfig) (client.Client, error) {
 	if f.wsErr != nil {
 		return nil, f.wsErr
 	}
@@ -77,22 +83,22 @@ func TestProvider_Schema(t *testing.T) {
 		t.Error("expected non-empty schema description")
 	}
 
-	// Verify host attribute exists and is required
+	// Verify host attribute exists and is optional (TRUENAS_HOST fallback)
 	hostAttr, ok := resp.Schema.Attributes["host"]
 	if !ok {
 		t.Fatal("expected 'host' attribute in schema")
 	}
-	if !hostAttr.IsRequired() {
-		t.Error("expected 'host' attribute to be required")
+	if !hostAttr.IsOptional() {
+		t.Error("expected 'host' attribute to be optional")
 	}
 
-	// Verify auth_method attribute exists and is required
+	// Verify auth_method attribute exists and is optional (TRUENAS_AUTH_METHOD fallback)
 	authMethodAttr, ok := resp.Schema.Attributes["auth_method"]
 	if !ok {
 		t.Fatal("expected 'auth_method' attribute in schema")
 	}
-	if !authMethodAttr.IsRequired() {
-		t.Error("expected 'auth_method' attribute to be required")
+	if !authMethodAttr.IsOptional() {
+		t.Error("expected 'auth_method' attribute to be optional")
 	}
 
 	// Verify ssh block exists
@@ -140,13 +146,13 @@ func TestProvider_Schema_SSHBlock(t *testing.T) {
 		t.Error("expected 'user' attribute to be optional")
 	}
 
-	// Verify private_key attribute exists, is required, and is sensitive
+	// Verify private_key attribute exists, is optional, and is sensitive
 	privateKeyAttr, ok := singleBlock.Attributes["private_key"]
 	if !ok {
 		t.Fatal("expected 'private_key' attribute in ssh block")
 	}
-	if !privateKeyAttr.IsRequired() {
-		t.Error("expected 'private_key' attribute to be required")
+	if !privateKeyAttr.IsOptional() {
+		t.Error("expected 'private_key' attribute to be optional")
 	}
 	if !privateKeyAttr.IsSensitive() {
 		t.Error("expected 'private_key' attribute to be sensitive")
@@ -179,9 +185,9 @@ func TestProvider_Schema_SSHBlock_HostKeyFingerprint(t *testing.T) {
 		t.Fatal("expected 'host_key_fingerprint' attribute in ssh block")
 	}
 
-	// Verify it is required
-	if !hostKeyFingerprintAttr.IsRequired() {
-		t.Error("expected 'host_key_fingerprint' attribute to be required")
+	// Verify it is optional (TRUENAS_SSH_HOST_KEY_FINGERPRINT fallback)
+	if !hostKeyFingerprintAttr.IsOptional() {
+		t.Error("expected 'host_key_fingerprint' attribute to be optional")
 	}
 
 	// Verify it is NOT sensitive (fingerprints are not secrets)
@@ -219,10 +225,11 @@ func TestProvider_Schema_WebSocketBlock(t *testing.T) {
 		}
 	}
 
-	// Check api_key is sensitive
-	apiKeyAttr := singleBlock.Attributes["api_key"]
-	if !apiKeyAttr.IsSensitive() {
-		t.Error("api_key attribute should be sensitive")
+	// Check credentials are sensitive
+	for _, attr := range []string{"api_key", "password", "otp_secret"} {
+		if a, ok := singleBlock.Attributes[attr]; !ok || !a.IsSensitive() {
+			t.Errorf("%s attribute should exist and be sensitive", attr)
+		}
 	}
 
 	// Check optional attributes
@@ -318,6 +325,78 @@ func TestProvider_Resources(t *testing.T) {
 	}
 }
 
+func TestProvider_Functions(t *testing.T) {
+	p := &TrueNASProvider{version: "1.0.0"}
+
+	registered := make(map[string]bool)
+	for _, factory := range p.Functions(context.Background()) {
+		resp := &function.MetadataResponse{}
+		factory().Metadata(context.Background(), function.MetadataRequest{}, resp)
+		registered[resp.Name] = true
+	}
+
+	expected := []string{
+		"parse_size",
+		"format_size",
+		"normalize_mode",
+		"dataset_path",
+		"cron_next_runs",
+	}
+	for _, name := range expected {
+		if !registered[name] {
+			t.Errorf("expected function %q to be registered", name)
+		}
+	}
+}
+
+func TestProvider_EphemeralResources(t *testing.T) {
+	p := &TrueNASProvider{version: "1.0.0"}
+
+	registered := make(map[string]bool)
+	for _, factory := range p.EphemeralResources(context.Background()) {
+		resp := &ephemeral.MetadataResponse{}
+		factory().Metadata(context.Background(), ephemeral.MetadataRequest{ProviderTypeName: "truenas"}, resp)
+		registered[resp.TypeName] = true
+	}
+
+	expected := []string{
+		"truenas_api_key",
+		"truenas_auth_token",
+	}
+	for _, name := range expected {
+		if !registered[name] {
+			t.Errorf("expected ephemeral resource %q to be registered", name)
+		}
+	}
+}
+
+func TestProvider_ListResources(t *testing.T) {
+	p := &TrueNASProvider{version: "1.0.0"}
+
+	registered := make(map[string]bool)
+	for _, factory := range p.ListResources(context.Background()) {
+		resp := &resource.MetadataResponse{}
+		factory().Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "truenas"}, resp)
+		registered[resp.TypeName] = true
+	}
+
+	expected := []string{
+		"truenas_dataset",
+		"truenas_zvol",
+		"truenas_snapshot",
+		"truenas_app",
+		"truenas_cron_job",
+		"truenas_cloudsync_task",
+		"truenas_vm",
+		"truenas_virt_instance",
+	}
+	for _, name := range expected {
+		if !registered[name] {
+			t.Errorf("expected list resource %q to be registered", name)
+		}
+	}
+}
+
 // Test ED25519 key for testing (same as in client tests)
 const testHostKeyFingerprint = "SHA256:uVW+XYZ0123456789ABCDEFghijklmnopqrstuv"
 
@@ -340,62 +419,8 @@ func createTestConfigureRequest(t *testing.T, host, authMethod string, ssh *SSHB
 	p.Schema(context.Background(), schemaReq, schemaResp)
 
 	// Build SSH block value
-	var sshValue tftypes.Value
-	sshObjectType := tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"port":                 tftypes.Number,
-			"user":                 tftypes.String,
-			"private_key":          tftypes.String,
-			"host_key_fingerprint": tftypes.String,
-			"max_sessions":         tftypes.Number,
-		},
-	}
-	if ssh == nil {
-		sshValue = tftypes.NewValue(sshObjectType, nil)
-	} else {
-		var portValue tftypes.Value
-		if ssh.Port.IsNull() {
-			portValue = tftypes.NewValue(tftypes.Number, nil)
-		} else {
-			portValue = tftypes.NewValue(tftypes.Number, ssh.Port.ValueInt64())
-		}
-
-		var userValue tftypes.Value
-		if ssh.User.IsNull() {
-			userValue = tftypes.NewValue(tftypes.String, nil)
-		} else {
-			userValue = tftypes.NewValue(tftypes.String, ssh.User.ValueString())
-		}
-
-		var privateKeyValue tftypes.Value
-		if ssh.PrivateKey.IsNull() {
-			privateKeyValue = tftypes.NewValue(tftypes.String, nil)
-		} else {
-			privateKeyValue = tftypes.NewValue(tftypes.String, ssh.PrivateKey.ValueString())
-		}
-
-		var hostKeyFingerprintValue tftypes.Value
-		if ssh.HostKeyFingerprint.IsNull() {
-			hostKeyFingerprintValue = tftypes.NewValue(tftypes.String, nil)
-		} else {
-			hostKeyFingerprintValue = tftypes.NewValue(tftypes.String, ssh.HostKeyFingerprint.ValueString())
-		}
-
-		var maxSessionsValue tftypes.Value
-		if ssh.MaxSessions.IsNull() {
-			maxSessionsValue = tftypes.NewValue(tftypes.Number, nil)
-		} else {
-			maxSessionsValue = tftypes.NewValue(tftypes.Number, ssh.MaxSessions.ValueInt64())
-		}
-
-		sshValue = tftypes.NewValue(sshObjectType, map[string]tftypes.Value{
-			"port":                 portValue,
-			"user":                 userValue,
-			"private_key":          privateKeyValue,
-			"host_key_fingerprint": hostKeyFingerprintValue,
-			"max_sessions":         maxSessionsValue,
-		})
-	}
+	sshObjectType := testSSHObjectType
+	sshValue := sshObjectValue(ssh)
 
 	// Build WebSocket block value (null for these tests)
 	websocketObjectType := tftypes.Object{
@@ -419,6 +444,10 @@ func createTestConfigureRequest(t *testing.T, host, authMethod string, ssh *SSHB
 			"websocket":   websocketObjectType,
 			"rate_limit":  tftypes.Number,
 			"max_retries": tftypes.Number,
+			"read_only":   tftypes.Bool,
+
+			"rate_limit_weights":    tftypes.Map{ElementType: tftypes.Number},
+			"skip_reference_checks": tftypes.Bool,
 		},
 	}, map[string]tftypes.Value{
 		"host":        tftypes.NewValue(tftypes.String, host),
@@ -427,6 +456,10 @@ func createTestConfigureRequest(t *testing.T, host, authMethod string, ssh *SSHB
 		"websocket":   websocketValue,
 		"rate_limit":  tftypes.NewValue(tftypes.Number, nil),
 		"max_retries": tftypes.NewValue(tftypes.Number, nil),
+		"read_only":   tftypes.NewValue(tftypes.Bool, nil),
+
+		"rate_limit_weights":    tftypes.NewValue(tftypes.Map{ElementType: tftypes.Number}, nil),
+		"skip_reference_checks": tftypes.NewValue(tftypes.Bool, nil),
 	})
 
 	config, diags := tfsdk.Config{
@@ -521,6 +554,52 @@ func TestProvider_Configure_Success(t *testing.T) {
 	if resp.ResourceData == nil {
 		t.Error("expected ResourceData to be set")
 	}
+	if resp.ListResourceData == nil {
+		t.Error("expected ListResourceData to be set")
+	}
+}
+
+func TestProvider_Configure_ReadOnly(t *testing.T) {
+	t.Setenv(EnvReadOnly, "true")
+	var calls []string
+	mock := newTestMockClient(truenas.Version{Major: 24, Minor: 10})
+	mock.CallFunc = func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+		calls = append(calls, method)
+		return json.RawMessage(`[]`), nil
+	}
+
+	p := &TrueNASProvider{
+		version: "1.0.0",
+		factory: &mockClientFactory{sshClient: mock},
+	}
+
+	ssh := &SSHBlockModel{
+		PrivateKey:         types.StringValue(testPrivateKey),
+		HostKeyFingerprint: types.StringValue(testHostKeyFingerprint),
+	}
+
+	req := createTestConfigureRequest(t, "truenas.local", "ssh", ssh)
+	resp := &provider.ConfigureResponse{}
+
+	p.Configure(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+
+	svc := resp.ResourceData.(*services.TrueNASServices)
+	if !svc.ReadOnly {
+		t.Error("expected services to be marked read-only")
+	}
+	if _, err := svc.Client.Call(context.Background(), "pool.dataset.query", nil); err != nil {
+		t.Errorf("expected queries to be allowed, got %v", err)
+	}
+	if _, err := svc.Client.Call(context.Background(), "pool.dataset.delete", "tank/x"); !errors.Is(err, transport.ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "pool.dataset.query" {
+		t.Errorf("expected only the query to reach the client, got %v", calls)
+	}
 }
 
 func TestProvider_Configure_WithCustomPortAndUser(t *testing.T) {
@@ -558,15 +637,7 @@ func TestProvider_Configure_ConfigParseError(t *testing.T) {
 	p.Schema(context.Background(), schemaReq, schemaResp)
 
 	// Create an invalid config value with wrong type (number instead of string for host)
-	sshObjectType := tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"port":                 tftypes.Number,
-			"user":                 tftypes.String,
-			"private_key":          tftypes.String,
-			"host_key_fingerprint": tftypes.String,
-			"max_sessions":         tftypes.Number,
-		},
-	}
+	sshObjectType := testSSHObjectType
 	websocketObjectType := tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
 			"api_key":              tftypes.String,
@@ -585,20 +656,25 @@ func TestProvider_Configure_ConfigParseError(t *testing.T) {
 			"websocket":   websocketObjectType,
 			"rate_limit":  tftypes.Number,
 			"max_retries": tftypes.Number,
+			"read_only":   tftypes.Bool,
+
+			"rate_limit_weights":    tftypes.Map{ElementType: tftypes.Number},
+			"skip_reference_checks": tftypes.Bool,
 		},
 	}, map[string]tftypes.Value{
 		"host":        tftypes.NewValue(tftypes.Number, 123), // Wrong type!
 		"auth_method": tftypes.NewValue(tftypes.String, "ssh"),
-		"ssh": tftypes.NewValue(sshObjectType, map[string]tftypes.Value{
-			"port":                 tftypes.NewValue(tftypes.Number, nil),
-			"user":                 tftypes.NewValue(tftypes.String, nil),
-			"private_key":          tftypes.NewValue(tftypes.String, testPrivateKey),
-			"host_key_fingerprint": tftypes.NewValue(tftypes.String, testHostKeyFingerprint),
-			"max_sessions":         tftypes.NewValue(tftypes.Number, nil),
+		"ssh": sshObjectValue(&SSHBlockModel{
+			PrivateKey:         types.StringValue(testPrivateKey),
+			HostKeyFingerprint: types.StringValue(testHostKeyFingerprint),
 		}),
 		"websocket":   tftypes.NewValue(websocketObjectType, nil),
 		"rate_limit":  tftypes.NewValue(tftypes.Number, nil),
 		"max_retries": tftypes.NewValue(tftypes.Number, nil),
+		"read_only":   tftypes.NewValue(tftypes.Bool, nil),
+
+		"rate_limit_weights":    tftypes.NewValue(tftypes.Map{ElementType: tftypes.Number}, nil),
+		"skip_reference_checks": tftypes.NewValue(tftypes.Bool, nil),
 	})
 
 	config := tfsdk.Config{
@@ -630,15 +706,7 @@ func TestProvider_Configure_InvalidSSHClient(t *testing.T) {
 	p.Schema(context.Background(), schemaReq, schemaResp)
 
 	// Create config with empty private_key (this will fail client validation)
-	sshObjectType := tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"port":                 tftypes.Number,
-			"user":                 tftypes.String,
-			"private_key":          tftypes.String,
-			"host_key_fingerprint": tftypes.String,
-			"max_sessions":         tftypes.Number,
-		},
-	}
+	sshObjectType := testSSHObjectType
 	websocketObjectType := tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
 			"api_key":              tftypes.String,
@@ -657,20 +725,25 @@ func TestProvider_Configure_InvalidSSHClient(t *testing.T) {
 			"websocket":   websocketObjectType,
 			"rate_limit":  tftypes.Number,
 			"max_retries": tftypes.Number,
+			"read_only":   tftypes.Bool,
+
+			"rate_limit_weights":    tftypes.Map{ElementType: tftypes.Number},
+			"skip_reference_checks": tftypes.Bool,
 		},
 	}, map[string]tftypes.Value{
 		"host":        tftypes.NewValue(tftypes.String, "truenas.local"),
 		"auth_method": tftypes.NewValue(tftypes.String, "ssh"),
-		"ssh": tftypes.NewValue(sshObjectType, map[string]tftypes.Value{
-			"port":                 tftypes.NewValue(tftypes.Number, nil),
-			"user":                 tftypes.NewValue(tftypes.String, nil),
-			"private_key":          tftypes.NewValue(tftypes.String, ""), // Empty private key
-			"host_key_fingerprint": tftypes.NewValue(tftypes.String, testHostKeyFingerprint),
-			"max_sessions":         tftypes.NewValue(tftypes.Number, nil),
+		"ssh": sshObjectValue(&SSHBlockModel{
+			PrivateKey:         types.StringValue(""), // Empty private key
+			HostKeyFingerprint: types.StringValue(testHostKeyFingerprint),
 		}),
 		"websocket":   tftypes.NewValue(websocketObjectType, nil),
 		"rate_limit":  tftypes.NewValue(tftypes.Number, nil),
 		"max_retries": tftypes.NewValue(tftypes.Number, nil),
+		"read_only":   tftypes.NewValue(tftypes.Bool, nil),
+
+		"rate_limit_weights":    tftypes.NewValue(tftypes.Map{ElementType: tftypes.Number}, nil),
+		"skip_reference_checks": tftypes.NewValue(tftypes.Bool, nil),
 	})
 
 	config := tfsdk.Config{
@@ -773,6 +846,102 @@ func containsSubstring(s, substr string) bool {
 	return false
 }
 
+// testSSHBastionObjectType is the tftypes type of the ssh.bastion block.
+var testSSHBastionObjectType = tftypes.Object{
+	AttributeTypes: map[string]tftypes.Type{
+		"host":                 tftypes.String,
+		"port":                 tftypes.Number,
+		"user":                 tftypes.String,
+		"private_key":          tftypes.String,
+		"host_key_fingerprint": tftypes.String,
+	},
+}
+
+// testSSHObjectType is the tftypes type of the ssh block.
+var testSSHObjectType = tftypes.Object{
+	AttributeTypes: map[string]tftypes.Type{
+		"port":                  tftypes.Number,
+		"user":                  tftypes.String,
+		"private_key":           tftypes.String,
+		"private_key_file":      tftypes.String,
+		"use_agent":             tftypes.Bool,
+		"host_key_fingerprint":  tftypes.String,
+		"host_key_fingerprints": tftypes.List{ElementType: tftypes.String},
+		"known_hosts_file":      tftypes.String,
+		"max_sessions":          tftypes.Number,
+		"sudo":                  tftypes.Bool,
+		"sudo_password":         tftypes.String,
+		"bastion":               testSSHBastionObjectType,
+	},
+}
+
+// sshObjectValue converts an ssh block into a tftypes value, null when ssh
+// is nil.
+func sshObjectValue(ssh *SSHBlockModel) tftypes.Value {
+	if ssh == nil {
+		return tftypes.NewValue(testSSHObjectType, nil)
+	}
+
+	fingerprints := tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, nil)
+	if !ssh.HostKeyFingerprints.IsNull() {
+		var elems []tftypes.Value
+		for _, e := range ssh.HostKeyFingerprints.Elements() {
+			elems = append(elems, stringValueOrNull(e.(types.String)))
+		}
+		fingerprints = tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, elems)
+	}
+
+	bastion := tftypes.NewValue(testSSHBastionObjectType, nil)
+	if b := ssh.Bastion; b != nil {
+		bastion = tftypes.NewValue(testSSHBastionObjectType, map[string]tftypes.Value{
+			"host":                 stringValueOrNull(b.Host),
+			"port":                 int64ValueOrNull(b.Port),
+			"user":                 stringValueOrNull(b.User),
+			"private_key":          stringValueOrNull(b.PrivateKey),
+			"host_key_fingerprint": stringValueOrNull(b.HostKeyFingerprint),
+		})
+	}
+
+	return tftypes.NewValue(testSSHObjectType, map[string]tftypes.Value{
+		"port":                  int64ValueOrNull(ssh.Port),
+		"user":                  stringValueOrNull(ssh.User),
+		"private_key":           stringValueOrNull(ssh.PrivateKey),
+		"private_key_file":      stringValueOrNull(ssh.PrivateKeyFile),
+		"use_agent":             boolValueOrNull(ssh.UseAgent),
+		"host_key_fingerprint":  stringValueOrNull(ssh.HostKeyFingerprint),
+		"host_key_fingerprints": fingerprints,
+		"known_hosts_file":      stringValueOrNull(ssh.KnownHostsFile),
+		"max_sessions":          int64ValueOrNull(ssh.MaxSessions),
+		"sudo":                  boolValueOrNull(ssh.Sudo),
+		"sudo_password":         stringValueOrNull(ssh.SudoPassword),
+		"bastion":               bastion,
+	})
+}
+
+// boolValueOrNull converts a framework bool into a tftypes value.
+func boolValueOrNull(v types.Bool) tftypes.Value {
+	if v.IsNull() {
+		return tftypes.NewValue(tftypes.Bool, nil)
+	}
+	return tftypes.NewValue(tftypes.Bool, v.ValueBool())
+}
+
+// int64ValueOrNull converts a framework integer into a tftypes value.
+func int64ValueOrNull(v types.Int64) tftypes.Value {
+	if v.IsNull() {
+		return tftypes.NewValue(tftypes.Number, nil)
+	}
+	return tftypes.NewValue(tftypes.Number, v.ValueInt64())
+}
+
+// stringValueOrNull converts a framework string into a tftypes value.
+func stringValueOrNull(v types.String) tftypes.Value {
+	if v.IsNull() {
+		return tftypes.NewValue(tftypes.String, nil)
+	}
+	return tftypes.NewValue(tftypes.String, v.ValueString())
+}
+
 // createTestConfigureRequestWithWebSocket creates a provider.ConfigureRequest with SSH and WebSocket config
 func createTestConfigureRequestWithWebSocket(t *testing.T, host, authMethod string, ssh *SSHBlockModel, ws *WebSocketBlockModel) provider.ConfigureRequest {
 	t.Helper()
@@ -784,62 +953,8 @@ func createTestConfigureRequestWithWebSocket(t *testing.T, host, authMethod stri
 	p.Schema(context.Background(), schemaReq, schemaResp)
 
 	// Build SSH block value
-	var sshValue tftypes.Value
-	sshObjectType := tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"port":                 tftypes.Number,
-			"user":                 tftypes.String,
-			"private_key":          tftypes.String,
-			"host_key_fingerprint": tftypes.String,
-			"max_sessions":         tftypes.Number,
-		},
-	}
-	if ssh == nil {
-		sshValue = tftypes.NewValue(sshObjectType, nil)
-	} else {
-		var portValue tftypes.Value
-		if ssh.Port.IsNull() {
-			portValue = tftypes.NewValue(tftypes.Number, nil)
-		} else {
-			portValue = tftypes.NewValue(tftypes.Number, ssh.Port.ValueInt64())
-		}
-
-		var userValue tftypes.Value
-		if ssh.User.IsNull() {
-			userValue = tftypes.NewValue(tftypes.String, nil)
-		} else {
-			userValue = tftypes.NewValue(tftypes.String, ssh.User.ValueString())
-		}
-
-		var privateKeyValue tftypes.Value
-		if ssh.PrivateKey.IsNull() {
-			privateKeyValue = tftypes.NewValue(tftypes.String, nil)
-		} else {
-			privateKeyValue = tftypes.NewValue(tftypes.String, ssh.PrivateKey.ValueString())
-		}
-
-		var hostKeyFingerprintValue tftypes.Value
-		if ssh.HostKeyFingerprint.IsNull() {
-			hostKeyFingerprintValue = tftypes.NewValue(tftypes.String, nil)
-		} else {
-			hostKeyFingerprintValue = tftypes.NewValue(tftypes.String, ssh.HostKeyFingerprint.ValueString())
-		}
-
-		var maxSessionsValue tftypes.Value
-		if ssh.MaxSessions.IsNull() {
-			maxSessionsValue = tftypes.NewValue(tftypes.Number, nil)
-		} else {
-			maxSessionsValue = tftypes.NewValue(tftypes.Number, ssh.MaxSessions.ValueInt64())
-		}
-
-		sshValue = tftypes.NewValue(sshObjectType, map[string]tftypes.Value{
-			"port":                 portValue,
-			"user":                 userValue,
-			"private_key":          privateKeyValue,
-			"host_key_fingerprint": hostKeyFingerprintValue,
-			"max_sessions":         maxSessionsValue,
-		})
-	}
+	sshObjectType := testSSHObjectType
+	sshValue := sshObjectValue(ssh)
 
 	// Build WebSocket block value
 	var websocketValue tftypes.Value
@@ -847,6 +962,15 @@ func createTestConfigureRequestWithWebSocket(t *testing.T, host, authMethod stri
 		AttributeTypes: map[string]tftypes.Type{
 			"username":             tftypes.String,
 			"api_key":              tftypes.String,
+			"password":             tftypes.String,
+			"otp_secret":           tftypes.String,
+			"ca_cert_pem":          tftypes.String,
+			"ca_cert_file":         tftypes.String,
+			"tls_fingerprint":      tftypes.String,
+			"client_cert_pem":      tftypes.String,
+			"client_cert_file":     tftypes.String,
+			"client_key_pem":       tftypes.String,
+			"client_key_file":      tftypes.String,
 			"port":                 tftypes.Number,
 			"insecure_skip_verify": tftypes.Bool,
 			"max_concurrent":       tftypes.Number,
@@ -871,6 +995,20 @@ func createTestConfigureRequestWithWebSocket(t *testing.T, host, authMethod stri
 			apiKeyValue = tftypes.NewValue(tftypes.String, ws.APIKey.ValueString())
 		}
 
+		var passwordValue tftypes.Value
+		if ws.Password.IsNull() {
+			passwordValue = tftypes.NewValue(tftypes.String, nil)
+		} else {
+			passwordValue = tftypes.NewValue(tftypes.String, ws.Password.ValueString())
+		}
+
+		var otpSecretValue tftypes.Value
+		if ws.OTPSecret.IsNull() {
+			otpSecretValue = tftypes.NewValue(tftypes.String, nil)
+		} else {
+			otpSecretValue = tftypes.NewValue(tftypes.String, ws.OTPSecret.ValueString())
+		}
+
 		var portValue tftypes.Value
 		if ws.Port.IsNull() {
 			portValue = tftypes.NewValue(tftypes.Number, nil)
@@ -909,6 +1047,15 @@ func createTestConfigureRequestWithWebSocket(t *testing.T, host, authMethod stri
 		websocketValue = tftypes.NewValue(websocketObjectType, map[string]tftypes.Value{
 			"username":             usernameValue,
 			"api_key":              apiKeyValue,
+			"password":             passwordValue,
+			"otp_secret":           otpSecretValue,
+			"ca_cert_pem":          stringValueOrNull(ws.CACertPEM),
+			"ca_cert_file":         stringValueOrNull(ws.CACertFile),
+			"tls_fingerprint":      stringValueOrNull(ws.TLSFingerprint),
+			"client_cert_pem":      stringValueOrNull(ws.ClientCertPEM),
+			"client_cert_file":     stringValueOrNull(ws.ClientCertFile),
+			"client_key_pem":       stringValueOrNull(ws.ClientKeyPEM),
+			"client_key_file":      stringValueOrNull(ws.ClientKeyFile),
 			"port":                 portValue,
 			"insecure_skip_verify": insecureSkipVerifyValue,
 			"max_concurrent":       maxConcurrentValue,
@@ -926,6 +1073,10 @@ func createTestConfigureRequestWithWebSocket(t *testing.T, host, authMethod stri
 			"websocket":   websocketObjectType,
 			"rate_limit":  tftypes.Number,
 			"max_retries": tftypes.Number,
+			"read_only":   tftypes.Bool,
+
+			"rate_limit_weights":    tftypes.Map{ElementType: tftypes.Number},
+			"skip_reference_checks": tftypes.Bool,
 		},
 	}, map[string]tftypes.Value{
 		"host":        tftypes.NewValue(tftypes.String, host),
@@ -934,6 +1085,10 @@ func createTestConfigureRequestWithWebSocket(t *testing.T, host, authMethod stri
 		"websocket":   websocketValue,
 		"rate_limit":  tftypes.NewValue(tftypes.Number, nil),
 		"max_retries": tftypes.NewValue(tftypes.Number, nil),
+		"read_only":   tftypes.NewValue(tftypes.Bool, nil),
+
+		"rate_limit_weights":    tftypes.NewValue(tftypes.Map{ElementType: tftypes.Number}, nil),
+		"skip_reference_checks": tftypes.NewValue(tftypes.Bool, nil),
 	})
 
 	config, diags := tfsdk.Config{
@@ -983,8 +1138,18 @@ func TestProvider_Configure_WebSocketAuthMethod_MissingWebSocketBlock(t *testing
 	}
 }
 
-func TestProvider_Configure_WebSocketAuthMethod_MissingSSHBlock(t *testing.T) {
-	p := &TrueNASProvider{version: "1.0.0"}
+func TestProvider_Configure_WebSocketAuthMethod_WithoutSSHBlock(t *testing.T) {
+	wsMock := newTestMockClient(truenas.Version{Major: 25, Minor: 4})
+
+	var gotConfig transport.WebSocketConfig
+	factory := &mockClientFactory{
+		wsClient: wsMock,
+		sshErr:   errors.New("ssh must not be used in API-only mode"),
+	}
+	p := &TrueNASProvider{
+		version: "1.0.0",
+		factory: &wsConfigCapturingFactory{mockClientFactory: factory, got: &gotConfig},
+	}
 
 	ws := &WebSocketBlockModel{
 		Username:           types.StringValue("root"),
@@ -1001,23 +1166,172 @@ func TestProvider_Configure_WebSocketAuthMethod_MissingSSHBlock(t *testing.T) {
 
 	p.Configure(context.Background(), req, resp)
 
-	if !resp.Diagnostics.HasError() {
-		t.Fatal("expected error for missing ssh block when using websocket")
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
 	}
 
-	// Check error message mentions SSH and fallback
-	found := false
-	for _, d := range resp.Diagnostics {
-		if d.Severity() == diag.SeverityError && containsString(d.Detail(), "fallback") {
-			found = true
-			break
-		}
+	// Without an ssh block the fallback is the API-only client
+	if _, ok := gotConfig.Fallback.(*transport.APIFallbackClient); !ok {
+		t.Errorf("expected API-only fallback client, got %T", gotConfig.Fallback)
 	}
-	if !found {
-		t.Error("expected error message to mention 'fallback'")
+}
+
+func TestProvider_Configure_WebSocketAuthMethod_Password(t *testing.T) {
+	wsMock := newTestMockClient(truenas.Version{Major: 25, Minor: 4})
+
+	var gotConfig transport.WebSocketConfig
+	p := &TrueNASProvider{
+		version: "1.0.0",
+		factory: &wsConfigCapturingFactory{mockClientFactory: &mockClientFactory{wsClient: wsMock}, got: &gotConfig},
+	}
+
+	ws := &WebSocketBlockModel{
+		Username:  types.StringValue("breakglass"),
+		Password:  types.StringValue("hunter2"),
+		OTPSecret: types.StringValue("JBSWY3DPEHPK3PXP"),
+	}
+
+	req := createTestConfigureRequestWithWebSocket(t, "truenas.local", "websocket", nil, ws)
+	resp := &provider.ConfigureResponse{}
+
+	p.Configure(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+	if gotConfig.Password != "hunter2" || gotConfig.OTPSecret != "JBSWY3DPEHPK3PXP" || gotConfig.APIKey != "" {
+		t.Errorf("unexpected credentials in config: %+v", gotConfig)
 	}
 }
 
+func TestProvider_Configure_WebSocketAuthMethod_TLSFingerprint(t *testing.T) {
+	wsMock := newTestMockClient(truenas.Version{Major: 25, Minor: 4})
+
+	var gotConfig transport.WebSocketConfig
+	p := &TrueNASProvider{
+		version: "1.0.0",
+		factory: &wsConfigCapturingFactory{mockClientFactory: &mockClientFactory{wsClient: wsMock}, got: &gotConfig},
+	}
+
+	ws := &WebSocketBlockModel{
+		Username:       types.StringValue("root"),
+		APIKey:         types.StringValue("test-api-key"),
+		TLSFingerprint: types.StringValue(strings.Repeat("AB:", 31) + "AB"),
+	}
+
+	req := createTestConfigureRequestWithWebSocket(t, "truenas.local", "websocket", nil, ws)
+	resp := &provider.ConfigureResponse{}
+
+	p.Configure(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+	if gotConfig.TLSConfig == nil || gotConfig.TLSConfig.VerifyConnection == nil {
+		t.Fatalf("expected a pinning TLS config, got %+v", gotConfig.TLSConfig)
+	}
+}
+
+func TestProvider_Configure_WebSocketAuthMethod_InvalidCredentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		ws        *WebSocketBlockModel
+		wantPath  string
+		wantError string
+	}{
+		{
+			name:      "missing",
+			ws:        &WebSocketBlockModel{Username: types.StringValue("root")},
+			wantPath:  "websocket.api_key",
+			wantError: "Missing WebSocket Credentials",
+		},
+		{
+			name:      "conflicting",
+			ws:        &WebSocketBlockModel{Username: types.StringValue("root"), APIKey: types.StringValue("key"), Password: types.StringValue("secret")},
+			wantPath:  "websocket.password",
+			wantError: "Conflicting WebSocket Credentials",
+		},
+		{
+			name:      "otp with api key",
+			ws:        &WebSocketBlockModel{Username: types.StringValue("root"), APIKey: types.StringValue("key"), OTPSecret: types.StringValue("JBSWY3DPEHPK3PXP")},
+			wantPath:  "websocket.otp_secret",
+			wantError: "Invalid WebSocket OTP Secret",
+		},
+		{
+			name:      "otp not base32",
+			ws:        &WebSocketBlockModel{Username: types.StringValue("root"), Password: types.StringValue("secret"), OTPSecret: types.StringValue("not-a-secret!")},
+			wantPath:  "websocket.otp_secret",
+			wantError: "Invalid WebSocket OTP Secret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &TrueNASProvider{
+				version: "1.0.0",
+				factory: &mockClientFactory{wsErr: errors.New("client must not be created")},
+			}
+			req := createTestConfigureRequestWithWebSocket(t, "truenas.local", "websocket", nil, tt.ws)
+			resp := &provider.ConfigureResponse{}
+
+			p.Configure(context.Background(), req, resp)
+
+			if resp.Diagnostics.ErrorsCount() != 1 {
+				t.Fatalf("expected one error, got %v", resp.Diagnostics)
+			}
+			d := resp.Diagnostics.Errors()[0]
+			if d.Summary() != tt.wantError {
+				t.Errorf("expected %q, got %q", tt.wantError, d.Summary())
+			}
+			withPath, ok := d.(diag.DiagnosticWithPath)
+			if !ok || withPath.Path().String() != tt.wantPath {
+				t.Errorf("expected error at %s, got %v", tt.wantPath, d)
+			}
+		})
+	}
+}
+
+func TestProvider_Configure_WebSocketAuthMethod_WithoutSSHBlock_OldVersionRejected(t *testing.T) {
+	wsMock := newTestMockClient(truenas.Version{Major: 24, Minor: 10, Raw: "TrueNAS-SCALE-24.10.2"})
+
+	p := &TrueNASProvider{
+		version: "1.0.0",
+		factory: &mockClientFactory{wsClient: wsMock},
+	}
+
+	ws := &WebSocketBlockModel{
+		Username:           types.StringValue("root"),
+		APIKey:             types.StringValue("test-api-key"),
+		Port:               types.Int64Null(),
+		InsecureSkipVerify: types.BoolNull(),
+		MaxConcurrent:      types.Int64Null(),
+		ConnectTimeout:     types.Int64Null(),
+		MaxRetries:         types.Int64Null(),
+	}
+
+	req := createTestConfigureRequestWithWebSocket(t, "truenas.local", "websocket", nil, ws)
+	resp := &provider.ConfigureResponse{}
+
+	p.Configure(context.Background(), req, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for TrueNAS < 25.0 in API-only mode")
+	}
+	if !containsString(resp.Diagnostics[0].Summary(), "25.0") {
+		t.Errorf("expected version error, got %q", resp.Diagnostics[0].Summary())
+	}
+}
+
+// wsConfigCapturingFactory records the WebSocket config passed to the factory.
+type wsConfigCapturingFactory struct {
+	*mockClientFactory
+	got *transport.WebSocketConfig
+}
+
+func (f *wsConfigCapturingFactory) NewWebSocketClient(cfg transport.WebSocketConfig) (client.Client, error) {
+	*f.got = cfg
+	return f.mockClientFactory.NewWebSocketClient(cfg)
+}
+
 func TestProvider_Configure_WebSocketAuthMethod_Success(t *testing.T) {
 	sshMock := newTestMockClient(truenas.Version{Major: 25, Minor: 0})
 	wsMock := newTestMockClient(truenas.Version{Major: 25, Minor: 0})
@@ -1114,6 +1428,57 @@ func TestProvider_Configure_WebSocketAuthMethod_WithAllOptions(t *testing.T) {
 	}
 }
 
+func TestProvider_Configure_WebSocketAuthMethod_RetriesBusyCall(t *testing.T) {
+	calls := 0
+	wsMock := newTestMockClient(truenas.Version{Major: 25, Minor: 0})
+	wsMock.CallFunc = func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("[EBUSY] dataset is busy")
+		}
+		return json.RawMessage(`true`), nil
+	}
+
+	p := &TrueNASProvider{
+		version: "1.0.0",
+		factory: &mockClientFactory{
+			sshClient: newTestMockClient(truenas.Version{Major: 25, Minor: 0}),
+			wsClient:  wsMock,
+		},
+	}
+	ssh := &SSHBlockModel{
+		Port:               types.Int64Null(),
+		User:               types.StringNull(),
+		PrivateKey:         types.StringValue(testPrivateKey),
+		HostKeyFingerprint: types.StringValue(testHostKeyFingerprint),
+		MaxSessions:        types.Int64Null(),
+	}
+	ws := &WebSocketBlockModel{
+		Username:           types.StringValue("root"),
+		APIKey:             types.StringValue("test-api-key"),
+		Port:               types.Int64Null(),
+		InsecureSkipVerify: types.BoolNull(),
+		MaxConcurrent:      types.Int64Null(),
+		ConnectTimeout:     types.Int64Null(),
+		MaxRetries:         types.Int64Value(1),
+	}
+
+	req := createTestConfigureRequestWithWebSocket(t, "truenas.local", "websocket", ssh, ws)
+	resp := &provider.ConfigureResponse{}
+	p.Configure(context.Background(), req, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+
+	svc := resp.ResourceData.(*services.TrueNASServices)
+	if _, err := svc.Client.Call(context.Background(), "pool.dataset.delete", "tank/x"); err != nil {
+		t.Fatalf("expected the busy call to be retried, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
 func TestProvider_Configure_InvalidAuthMethod_MentionsWebSocket(t *testing.T) {
 	p := &TrueNASProvider{version: "1.0.0"}
 
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// sshTransportConfig builds the transport configuration of the ssh block for host.
+// It reports whether the SSH settings are valid.
+func sshTransportConfig(ctx context.Context, host string, block *SSHBlockModel, diags *diag.Diagnostics) (transport.SSHConfig, bool) {
+	sshPath := path.Root("ssh")
+	cfg := transport.SSHConfig{
+		Host:           host,
+		Port:           int(block.Port.ValueInt64()),
+		User:           block.User.ValueString(),
+		UseAgent:       block.UseAgent.ValueBool(),
+		KnownHostsFile: block.KnownHostsFile.ValueString(),
+		MaxSessions:    int(block.MaxSessions.ValueInt64()),
+	}
+
+	privateKey, privateKeyFile := block.PrivateKey.ValueString(), block.PrivateKeyFile.ValueString()
+	switch {
+	case privateKey != "" && privateKeyFile != "":
+		diags.AddAttributeError(
+			sshPath.AtName("private_key_file"),
+			"Conflicting SSH Configuration",
+			"Only one of ssh.private_key and ssh.private_key_file can be set.",
+		)
+	case privateKeyFile != "":
+		data, err := os.ReadFile(privateKeyFile)
+		if err != nil {
+			diags.AddAttributeError(sshPath.AtName("private_key_file"), "Unable to Read SSH Private Key", err.Error())
+		}
+		cfg.PrivateKey = string(data)
+	case privateKey != "":
+		cfg.PrivateKey = privateKey
+	case !cfg.UseAgent:
+		diags.AddAttributeError(
+			sshPath.AtName("private_key"),
+			"Missing SSH Private Key",
+			fmt.Sprintf("Set ssh.private_key or ssh.private_key_file, or set ssh.use_agent to authenticate with ssh-agent. "+
+				"The key can also be supplied with the %s or %s environment variable.", EnvSSHPrivateKey, EnvSSHPrivateKeyFile),
+		)
+	}
+
+	if fp := block.HostKeyFingerprint.ValueString(); fp != "" {
+		cfg.HostKeyFingerprints = append(cfg.HostKeyFingerprints, fp)
+	}
+	if !block.HostKeyFingerprints.IsNull() && !block.HostKeyFingerprints.IsUnknown() {
+		var fingerprints []string
+		diags.Append(block.HostKeyFingerprints.ElementsAs(ctx, &fingerprints, false)...)
+		cfg.HostKeyFingerprints = append(cfg.HostKeyFingerprints, fingerprints...)
+	}
+	if len(cfg.HostKeyFingerprints) == 0 && cfg.KnownHostsFile == "" {
+		diags.AddAttributeError(
+			sshPath.AtName("host_key_fingerprint"),
+			"Missing SSH Host Key Fingerprint",
+			"Set ssh.host_key_fingerprint, ssh.host_key_fingerprints or ssh.known_hosts_file. "+
+				missingValueDetail("ssh.host_key_fingerprint", EnvSSHHostKeyFingerprint),
+		)
+	}
+
+	// Root needs no elevation; any other user does unless told otherwise
+	cfg.SudoPassword = block.SudoPassword.ValueString()
+	if block.Sudo.IsNull() || block.Sudo.IsUnknown() {
+		cfg.Sudo = cfg.SudoPassword != "" || (cfg.User != "" && cfg.User != "root")
+	} else {
+		cfg.Sudo = block.Sudo.ValueBool()
+	}
+	if cfg.SudoPassword != "" && !cfg.Sudo {
+		diags.AddAttributeError(
+			sshPath.AtName("sudo_password"),
+			"Conflicting SSH Configuration",
+			"ssh.sudo_password is set but ssh.sudo is false.",
+		)
+	}
+
+	if b := block.Bastion; b != nil {
+		bastionPath := sshPath.AtName("bastion")
+		cfg.Bastion = &transport.SSHBastionConfig{
+			Host:       b.Host.ValueString(),
+			Port:       int(b.Port.ValueInt64()),
+			User:       b.User.ValueString(),
+			PrivateKey: b.PrivateKey.ValueString(),
+		}
+		if fp := b.HostKeyFingerprint.ValueString(); fp != "" {
+			cfg.Bastion.HostKeyFingerprints = []string{fp}
+		}
+		if cfg.Bastion.Host == "" {
+			diags.AddAttributeError(bastionPath.AtName("host"), "Missing SSH Bastion Host", "ssh.bastion.host is required when the bastion block is set.")
+		}
+		if len(cfg.Bastion.HostKeyFingerprints) == 0 && cfg.KnownHostsFile == "" {
+			diags.AddAttributeError(
+				bastionPath.AtName("host_key_fingerprint"),
+				"Missing SSH Bastion Host Key Fingerprint",
+				"Set ssh.bastion.host_key_fingerprint, or ssh.known_hosts_file with an entry for the bastion.",
+			)
+		}
+	}
+
+	return cfg, !diags.HasError()
+}
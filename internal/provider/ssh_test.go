@@ -0,0 +1,266 @@
+package provider
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/fakenas"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestSSHTransportConfig(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyFile, []byte(testPrivateKey), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	var diags diag.Diagnostics
+	cfg, ok := sshTransportConfig(context.Background(), "nas.local", &SSHBlockModel{
+		User:                types.StringValue("terraform"),
+		PrivateKeyFile:      types.StringValue(keyFile),
+		UseAgent:            types.BoolValue(true),
+		HostKeyFingerprint:  types.StringValue("SHA256:current"),
+		HostKeyFingerprints: types.ListValueMust(types.StringType, []attr.Value{types.StringValue("SHA256:next")}),
+		KnownHostsFile:      types.StringValue("/etc/ssh/ssh_known_hosts"),
+		Bastion: &SSHBastionModel{
+			Host:               types.StringValue("jump.example.com"),
+			Port:               types.Int64Value(2222),
+			HostKeyFingerprint: types.StringValue("SHA256:jump"),
+		},
+	}, &diags)
+	if !ok {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+
+	if cfg.Host != "nas.local" || cfg.User != "terraform" || !cfg.UseAgent {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.PrivateKey != testPrivateKey {
+		t.Error("expected private key to be read from private_key_file")
+	}
+	if want := []string{"SHA256:current", "SHA256:next"}; !reflect.DeepEqual(cfg.HostKeyFingerprints, want) {
+		t.Errorf("expected fingerprints %v, got %v", want, cfg.HostKeyFingerprints)
+	}
+	if cfg.KnownHostsFile != "/etc/ssh/ssh_known_hosts" {
+		t.Errorf("unexpected known_hosts_file %q", cfg.KnownHostsFile)
+	}
+	b := cfg.Bastion
+	if b == nil || b.Host != "jump.example.com" || b.Port != 2222 || !reflect.DeepEqual(b.HostKeyFingerprints, []string{"SHA256:jump"}) {
+		t.Errorf("unexpected bastion: %+v", b)
+	}
+}
+
+func TestSSHTransportConfig_Invalid(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	tests := []struct {
+		name      string
+		ssh       *SSHBlockModel
+		wantPath  string
+		wantError string
+	}{
+		{
+			name:      "no credentials",
+			ssh:       &SSHBlockModel{HostKeyFingerprint: types.StringValue(testHostKeyFingerprint)},
+			wantPath:  "ssh.private_key",
+			wantError: "Missing SSH Private Key",
+		},
+		{
+			name:      "key and key file",
+			ssh:       &SSHBlockModel{PrivateKey: types.StringValue(testPrivateKey), PrivateKeyFile: types.StringValue("/id"), HostKeyFingerprint: types.StringValue(testHostKeyFingerprint)},
+			wantPath:  "ssh.private_key_file",
+			wantError: "Conflicting SSH Configuration",
+		},
+		{
+			name:      "unreadable key file",
+			ssh:       &SSHBlockModel{PrivateKeyFile: types.StringValue(missing), HostKeyFingerprint: types.StringValue(testHostKeyFingerprint)},
+			wantPath:  "ssh.private_key_file",
+			wantError: "Unable to Read SSH Private Key",
+		},
+		{
+			name:      "no host key",
+			ssh:       &SSHBlockModel{UseAgent: types.BoolValue(true)},
+			wantPath:  "ssh.host_key_fingerprint",
+			wantError: "Missing SSH Host Key Fingerprint",
+		},
+		{
+			name: "sudo password without sudo",
+			ssh: &SSHBlockModel{
+				UseAgent:           types.BoolValue(true),
+				HostKeyFingerprint: types.StringValue(testHostKeyFingerprint),
+				Sudo:               types.BoolValue(false),
+				SudoPassword:       types.StringValue("secret"),
+			},
+			wantPath:  "ssh.sudo_password",
+			wantError: "Conflicting SSH Configuration",
+		},
+		{
+			name: "bastion without host",
+			ssh: &SSHBlockModel{
+				UseAgent:           types.BoolValue(true),
+				HostKeyFingerprint: types.StringValue(testHostKeyFingerprint),
+				Bastion:            &SSHBastionModel{HostKeyFingerprint: types.StringValue(testHostKeyFingerprint)},
+			},
+			wantPath:  "ssh.bastion.host",
+			wantError: "Missing SSH Bastion Host",
+		},
+		{
+			name: "bastion without host key",
+			ssh: &SSHBlockModel{
+				UseAgent:           types.BoolValue(true),
+				HostKeyFingerprint: types.StringValue(testHostKeyFingerprint),
+				Bastion:            &SSHBastionModel{Host: types.StringValue("jump")},
+			},
+			wantPath:  "ssh.bastion.host_key_fingerprint",
+			wantError: "Missing SSH Bastion Host Key Fingerprint",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			if _, ok := sshTransportConfig(context.Background(), "nas.local", tt.ssh, &diags); ok {
+				t.Fatal("expected configuration to be rejected")
+			}
+			if diags.ErrorsCount() != 1 {
+				t.Fatalf("expected one error, got %v", diags)
+			}
+			d := diags.Errors()[0]
+			if d.Summary() != tt.wantError {
+				t.Errorf("expected %q, got %q", tt.wantError, d.Summary())
+			}
+			withPath, ok := d.(diag.DiagnosticWithPath)
+			if !ok || withPath.Path().String() != tt.wantPath {
+				t.Errorf("expected error at %s, got %v", tt.wantPath, d)
+			}
+		})
+	}
+}
+
+func TestSSHTransportConfig_SudoDefault(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     types.String
+		sudo     types.Bool
+		password types.String
+		want     bool
+	}{
+		{"default user", types.StringNull(), types.BoolNull(), types.StringNull(), false},
+		{"root", types.StringValue("root"), types.BoolNull(), types.StringNull(), false},
+		{"non-root", types.StringValue("terraform"), types.BoolNull(), types.StringNull(), true},
+		{"non-root opted out", types.StringValue("terraform"), types.BoolValue(false), types.StringNull(), false},
+		{"root opted in", types.StringValue("root"), types.BoolValue(true), types.StringNull(), true},
+		{"password", types.StringNull(), types.BoolNull(), types.StringValue("secret"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			cfg, ok := sshTransportConfig(context.Background(), "nas.local", &SSHBlockModel{
+				User:               tt.user,
+				UseAgent:           types.BoolValue(true),
+				HostKeyFingerprint: types.StringValue(testHostKeyFingerprint),
+				Sudo:               tt.sudo,
+				SudoPassword:       tt.password,
+			}, &diags)
+			if !ok {
+				t.Fatalf("unexpected errors: %v", diags)
+			}
+			if cfg.Sudo != tt.want {
+				t.Errorf("expected sudo %v, got %v", tt.want, cfg.Sudo)
+			}
+		})
+	}
+}
+
+func TestDefaultClientFactory_SSHSudo(t *testing.T) {
+	key, signer := fakenas.GenerateSSHKey(t)
+	srv := fakenas.NewServer(t)
+	sshd := srv.ServeSSH(t,
+		fakenas.WithAuthorizedKey(signer.PublicKey()),
+		fakenas.WithSSHUser("terraform"),
+		fakenas.WithSudoPassword("secret"),
+	)
+
+	var diags diag.Diagnostics
+	cfg, ok := sshTransportConfig(context.Background(), sshd.Host, &SSHBlockModel{
+		Port:               types.Int64Value(int64(sshd.Port)),
+		User:               types.StringValue("terraform"),
+		PrivateKey:         types.StringValue(key),
+		HostKeyFingerprint: types.StringValue(sshd.Fingerprint()),
+		SudoPassword:       types.StringValue("secret"),
+	}, &diags)
+	if !ok {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+
+	c, err := (&DefaultClientFactory{}).NewSSHClient(cfg)
+	if err != nil {
+		t.Fatalf("NewSSHClient: %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if err := c.MkdirAll(ctx, "/mnt/tank/app", 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := c.RemoveAll(ctx, "/mnt/tank/app"); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+}
+
+func TestDefaultClientFactory_SSHThroughBastion(t *testing.T) {
+	key, signer := fakenas.GenerateSSHKey(t)
+	srv := fakenas.NewServer(t)
+	sshd := srv.ServeSSH(t, fakenas.WithAuthorizedKey(signer.PublicKey()))
+	bastion := fakenas.NewBastion(t, fakenas.WithAuthorizedKey(signer.PublicKey()))
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(keyFile, []byte(key), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	// known_hosts vouches for both hops
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	known := knownhosts.Line([]string{net.JoinHostPort(sshd.Host, strconv.Itoa(sshd.Port))}, sshd.HostKey()) + "\n" +
+		knownhosts.Line([]string{net.JoinHostPort(bastion.Host, strconv.Itoa(bastion.Port))}, bastion.HostKey()) + "\n"
+	if err := os.WriteFile(knownHostsFile, []byte(known), 0o600); err != nil {
+		t.Fatalf("write known_hosts: %v", err)
+	}
+
+	var diags diag.Diagnostics
+	cfg, ok := sshTransportConfig(context.Background(), sshd.Host, &SSHBlockModel{
+		Port:           types.Int64Value(int64(sshd.Port)),
+		PrivateKeyFile: types.StringValue(keyFile),
+		KnownHostsFile: types.StringValue(knownHostsFile),
+		Bastion: &SSHBastionModel{
+			Host: types.StringValue(bastion.Host),
+			Port: types.Int64Value(int64(bastion.Port)),
+		},
+	}, &diags)
+	if !ok {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+
+	c, err := (&DefaultClientFactory{}).NewSSHClient(cfg)
+	if err != nil {
+		t.Fatalf("NewSSHClient: %v", err)
+	}
+	defer c.Close()
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if c.Version().Raw != srv.Version().Raw {
+		t.Errorf("expected version %s, got %s", srv.Version().Raw, c.Version().Raw)
+	}
+	if bastion.Tunnels() != 1 {
+		t.Errorf("expected the connection to go through the bastion, got %d tunnels", bastion.Tunnels())
+	}
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// websocketTLSConfig builds the TLS configuration of the websocket block. It
+// returns a nil config when only insecure_skip_verify, or nothing, is set,
+// and reports whether the TLS settings are valid.
+func websocketTLSConfig(ws *WebSocketBlockModel, diags *diag.Diagnostics) (*tls.Config, bool) {
+	wsPath := path.Root("websocket")
+
+	caCert := pemOrFile(ws.CACertPEM, ws.CACertFile, wsPath.AtName("ca_cert_pem"), wsPath.AtName("ca_cert_file"), diags)
+	clientCert := pemOrFile(ws.ClientCertPEM, ws.ClientCertFile, wsPath.AtName("client_cert_pem"), wsPath.AtName("client_cert_file"), diags)
+	clientKey := pemOrFile(ws.ClientKeyPEM, ws.ClientKeyFile, wsPath.AtName("client_key_pem"), wsPath.AtName("client_key_file"), diags)
+	if diags.HasError() {
+		return nil, false
+	}
+
+	fingerprint := ws.TLSFingerprint.ValueString()
+	if fingerprint != "" {
+		if _, err := transport.ParseFingerprint(fingerprint); err != nil {
+			diags.AddAttributeError(wsPath.AtName("tls_fingerprint"), "Invalid TLS Fingerprint", err.Error()+".")
+			return nil, false
+		}
+	}
+	if (clientCert == nil) != (clientKey == nil) {
+		diags.AddAttributeError(
+			wsPath.AtName("client_cert_pem"),
+			"Incomplete Client Certificate",
+			"A client certificate and its private key must be configured together.",
+		)
+		return nil, false
+	}
+
+	if caCert == nil && fingerprint == "" && clientCert == nil {
+		return nil, true
+	}
+	if ws.InsecureSkipVerify.ValueBool() && (caCert != nil || fingerprint != "") {
+		diags.AddAttributeError(
+			wsPath.AtName("insecure_skip_verify"),
+			"Conflicting TLS Configuration",
+			"insecure_skip_verify disables the verification that a CA certificate or tls_fingerprint configures. Remove one of them.",
+		)
+		return nil, false
+	}
+
+	tlsConfig, err := transport.NewTLSConfig(transport.TLSOptions{
+		InsecureSkipVerify: ws.InsecureSkipVerify.ValueBool(),
+		CACertPEM:          caCert,
+		Fingerprint:        fingerprint,
+		ClientCertPEM:      clientCert,
+		ClientKeyPEM:       clientKey,
+	})
+	if err != nil {
+		diags.AddAttributeError(wsPath, "Invalid TLS Configuration", err.Error()+".")
+		return nil, false
+	}
+	return tlsConfig, true
+}
+
+// pemOrFile returns PEM content given inline or as a file path, or nil when
+// neither is set. Setting both is an error.
+func pemOrFile(pemValue, fileValue types.String, pemPath, filePath path.Path, diags *diag.Diagnostics) []byte {
+	inline, file := pemValue.ValueString(), fileValue.ValueString()
+	switch {
+	case inline != "" && file != "":
+		diags.AddAttributeError(
+			filePath,
+			"Conflicting TLS Configuration",
+			fmt.Sprintf("Only one of %s and %s can be set.", pemPath, filePath),
+		)
+		return nil
+	case inline != "":
+		return []byte(inline)
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			diags.AddAttributeError(filePath, "Unable to Read TLS File", err.Error())
+			return nil
+		}
+		return data
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/fakenas"
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// fakeNASFingerprint returns the SHA-256 fingerprint of srv's certificate.
+func fakeNASFingerprint(srv *fakenas.Server) string {
+	block, _ := pem.Decode(srv.CertificatePEM())
+	sum := sha256.Sum256(block.Bytes)
+	return transport.FormatFingerprint(sum[:])
+}
+
+func TestWebSocketTLSConfig_NothingSet(t *testing.T) {
+	var diags diag.Diagnostics
+	cfg, ok := websocketTLSConfig(&WebSocketBlockModel{InsecureSkipVerify: types.BoolValue(true)}, &diags)
+	if !ok || diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if cfg != nil {
+		t.Error("expected no TLS config without TLS settings")
+	}
+}
+
+func TestWebSocketTLSConfig_Invalid(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.pem")
+	tests := []struct {
+		name      string
+		ws        *WebSocketBlockModel
+		wantPath  string
+		wantError string
+	}{
+		{
+			name:      "pem and file",
+			ws:        &WebSocketBlockModel{CACertPEM: types.StringValue("pem"), CACertFile: types.StringValue("/ca.pem")},
+			wantPath:  "websocket.ca_cert_file",
+			wantError: "Conflicting TLS Configuration",
+		},
+		{
+			name:      "unreadable file",
+			ws:        &WebSocketBlockModel{CACertFile: types.StringValue(missing)},
+			wantPath:  "websocket.ca_cert_file",
+			wantError: "Unable to Read TLS File",
+		},
+		{
+			name:      "bad fingerprint",
+			ws:        &WebSocketBlockModel{TLSFingerprint: types.StringValue("AB:CD")},
+			wantPath:  "websocket.tls_fingerprint",
+			wantError: "Invalid TLS Fingerprint",
+		},
+		{
+			name:      "insecure with pin",
+			ws:        &WebSocketBlockModel{InsecureSkipVerify: types.BoolValue(true), TLSFingerprint: types.StringValue(strings.Repeat("ab", 32))},
+			wantPath:  "websocket.insecure_skip_verify",
+			wantError: "Conflicting TLS Configuration",
+		},
+		{
+			name:      "certificate without key",
+			ws:        &WebSocketBlockModel{ClientCertPEM: types.StringValue("cert")},
+			wantPath:  "websocket.client_cert_pem",
+			wantError: "Incomplete Client Certificate",
+		},
+		{
+			name:      "ca without certificates",
+			ws:        &WebSocketBlockModel{CACertPEM: types.StringValue("not a certificate")},
+			wantPath:  "websocket",
+			wantError: "Invalid TLS Configuration",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			if _, ok := websocketTLSConfig(tt.ws, &diags); ok {
+				t.Fatal("expected configuration to be rejected")
+			}
+			if diags.ErrorsCount() != 1 {
+				t.Fatalf("expected one error, got %v", diags)
+			}
+			d := diags.Errors()[0]
+			if d.Summary() != tt.wantError {
+				t.Errorf("expected %q, got %q", tt.wantError, d.Summary())
+			}
+			withPath, ok := d.(diag.DiagnosticWithPath)
+			if !ok || withPath.Path().String() != tt.wantPath {
+				t.Errorf("expected error at %s, got %v", tt.wantPath, d)
+			}
+		})
+	}
+}
+
+func TestDefaultClientFactory_WebSocketTLS(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, srv.CertificatePEM(), 0o600); err != nil {
+		t.Fatalf("write CA file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		ws      *WebSocketBlockModel
+		wantErr string
+	}{
+		{"ca file", &WebSocketBlockModel{CACertFile: types.StringValue(caFile)}, ""},
+		{"ca pem and pin", &WebSocketBlockModel{CACertPEM: types.StringValue(string(srv.CertificatePEM())), TLSFingerprint: types.StringValue(fakeNASFingerprint(srv))}, ""},
+		{"pin only", &WebSocketBlockModel{TLSFingerprint: types.StringValue(fakeNASFingerprint(srv))}, ""},
+		{"wrong pin", &WebSocketBlockModel{TLSFingerprint: types.StringValue(strings.Repeat("00", 32))}, "does not match the pinned fingerprint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			tlsConfig, ok := websocketTLSConfig(tt.ws, &diags)
+			if !ok {
+				t.Fatalf("unexpected errors: %v", diags)
+			}
+
+			c, err := (&DefaultClientFactory{}).NewWebSocketClient(transport.WebSocketConfig{
+				Host:       srv.Host,
+				Port:       srv.Port,
+				Username:   srv.Username(),
+				APIKey:     srv.APIKey(),
+				TLSConfig:  tlsConfig,
+				MaxRetries: 1,
+			})
+			if err != nil {
+				t.Fatalf("NewWebSocketClient: %v", err)
+			}
+			defer c.Close()
+
+			err = c.Connect(context.Background())
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// validationLineRegex matches one line of a ValidationErrors message, as in
+// "[EINVAL] pool_dataset_create.quota: Must be greater than 1 MiB".
+var validationLineRegex = regexp.MustCompile(`^\[(E[A-Z]+)\]\s*([\w.-]+):\s*(.*)$`)
+
+// fieldError is one attribute of a middleware ValidationErrors error.
+type fieldError struct {
+	// Field is the middleware path of the attribute, for example
+	// "pool_dataset_create.quota".
+	Field   string
+	Message string
+	// Errno is the errno name, for example "EINVAL".
+	Errno string
+}
+
+// validationErrors returns the attributes of a middleware ValidationErrors
+// error. Over WebSocket they come from the extra member of the JSON-RPC error;
+// otherwise, as for SSH and job errors, they are parsed from the message.
+func validationErrors(err error) []fieldError {
+	var rpcErr *client.JSONRPCError
+	if errors.As(err, &rpcErr) && rpcErr.Data != nil && len(rpcErr.Data.Extra) > 0 {
+		var fields []fieldError
+		for _, x := range rpcErr.Data.Extra {
+			triple, ok := x.([]any)
+			if !ok || len(triple) < 2 {
+				continue
+			}
+			field, _ := triple[0].(string)
+			message, _ := triple[1].(string)
+			if field == "" {
+				continue
+			}
+			errno := "EINVAL"
+			if len(triple) > 2 {
+				if n, ok := triple[2].(float64); ok && services.ErrnoString(int(n)) != "" {
+					errno = services.ErrnoString(int(n))
+				}
+			}
+			fields = append(fields, fieldError{Field: field, Message: message, Errno: errno})
+		}
+		return fields
+	}
+
+	var tnErr *client.TrueNASError
+	if errors.As(err, &tnErr) && tnErr.Field != "" {
+		message := strings.TrimSpace(strings.TrimPrefix(tnErr.Message, tnErr.Field+":"))
+		if i := strings.IndexByte(message, '\n'); i >= 0 {
+			message = message[:i]
+		}
+		return []fieldError{{Field: tnErr.Field, Message: message, Errno: tnErr.Code}}
+	}
+
+	var fields []fieldError
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if m := validationLineRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			fields = append(fields, fieldError{Field: m[2], Message: m[3], Errno: m[1]})
+		}
+	}
+	return fields
+}
+
+// isNotFoundError reports whether err is the error of a call on an object
+// that does not exist: a services.ErrNotFound, or a middleware error with the
+// ENOENT errno. Messages are not matched, as an unrelated failure mentioning
+// "not found" would otherwise remove a resource from state.
+func isNotFoundError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, services.ErrNotFound) || services.ErrnoName(err) == "ENOENT"
+}
+
+// schemaPaths is the part of a resource schema used to resolve attribute
+// paths, as implemented by the Schema of tfsdk.State and tfsdk.Plan.
+type schemaPaths interface {
+	TypeAtPath(ctx context.Context, p path.Path) (attr.Type, diag.Diagnostics)
+}
+
+// addAPIError adds the error of a failed middleware call to diags. Each
+// attribute of a ValidationErrors error that matches an attribute of schema
+// is added as an attribute error, so that Terraform shows it on the
+// offending line of the configuration; anything else is added as a
+// resource-level error. detail describes the failed operation and is
+// followed by the middleware message.
+//
+// aliases maps middleware attribute names to schema attribute names where
+// they differ, for example "app_name" to "name".
+func (b *BaseResource) addAPIError(ctx context.Context, diags *diag.Diagnostics, schema schemaPaths, summary, detail string, err error, aliases map[string]string) {
+	fields := validationErrors(err)
+	if len(fields) == 0 || schema == nil {
+		diags.AddError(summary, detail+": "+err.Error())
+		return
+	}
+	for _, f := range fields {
+		if p, ok := attributePath(ctx, schema, f.Field, aliases); ok {
+			diags.AddAttributeError(p, summary, detail+": "+f.Message)
+		} else {
+			diags.AddError(summary, detail+": "+f.Field+": "+f.Message)
+		}
+	}
+}
+
+// attributePath resolves a middleware attribute path such as
+// "pool_dataset_create.quota" or "app_create.values.0.name" to the deepest
+// matching path of schema. The leading segment naming the call's argument
+// is dropped when it is not itself an attribute.
+func attributePath(ctx context.Context, schema schemaPaths, field string, aliases map[string]string) (path.Path, bool) {
+	segments := strings.Split(field, ".")
+	for start := 0; start < len(segments) && start < 2; start++ {
+		if p, ok := resolvePath(ctx, schema, segments[start:], aliases); ok {
+			return p, true
+		}
+	}
+	return path.Empty(), false
+}
+
+// resolvePath walks segments through schema, stopping at the first segment
+// that does not match. It reports false when the first segment does not
+// match a root attribute.
+func resolvePath(ctx context.Context, schema schemaPaths, segments []string, aliases map[string]string) (path.Path, bool) {
+	root := segments[0]
+	if alias, ok := aliases[root]; ok {
+		root = alias
+	}
+	p := path.Root(root)
+	if _, d := schema.TypeAtPath(ctx, p); d.HasError() {
+		return path.Empty(), false
+	}
+
+	for _, seg := range segments[1:] {
+		var candidates []path.Path
+		if n, err := strconv.Atoi(seg); err == nil {
+			candidates = append(candidates, p.AtListIndex(n))
+		} else {
+			candidates = append(candidates, p.AtName(seg))
+		}
+		candidates = append(candidates, p.AtMapKey(seg))
+
+		matched := false
+		for _, c := range candidates {
+			if _, d := schema.TypeAtPath(ctx, c); !d.HasError() {
+				p, matched = c, true
+				break
+			}
+		}
+		if !matched {
+			break
+		}
+	}
+	return p, true
+}
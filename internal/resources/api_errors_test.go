@@ -0,0 +1,180 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// validationRPCError returns the JSON-RPC error the middleware sends over
+// WebSocket for ValidationErrors, with extra decoded as encoding/json does.
+func validationRPCError(extra ...[]any) *client.JSONRPCError {
+	data := &client.JSONRPCData{Error: 22}
+	for _, x := range extra {
+		data.Extra = append(data.Extra, x)
+		data.Reason += fmt.Sprintf("[EINVAL] %s: %s\n", x[0], x[1])
+	}
+	return &client.JSONRPCError{Code: -32001, Message: "Method call error", Data: data}
+}
+
+func TestValidationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected []fieldError
+	}{
+		{
+			name: "websocket extra",
+			err: validationRPCError(
+				[]any{"pool_dataset_create.quota", "Must be at least 1 MiB", float64(22)},
+				[]any{"pool_dataset_create.name", "Dataset already exists", float64(17)},
+			),
+			expected: []fieldError{
+				{Field: "pool_dataset_create.quota", Message: "Must be at least 1 MiB", Errno: "EINVAL"},
+				{Field: "pool_dataset_create.name", Message: "Dataset already exists", Errno: "EEXIST"},
+			},
+		},
+		{
+			name: "wrapped job error",
+			err:  fmt.Errorf("create app: %w", client.ParseTrueNASError("[EINVAL] app_create.app_name: Invalid name")),
+			expected: []fieldError{
+				{Field: "app_create.app_name", Message: "Invalid name", Errno: "EINVAL"},
+			},
+		},
+		{
+			name: "message lines",
+			err:  errors.New("[EINVAL] cronjob_create.command: Field required\n[EINVAL] cronjob_create.user: Field required"),
+			expected: []fieldError{
+				{Field: "cronjob_create.command", Message: "Field required", Errno: "EINVAL"},
+				{Field: "cronjob_create.user", Message: "Field required", Errno: "EINVAL"},
+			},
+		},
+		{
+			name: "not a validation error",
+			err:  errors.New("connection refused"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := validationErrors(tc.err)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("validationErrors() = %#v, expected %#v", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestIsNotFoundError_Errno(t *testing.T) {
+	err := &client.JSONRPCError{Data: &client.JSONRPCData{Error: 2, Reason: "[ENOENT] tank/data"}}
+	if !isNotFoundError(fmt.Errorf("get dataset: %w", err)) {
+		t.Error("expected an ENOENT error to be a not-found error")
+	}
+	if isNotFoundError(client.ParseTrueNASError("[EINVAL] pool_dataset_create.quota: Invalid")) {
+		t.Error("expected an EINVAL error not to be a not-found error")
+	}
+}
+
+func TestAddAPIError(t *testing.T) {
+	ctx := context.Background()
+	datasetSchema := getDatasetResourceSchema(t).Schema
+	appSchema := getAppResourceSchema(t).Schema
+	virtSchema := getVirtInstanceResourceSchema(t).Schema
+	b := &BaseResource{}
+
+	tests := []struct {
+		name    string
+		schema  schemaPaths
+		err     error
+		aliases map[string]string
+		// paths lists the path of each diagnostic, empty for resource-level ones.
+		paths   []path.Path
+		details []string
+	}{
+		{
+			name:    "attribute",
+			schema:  datasetSchema,
+			err:     validationRPCError([]any{"pool_dataset_create.quota", "Must be at least 1 MiB", float64(22)}),
+			paths:   []path.Path{path.Root("quota")},
+			details: []string{"Unable to create resource: Must be at least 1 MiB"},
+		},
+		{
+			name:    "alias",
+			schema:  appSchema,
+			err:     client.ParseTrueNASError("[EINVAL] app_create.app_name: Invalid name"),
+			aliases: appAPIFields,
+			paths:   []path.Path{path.Root("name")},
+			details: []string{"Unable to create resource: Invalid name"},
+		},
+		{
+			name:    "list element",
+			schema:  virtSchema,
+			err:     validationRPCError([]any{"virt_instance_create.disk.1.source", "Path does not exist", float64(22)}),
+			paths:   []path.Path{path.Root("disk").AtListIndex(1).AtName("source")},
+			details: []string{"Unable to create resource: Path does not exist"},
+		},
+		{
+			name:    "nested attribute not in schema",
+			schema:  datasetSchema,
+			err:     validationRPCError([]any{"pool_dataset_create.quota.bytes", "Invalid", float64(22)}),
+			paths:   []path.Path{path.Root("quota")},
+			details: []string{"Unable to create resource: Invalid"},
+		},
+		{
+			name:   "unknown attribute",
+			schema: datasetSchema,
+			err: validationRPCError(
+				[]any{"pool_dataset_create.quota", "Must be at least 1 MiB", float64(22)},
+				[]any{"pool_dataset_create.encryption_options", "Invalid", float64(22)},
+			),
+			paths: []path.Path{path.Root("quota"), path.Empty()},
+			details: []string{
+				"Unable to create resource: Must be at least 1 MiB",
+				"Unable to create resource: pool_dataset_create.encryption_options: Invalid",
+			},
+		},
+		{
+			name:    "not a validation error",
+			schema:  datasetSchema,
+			err:     errors.New("connection refused"),
+			paths:   []path.Path{path.Empty()},
+			details: []string{"Unable to create resource: connection refused"},
+		},
+		{
+			name:    "no schema",
+			err:     validationRPCError([]any{"pool_dataset_create.quota", "Invalid", float64(22)}),
+			paths:   []path.Path{path.Empty()},
+			details: []string{"Unable to create resource: [EINVAL] pool_dataset_create.quota: Invalid\n"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			b.addAPIError(ctx, &diags, tc.schema, "Unable to Create Resource", "Unable to create resource", tc.err, tc.aliases)
+
+			if len(diags) != len(tc.paths) {
+				t.Fatalf("expected %d diagnostics, got %d: %v", len(tc.paths), len(diags), diags)
+			}
+			for i, d := range diags {
+				if d.Summary() != "Unable to Create Resource" {
+					t.Errorf("diagnostic %d: unexpected summary %q", i, d.Summary())
+				}
+				if d.Detail() != tc.details[i] {
+					t.Errorf("diagnostic %d: expected detail %q, got %q", i, tc.details[i], d.Detail())
+				}
+				got := path.Empty()
+				if withPath, ok := d.(diag.DiagnosticWithPath); ok {
+					got = withPath.Path()
+				}
+				if !got.Equal(tc.paths[i]) {
+					t.Errorf("diagnostic %d: expected path %q, got %q", i, tc.paths[i], got)
+				}
+			}
+		})
+	}
+}
@@ -2,13 +2,18 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
 	customtypes "github.com/deevus/terraform-provider-truenas/internal/types"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	listschema "github.com/hashicorp/terraform-plugin-framework/list/schema"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -23,6 +28,9 @@ import (
 var _ resource.Resource = &AppResource{}
 var _ resource.ResourceWithConfigure = &AppResource{}
 var _ resource.ResourceWithImportState = &AppResource{}
+var _ resource.ResourceWithModifyPlan = &AppResource{}
+var _ resource.ResourceWithIdentity = &AppResource{}
+var _ list.ListResourceWithConfigure = &AppResource{}
 
 // AppResource defines the resource implementation.
 type AppResource struct {
@@ -40,6 +48,14 @@ type AppResourceModel struct {
 	StateTimeout    types.Int64                             `tfsdk:"state_timeout"`
 	State           types.String                            `tfsdk:"state"`
 	RestartTriggers types.Map                               `tfsdk:"restart_triggers"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
+}
+
+// appAPIFields maps app.create and app.update attributes to the schema.
+var appAPIFields = map[string]string{
+	"app_name":                     "name",
+	"custom_compose_config_string": "compose_config",
 }
 
 // NewAppResource creates a new AppResource.
@@ -47,10 +63,20 @@ func NewAppResource() resource.Resource {
 	return &AppResource{}
 }
 
+// NewAppListResource creates an AppResource for listing apps.
+func NewAppListResource() list.ListResource {
+	return &AppResource{}
+}
+
 func (r *AppResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_app"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply.
+func (r *AppResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_app", req, resp)
+}
+
 func (r *AppResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages a TrueNAS application (custom Docker Compose app or catalog app).",
@@ -114,11 +140,21 @@ func (r *AppResource) Schema(ctx context.Context, req resource.SchemaRequest, re
 				ElementType: types.StringType,
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.BlockAll(ctx),
+		},
 	}
 }
 
+func (r *AppResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = nameIdentitySchema("App name.")
+}
 
 func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data AppResourceModel
 
 	// Read Terraform plan data into the model
@@ -127,6 +163,12 @@ func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, re
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Build create opts
 	opts := r.buildCreateOpts(ctx, &data)
 	appName := data.Name.ValueString()
@@ -134,10 +176,8 @@ func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, re
 	// Call the TrueNAS API (CreateApp handles CallAndWait + GetApp internally)
 	app, err := r.services.App.CreateApp(ctx, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create App",
-			fmt.Sprintf("Unable to create app %q: %s", appName, err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create App",
+			fmt.Sprintf("Unable to create app %q", appName), err, appAPIFields)
 		return
 	}
 
@@ -177,11 +217,7 @@ func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, re
 		}
 
 		// Wait for stable state and query final state
-		queryFunc := func(ctx context.Context, n string) (string, error) {
-			return r.queryAppState(ctx, n)
-		}
-
-		finalState, err := waitForStableState(ctx, appName, timeout, queryFunc)
+		finalState, err := r.waitForAppState(ctx, appName, timeout)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Timeout Waiting for App State",
@@ -201,6 +237,7 @@ func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, re
 
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, nameIdentity{Name: data.Name}, &resp.Diagnostics)
 }
 
 func (r *AppResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -212,6 +249,12 @@ func (r *AppResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Preserve user-specified values from prior state (these are not returned by API)
 	priorDesiredState := data.DesiredState
 	priorStateTimeout := data.StateTimeout
@@ -219,10 +262,15 @@ func (r *AppResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 
 	// Use the name to query the app
 	appName := data.Name.ValueString()
+	setIdentity(ctx, resp.Identity, nameIdentity{Name: data.Name}, &resp.Diagnostics)
 
 	// Call the TrueNAS API with config retrieval
 	app, err := r.services.App.GetAppWithConfig(ctx, appName)
 	if err != nil {
+		if isNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Read App",
 			fmt.Sprintf("Unable to read app %q: %s", appName, err.Error()),
@@ -278,6 +326,10 @@ func (r *AppResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 }
 
 func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var data AppResourceModel
 	var stateData AppResourceModel
 
@@ -287,6 +339,12 @@ func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Read current state data to detect compose_config changes
 	resp.Diagnostics.Append(req.State.Get(ctx, &stateData)...)
 	if resp.Diagnostics.HasError() {
@@ -304,10 +362,8 @@ func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		// Call app.update and wait for completion
 		_, err := r.services.App.UpdateApp(ctx, appName, updateOpts)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Update App",
-				fmt.Sprintf("Unable to update app %q: %s", appName, err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update App",
+				fmt.Sprintf("Unable to update app %q", appName), err, appAPIFields)
 			return
 		}
 	}
@@ -334,11 +390,7 @@ func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, re
 
 	// Wait for transitional states to complete before reconciling
 	if !isStableState(currentState) {
-		queryFunc := func(ctx context.Context, n string) (string, error) {
-			return r.queryAppState(ctx, n)
-		}
-
-		stableState, err := waitForStableState(ctx, appName, timeout, queryFunc)
+		stableState, err := r.waitForAppState(ctx, appName, timeout)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Timeout Waiting for App State",
@@ -371,11 +423,7 @@ func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, re
 		}
 
 		// Wait for stable state after restart
-		queryFunc := func(ctx context.Context, n string) (string, error) {
-			return r.queryAppState(ctx, n)
-		}
-
-		stableState, err := waitForStableState(ctx, appName, timeout, queryFunc)
+		stableState, err := r.waitForAppState(ctx, appName, timeout)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Timeout Waiting for App State After Restart",
@@ -420,9 +468,14 @@ func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, re
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, nameIdentity{Name: data.Name}, &resp.Diagnostics)
 }
 
 func (r *AppResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data AppResourceModel
 
 	// Read Terraform prior state data into the model
@@ -431,10 +484,19 @@ func (r *AppResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Call the TrueNAS API
 	appName := data.Name.ValueString()
 	err := r.services.App.DeleteApp(ctx, appName)
 	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Delete App",
 			fmt.Sprintf("Unable to delete app %q: %s", appName, err.Error()),
@@ -444,11 +506,39 @@ func (r *AppResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 }
 
 func (r *AppResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if !resolveImportID(ctx, &req, &nameIdentity{}, &resp.Diagnostics) {
+		return
+	}
 	// The import ID is the app name - set it to both id and name attributes
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), req.ID)...)
 }
 
+func (r *AppResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = listschema.Schema{
+		Description: "Lists the apps on TrueNAS.",
+	}
+}
+
+// List lists every app, named by its name.
+func (r *AppResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	apps, err := r.services.App.ListApps(ctx)
+	if err != nil {
+		listError(stream, "Unable to List Apps", fmt.Sprintf("Unable to list apps: %s", err.Error()))
+		return
+	}
+
+	entries := make([]listEntry, len(apps))
+	for i, app := range apps {
+		entries[i] = listEntry{
+			ImportID:    app.Name,
+			DisplayName: app.Name,
+			Identity:    nameIdentity{Name: types.StringValue(app.Name)},
+		}
+	}
+	stream.Results = listResults(ctx, r, req, entries)
+}
+
 // buildCreateOpts builds the CreateAppOpts from the model.
 func (r *AppResource) buildCreateOpts(_ context.Context, data *AppResourceModel) truenas.CreateAppOpts {
 	opts := truenas.CreateAppOpts{
@@ -535,11 +625,7 @@ func (r *AppResource) reconcileDesiredState(
 	}
 
 	// Wait for stable state
-	queryFunc := func(ctx context.Context, n string) (string, error) {
-		return r.queryAppState(ctx, n)
-	}
-
-	finalState, err := waitForStableState(ctx, name, timeout, queryFunc)
+	finalState, err := r.waitForAppState(ctx, name, timeout)
 	if err != nil {
 		return err
 	}
@@ -2,15 +2,21 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -18,16 +24,22 @@ var (
 	_ resource.Resource                = &AppRegistryResource{}
 	_ resource.ResourceWithConfigure   = &AppRegistryResource{}
 	_ resource.ResourceWithImportState = &AppRegistryResource{}
+	_ resource.ResourceWithModifyPlan  = &AppRegistryResource{}
+	_ resource.ResourceWithIdentity    = &AppRegistryResource{}
 )
 
 // AppRegistryResourceModel describes the resource data model.
 type AppRegistryResourceModel struct {
-	ID          types.String `tfsdk:"id"`
-	Name        types.String `tfsdk:"name"`
-	Description types.String `tfsdk:"description"`
-	Username    types.String `tfsdk:"username"`
-	Password    types.String `tfsdk:"password"`
-	URI         types.String `tfsdk:"uri"`
+	ID                types.String `tfsdk:"id"`
+	Name              types.String `tfsdk:"name"`
+	Description       types.String `tfsdk:"description"`
+	Username          types.String `tfsdk:"username"`
+	Password          types.String `tfsdk:"password"`
+	PasswordWO        types.String `tfsdk:"password_wo"`
+	PasswordWOVersion types.Int64  `tfsdk:"password_wo_version"`
+	URI               types.String `tfsdk:"uri"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // AppRegistryResource defines the resource implementation.
@@ -44,6 +56,11 @@ func (r *AppRegistryResource) Metadata(ctx context.Context, req resource.Metadat
 	resp.TypeName = req.ProviderTypeName + "_app_registry"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply.
+func (r *AppRegistryResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_app_registry", req, resp)
+}
+
 func (r *AppRegistryResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages Docker registry credentials for pulling images from private container registries.",
@@ -70,10 +87,15 @@ func (r *AppRegistryResource) Schema(ctx context.Context, req resource.SchemaReq
 				Required:    true,
 			},
 			"password": schema.StringAttribute{
-				Description: "Registry password or token.",
-				Required:    true,
+				Description: "Registry password or token. Exactly one of password and password_wo must be set.",
+				Optional:    true,
 				Sensitive:   true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("password_wo")),
+				},
 			},
+			"password_wo":         writeOnlyAttribute("password", "Registry password or token."),
+			"password_wo_version": writeOnlyVersionAttribute("password"),
 			"uri": schema.StringAttribute{
 				Description: "Registry URL.",
 				Optional:    true,
@@ -81,11 +103,21 @@ func (r *AppRegistryResource) Schema(ctx context.Context, req resource.SchemaReq
 				Default:     stringdefault.StaticString("https://index.docker.io/v1/"),
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.BlockAll(ctx),
+		},
 	}
 }
 
+func (r *AppRegistryResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = numericIdentitySchema("App registry ID.")
+}
 
 func (r *AppRegistryResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data AppRegistryResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
@@ -93,20 +125,30 @@ func (r *AppRegistryResource) Create(ctx context.Context, req resource.CreateReq
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.PasswordWO = writeOnlyValue(ctx, req.Config, path.Root("password_wo"), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	opts := buildRegistryOpts(&data)
 
 	reg, err := r.services.App.CreateRegistry(ctx, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create App Registry",
-			fmt.Sprintf("Unable to create app registry: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create App Registry",
+			"Unable to create app registry", err, nil)
 		return
 	}
 
 	mapAppRegistryToModel(reg, &data)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(reg.ID)}, &resp.Diagnostics)
 }
 
 func (r *AppRegistryResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -117,6 +159,12 @@ func (r *AppRegistryResource) Read(ctx context.Context, req resource.ReadRequest
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	id, err := strconv.ParseInt(data.ID.ValueString(), 10, 64)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -125,9 +173,14 @@ func (r *AppRegistryResource) Read(ctx context.Context, req resource.ReadRequest
 		)
 		return
 	}
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(id)}, &resp.Diagnostics)
 
 	reg, err := r.services.App.GetRegistry(ctx, id)
 	if err != nil {
+		if isNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Read App Registry",
 			fmt.Sprintf("Unable to query app registry: %s", err.Error()),
@@ -147,6 +200,10 @@ func (r *AppRegistryResource) Read(ctx context.Context, req resource.ReadRequest
 }
 
 func (r *AppRegistryResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var state AppRegistryResourceModel
 	var plan AppRegistryResourceModel
 
@@ -156,6 +213,12 @@ func (r *AppRegistryResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, plan.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Parse ID from state
 	id, err := strconv.ParseInt(state.ID.ValueString(), 10, 64)
 	if err != nil {
@@ -166,23 +229,31 @@ func (r *AppRegistryResource) Update(ctx context.Context, req resource.UpdateReq
 		return
 	}
 
+	plan.PasswordWO = writeOnlyValue(ctx, req.Config, path.Root("password_wo"), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	opts := buildRegistryOpts(&plan)
 
 	reg, err := r.services.App.UpdateRegistry(ctx, id, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Update App Registry",
-			fmt.Sprintf("Unable to update app registry: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update App Registry",
+			"Unable to update app registry", err, nil)
 		return
 	}
 
 	mapAppRegistryToModel(reg, &plan)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(reg.ID)}, &resp.Diagnostics)
 }
 
 func (r *AppRegistryResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data AppRegistryResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -190,6 +261,12 @@ func (r *AppRegistryResource) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	id, err := strconv.ParseInt(data.ID.ValueString(), 10, 64)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -201,6 +278,9 @@ func (r *AppRegistryResource) Delete(ctx context.Context, req resource.DeleteReq
 
 	err = r.services.App.DeleteRegistry(ctx, id)
 	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Delete App Registry",
 			fmt.Sprintf("Unable to delete app registry: %s", err.Error()),
@@ -209,13 +289,32 @@ func (r *AppRegistryResource) Delete(ctx context.Context, req resource.DeleteReq
 	}
 }
 
+// ImportState imports a registry by ID or by "name:<name>".
+func (r *AppRegistryResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	r.importByLookup(ctx, req, resp, "app registry", map[string]importLookup{
+		"name": func(ctx context.Context, name string) ([]int64, error) {
+			registries, err := r.services.App.ListRegistries(ctx)
+			if err != nil {
+				return nil, err
+			}
+			var ids []int64
+			for _, reg := range registries {
+				if reg.Name == name {
+					ids = append(ids, reg.ID)
+				}
+			}
+			return ids, nil
+		},
+	})
+}
+
 // buildRegistryOpts builds CreateRegistryOpts from the resource model.
 func buildRegistryOpts(data *AppRegistryResourceModel) truenas.CreateRegistryOpts {
 	return truenas.CreateRegistryOpts{
 		Name:        data.Name.ValueString(),
 		Description: data.Description.ValueString(),
 		Username:    data.Username.ValueString(),
-		Password:    data.Password.ValueString(),
+		Password:    secretValue(data.Password, data.PasswordWO),
 		URI:         data.URI.ValueString(),
 	}
 }
@@ -226,6 +325,10 @@ func mapAppRegistryToModel(registry *truenas.Registry, data *AppRegistryResource
 	data.Name = types.StringValue(registry.Name)
 	data.Description = types.StringValue(registry.Description)
 	data.Username = types.StringValue(registry.Username)
-	data.Password = types.StringValue(registry.Password)
+	// A write-only password must not be written to state, so it is not
+	// checked for drift either
+	if data.PasswordWOVersion.IsNull() {
+		data.Password = types.StringValue(registry.Password)
+	}
 	data.URI = types.StringValue(registry.URI)
 }
@@ -152,6 +152,9 @@ type appRegistryModelParams struct {
 	Username    interface{}
 	Password    interface{}
 	URI         interface{}
+
+	PasswordWO        interface{}
+	PasswordWOVersion interface{}
 }
 
 func createAppRegistryModelValue(p appRegistryModelParams) tftypes.Value {
@@ -163,6 +166,11 @@ func createAppRegistryModelValue(p appRegistryModelParams) tftypes.Value {
 		"username":    tftypes.NewValue(tftypes.String, p.Username),
 		"password":    tftypes.NewValue(tftypes.String, p.Password),
 		"uri":         tftypes.NewValue(tftypes.String, p.URI),
+
+		"password_wo":         tftypes.NewValue(tftypes.String, p.PasswordWO),
+		"password_wo_version": tftypes.NewValue(tftypes.Number, p.PasswordWOVersion),
+
+		"timeouts": nullTimeouts(),
 	}
 
 	// Create object type matching the schema
@@ -174,6 +182,11 @@ func createAppRegistryModelValue(p appRegistryModelParams) tftypes.Value {
 			"username":    tftypes.String,
 			"password":    tftypes.String,
 			"uri":         tftypes.String,
+
+			"password_wo":         tftypes.String,
+			"password_wo_version": tftypes.Number,
+
+			"timeouts": timeoutsType,
 		},
 	}
 
@@ -848,6 +861,44 @@ func TestAppRegistryResource_Delete_APIError(t *testing.T) {
 	}
 }
 
+func TestAppRegistryResource_Delete_NotFound(t *testing.T) {
+	r := &AppRegistryResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			App: &truenas.MockAppService{
+				DeleteRegistryFunc: func(ctx context.Context, id int64) error {
+					return &services.NotFoundError{Err: errors.New("[ENOENT] Registry 1 is gone")}
+				},
+			},
+		}},
+	}
+
+	schemaResp := getAppRegistryResourceSchema(t)
+	stateValue := createAppRegistryModelValue(appRegistryModelParams{
+		ID:          "1",
+		Name:        "test",
+		Description: "",
+		Username:    "user",
+		Password:    "pass",
+		URI:         "https://example.com",
+	})
+
+	req := resource.DeleteRequest{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    stateValue,
+		},
+	}
+
+	resp := &resource.DeleteResponse{}
+
+	r.Delete(context.Background(), req, resp)
+
+	// An object deleted outside Terraform is already gone
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+}
+
 func TestAppRegistryResource_Delete_InvalidID(t *testing.T) {
 	r := &AppRegistryResource{
 		BaseResource: BaseResource{services: &services.TrueNASServices{
@@ -935,3 +986,126 @@ func TestAppRegistryResource_Read_NullDescription(t *testing.T) {
 		t.Errorf("expected description empty for null, got %q", resultData.Description.ValueString())
 	}
 }
+
+func TestAppRegistryResource_Create_WriteOnlyPassword(t *testing.T) {
+	var capturedOpts truenas.CreateRegistryOpts
+
+	r := &AppRegistryResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			App: &truenas.MockAppService{
+				CreateRegistryFunc: func(ctx context.Context, opts truenas.CreateRegistryOpts) (*truenas.Registry, error) {
+					capturedOpts = opts
+					return &truenas.Registry{
+						ID:       1,
+						Name:     "ghcr",
+						Username: "github-user",
+						Password: opts.Password,
+						URI:      "https://ghcr.io",
+					}, nil
+				},
+			},
+		}},
+	}
+
+	schemaResp := getAppRegistryResourceSchema(t)
+	params := appRegistryModelParams{
+		Name:              "ghcr",
+		Description:       "",
+		Username:          "github-user",
+		URI:               "https://ghcr.io",
+		PasswordWOVersion: 1,
+	}
+	planValue := createAppRegistryModelValue(params)
+	params.PasswordWO = "ghp_token123"
+	configValue := createAppRegistryModelValue(params)
+
+	req := resource.CreateRequest{
+		Plan: tfsdk.Plan{
+			Schema: schemaResp.Schema,
+			Raw:    planValue,
+		},
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw:    configValue,
+		},
+	}
+
+	resp := &resource.CreateResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+		},
+	}
+
+	r.Create(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+
+	if capturedOpts.Password != "ghp_token123" {
+		t.Errorf("expected password 'ghp_token123', got %q", capturedOpts.Password)
+	}
+
+	var resultData AppRegistryResourceModel
+	resp.State.Get(context.Background(), &resultData)
+	if !resultData.Password.IsNull() {
+		t.Errorf("expected password to be null, got %q", resultData.Password.ValueString())
+	}
+	if resultData.PasswordWOVersion.ValueInt64() != 1 {
+		t.Errorf("expected password_wo_version 1, got %d", resultData.PasswordWOVersion.ValueInt64())
+	}
+}
+
+func TestAppRegistryResource_Read_WriteOnlyPassword(t *testing.T) {
+	r := &AppRegistryResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			App: &truenas.MockAppService{
+				GetRegistryFunc: func(ctx context.Context, id int64) (*truenas.Registry, error) {
+					return &truenas.Registry{
+						ID:       1,
+						Name:     "ghcr",
+						Username: "github-user",
+						Password: "rotated-outside-terraform",
+						URI:      "https://ghcr.io",
+					}, nil
+				},
+			},
+		}},
+	}
+
+	schemaResp := getAppRegistryResourceSchema(t)
+	stateValue := createAppRegistryModelValue(appRegistryModelParams{
+		ID:                "1",
+		Name:              "ghcr",
+		Description:       "",
+		Username:          "github-user",
+		URI:               "https://ghcr.io",
+		PasswordWOVersion: 1,
+	})
+
+	req := resource.ReadRequest{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    stateValue,
+		},
+	}
+
+	resp := &resource.ReadResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+		},
+	}
+
+	r.Read(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+
+	// The password must not be read back into state
+	var resultData AppRegistryResourceModel
+	resp.State.Get(context.Background(), &resultData)
+	if !resultData.Password.IsNull() {
+		t.Errorf("expected password to be null, got %q", resultData.Password.ValueString())
+	}
+}
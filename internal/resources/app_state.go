@@ -41,15 +41,30 @@ func isValidDesiredState(state string) bool {
 // stateQueryFunc is a function type for querying app state.
 type stateQueryFunc func(ctx context.Context, name string) (string, error)
 
-// waitForStableState polls until the app reaches a stable state or timeout.
-// Returns the final state or an error if timeout is reached.
-func waitForStableState(ctx context.Context, name string, timeout time.Duration, queryState stateQueryFunc) (string, error) {
+// waitForStableState waits until the app reaches a stable state or timeout,
+// and returns the final state or an error if timeout is reached. states,
+// when not nil, delivers the states reported by change events (see
+// watchStates), and the state is then only queried every eventPollInterval
+// in case an event was missed. Otherwise the state is polled.
+func waitForStableState(ctx context.Context, name string, timeout time.Duration, queryState stateQueryFunc, states <-chan string) (string, error) {
 	const pollInterval = 5 * time.Second
 
 	deadline := time.Now().Add(timeout)
 
+	interval := func() time.Duration {
+		d := pollInterval
+		if states != nil {
+			d = eventPollInterval
+		}
+		// For testing, use shorter interval if timeout is very short
+		if timeout < d {
+			d = timeout / 10
+		}
+		return d
+	}
+
+	state, err := queryState(ctx, name)
 	for {
-		state, err := queryState(ctx, name)
 		if err != nil {
 			return "", fmt.Errorf("failed to query app state: %w", err)
 		}
@@ -62,17 +77,19 @@ func waitForStableState(ctx context.Context, name string, timeout time.Duration,
 			return "", fmt.Errorf("timeout waiting for app state: app %q is stuck in %s state after %v", name, state, timeout)
 		}
 
-		// For testing, use shorter interval if timeout is very short
-		sleepDuration := pollInterval
-		if timeout < pollInterval {
-			sleepDuration = timeout / 10
-		}
-
 		select {
 		case <-ctx.Done():
 			return "", ctx.Err()
-		case <-time.After(sleepDuration):
-			// Continue polling
+		case event, ok := <-states:
+			if ok {
+				state = event
+				continue
+			}
+			// The subscription ended, so fall back to polling
+			states = nil
+		case <-time.After(min(interval(), time.Until(deadline))):
 		}
+
+		state, err = queryState(ctx, name)
 	}
 }
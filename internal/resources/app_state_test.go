@@ -80,7 +80,7 @@ func TestWaitForStableState_AlreadyStable(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	state, err := waitForStableState(ctx, "myapp", 30*time.Second, queryFunc)
+	state, err := waitForStableState(ctx, "myapp", 30*time.Second, queryFunc, nil)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -104,7 +104,7 @@ func TestWaitForStableState_TransitionsToStable(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	state, err := waitForStableState(ctx, "myapp", 30*time.Second, queryFunc)
+	state, err := waitForStableState(ctx, "myapp", 30*time.Second, queryFunc, nil)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -123,7 +123,7 @@ func TestWaitForStableState_Timeout(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	_, err := waitForStableState(ctx, "myapp", 100*time.Millisecond, queryFunc)
+	_, err := waitForStableState(ctx, "myapp", 100*time.Millisecond, queryFunc, nil)
 
 	if err == nil {
 		t.Fatal("expected timeout error")
@@ -143,7 +143,7 @@ func TestWaitForStableState_ContextCancelled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately before first poll
 
-	_, err := waitForStableState(ctx, "myapp", 30*time.Second, queryFunc)
+	_, err := waitForStableState(ctx, "myapp", 30*time.Second, queryFunc, nil)
 
 	if err == nil {
 		t.Fatal("expected context cancellation error")
@@ -169,7 +169,7 @@ func TestWaitForStableState_ShortTimeoutUsesShortPollInterval(t *testing.T) {
 	timeout := 100 * time.Millisecond
 
 	start := time.Now()
-	state, err := waitForStableState(ctx, "myapp", timeout, queryFunc)
+	state, err := waitForStableState(ctx, "myapp", timeout, queryFunc, nil)
 	elapsed := time.Since(start)
 
 	if err != nil {
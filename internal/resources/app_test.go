@@ -159,6 +159,7 @@ func newAppModelValue(p appModelParams) tftypes.Value {
 			"state_timeout":    tftypes.Number,
 			"state":            tftypes.String,
 			"restart_triggers": tftypes.Map{ElementType: tftypes.String},
+			"timeouts":         timeoutsType,
 		},
 	}, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, p.ID),
@@ -169,6 +170,7 @@ func newAppModelValue(p appModelParams) tftypes.Value {
 		"state_timeout":    tftypes.NewValue(tftypes.Number, p.StateTimeout),
 		"state":            tftypes.NewValue(tftypes.String, p.State),
 		"restart_triggers": triggersValue,
+		"timeouts":         nullTimeouts(),
 	})
 }
 
@@ -2,10 +2,13 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/deevus/truenas-go/client"
+	"github.com/deevus/terraform-provider-truenas/internal/capabilities"
 	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 )
@@ -43,3 +46,48 @@ func (b *BaseResource) Configure(ctx context.Context, req resource.ConfigureRequ
 func (b *BaseResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
+
+// checkWritable adds an error diagnostic and returns false when the provider
+// is read-only, so that applies fail before any call is made. operation is
+// the verb of the refused change, such as "create".
+func (b *BaseResource) checkWritable(diags *diag.Diagnostics, operation string) bool {
+	if b.services == nil || !b.services.ReadOnly {
+		return true
+	}
+	diags.AddError(
+		"Provider Is Read-Only",
+		fmt.Sprintf("The provider is configured with read_only = true and cannot %s resources. "+
+			"Plans, refreshes, data sources and imports keep working; set read_only = false to apply changes.", operation),
+	)
+	return false
+}
+
+// checkCapabilities reports, at plan time, when the detected TrueNAS version
+// does not support the resource typeName or one of its configured attributes.
+// Destroy plans are not checked so that unsupported resources can be removed.
+func (b *BaseResource) checkCapabilities(typeName string, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	version := b.services.Version()
+	if !capabilities.Check(typeName, version, &resp.Diagnostics) {
+		return
+	}
+	capabilities.CheckAttributes(typeName, version, req.Config.Raw, &resp.Diagnostics)
+}
+
+// addShellRequiredError adds a diagnostic explaining that an operation needs
+// SSH when err reports an operation the configured transport cannot perform
+// (WebSocket without an ssh block). It reports whether a diagnostic was added.
+func addShellRequiredError(diags *diag.Diagnostics, resourceType, operation string, err error) bool {
+	if !errors.Is(err, client.ErrUnsupportedOperation) {
+		return false
+	}
+	diags.AddError(
+		"Operation Requires SSH",
+		fmt.Sprintf("%s cannot %s over the TrueNAS API alone: %s. "+
+			"Add an ssh block to the provider configuration so the WebSocket transport can use it for shell operations.",
+			resourceType, operation, err.Error()),
+	)
+	return true
+}
@@ -2,9 +2,14 @@ package resources
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
@@ -116,3 +121,29 @@ func importStateTestSchema() schema.Schema {
 		},
 	}
 }
+
+func TestAddShellRequiredError_UnsupportedOperation(t *testing.T) {
+	var diags diag.Diagnostics
+	err := fmt.Errorf("DeleteFile requires SSH fallback client: %w", client.ErrUnsupportedOperation)
+
+	if !addShellRequiredError(&diags, "truenas_file", "delete files", err) {
+		t.Fatal("expected diagnostic to be added")
+	}
+	if !diags.HasError() {
+		t.Fatal("expected error diagnostic")
+	}
+	if !strings.Contains(diags[0].Detail(), "truenas_file") || !strings.Contains(diags[0].Detail(), "ssh block") {
+		t.Errorf("expected detail to name the resource and the ssh block, got %q", diags[0].Detail())
+	}
+}
+
+func TestAddShellRequiredError_OtherError(t *testing.T) {
+	var diags diag.Diagnostics
+
+	if addShellRequiredError(&diags, "truenas_file", "delete files", errors.New("permission denied")) {
+		t.Fatal("expected no diagnostic for unrelated errors")
+	}
+	if diags.HasError() {
+		t.Fatal("expected no diagnostics")
+	}
+}
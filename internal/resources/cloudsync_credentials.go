@@ -2,20 +2,28 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var _ resource.Resource = &CloudSyncCredentialsResource{}
 var _ resource.ResourceWithConfigure = &CloudSyncCredentialsResource{}
 var _ resource.ResourceWithImportState = &CloudSyncCredentialsResource{}
+var _ resource.ResourceWithModifyPlan = &CloudSyncCredentialsResource{}
+var _ resource.ResourceWithIdentity = &CloudSyncCredentialsResource{}
 
 // CloudSyncCredentialsResourceModel describes the resource data model.
 type CloudSyncCredentialsResourceModel struct {
@@ -26,39 +34,51 @@ type CloudSyncCredentialsResourceModel struct {
 	GCS    *GCSBlock    `tfsdk:"gcs"`
 	Azure  *AzureBlock  `tfsdk:"azure"`
 	WebDAV *WebDAVBlock `tfsdk:"webdav"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // S3Block represents S3 credentials.
 type S3Block struct {
-	AccessKeyID     types.String `tfsdk:"access_key_id"`
-	SecretAccessKey types.String `tfsdk:"secret_access_key"`
-	Endpoint        types.String `tfsdk:"endpoint"`
-	Region          types.String `tfsdk:"region"`
+	AccessKeyID              types.String `tfsdk:"access_key_id"`
+	SecretAccessKey          types.String `tfsdk:"secret_access_key"`
+	SecretAccessKeyWO        types.String `tfsdk:"secret_access_key_wo"`
+	SecretAccessKeyWOVersion types.Int64  `tfsdk:"secret_access_key_wo_version"`
+	Endpoint                 types.String `tfsdk:"endpoint"`
+	Region                   types.String `tfsdk:"region"`
 }
 
 // B2Block represents Backblaze B2 credentials.
 type B2Block struct {
-	Account types.String `tfsdk:"account"`
-	Key     types.String `tfsdk:"key"`
+	Account      types.String `tfsdk:"account"`
+	Key          types.String `tfsdk:"key"`
+	KeyWO        types.String `tfsdk:"key_wo"`
+	KeyWOVersion types.Int64  `tfsdk:"key_wo_version"`
 }
 
 // GCSBlock represents Google Cloud Storage credentials.
 type GCSBlock struct {
-	ServiceAccountCredentials types.String `tfsdk:"service_account_credentials"`
+	ServiceAccountCredentials          types.String `tfsdk:"service_account_credentials"`
+	ServiceAccountCredentialsWO        types.String `tfsdk:"service_account_credentials_wo"`
+	ServiceAccountCredentialsWOVersion types.Int64  `tfsdk:"service_account_credentials_wo_version"`
 }
 
 // AzureBlock represents Azure Blob Storage credentials.
 type AzureBlock struct {
-	Account types.String `tfsdk:"account"`
-	Key     types.String `tfsdk:"key"`
+	Account      types.String `tfsdk:"account"`
+	Key          types.String `tfsdk:"key"`
+	KeyWO        types.String `tfsdk:"key_wo"`
+	KeyWOVersion types.Int64  `tfsdk:"key_wo_version"`
 }
 
 // WebDAVBlock represents WebDAV Storage credentials.
 type WebDAVBlock struct {
-	URL      types.String `tfsdk:"url"`
-	Vendor   types.String `tfsdk:"vendor"`
-	User     types.String `tfsdk:"user"`
-	Password types.String `tfsdk:"pass"`
+	URL               types.String `tfsdk:"url"`
+	Vendor            types.String `tfsdk:"vendor"`
+	User              types.String `tfsdk:"user"`
+	Password          types.String `tfsdk:"pass"`
+	PasswordWO        types.String `tfsdk:"pass_wo"`
+	PasswordWOVersion types.Int64  `tfsdk:"pass_wo_version"`
 }
 
 // CloudSyncCredentialsResource defines the resource implementation.
@@ -75,6 +95,11 @@ func (r *CloudSyncCredentialsResource) Metadata(ctx context.Context, req resourc
 	resp.TypeName = req.ProviderTypeName + "_cloudsync_credentials"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply.
+func (r *CloudSyncCredentialsResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_cloudsync_credentials", req, resp)
+}
+
 func (r *CloudSyncCredentialsResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages cloud sync credentials for backup tasks.",
@@ -105,6 +130,8 @@ func (r *CloudSyncCredentialsResource) Schema(ctx context.Context, req resource.
 						Optional:    true,
 						Sensitive:   true,
 					},
+					"secret_access_key_wo":         writeOnlyAttribute("secret_access_key", "Secret access key."),
+					"secret_access_key_wo_version": writeOnlyVersionAttribute("secret_access_key"),
 					"endpoint": schema.StringAttribute{
 						Description: "Custom endpoint URL for S3-compatible storage.",
 						Optional:    true,
@@ -128,6 +155,8 @@ func (r *CloudSyncCredentialsResource) Schema(ctx context.Context, req resource.
 						Optional:    true,
 						Sensitive:   true,
 					},
+					"key_wo":         writeOnlyAttribute("key", "Application key."),
+					"key_wo_version": writeOnlyVersionAttribute("key"),
 				},
 			},
 			"gcs": schema.SingleNestedBlock{
@@ -138,6 +167,8 @@ func (r *CloudSyncCredentialsResource) Schema(ctx context.Context, req resource.
 						Optional:    true,
 						Sensitive:   true,
 					},
+					"service_account_credentials_wo":         writeOnlyAttribute("service_account_credentials", "Service account JSON credentials."),
+					"service_account_credentials_wo_version": writeOnlyVersionAttribute("service_account_credentials"),
 				},
 			},
 			"azure": schema.SingleNestedBlock{
@@ -153,6 +184,8 @@ func (r *CloudSyncCredentialsResource) Schema(ctx context.Context, req resource.
 						Optional:    true,
 						Sensitive:   true,
 					},
+					"key_wo":         writeOnlyAttribute("key", "Account key."),
+					"key_wo_version": writeOnlyVersionAttribute("key"),
 				},
 			},
 			"webdav": schema.SingleNestedBlock{
@@ -175,8 +208,11 @@ func (r *CloudSyncCredentialsResource) Schema(ctx context.Context, req resource.
 						Optional:    true,
 						Sensitive:   true,
 					},
+					"pass_wo":         writeOnlyAttribute("pass", "WebDAV account password."),
+					"pass_wo_version": writeOnlyVersionAttribute("pass"),
 				},
 			},
+			"timeouts": timeouts.BlockAll(ctx),
 		},
 	}
 }
@@ -189,29 +225,29 @@ func validateProviderBlock(data *CloudSyncCredentialsResourceModel) []string {
 		if data.S3.AccessKeyID.IsNull() || data.S3.AccessKeyID.ValueString() == "" {
 			errors = append(errors, "s3.access_key_id is required when s3 block is specified")
 		}
-		if data.S3.SecretAccessKey.IsNull() || data.S3.SecretAccessKey.ValueString() == "" {
-			errors = append(errors, "s3.secret_access_key is required when s3 block is specified")
+		if secretValue(data.S3.SecretAccessKey, data.S3.SecretAccessKeyWO) == "" {
+			errors = append(errors, "s3.secret_access_key or s3.secret_access_key_wo is required when s3 block is specified")
 		}
 	}
 	if data.B2 != nil {
 		if data.B2.Account.IsNull() || data.B2.Account.ValueString() == "" {
 			errors = append(errors, "b2.account is required when b2 block is specified")
 		}
-		if data.B2.Key.IsNull() || data.B2.Key.ValueString() == "" {
-			errors = append(errors, "b2.key is required when b2 block is specified")
+		if secretValue(data.B2.Key, data.B2.KeyWO) == "" {
+			errors = append(errors, "b2.key or b2.key_wo is required when b2 block is specified")
 		}
 	}
 	if data.GCS != nil {
-		if data.GCS.ServiceAccountCredentials.IsNull() || data.GCS.ServiceAccountCredentials.ValueString() == "" {
-			errors = append(errors, "gcs.service_account_credentials is required when gcs block is specified")
+		if secretValue(data.GCS.ServiceAccountCredentials, data.GCS.ServiceAccountCredentialsWO) == "" {
+			errors = append(errors, "gcs.service_account_credentials or gcs.service_account_credentials_wo is required when gcs block is specified")
 		}
 	}
 	if data.Azure != nil {
 		if data.Azure.Account.IsNull() || data.Azure.Account.ValueString() == "" {
 			errors = append(errors, "azure.account is required when azure block is specified")
 		}
-		if data.Azure.Key.IsNull() || data.Azure.Key.ValueString() == "" {
-			errors = append(errors, "azure.key is required when azure block is specified")
+		if secretValue(data.Azure.Key, data.Azure.KeyWO) == "" {
+			errors = append(errors, "azure.key or azure.key_wo is required when azure block is specified")
 		}
 	}
 	if data.WebDAV != nil {
@@ -224,20 +260,40 @@ func validateProviderBlock(data *CloudSyncCredentialsResourceModel) []string {
 		if data.WebDAV.User.IsNull() || data.WebDAV.User.ValueString() == "" {
 			errors = append(errors, "webdav.user is required when webdav block is specified")
 		}
-		if data.WebDAV.Password.IsNull() || data.WebDAV.Password.ValueString() == "" {
-			errors = append(errors, "webdav.pass is required when webdav block is specified")
+		if secretValue(data.WebDAV.Password, data.WebDAV.PasswordWO) == "" {
+			errors = append(errors, "webdav.pass or webdav.pass_wo is required when webdav block is specified")
 		}
 	}
 
 	return errors
 }
 
+// readCredentialsWriteOnly copies the write-only secret of the configured
+// provider block from config into data.
+func readCredentialsWriteOnly(ctx context.Context, config tfsdk.Config, data *CloudSyncCredentialsResourceModel, diags *diag.Diagnostics) {
+	if data.S3 != nil {
+		data.S3.SecretAccessKeyWO = writeOnlyValue(ctx, config, path.Root("s3").AtName("secret_access_key_wo"), diags)
+	}
+	if data.B2 != nil {
+		data.B2.KeyWO = writeOnlyValue(ctx, config, path.Root("b2").AtName("key_wo"), diags)
+	}
+	if data.GCS != nil {
+		data.GCS.ServiceAccountCredentialsWO = writeOnlyValue(ctx, config, path.Root("gcs").AtName("service_account_credentials_wo"), diags)
+	}
+	if data.Azure != nil {
+		data.Azure.KeyWO = writeOnlyValue(ctx, config, path.Root("azure").AtName("key_wo"), diags)
+	}
+	if data.WebDAV != nil {
+		data.WebDAV.PasswordWO = writeOnlyValue(ctx, config, path.Root("webdav").AtName("pass_wo"), diags)
+	}
+}
+
 // getProviderAndAttributes extracts provider type and attributes from the model.
 func getProviderAndAttributes(data *CloudSyncCredentialsResourceModel) (string, map[string]string) {
 	if data.S3 != nil {
 		attrs := map[string]string{
 			"access_key_id":     data.S3.AccessKeyID.ValueString(),
-			"secret_access_key": data.S3.SecretAccessKey.ValueString(),
+			"secret_access_key": secretValue(data.S3.SecretAccessKey, data.S3.SecretAccessKeyWO),
 		}
 		if !data.S3.Endpoint.IsNull() {
 			attrs["endpoint"] = data.S3.Endpoint.ValueString()
@@ -250,18 +306,18 @@ func getProviderAndAttributes(data *CloudSyncCredentialsResourceModel) (string,
 	if data.B2 != nil {
 		return "B2", map[string]string{
 			"account": data.B2.Account.ValueString(),
-			"key":     data.B2.Key.ValueString(),
+			"key":     secretValue(data.B2.Key, data.B2.KeyWO),
 		}
 	}
 	if data.GCS != nil {
 		return "GOOGLE_CLOUD_STORAGE", map[string]string{
-			"service_account_credentials": data.GCS.ServiceAccountCredentials.ValueString(),
+			"service_account_credentials": secretValue(data.GCS.ServiceAccountCredentials, data.GCS.ServiceAccountCredentialsWO),
 		}
 	}
 	if data.Azure != nil {
 		return "AZUREBLOB", map[string]string{
 			"account": data.Azure.Account.ValueString(),
-			"key":     data.Azure.Key.ValueString(),
+			"key":     secretValue(data.Azure.Key, data.Azure.KeyWO),
 		}
 	}
 	if data.WebDAV != nil {
@@ -269,16 +325,31 @@ func getProviderAndAttributes(data *CloudSyncCredentialsResourceModel) (string,
 			"url":    data.WebDAV.URL.ValueString(),
 			"vendor": data.WebDAV.Vendor.ValueString(),
 			"user":   data.WebDAV.User.ValueString(),
-			"pass":   data.WebDAV.Password.ValueString(),
+			"pass":   secretValue(data.WebDAV.Password, data.WebDAV.PasswordWO),
 		}
 	}
 	return "", nil
 }
 
+func (r *CloudSyncCredentialsResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = numericIdentitySchema("Cloud sync credential ID.")
+}
+
 func (r *CloudSyncCredentialsResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data CloudSyncCredentialsResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	readCredentialsWriteOnly(ctx, req.Config, &data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -306,10 +377,8 @@ func (r *CloudSyncCredentialsResource) Create(ctx context.Context, req resource.
 		Attributes:   attributes,
 	})
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Cloud Sync Credentials",
-			fmt.Sprintf("Unable to create credentials: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create Cloud Sync Credentials",
+			"Unable to create credentials", err, nil)
 		return
 	}
 
@@ -324,6 +393,7 @@ func (r *CloudSyncCredentialsResource) Create(ctx context.Context, req resource.
 	data.ID = types.StringValue(fmt.Sprintf("%d", cred.ID))
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(cred.ID)}, &resp.Diagnostics)
 }
 
 func (r *CloudSyncCredentialsResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -334,6 +404,12 @@ func (r *CloudSyncCredentialsResource) Read(ctx context.Context, req resource.Re
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	id, err := strconv.ParseInt(data.ID.ValueString(), 10, 64)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -342,9 +418,14 @@ func (r *CloudSyncCredentialsResource) Read(ctx context.Context, req resource.Re
 		)
 		return
 	}
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(id)}, &resp.Diagnostics)
 
 	cred, err := r.services.CloudSync.GetCredential(ctx, id)
 	if err != nil {
+		if isNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Read Credentials",
 			fmt.Sprintf("Unable to read credentials %q: %s", data.ID.ValueString(), err.Error()),
@@ -365,11 +446,22 @@ func (r *CloudSyncCredentialsResource) Read(ctx context.Context, req resource.Re
 }
 
 func (r *CloudSyncCredentialsResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var state CloudSyncCredentialsResourceModel
 	var plan CloudSyncCredentialsResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	readCredentialsWriteOnly(ctx, req.Config, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, plan.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -406,10 +498,8 @@ func (r *CloudSyncCredentialsResource) Update(ctx context.Context, req resource.
 		Attributes:   attributes,
 	})
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Update Credentials",
-			fmt.Sprintf("Unable to update credentials: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update Credentials",
+			"Unable to update credentials", err, nil)
 		return
 	}
 
@@ -425,9 +515,14 @@ func (r *CloudSyncCredentialsResource) Update(ctx context.Context, req resource.
 	plan.Name = types.StringValue(cred.Name)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(cred.ID)}, &resp.Diagnostics)
 }
 
 func (r *CloudSyncCredentialsResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data CloudSyncCredentialsResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -435,6 +530,12 @@ func (r *CloudSyncCredentialsResource) Delete(ctx context.Context, req resource.
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	id, err := strconv.ParseInt(data.ID.ValueString(), 10, 64)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -446,6 +547,9 @@ func (r *CloudSyncCredentialsResource) Delete(ctx context.Context, req resource.
 
 	err = r.services.CloudSync.DeleteCredential(ctx, id)
 	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Delete Credentials",
 			fmt.Sprintf("Unable to delete credentials: %s", err.Error()),
@@ -453,3 +557,22 @@ func (r *CloudSyncCredentialsResource) Delete(ctx context.Context, req resource.
 		return
 	}
 }
+
+// ImportState imports credentials by ID or by "name:<name>".
+func (r *CloudSyncCredentialsResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	r.importByLookup(ctx, req, resp, "cloud sync credential", map[string]importLookup{
+		"name": func(ctx context.Context, name string) ([]int64, error) {
+			creds, err := r.services.CloudSync.ListCredentials(ctx)
+			if err != nil {
+				return nil, err
+			}
+			var ids []int64
+			for _, cred := range creds {
+				if cred.Name == name {
+					ids = append(ids, cred.ID)
+				}
+			}
+			return ids, nil
+		},
+	})
+}
@@ -105,20 +105,32 @@ type s3BlockParams struct {
 	SecretAccessKey interface{}
 	Endpoint        interface{}
 	Region          interface{}
+
+	SecretAccessKeyWO        interface{}
+	SecretAccessKeyWOVersion interface{}
 }
 
 type b2BlockParams struct {
 	Account interface{}
 	Key     interface{}
+
+	KeyWO        interface{}
+	KeyWOVersion interface{}
 }
 
 type gcsBlockParams struct {
 	ServiceAccountCredentials interface{}
+
+	ServiceAccountCredentialsWO        interface{}
+	ServiceAccountCredentialsWOVersion interface{}
 }
 
 type azureBlockParams struct {
 	Account interface{}
 	Key     interface{}
+
+	KeyWO        interface{}
+	KeyWOVersion interface{}
 }
 
 type webdavBlockParams struct {
@@ -126,6 +138,9 @@ type webdavBlockParams struct {
 	Vendor interface{}
 	User   interface{}
 	Pass   interface{}
+
+	PassWO        interface{}
+	PassWOVersion interface{}
 }
 
 func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tftypes.Value {
@@ -135,6 +150,9 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 			"secret_access_key": tftypes.String,
 			"endpoint":          tftypes.String,
 			"region":            tftypes.String,
+
+			"secret_access_key_wo":         tftypes.String,
+			"secret_access_key_wo_version": tftypes.Number,
 		},
 	}, nil)
 	if p.S3 != nil {
@@ -144,12 +162,18 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 				"secret_access_key": tftypes.String,
 				"endpoint":          tftypes.String,
 				"region":            tftypes.String,
+
+				"secret_access_key_wo":         tftypes.String,
+				"secret_access_key_wo_version": tftypes.Number,
 			},
 		}, map[string]tftypes.Value{
 			"access_key_id":     tftypes.NewValue(tftypes.String, p.S3.AccessKeyID),
 			"secret_access_key": tftypes.NewValue(tftypes.String, p.S3.SecretAccessKey),
 			"endpoint":          tftypes.NewValue(tftypes.String, p.S3.Endpoint),
 			"region":            tftypes.NewValue(tftypes.String, p.S3.Region),
+
+			"secret_access_key_wo":         tftypes.NewValue(tftypes.String, p.S3.SecretAccessKeyWO),
+			"secret_access_key_wo_version": tftypes.NewValue(tftypes.Number, p.S3.SecretAccessKeyWOVersion),
 		})
 	}
 
@@ -157,6 +181,9 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 		AttributeTypes: map[string]tftypes.Type{
 			"account": tftypes.String,
 			"key":     tftypes.String,
+
+			"key_wo":         tftypes.String,
+			"key_wo_version": tftypes.Number,
 		},
 	}, nil)
 	if p.B2 != nil {
@@ -164,25 +191,40 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 			AttributeTypes: map[string]tftypes.Type{
 				"account": tftypes.String,
 				"key":     tftypes.String,
+
+				"key_wo":         tftypes.String,
+				"key_wo_version": tftypes.Number,
 			},
 		}, map[string]tftypes.Value{
 			"account": tftypes.NewValue(tftypes.String, p.B2.Account),
 			"key":     tftypes.NewValue(tftypes.String, p.B2.Key),
+
+			"key_wo":         tftypes.NewValue(tftypes.String, p.B2.KeyWO),
+			"key_wo_version": tftypes.NewValue(tftypes.Number, p.B2.KeyWOVersion),
 		})
 	}
 
 	gcsValue := tftypes.NewValue(tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
 			"service_account_credentials": tftypes.String,
+
+			"service_account_credentials_wo":         tftypes.String,
+			"service_account_credentials_wo_version": tftypes.Number,
 		},
 	}, nil)
 	if p.GCS != nil {
 		gcsValue = tftypes.NewValue(tftypes.Object{
 			AttributeTypes: map[string]tftypes.Type{
 				"service_account_credentials": tftypes.String,
+
+				"service_account_credentials_wo":         tftypes.String,
+				"service_account_credentials_wo_version": tftypes.Number,
 			},
 		}, map[string]tftypes.Value{
 			"service_account_credentials": tftypes.NewValue(tftypes.String, p.GCS.ServiceAccountCredentials),
+
+			"service_account_credentials_wo":         tftypes.NewValue(tftypes.String, p.GCS.ServiceAccountCredentialsWO),
+			"service_account_credentials_wo_version": tftypes.NewValue(tftypes.Number, p.GCS.ServiceAccountCredentialsWOVersion),
 		})
 	}
 
@@ -190,6 +232,9 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 		AttributeTypes: map[string]tftypes.Type{
 			"account": tftypes.String,
 			"key":     tftypes.String,
+
+			"key_wo":         tftypes.String,
+			"key_wo_version": tftypes.Number,
 		},
 	}, nil)
 	if p.Azure != nil {
@@ -197,10 +242,16 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 			AttributeTypes: map[string]tftypes.Type{
 				"account": tftypes.String,
 				"key":     tftypes.String,
+
+				"key_wo":         tftypes.String,
+				"key_wo_version": tftypes.Number,
 			},
 		}, map[string]tftypes.Value{
 			"account": tftypes.NewValue(tftypes.String, p.Azure.Account),
 			"key":     tftypes.NewValue(tftypes.String, p.Azure.Key),
+
+			"key_wo":         tftypes.NewValue(tftypes.String, p.Azure.KeyWO),
+			"key_wo_version": tftypes.NewValue(tftypes.Number, p.Azure.KeyWOVersion),
 		})
 	}
 
@@ -210,6 +261,9 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 			"vendor": tftypes.String,
 			"user":   tftypes.String,
 			"pass":   tftypes.String,
+
+			"pass_wo":         tftypes.String,
+			"pass_wo_version": tftypes.Number,
 		},
 	}, nil)
 	if p.WebDAV != nil {
@@ -219,12 +273,18 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 				"vendor": tftypes.String,
 				"user":   tftypes.String,
 				"pass":   tftypes.String,
+
+				"pass_wo":         tftypes.String,
+				"pass_wo_version": tftypes.Number,
 			},
 		}, map[string]tftypes.Value{
 			"url":    tftypes.NewValue(tftypes.String, p.WebDAV.URL),
 			"vendor": tftypes.NewValue(tftypes.String, p.WebDAV.Vendor),
 			"user":   tftypes.NewValue(tftypes.String, p.WebDAV.User),
 			"pass":   tftypes.NewValue(tftypes.String, p.WebDAV.Pass),
+
+			"pass_wo":         tftypes.NewValue(tftypes.String, p.WebDAV.PassWO),
+			"pass_wo_version": tftypes.NewValue(tftypes.Number, p.WebDAV.PassWOVersion),
 		})
 	}
 
@@ -238,23 +298,35 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 					"secret_access_key": tftypes.String,
 					"endpoint":          tftypes.String,
 					"region":            tftypes.String,
+
+					"secret_access_key_wo":         tftypes.String,
+					"secret_access_key_wo_version": tftypes.Number,
 				},
 			},
 			"b2": tftypes.Object{
 				AttributeTypes: map[string]tftypes.Type{
 					"account": tftypes.String,
 					"key":     tftypes.String,
+
+					"key_wo":         tftypes.String,
+					"key_wo_version": tftypes.Number,
 				},
 			},
 			"gcs": tftypes.Object{
 				AttributeTypes: map[string]tftypes.Type{
 					"service_account_credentials": tftypes.String,
+
+					"service_account_credentials_wo":         tftypes.String,
+					"service_account_credentials_wo_version": tftypes.Number,
 				},
 			},
 			"azure": tftypes.Object{
 				AttributeTypes: map[string]tftypes.Type{
 					"account": tftypes.String,
 					"key":     tftypes.String,
+
+					"key_wo":         tftypes.String,
+					"key_wo_version": tftypes.Number,
 				},
 			},
 			"webdav": tftypes.Object{
@@ -263,8 +335,12 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 					"vendor": tftypes.String,
 					"user":   tftypes.String,
 					"pass":   tftypes.String,
+
+					"pass_wo":         tftypes.String,
+					"pass_wo_version": tftypes.Number,
 				},
 			},
+			"timeouts": timeoutsType,
 		},
 	}, map[string]tftypes.Value{
 		"id":     tftypes.NewValue(tftypes.String, p.ID),
@@ -273,7 +349,8 @@ func createCloudSyncCredentialsModelValue(p cloudSyncCredentialsModelParams) tft
 		"b2":     b2Value,
 		"gcs":    gcsValue,
 		"azure":  azureValue,
-		"webdav": webdavValue,
+		"webdav":   webdavValue,
+		"timeouts": nullTimeouts(),
 	})
 }
 
@@ -771,7 +848,7 @@ func TestCloudSyncCredentialsResource_Create_WebDAV_MissingRequiredFields(t *tes
 		if errStr != "webdav.url is required when webdav block is specified" &&
 			errStr != "webdav.vendor is required when webdav block is specified" &&
 			errStr != "webdav.user is required when webdav block is specified" &&
-			errStr != "webdav.pass is required when webdav block is specified" {
+			errStr != "webdav.pass or webdav.pass_wo is required when webdav block is specified" {
 			t.Errorf("expected error about missing webdav required field, got: %s", errStr)
 		}
 	}
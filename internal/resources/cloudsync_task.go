@@ -2,11 +2,18 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	listschema "github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
@@ -14,6 +21,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -21,6 +29,9 @@ var (
 	_ resource.Resource                = &CloudSyncTaskResource{}
 	_ resource.ResourceWithConfigure   = &CloudSyncTaskResource{}
 	_ resource.ResourceWithImportState = &CloudSyncTaskResource{}
+	_ resource.ResourceWithModifyPlan  = &CloudSyncTaskResource{}
+	_ resource.ResourceWithIdentity    = &CloudSyncTaskResource{}
+	_ list.ListResourceWithConfigure   = &CloudSyncTaskResource{}
 )
 
 // CloudSyncTaskResourceModel describes the resource data model.
@@ -47,6 +58,8 @@ type CloudSyncTaskResourceModel struct {
 	GCS                *TaskGCSBlock    `tfsdk:"gcs"`
 	Azure              *TaskAzureBlock  `tfsdk:"azure"`
 	WebDAV             *TaskWebDAVBlock `tfsdk:"webdav"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // ScheduleBlock represents cron schedule settings.
@@ -60,8 +73,12 @@ type ScheduleBlock struct {
 
 // EncryptionBlock represents encryption settings for cloud storage.
 type EncryptionBlock struct {
-	Password types.String `tfsdk:"password"`
-	Salt     types.String `tfsdk:"salt"`
+	Password          types.String `tfsdk:"password"`
+	PasswordWO        types.String `tfsdk:"password_wo"`
+	PasswordWOVersion types.Int64  `tfsdk:"password_wo_version"`
+	Salt              types.String `tfsdk:"salt"`
+	SaltWO            types.String `tfsdk:"salt_wo"`
+	SaltWOVersion     types.Int64  `tfsdk:"salt_wo_version"`
 }
 
 // TaskS3Block represents S3-compatible storage settings.
@@ -103,10 +120,29 @@ func NewCloudSyncTaskResource() resource.Resource {
 	return &CloudSyncTaskResource{}
 }
 
+// NewCloudSyncTaskListResource creates a CloudSyncTaskResource for listing
+// cloud sync tasks.
+func NewCloudSyncTaskListResource() list.ListResource {
+	return &CloudSyncTaskResource{}
+}
+
 func (r *CloudSyncTaskResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_cloudsync_task"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply
+// and references to objects that do not exist.
+func (r *CloudSyncTaskResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_cloudsync_task", req, resp)
+	if !r.checksReferences(req, resp.Diagnostics) {
+		return
+	}
+
+	if credentials, changed := plannedReference[types.Int64](ctx, req, path.Root("credentials")); changed {
+		r.checkCloudSyncCredentials(ctx, &resp.Diagnostics, path.Root("credentials"), credentials.ValueInt64())
+	}
+}
+
 func (r *CloudSyncTaskResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages cloud sync backup tasks.",
@@ -231,12 +267,16 @@ func (r *CloudSyncTaskResource) Schema(ctx context.Context, req resource.SchemaR
 						Optional:    true,
 						Sensitive:   true,
 					},
+					"password_wo":         writeOnlyAttribute("password", "Encryption password."),
+					"password_wo_version": writeOnlyVersionAttribute("password"),
 					"salt": schema.StringAttribute{
 						Description: "Encryption salt.",
 						Optional:    true,
 						Computed:    true,
 						Sensitive:   true,
 					},
+					"salt_wo":         writeOnlyAttribute("salt", "Encryption salt."),
+					"salt_wo_version": writeOnlyVersionAttribute("salt"),
 				},
 			},
 			"s3": schema.SingleNestedBlock{
@@ -300,14 +340,30 @@ func (r *CloudSyncTaskResource) Schema(ctx context.Context, req resource.SchemaR
 					},
 				},
 			},
+			"timeouts": timeouts.BlockAll(ctx),
 		},
 	}
 }
 
+func (r *CloudSyncTaskResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = numericIdentitySchema("Cloud sync task ID.")
+}
+
 func (r *CloudSyncTaskResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data CloudSyncTaskResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	readEncryptionWriteOnly(ctx, req.Config, &data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -351,10 +407,8 @@ func (r *CloudSyncTaskResource) Create(ctx context.Context, req resource.CreateR
 	// Call service
 	task, err := r.services.CloudSync.CreateTask(ctx, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Cloud Sync Task",
-			fmt.Sprintf("Unable to create task: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create Cloud Sync Task",
+			"Unable to create task", err, nil)
 		return
 	}
 
@@ -381,6 +435,7 @@ func (r *CloudSyncTaskResource) Create(ctx context.Context, req resource.CreateR
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(task.ID)}, &resp.Diagnostics)
 }
 
 // buildCloudSyncTaskOpts builds CreateCloudSyncTaskOpts from the resource model.
@@ -440,15 +495,23 @@ func buildCloudSyncTaskOpts(ctx context.Context, data *CloudSyncTaskResourceMode
 	// Handle encryption
 	if data.Encryption != nil {
 		opts.Encryption = true
-		opts.EncryptionPassword = data.Encryption.Password.ValueString()
-		if !data.Encryption.Salt.IsNull() && !data.Encryption.Salt.IsUnknown() {
-			opts.EncryptionSalt = data.Encryption.Salt.ValueString()
-		}
+		opts.EncryptionPassword = secretValue(data.Encryption.Password, data.Encryption.PasswordWO)
+		opts.EncryptionSalt = secretValue(data.Encryption.Salt, data.Encryption.SaltWO)
 	}
 
 	return opts
 }
 
+// readEncryptionWriteOnly copies the write-only encryption secrets from
+// config into data.
+func readEncryptionWriteOnly(ctx context.Context, config tfsdk.Config, data *CloudSyncTaskResourceModel, diags *diag.Diagnostics) {
+	if data.Encryption == nil {
+		return
+	}
+	data.Encryption.PasswordWO = writeOnlyValue(ctx, config, path.Root("encryption").AtName("password_wo"), diags)
+	data.Encryption.SaltWO = writeOnlyValue(ctx, config, path.Root("encryption").AtName("salt_wo"), diags)
+}
+
 // validateTaskProviderBlock validates that required fields are present in the specified provider block.
 func validateTaskProviderBlock(data *CloudSyncTaskResourceModel) []string {
 	var errors []string
@@ -474,8 +537,8 @@ func validateTaskProviderBlock(data *CloudSyncTaskResourceModel) []string {
 		}
 	}
 	if data.Encryption != nil {
-		if data.Encryption.Password.IsNull() || data.Encryption.Password.ValueString() == "" {
-			errors = append(errors, "encryption.password is required when encryption block is specified")
+		if secretValue(data.Encryption.Password, data.Encryption.PasswordWO) == "" {
+			errors = append(errors, "encryption.password or encryption.password_wo is required when encryption block is specified")
 		}
 	}
 
@@ -557,7 +620,11 @@ func mapTaskToModel(task *truenas.CloudSyncTask, data *CloudSyncTaskResourceMode
 	}
 
 	// Note: encryption, provider blocks, and exclude are preserved from plan
-	// since API may not return complete information
+	// since API may not return complete information. A salt that was not
+	// configured, or is write-only, is recorded as null.
+	if data.Encryption != nil && data.Encryption.Salt.IsUnknown() {
+		data.Encryption.Salt = types.StringNull()
+	}
 }
 
 func (r *CloudSyncTaskResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -568,6 +635,12 @@ func (r *CloudSyncTaskResource) Read(ctx context.Context, req resource.ReadReque
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	id, err := strconv.ParseInt(data.ID.ValueString(), 10, 64)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -576,9 +649,14 @@ func (r *CloudSyncTaskResource) Read(ctx context.Context, req resource.ReadReque
 		)
 		return
 	}
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(id)}, &resp.Diagnostics)
 
 	task, err := r.services.CloudSync.GetTask(ctx, id)
 	if err != nil {
+		if isNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Read Task",
 			fmt.Sprintf("Unable to query task: %s", err.Error()),
@@ -598,11 +676,22 @@ func (r *CloudSyncTaskResource) Read(ctx context.Context, req resource.ReadReque
 }
 
 func (r *CloudSyncTaskResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var state CloudSyncTaskResourceModel
 	var plan CloudSyncTaskResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	readEncryptionWriteOnly(ctx, req.Config, &plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, plan.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -631,10 +720,8 @@ func (r *CloudSyncTaskResource) Update(ctx context.Context, req resource.UpdateR
 	// Call service
 	task, err := r.services.CloudSync.UpdateTask(ctx, id, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Update Cloud Sync Task",
-			fmt.Sprintf("Unable to update task: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update Cloud Sync Task",
+			"Unable to update task", err, nil)
 		return
 	}
 
@@ -661,9 +748,14 @@ func (r *CloudSyncTaskResource) Update(ctx context.Context, req resource.UpdateR
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(id)}, &resp.Diagnostics)
 }
 
 func (r *CloudSyncTaskResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data CloudSyncTaskResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -671,6 +763,12 @@ func (r *CloudSyncTaskResource) Delete(ctx context.Context, req resource.DeleteR
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	id, err := strconv.ParseInt(data.ID.ValueString(), 10, 64)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -682,6 +780,9 @@ func (r *CloudSyncTaskResource) Delete(ctx context.Context, req resource.DeleteR
 
 	err = r.services.CloudSync.DeleteTask(ctx, id)
 	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Delete Task",
 			fmt.Sprintf("Unable to delete task: %s", err.Error()),
@@ -689,3 +790,53 @@ func (r *CloudSyncTaskResource) Delete(ctx context.Context, req resource.DeleteR
 		return
 	}
 }
+
+// ImportState imports a task by ID or by "description:<description>".
+func (r *CloudSyncTaskResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	r.importByLookup(ctx, req, resp, "cloud sync task", map[string]importLookup{
+		"description": func(ctx context.Context, description string) ([]int64, error) {
+			tasks, err := r.services.CloudSync.ListTasks(ctx)
+			if err != nil {
+				return nil, err
+			}
+			var ids []int64
+			for _, task := range tasks {
+				if task.Description == description {
+					ids = append(ids, task.ID)
+				}
+			}
+			return ids, nil
+		},
+	})
+
+	// Read only fills in a schedule that is in state.
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("schedule"), &ScheduleBlock{})...)
+	}
+}
+
+func (r *CloudSyncTaskResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = listschema.Schema{
+		Description: "Lists the cloud sync tasks on TrueNAS.",
+	}
+}
+
+// List lists every cloud sync task, named by its description.
+func (r *CloudSyncTaskResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	tasks, err := r.services.CloudSync.ListTasks(ctx)
+	if err != nil {
+		listError(stream, "Unable to List Tasks", fmt.Sprintf("Unable to list cloud sync tasks: %s", err.Error()))
+		return
+	}
+
+	entries := make([]listEntry, len(tasks))
+	for i, task := range tasks {
+		id := strconv.FormatInt(task.ID, 10)
+		entries[i] = listEntry{
+			ImportID:    id,
+			DisplayName: displayName(task.Description, id),
+			Identity:    numericIdentity{ID: types.Int64Value(task.ID)},
+		}
+	}
+	stream.Results = listResults(ctx, r, req, entries)
+}
@@ -212,6 +212,11 @@ type scheduleBlockParams struct {
 type encryptionBlockParams struct {
 	Password interface{}
 	Salt     interface{}
+
+	PasswordWO        interface{}
+	PasswordWOVersion interface{}
+	SaltWO            interface{}
+	SaltWOVersion     interface{}
 }
 
 type taskS3BlockParams struct {
@@ -254,6 +259,11 @@ func createCloudSyncTaskModelValue(p cloudSyncTaskModelParams) tftypes.Value {
 		AttributeTypes: map[string]tftypes.Type{
 			"password": tftypes.String,
 			"salt":     tftypes.String,
+
+			"password_wo":         tftypes.String,
+			"password_wo_version": tftypes.Number,
+			"salt_wo":             tftypes.String,
+			"salt_wo_version":     tftypes.Number,
 		},
 	}
 
@@ -334,6 +344,11 @@ func createCloudSyncTaskModelValue(p cloudSyncTaskModelParams) tftypes.Value {
 		values["encryption"] = tftypes.NewValue(encryptionType, map[string]tftypes.Value{
 			"password": tftypes.NewValue(tftypes.String, p.Encryption.Password),
 			"salt":     tftypes.NewValue(tftypes.String, p.Encryption.Salt),
+
+			"password_wo":         tftypes.NewValue(tftypes.String, p.Encryption.PasswordWO),
+			"password_wo_version": tftypes.NewValue(tftypes.Number, p.Encryption.PasswordWOVersion),
+			"salt_wo":             tftypes.NewValue(tftypes.String, p.Encryption.SaltWO),
+			"salt_wo_version":     tftypes.NewValue(tftypes.Number, p.Encryption.SaltWOVersion),
 		})
 	} else {
 		values["encryption"] = tftypes.NewValue(encryptionType, nil)
@@ -413,8 +428,10 @@ func createCloudSyncTaskModelValue(p cloudSyncTaskModelParams) tftypes.Value {
 			"gcs":                   bucketFolderType,
 			"azure":                 containerFolderType,
 			"webdav":                webdavFolderType,
+			"timeouts":              timeoutsType,
 		},
 	}
+	values["timeouts"] = nullTimeouts()
 
 	return tftypes.NewValue(objectType, values)
 }
@@ -1615,6 +1632,47 @@ func TestCloudSyncTaskResource_Delete_APIError(t *testing.T) {
 	}
 }
 
+func TestCloudSyncTaskResource_Delete_NotFound(t *testing.T) {
+	r := &CloudSyncTaskResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			CloudSync: &truenas.MockCloudSyncService{
+				DeleteTaskFunc: func(ctx context.Context, id int64) error {
+					return &services.NotFoundError{Err: errors.New("[ENOENT] Cloud Sync Task 10 is gone")}
+				},
+			},
+		}},
+	}
+
+	schemaResp := getCloudSyncTaskResourceSchema(t)
+	stateValue := createCloudSyncTaskModelValue(cloudSyncTaskModelParams{
+		ID:          "10",
+		Description: "Daily Backup",
+		Path:        "/mnt/tank/data",
+		Credentials: 5,
+		Direction:   "push",
+		S3: &taskS3BlockParams{
+			Bucket: "my-bucket",
+			Folder: "/backups/",
+		},
+	})
+
+	req := resource.DeleteRequest{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    stateValue,
+		},
+	}
+
+	resp := &resource.DeleteResponse{}
+
+	r.Delete(context.Background(), req, resp)
+
+	// An object deleted outside Terraform is already gone
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+}
+
 func TestCloudSyncTaskResource_Create_MultipleProviderBlocks(t *testing.T) {
 	r := &CloudSyncTaskResource{
 		BaseResource: BaseResource{services: &services.TrueNASServices{
@@ -2,10 +2,16 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	listschema "github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
@@ -19,6 +25,9 @@ var (
 	_ resource.Resource                = &CronJobResource{}
 	_ resource.ResourceWithConfigure   = &CronJobResource{}
 	_ resource.ResourceWithImportState = &CronJobResource{}
+	_ resource.ResourceWithModifyPlan  = &CronJobResource{}
+	_ resource.ResourceWithIdentity    = &CronJobResource{}
+	_ list.ListResourceWithConfigure   = &CronJobResource{}
 )
 
 // CronJobResourceModel describes the resource data model.
@@ -31,6 +40,8 @@ type CronJobResourceModel struct {
 	CaptureStdout types.Bool     `tfsdk:"capture_stdout"`
 	CaptureStderr types.Bool     `tfsdk:"capture_stderr"`
 	Schedule      *ScheduleBlock `tfsdk:"schedule"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // CronJobResource defines the resource implementation.
@@ -43,10 +54,20 @@ func NewCronJobResource() resource.Resource {
 	return &CronJobResource{}
 }
 
+// NewCronJobListResource creates a CronJobResource for listing cron jobs.
+func NewCronJobListResource() list.ListResource {
+	return &CronJobResource{}
+}
+
 func (r *CronJobResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_cron_job"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply.
+func (r *CronJobResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_cron_job", req, resp)
+}
+
 func (r *CronJobResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages cron jobs for scheduled task execution.",
@@ -123,10 +144,15 @@ func (r *CronJobResource) Schema(ctx context.Context, req resource.SchemaRequest
 					},
 				},
 			},
+			"timeouts": timeouts.BlockAll(ctx),
 		},
 	}
 }
 
+func (r *CronJobResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = numericIdentitySchema("Cron job ID.")
+}
+
 // buildCronJobOpts builds typed options from the resource model.
 func buildCronJobOpts(data *CronJobResourceModel) truenas.CreateCronJobOpts {
 	opts := truenas.CreateCronJobOpts{
@@ -152,6 +178,10 @@ func buildCronJobOpts(data *CronJobResourceModel) truenas.CreateCronJobOpts {
 }
 
 func (r *CronJobResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data CronJobResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
@@ -159,14 +189,18 @@ func (r *CronJobResource) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	opts := buildCronJobOpts(&data)
 
 	job, err := r.services.Cron.Create(ctx, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Cron Job",
-			fmt.Sprintf("Unable to create cron job: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create Cron Job",
+			"Unable to create cron job", err, nil)
 		return
 	}
 
@@ -181,6 +215,7 @@ func (r *CronJobResource) Create(ctx context.Context, req resource.CreateRequest
 	mapCronJobToModel(job, &data)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(job.ID)}, &resp.Diagnostics)
 }
 
 func (r *CronJobResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -191,6 +226,12 @@ func (r *CronJobResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	id, err := strconv.ParseInt(data.ID.ValueString(), 10, 64)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -199,9 +240,14 @@ func (r *CronJobResource) Read(ctx context.Context, req resource.ReadRequest, re
 		)
 		return
 	}
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(id)}, &resp.Diagnostics)
 
 	job, err := r.services.Cron.Get(ctx, id)
 	if err != nil {
+		if isNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Read Cron Job",
 			fmt.Sprintf("Unable to query cron job: %s", err.Error()),
@@ -221,6 +267,10 @@ func (r *CronJobResource) Read(ctx context.Context, req resource.ReadRequest, re
 }
 
 func (r *CronJobResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var state CronJobResourceModel
 	var plan CronJobResourceModel
 
@@ -230,6 +280,12 @@ func (r *CronJobResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, plan.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Parse ID from state
 	id, err := strconv.ParseInt(state.ID.ValueString(), 10, 64)
 	if err != nil {
@@ -244,10 +300,8 @@ func (r *CronJobResource) Update(ctx context.Context, req resource.UpdateRequest
 
 	job, err := r.services.Cron.Update(ctx, id, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Update Cron Job",
-			fmt.Sprintf("Unable to update cron job: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update Cron Job",
+			"Unable to update cron job", err, nil)
 		return
 	}
 
@@ -263,9 +317,14 @@ func (r *CronJobResource) Update(ctx context.Context, req resource.UpdateRequest
 	mapCronJobToModel(job, &plan)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	setIdentity(ctx, resp.Identity, numericIdentity{ID: types.Int64Value(job.ID)}, &resp.Diagnostics)
 }
 
 func (r *CronJobResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data CronJobResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -273,6 +332,12 @@ func (r *CronJobResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	id, err := strconv.ParseInt(data.ID.ValueString(), 10, 64)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -284,6 +349,9 @@ func (r *CronJobResource) Delete(ctx context.Context, req resource.DeleteRequest
 
 	err = r.services.Cron.Delete(ctx, id)
 	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Delete Cron Job",
 			fmt.Sprintf("Unable to delete cron job: %s", err.Error()),
@@ -292,6 +360,56 @@ func (r *CronJobResource) Delete(ctx context.Context, req resource.DeleteRequest
 	}
 }
 
+// ImportState imports a cron job by ID or by "description:<description>".
+func (r *CronJobResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	r.importByLookup(ctx, req, resp, "cron job", map[string]importLookup{
+		"description": func(ctx context.Context, description string) ([]int64, error) {
+			jobs, err := r.services.Cron.List(ctx)
+			if err != nil {
+				return nil, err
+			}
+			var ids []int64
+			for _, job := range jobs {
+				if job.Description == description {
+					ids = append(ids, job.ID)
+				}
+			}
+			return ids, nil
+		},
+	})
+
+	// Read only fills in a schedule that is in state.
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("schedule"), &ScheduleBlock{})...)
+	}
+}
+
+func (r *CronJobResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = listschema.Schema{
+		Description: "Lists the cron jobs on TrueNAS.",
+	}
+}
+
+// List lists every cron job, named by its description.
+func (r *CronJobResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	jobs, err := r.services.Cron.List(ctx)
+	if err != nil {
+		listError(stream, "Unable to List Cron Jobs", fmt.Sprintf("Unable to list cron jobs: %s", err.Error()))
+		return
+	}
+
+	entries := make([]listEntry, len(jobs))
+	for i, job := range jobs {
+		id := strconv.FormatInt(job.ID, 10)
+		entries[i] = listEntry{
+			ImportID:    id,
+			DisplayName: displayName(job.Description, id),
+			Identity:    numericIdentity{ID: types.Int64Value(job.ID)},
+		}
+	}
+	stream.Results = listResults(ctx, r, req, entries)
+}
+
 // mapCronJobToModel maps a typed CronJob to the resource model.
 // The truenas-go CronJob type already handles stdout/stderr inversion,
 // so CaptureStdout/CaptureStderr can be used directly.
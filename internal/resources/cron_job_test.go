@@ -7,6 +7,7 @@ import (
 
 	truenas "github.com/deevus/truenas-go"
 	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
@@ -208,8 +209,10 @@ func createCronJobModelValue(p cronJobModelParams) tftypes.Value {
 			"capture_stdout": tftypes.Bool,
 			"capture_stderr": tftypes.Bool,
 			"schedule":       scheduleType,
+			"timeouts":       timeoutsType,
 		},
 	}
+	values["timeouts"] = nullTimeouts()
 
 	return tftypes.NewValue(objectType, values)
 }
@@ -597,6 +600,59 @@ func TestCronJobResource_Read_APIError(t *testing.T) {
 	}
 }
 
+func TestCronJobResource_Read_NotFoundError(t *testing.T) {
+	r := &CronJobResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Cron: &truenas.MockCronService{
+				GetFunc: func(ctx context.Context, id int64) (*truenas.CronJob, error) {
+					return nil, &services.NotFoundError{Err: errors.New("[ENOENT] CronJob 5 is gone")}
+				},
+			},
+		}},
+	}
+
+	schemaResp := getCronJobResourceSchema(t)
+	stateValue := createCronJobModelValue(cronJobModelParams{
+		ID:            "5",
+		User:          "root",
+		Command:       "/usr/local/bin/backup.sh",
+		Description:   "Daily Backup",
+		Enabled:       true,
+		CaptureStdout: false,
+		CaptureStderr: true,
+		Schedule: &scheduleBlockParams{
+			Minute: "0",
+			Hour:   "3",
+			Dom:    "*",
+			Month:  "*",
+			Dow:    "*",
+		},
+	})
+
+	req := resource.ReadRequest{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    stateValue,
+		},
+	}
+
+	resp := &resource.ReadResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+		},
+	}
+
+	r.Read(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+
+	if !resp.State.Raw.IsNull() {
+		t.Error("expected state to be removed (null) when the cron job was deleted")
+	}
+}
+
 func TestCronJobResource_Update_Success(t *testing.T) {
 	var capturedID int64
 	var capturedOpts truenas.UpdateCronJobOpts
@@ -899,6 +955,53 @@ func TestCronJobResource_Delete_Success(t *testing.T) {
 	}
 }
 
+func TestCronJobResource_Delete_ReadOnly(t *testing.T) {
+	r := &CronJobResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			ReadOnly: true,
+			Cron: &truenas.MockCronService{
+				DeleteFunc: func(ctx context.Context, id int64) error {
+					t.Error("expected Delete not to be called in read-only mode")
+					return nil
+				},
+			},
+		}},
+	}
+
+	schemaResp := getCronJobResourceSchema(t)
+	stateValue := createCronJobModelValue(cronJobModelParams{
+		ID:      "5",
+		User:    "root",
+		Command: "/usr/local/bin/backup.sh",
+		Enabled: true,
+		Schedule: &scheduleBlockParams{
+			Minute: "0",
+			Hour:   "3",
+			Dom:    "*",
+			Month:  "*",
+			Dow:    "*",
+		},
+	})
+
+	req := resource.DeleteRequest{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    stateValue,
+		},
+	}
+
+	resp := &resource.DeleteResponse{}
+
+	r.Delete(context.Background(), req, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error in read-only mode")
+	}
+	if got := resp.Diagnostics.Errors()[0].Summary(); got != "Provider Is Read-Only" {
+		t.Errorf("expected read-only diagnostic, got %q", got)
+	}
+}
+
 func TestCronJobResource_Delete_APIError(t *testing.T) {
 	r := &CronJobResource{
 		BaseResource: BaseResource{services: &services.TrueNASServices{
@@ -944,6 +1047,52 @@ func TestCronJobResource_Delete_APIError(t *testing.T) {
 	}
 }
 
+func TestCronJobResource_Delete_NotFound(t *testing.T) {
+	r := &CronJobResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Cron: &truenas.MockCronService{
+				DeleteFunc: func(ctx context.Context, id int64) error {
+					return &services.NotFoundError{Err: errors.New("[ENOENT] CronJob 5 is gone")}
+				},
+			},
+		}},
+	}
+
+	schemaResp := getCronJobResourceSchema(t)
+	stateValue := createCronJobModelValue(cronJobModelParams{
+		ID:            "5",
+		User:          "root",
+		Command:       "/usr/local/bin/backup.sh",
+		Description:   "Daily Backup",
+		Enabled:       true,
+		CaptureStdout: false,
+		CaptureStderr: true,
+		Schedule: &scheduleBlockParams{
+			Minute: "0",
+			Hour:   "3",
+			Dom:    "*",
+			Month:  "*",
+			Dow:    "*",
+		},
+	})
+
+	req := resource.DeleteRequest{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    stateValue,
+		},
+	}
+
+	resp := &resource.DeleteResponse{}
+
+	r.Delete(context.Background(), req, resp)
+
+	// An object deleted outside Terraform is already gone
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+}
+
 func TestCronJobResource_Create_CustomSchedule(t *testing.T) {
 	var capturedOpts truenas.CreateCronJobOpts
 
@@ -1285,3 +1434,39 @@ func TestCronJobResource_Create_DisabledJob(t *testing.T) {
 		t.Errorf("expected description 'Disabled Maintenance Job', got %q", resultData.Description.ValueString())
 	}
 }
+
+func TestCronJobResource_ImportState_ByDescription(t *testing.T) {
+	r := &CronJobResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Cron: &truenas.MockCronService{
+				ListFunc: func(ctx context.Context) ([]truenas.CronJob, error) {
+					return []truenas.CronJob{
+						{ID: 3, Description: "Daily Backup"},
+						{ID: 5, Description: "Weekly Scrub"},
+					}, nil
+				},
+			},
+		}},
+	}
+
+	schemaResp := getCronJobResourceSchema(t)
+	req := resource.ImportStateRequest{ID: "description:Weekly Scrub"}
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(context.Background()), nil),
+		},
+	}
+
+	r.ImportState(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+
+	var id string
+	resp.Diagnostics.Append(resp.State.GetAttribute(context.Background(), path.Root("id"), &id)...)
+	if id != "5" {
+		t.Errorf("expected ID '5', got %q", id)
+	}
+}
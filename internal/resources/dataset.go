@@ -2,22 +2,34 @@ package resources
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
 	customtypes "github.com/deevus/terraform-provider-truenas/internal/types"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/list"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var _ resource.Resource = &DatasetResource{}
 var _ resource.ResourceWithConfigure = &DatasetResource{}
 var _ resource.ResourceWithImportState = &DatasetResource{}
+var _ resource.ResourceWithModifyPlan = &DatasetResource{}
 var _ resource.ResourceWithValidateConfig = &DatasetResource{}
+var _ resource.ResourceWithIdentity = &DatasetResource{}
+var _ resource.ResourceWithMoveState = &DatasetResource{}
+var _ resource.ResourceWithUpgradeState = &DatasetResource{}
+var _ list.ListResourceWithConfigure = &DatasetResource{}
 
 // DatasetResource defines the resource implementation.
 type DatasetResource struct {
@@ -42,6 +54,8 @@ type DatasetResourceModel struct {
 	GID          types.Int64                    `tfsdk:"gid"`
 	ForceDestroy types.Bool                     `tfsdk:"force_destroy"`
 	SnapshotID   types.String                   `tfsdk:"snapshot_id"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // mapDatasetToModel maps API response fields to the Terraform model.
@@ -61,12 +75,34 @@ func NewDatasetResource() resource.Resource {
 	return &DatasetResource{}
 }
 
+// NewDatasetListResource creates a DatasetResource for listing datasets.
+func NewDatasetListResource() list.ListResource {
+	return &DatasetResource{}
+}
+
 func (r *DatasetResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_dataset"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply
+// and references to objects that do not exist.
+func (r *DatasetResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_dataset", req, resp)
+	if !r.checksReferences(req, resp.Diagnostics) {
+		return
+	}
+
+	var plan DatasetResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.checkPoolDatasetReferences(ctx, req, &resp.Diagnostics, getFullName(&plan), plan.Parent)
+}
+
 func (r *DatasetResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     1,
 		Description: "Manages a TrueNAS dataset. Use nested datasets instead of host_path for app storage.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -80,21 +116,21 @@ func (r *DatasetResource) Schema(ctx context.Context, req resource.SchemaRequest
 				Description: "Pool name. Use with 'path' attribute for pool-relative paths.",
 				Optional:    true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					requiresReplaceIfRenamed(),
 				},
 			},
 			"path": schema.StringAttribute{
 				Description: "Dataset path. With 'pool': relative path in pool. With 'parent': child dataset name.",
 				Optional:    true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					requiresReplaceIfRenamed(),
 				},
 			},
 			"parent": schema.StringAttribute{
 				Description: "Parent dataset ID (e.g., 'tank/data'). Use with 'path' attribute.",
 				Optional:    true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					requiresReplaceIfRenamed(),
 				},
 			},
 			"name": schema.StringAttribute{
@@ -102,7 +138,7 @@ func (r *DatasetResource) Schema(ctx context.Context, req resource.SchemaRequest
 				DeprecationMessage: "Use 'path' instead. This attribute will be removed in a future version.",
 				Optional:           true,
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					requiresReplaceIfRenamed(),
 				},
 			},
 			"mount_path": schema.StringAttribute{
@@ -163,6 +199,9 @@ func (r *DatasetResource) Schema(ctx context.Context, req resource.SchemaRequest
 			"mode": schema.StringAttribute{
 				Description: "Unix mode for the dataset mountpoint (e.g., '755'). Sets permissions via filesystem.setperm after creation.",
 				Optional:    true,
+				Validators: []validator.String{
+					modeValidator(),
+				},
 			},
 			"uid": schema.Int64Attribute{
 				Description: "Owner user ID for the dataset mountpoint.",
@@ -184,9 +223,16 @@ func (r *DatasetResource) Schema(ctx context.Context, req resource.SchemaRequest
 				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.BlockAll(ctx),
+		},
 	}
 }
 
+func (r *DatasetResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = poolPathIdentitySchema()
+}
+
 func (r *DatasetResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
 	var data DatasetResourceModel
 	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
@@ -209,6 +255,10 @@ func (r *DatasetResource) ValidateConfig(ctx context.Context, req resource.Valid
 }
 
 func (r *DatasetResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data DatasetResourceModel
 
 	// Read Terraform plan data into the model
@@ -217,6 +267,12 @@ func (r *DatasetResource) Create(ctx context.Context, req resource.CreateRequest
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Get the full dataset name
 	fullName := getFullName(&data)
 	if fullName == "" {
@@ -273,6 +329,7 @@ func (r *DatasetResource) Create(ctx context.Context, req resource.CreateRequest
 
 		// Save data into Terraform state
 		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		setIdentity(ctx, resp.Identity, newPoolPathIdentity(data.ID.ValueString()), &resp.Diagnostics)
 		return
 	}
 
@@ -316,10 +373,8 @@ func (r *DatasetResource) Create(ctx context.Context, req resource.CreateRequest
 	// Call the TrueNAS API
 	ds, err := r.services.Dataset.CreateDataset(ctx, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Dataset",
-			fmt.Sprintf("Unable to create dataset %q: %s", fullName, err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create Dataset",
+			fmt.Sprintf("Unable to create dataset %q", fullName), err, nil)
 		return
 	}
 
@@ -349,6 +404,7 @@ func (r *DatasetResource) Create(ctx context.Context, req resource.CreateRequest
 
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, newPoolPathIdentity(data.ID.ValueString()), &resp.Diagnostics)
 }
 
 func (r *DatasetResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -360,16 +416,29 @@ func (r *DatasetResource) Read(ctx context.Context, req resource.ReadRequest, re
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	datasetID := data.ID.ValueString()
+	setIdentity(ctx, resp.Identity, newPoolPathIdentity(datasetID), &resp.Diagnostics)
 
 	ds, err := r.services.Dataset.GetDataset(ctx, datasetID)
-	if err != nil {
+	if err != nil && !isNotFoundError(err) {
 		resp.Diagnostics.AddError(
 			"Unable to Read Dataset",
 			fmt.Sprintf("Unable to read dataset %q: %s", datasetID, err.Error()),
 		)
 		return
 	}
+	if err != nil {
+		ds = nil
+	}
+	if !checkMovedFrom(ctx, req, resp, datasetID, ds) {
+		return
+	}
 
 	// Dataset was deleted outside of Terraform - remove from state
 	if ds == nil {
@@ -402,6 +471,10 @@ func (r *DatasetResource) Read(ctx context.Context, req resource.ReadRequest, re
 }
 
 func (r *DatasetResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var data DatasetResourceModel
 	var state DatasetResourceModel
 
@@ -411,6 +484,12 @@ func (r *DatasetResource) Update(ctx context.Context, req resource.UpdateRequest
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Read current state
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -469,10 +548,8 @@ func (r *DatasetResource) Update(ctx context.Context, req resource.UpdateRequest
 	if hasChanges {
 		ds, err := r.services.Dataset.UpdateDataset(ctx, datasetID, updateOpts)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Update Dataset",
-				fmt.Sprintf("Unable to update dataset %q: %s", datasetID, err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update Dataset",
+				fmt.Sprintf("Unable to update dataset %q", datasetID), err, nil)
 			return
 		}
 
@@ -498,9 +575,14 @@ func (r *DatasetResource) Update(ctx context.Context, req resource.UpdateRequest
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, newPoolPathIdentity(data.ID.ValueString()), &resp.Diagnostics)
 }
 
 func (r *DatasetResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data DatasetResourceModel
 
 	// Read Terraform prior state data into the model
@@ -509,10 +591,19 @@ func (r *DatasetResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	datasetID := data.ID.ValueString()
 	recursive := !data.ForceDestroy.IsNull() && data.ForceDestroy.ValueBool()
 
 	if err := r.services.Dataset.DeleteDataset(ctx, datasetID, recursive); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Delete Dataset",
 			fmt.Sprintf("Unable to delete dataset %q: %s", datasetID, err.Error()),
@@ -520,6 +611,156 @@ func (r *DatasetResource) Delete(ctx context.Context, req resource.DeleteRequest
 	}
 }
 
+// ImportState imports by ID, such as "tank/data", or by identity.
+func (r *DatasetResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if !resolveImportID(ctx, &req, &poolPathIdentity{}, &resp.Diagnostics) {
+		return
+	}
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// UpgradeState upgrades state written by earlier schema versions.
+func (r *DatasetResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		// Version 0 is the schema before versions were recorded.
+		0: {StateUpgrader: upgradeFromRaw},
+	}
+}
+
+// MoveState moves a truenas_host_path to the dataset mounted at its path, so
+// that a directory managed as a host path can be managed as a dataset
+// without recreating it.
+func (r *DatasetResource) MoveState(ctx context.Context) []resource.StateMover {
+	hostPathSchema := resourceSchema(ctx, &HostPathResource{})
+	return []resource.StateMover{
+		renamedProviderMover(resourceSchema(ctx, r), "truenas_dataset"),
+		{
+			SourceSchema: &hostPathSchema,
+			StateMover:   moveHostPathToDataset,
+		},
+	}
+}
+
+// datasetMovedFromKey is the private data key holding the path of the host
+// path a dataset was moved from, until a refresh has checked that the
+// dataset is mounted there.
+const datasetMovedFromKey = "moved_from_host_path"
+
+// moveHostPathToDataset moves a host path such as /mnt/tank/apps to the
+// dataset tank/apps. Moves run without a configured provider, so the dataset
+// is checked against the path on the next refresh.
+func moveHostPathToDataset(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+	if req.SourceTypeName != "truenas_host_path" || !isTrueNASProvider(req.SourceProviderAddress) || req.SourceState == nil {
+		return
+	}
+
+	var source HostPathResourceModel
+	resp.Diagnostics.Append(req.SourceState.Get(ctx, &source)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	mountPath := source.Path.ValueString()
+
+	// The root dataset of a pool is not managed as a truenas_dataset.
+	datasetID, ok := strings.CutPrefix(mountPath, "/mnt/")
+	if !ok || !strings.Contains(datasetID, "/") {
+		resp.Diagnostics.AddError(
+			"Unable to Move Host Path",
+			fmt.Sprintf("Host path %q is not below a pool in /mnt, so it cannot be the mountpoint of a dataset managed by truenas_dataset.", mountPath),
+		)
+		return
+	}
+
+	identity := newPoolPathIdentity(datasetID)
+	data := DatasetResourceModel{
+		ID:        types.StringValue(datasetID),
+		Pool:      identity.Pool,
+		Path:      identity.Path,
+		MountPath: source.Path,
+		FullPath:  source.Path,
+		Mode:      source.Mode,
+		UID:       source.UID,
+		GID:       source.GID,
+		Timeouts:  source.Timeouts,
+	}
+	resp.Diagnostics.Append(resp.TargetState.Set(ctx, &data)...)
+	setIdentity(ctx, resp.TargetIdentity, identity, &resp.Diagnostics)
+
+	movedFrom, err := json.Marshal(mountPath)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to Move Host Path", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.TargetPrivate.SetKey(ctx, datasetMovedFromKey, movedFrom)...)
+}
+
+// checkMovedFrom reports an error unless ds, which is nil when it does not
+// exist, is mounted at the host path the resource was moved from, if any.
+// Once the check passes the host path is forgotten.
+func checkMovedFrom(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse, datasetID string, ds *truenas.Dataset) bool {
+	raw, diags := req.Private.GetKey(ctx, datasetMovedFromKey)
+	resp.Diagnostics.Append(diags...)
+	if raw == nil {
+		return !resp.Diagnostics.HasError()
+	}
+	var movedFrom string
+	if err := json.Unmarshal(raw, &movedFrom); err != nil {
+		resp.Diagnostics.AddError("Unable to Read Private State", err.Error())
+		return false
+	}
+
+	switch {
+	case ds == nil:
+		resp.Diagnostics.AddError(
+			"Moved Host Path Is Not a Dataset",
+			fmt.Sprintf("Host path %q was moved to dataset %q, which does not exist. Remove the moved block to keep managing it as a host path.", movedFrom, datasetID),
+		)
+		return false
+	case ds.Mountpoint != movedFrom:
+		resp.Diagnostics.AddError(
+			"Moved Host Path Is Not a Dataset",
+			fmt.Sprintf("Host path %q was moved to dataset %q, which is mounted at %q instead. Remove the moved block to keep managing it as a host path.", movedFrom, datasetID, ds.Mountpoint),
+		)
+		return false
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, datasetMovedFromKey, nil)...)
+	return !resp.Diagnostics.HasError()
+}
+
+func (r *DatasetResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = poolListSchema("datasets")
+}
+
+// List lists every dataset except the root datasets of pools, which are
+// created with the pool and cannot be managed as resources.
+func (r *DatasetResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var config poolListConfig
+	if diags := req.Config.Get(ctx, &config); diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	datasets, err := r.services.Dataset.ListDatasets(ctx)
+	if err != nil {
+		listError(stream, "Unable to List Datasets", fmt.Sprintf("Unable to list datasets: %s", err.Error()))
+		return
+	}
+
+	var entries []listEntry
+	for _, ds := range datasets {
+		pool, path := poolDatasetIDToParts(ds.ID)
+		if path == "" || (!config.Pool.IsNull() && pool != config.Pool.ValueString()) {
+			continue
+		}
+		entries = append(entries, listEntry{
+			ImportID:    ds.ID,
+			DisplayName: ds.ID,
+			Identity:    newPoolPathIdentity(ds.ID),
+		})
+	}
+	stream.Results = listResults(ctx, r, req, entries)
+}
+
 // getFullName returns the full dataset name from the model.
 func getFullName(data *DatasetResourceModel) string {
 	return poolDatasetFullName(data.Pool, data.Path, data.Parent, data.Name)
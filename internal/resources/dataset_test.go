@@ -7,7 +7,9 @@ import (
 
 	truenas "github.com/deevus/truenas-go"
 	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
@@ -428,6 +430,7 @@ func createDatasetResourceModelWithSnapshot(id, pool, path, parent, name, mountP
 			"gid":           tftypes.Number,
 			"force_destroy": tftypes.Bool,
 			"snapshot_id":   tftypes.String,
+			"timeouts":      timeoutsType,
 		},
 	}, map[string]tftypes.Value{
 		"id":            tftypes.NewValue(tftypes.String, id),
@@ -446,6 +449,7 @@ func createDatasetResourceModelWithSnapshot(id, pool, path, parent, name, mountP
 		"gid":           tftypes.NewValue(tftypes.Number, gid),
 		"force_destroy": tftypes.NewValue(tftypes.Bool, forceDestroy),
 		"snapshot_id":   tftypes.NewValue(tftypes.String, snapshotID),
+		"timeouts":      nullTimeouts(),
 	})
 }
 
@@ -2780,3 +2784,42 @@ func TestDatasetResource_Create_WithSnapshotId_APIError(t *testing.T) {
 		t.Fatal("expected error for clone API error")
 	}
 }
+
+func TestRequiresReplaceIfRenamed(t *testing.T) {
+	schemaResp := getDatasetResourceSchema(t)
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    createDatasetResourceModelValue(datasetModelParams{ID: "tank/media", Pool: "tank", Path: "media"}),
+	}
+
+	tests := []struct {
+		name     string
+		plan     datasetModelParams
+		expected bool
+	}{
+		{"parent form of the same name", datasetModelParams{ID: "tank/media", Parent: "tank", Path: "media"}, false},
+		{"deprecated name form of the same name", datasetModelParams{ID: "tank/media", Parent: "tank", Name: "media"}, false},
+		{"renamed", datasetModelParams{ID: "tank/media", Parent: "tank", Path: "movies"}, true},
+		{"unknown parent", datasetModelParams{ID: "tank/media", Parent: tftypes.UnknownValue, Path: "media"}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: createDatasetResourceModelValue(tc.plan)}
+			req := planmodifier.StringRequest{
+				Path:       path.Root("parent"),
+				PlanValue:  types.StringValue("tank"),
+				StateValue: types.StringNull(),
+				State:      state,
+				Plan:       plan,
+			}
+			resp := &planmodifier.StringResponse{PlanValue: req.PlanValue}
+			requiresReplaceIfRenamed().PlanModifyString(context.Background(), req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+			}
+			if resp.RequiresReplace != tc.expected {
+				t.Errorf("expected RequiresReplace %v, got %v", tc.expected, resp.RequiresReplace)
+			}
+		})
+	}
+}
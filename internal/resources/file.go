@@ -4,24 +4,34 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
 	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var _ resource.Resource = &FileResource{}
 var _ resource.ResourceWithConfigure = &FileResource{}
 var _ resource.ResourceWithImportState = &FileResource{}
+var _ resource.ResourceWithModifyPlan = &FileResource{}
 var _ resource.ResourceWithValidateConfig = &FileResource{}
+var _ resource.ResourceWithIdentity = &FileResource{}
+var _ resource.ResourceWithMoveState = &FileResource{}
+var _ resource.ResourceWithUpgradeState = &FileResource{}
 
 // FileResource defines the resource implementation.
 type FileResource struct {
@@ -30,16 +40,20 @@ type FileResource struct {
 
 // FileResourceModel describes the resource data model.
 type FileResourceModel struct {
-	ID           types.String `tfsdk:"id"`
-	HostPath     types.String `tfsdk:"host_path"`
-	RelativePath types.String `tfsdk:"relative_path"`
-	Path         types.String `tfsdk:"path"`
-	Content      types.String `tfsdk:"content"`
-	Mode         types.String `tfsdk:"mode"`
-	UID          types.Int64  `tfsdk:"uid"`
-	GID          types.Int64  `tfsdk:"gid"`
-	Checksum     types.String `tfsdk:"checksum"`
-	ForceDestroy types.Bool   `tfsdk:"force_destroy"`
+	ID               types.String `tfsdk:"id"`
+	HostPath         types.String `tfsdk:"host_path"`
+	RelativePath     types.String `tfsdk:"relative_path"`
+	Path             types.String `tfsdk:"path"`
+	Content          types.String `tfsdk:"content"`
+	ContentWO        types.String `tfsdk:"content_wo"`
+	ContentWOVersion types.Int64  `tfsdk:"content_wo_version"`
+	Mode             types.String `tfsdk:"mode"`
+	UID              types.Int64  `tfsdk:"uid"`
+	GID              types.Int64  `tfsdk:"gid"`
+	Checksum         types.String `tfsdk:"checksum"`
+	ForceDestroy     types.Bool   `tfsdk:"force_destroy"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // NewFileResource creates a new FileResource.
@@ -51,8 +65,14 @@ func (r *FileResource) Metadata(ctx context.Context, req resource.MetadataReques
 	resp.TypeName = req.ProviderTypeName + "_file"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply.
+func (r *FileResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_file", req, resp)
+}
+
 func (r *FileResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:     1,
 		Description: "Manages a file on TrueNAS for configuration deployment.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -79,14 +99,23 @@ func (r *FileResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 				},
 			},
 			"content": schema.StringAttribute{
-				Description: "Content of the file. Use templatefile() or file() to load from disk.",
-				Required:    true,
-				Sensitive:   true,
+				Description: "Content of the file. Use templatefile() or file() to load from disk. " +
+					"Exactly one of content and content_wo must be set.",
+				Optional:  true,
+				Sensitive: true,
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("content_wo")),
+				},
 			},
+			"content_wo":         writeOnlyAttribute("content", "Content of the file."),
+			"content_wo_version": writeOnlyVersionAttribute("content"),
 			"mode": schema.StringAttribute{
 				Description: "Unix mode (e.g., '0644'). Inherits from host_path if not specified.",
 				Optional:    true,
 				Computed:    true,
+				Validators: []validator.String{
+					modeValidator(),
+				},
 			},
 			"uid": schema.Int64Attribute{
 				Description: "Owner user ID. Inherits from host_path if not specified.",
@@ -99,7 +128,7 @@ func (r *FileResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 				Computed:    true,
 			},
 			"checksum": schema.StringAttribute{
-				Description: "SHA256 checksum of the file content.",
+				Description: "SHA256 checksum of the file content. Null when content_wo is used.",
 				Computed:    true,
 			},
 			"force_destroy": schema.BoolAttribute{
@@ -107,9 +136,16 @@ func (r *FileResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 				Optional:    true,
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.BlockAll(ctx),
+		},
 	}
 }
 
+func (r *FileResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = pathIdentitySchema("Absolute path of the file.")
+}
+
 func (r *FileResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
 	var data FileResourceModel
 	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
@@ -210,6 +246,16 @@ func computeChecksum(content string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// contentChecksum returns the checksum to record for content. The checksum
+// of write-only content is not recorded, as it would reveal whether a
+// guessed value is right.
+func contentChecksum(data *FileResourceModel, content string) types.String {
+	if !data.ContentWOVersion.IsNull() {
+		return types.StringNull()
+	}
+	return types.StringValue(computeChecksum(content))
+}
+
 // resolvePath resolves the full path from host_path + relative_path or standalone path.
 func (r *FileResource) resolvePath(data *FileResourceModel) string {
 	if !data.HostPath.IsNull() && !data.HostPath.IsUnknown() {
@@ -218,38 +264,57 @@ func (r *FileResource) resolvePath(data *FileResourceModel) string {
 	return data.Path.ValueString()
 }
 
-// parseMode converts mode string to fs.FileMode.
+// ParseMode parses a Unix mode written in octal, such as "0644" or "755".
+func ParseMode(mode string) (fs.FileMode, error) {
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode %q: must be an octal number such as 0644", mode)
+	}
+	return fs.FileMode(m), nil
+}
+
+// parseMode converts mode string to fs.FileMode. Configured modes have been
+// checked by modeValidator, so only an unset mode falls back to 0644.
 func parseMode(mode string) fs.FileMode {
 	if mode == "" {
 		return 0644
 	}
-	m, err := strconv.ParseUint(mode, 8, 32)
+	m, err := ParseMode(mode)
 	if err != nil {
 		return 0644
 	}
-	return fs.FileMode(m)
+	return m
 }
 
 func (r *FileResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data FileResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	data.ContentWO = writeOnlyValue(ctx, req.Config, path.Root("content_wo"), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	fullPath := r.resolvePath(&data)
-	content := data.Content.ValueString()
+	content := secretValue(data.Content, data.ContentWO)
 	mode := parseMode(data.Mode.ValueString())
 
 	// If using host_path + relative_path, create parent directories
 	if !data.HostPath.IsNull() && !data.HostPath.IsUnknown() {
 		parentDir := filepath.Dir(fullPath)
 		if err := r.services.Filesystem.Client().MkdirAll(ctx, parentDir, 0755); err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Create Parent Directory",
-				fmt.Sprintf("Unable to create directory %q: %s", parentDir, err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create Parent Directory",
+				fmt.Sprintf("Unable to create directory %q", parentDir), err, nil)
 			return
 		}
 	}
@@ -266,17 +331,15 @@ func (r *FileResource) Create(ctx context.Context, req resource.CreateRequest, r
 
 	// Write the file with ownership
 	if err := r.services.Filesystem.WriteFile(ctx, fullPath, params); err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create File",
-			fmt.Sprintf("Unable to write file %q: %s", fullPath, err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create File",
+			fmt.Sprintf("Unable to write file %q", fullPath), err, nil)
 		return
 	}
 
 	// Set computed values
 	data.ID = types.StringValue(fullPath)
 	data.Path = types.StringValue(fullPath)
-	data.Checksum = types.StringValue(computeChecksum(content))
+	data.Checksum = contentChecksum(&data, content)
 
 	// Set defaults for mode/uid/gid if not specified
 	if data.Mode.IsNull() || data.Mode.IsUnknown() {
@@ -290,6 +353,7 @@ func (r *FileResource) Create(ctx context.Context, req resource.CreateRequest, r
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, pathIdentity{Path: types.StringValue(fullPath)}, &resp.Diagnostics)
 }
 
 func (r *FileResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -300,20 +364,29 @@ func (r *FileResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Use path if set, otherwise fall back to ID (for import scenarios where
 	// only ID is populated by ImportStatePassthroughID)
 	fullPath := data.Path.ValueString()
 	if fullPath == "" {
 		fullPath = data.ID.ValueString()
 	}
+	setIdentity(ctx, resp.Identity, pathIdentity{Path: types.StringValue(fullPath)}, &resp.Diagnostics)
 
 	// Check if file exists
 	exists, err := r.services.Filesystem.Client().FileExists(ctx, fullPath)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Check File",
-			fmt.Sprintf("Unable to check if file %q exists: %s", fullPath, err.Error()),
-		)
+		if isNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Check File",
+			fmt.Sprintf("Unable to check if file %q exists", fullPath), err, nil)
 		return
 	}
 
@@ -323,34 +396,45 @@ func (r *FileResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	// Read file content for checksum calculation
-	content, err := r.services.Filesystem.Client().ReadFile(ctx, fullPath)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Read File",
-			fmt.Sprintf("Unable to read file %q: %s", fullPath, err.Error()),
-		)
-		return
-	}
-
 	// Update computed values to reflect actual remote state
 	// This also ensures path is set after import (where only ID is populated)
 	data.Path = types.StringValue(fullPath)
-	data.Checksum = types.StringValue(computeChecksum(string(content)))
+
+	// Write-only content is not checked for drift, so it is not read back
+	if data.ContentWOVersion.IsNull() {
+		content, err := r.services.Filesystem.Client().ReadFile(ctx, fullPath)
+		if err != nil {
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Read File",
+				fmt.Sprintf("Unable to read file %q", fullPath), err, nil)
+			return
+		}
+		data.Checksum = types.StringValue(computeChecksum(string(content)))
+	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *FileResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var data FileResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	data.ContentWO = writeOnlyValue(ctx, req.Config, path.Root("content_wo"), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	fullPath := r.resolvePath(&data)
-	content := data.Content.ValueString()
+	content := secretValue(data.Content, data.ContentWO)
 	mode := parseMode(data.Mode.ValueString())
 
 	// Build write file params
@@ -365,17 +449,15 @@ func (r *FileResource) Update(ctx context.Context, req resource.UpdateRequest, r
 
 	// Write the updated file with ownership
 	if err := r.services.Filesystem.WriteFile(ctx, fullPath, params); err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Update File",
-			fmt.Sprintf("Unable to write file %q: %s", fullPath, err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update File",
+			fmt.Sprintf("Unable to write file %q", fullPath), err, nil)
 		return
 	}
 
 	// Update computed values - explicitly set for consistency with Create
 	data.ID = types.StringValue(fullPath)
 	data.Path = types.StringValue(fullPath)
-	data.Checksum = types.StringValue(computeChecksum(content))
+	data.Checksum = contentChecksum(&data, content)
 
 	// Set defaults for mode/uid/gid if not specified (same as Create)
 	if data.Mode.IsNull() || data.Mode.IsUnknown() {
@@ -389,9 +471,14 @@ func (r *FileResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, pathIdentity{Path: types.StringValue(fullPath)}, &resp.Diagnostics)
 }
 
 func (r *FileResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data FileResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -399,6 +486,12 @@ func (r *FileResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	fullPath := data.Path.ValueString()
 
 	// If force_destroy is true, change ownership to root before deleting
@@ -409,10 +502,37 @@ func (r *FileResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 	}
 
 	if err := r.services.Filesystem.Client().DeleteFile(ctx, fullPath); err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Delete File",
-			fmt.Sprintf("Unable to delete file %q: %s", fullPath, err.Error()),
-		)
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
+		if addShellRequiredError(&resp.Diagnostics, "truenas_file", "delete files", err) {
+			return
+		}
+		r.addAPIError(ctx, &resp.Diagnostics, req.State.Schema, "Unable to Delete File",
+			fmt.Sprintf("Unable to delete file %q", fullPath), err, nil)
 		return
 	}
 }
+
+// ImportState imports by the absolute path of the file, or by identity.
+func (r *FileResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if !resolveImportID(ctx, &req, &pathIdentity{}, &resp.Diagnostics) {
+		return
+	}
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+// UpgradeState upgrades state written by earlier schema versions.
+func (r *FileResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		// Version 0 is the schema before versions were recorded.
+		0: {StateUpgrader: upgradeFromRaw},
+	}
+}
+
+// MoveState moves a truenas_file from another TrueNAS provider address.
+func (r *FileResource) MoveState(ctx context.Context) []resource.StateMover {
+	return []resource.StateMover{
+		renamedProviderMover(resourceSchema(ctx, r), "truenas_file"),
+	}
+}
@@ -5,14 +5,18 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io/fs"
 	"testing"
 
 	truenas "github.com/deevus/truenas-go"
 	"github.com/deevus/truenas-go/client"
 	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	tfpath "github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
@@ -52,13 +56,20 @@ func TestFileResource_Schema(t *testing.T) {
 
 	r.Schema(context.Background(), req, resp)
 
-	// Verify required attributes
+	// Verify content and its write-only alternative
 	contentAttr, ok := resp.Schema.Attributes["content"]
 	if !ok {
 		t.Fatal("expected 'content' attribute")
 	}
-	if !contentAttr.IsRequired() {
-		t.Error("expected 'content' to be required")
+	if !contentAttr.IsOptional() || !contentAttr.IsSensitive() {
+		t.Error("expected 'content' to be optional and sensitive")
+	}
+	contentWOAttr, ok := resp.Schema.Attributes["content_wo"]
+	if !ok {
+		t.Fatal("expected 'content_wo' attribute")
+	}
+	if !contentWOAttr.IsWriteOnly() || !contentWOAttr.IsSensitive() {
+		t.Error("expected 'content_wo' to be write-only and sensitive")
 	}
 
 	// Verify optional attributes
@@ -390,6 +401,11 @@ func createFileResourceModelWithForceDestroy(id, hostPath, relativePath, path, c
 			"gid":           tftypes.Number,
 			"checksum":      tftypes.String,
 			"force_destroy": tftypes.Bool,
+
+			"content_wo":         tftypes.String,
+			"content_wo_version": tftypes.Number,
+
+			"timeouts": timeoutsType,
 		},
 	}, map[string]tftypes.Value{
 		"id":            tftypes.NewValue(tftypes.String, id),
@@ -402,6 +418,11 @@ func createFileResourceModelWithForceDestroy(id, hostPath, relativePath, path, c
 		"gid":           tftypes.NewValue(tftypes.Number, gid),
 		"checksum":      tftypes.NewValue(tftypes.String, checksum),
 		"force_destroy": tftypes.NewValue(tftypes.Bool, forceDestroy),
+
+		"content_wo":         tftypes.NewValue(tftypes.String, nil),
+		"content_wo_version": tftypes.NewValue(tftypes.Number, nil),
+
+		"timeouts": nullTimeouts(),
 	})
 }
 
@@ -567,6 +588,45 @@ func TestFileResource_Create_WriteError(t *testing.T) {
 	}
 }
 
+func TestFileResource_Create_WriteValidationError(t *testing.T) {
+	r := &FileResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Filesystem: &truenas.MockFilesystemService{
+				ClientFunc: func() truenas.FileCaller {
+					return &client.MockClient{
+						MkdirAllFunc: func(ctx context.Context, path string, mode fs.FileMode) error {
+							return nil
+						},
+					}
+				},
+				WriteFileFunc: func(ctx context.Context, path string, params truenas.WriteFileParams) error {
+					return validationRPCError([]any{"filesystem_file_receive.mode", "Invalid mode", float64(22)})
+				},
+			},
+		}},
+	}
+
+	schemaResp := getFileResourceSchema(t)
+	planValue := createFileResourceModel(nil, "/mnt/storage/apps", "config.txt", nil, "content", "0644", 0, 0, nil)
+
+	req := resource.CreateRequest{
+		Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: planValue},
+	}
+	resp := &resource.CreateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema},
+	}
+
+	r.Create(context.Background(), req, resp)
+
+	if resp.Diagnostics.ErrorsCount() != 1 {
+		t.Fatalf("expected one error, got %v", resp.Diagnostics)
+	}
+	withPath, ok := resp.Diagnostics.Errors()[0].(diag.DiagnosticWithPath)
+	if !ok || !withPath.Path().Equal(tfpath.Root("mode")) {
+		t.Errorf("expected the error on mode, got %v", resp.Diagnostics.Errors()[0])
+	}
+}
+
 // Read operation tests
 
 // Helper to compute checksum in tests
@@ -1011,6 +1071,48 @@ func TestFileResource_Delete_Error(t *testing.T) {
 	}
 }
 
+func TestFileResource_Delete_WithoutSSH(t *testing.T) {
+	r := &FileResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Filesystem: &truenas.MockFilesystemService{
+				ClientFunc: func() truenas.FileCaller {
+					return &client.MockClient{
+						DeleteFileFunc: func(ctx context.Context, path string) error {
+							return fmt.Errorf("delete file %q: %w", path, client.ErrUnsupportedOperation)
+						},
+					}
+				},
+			},
+		}},
+	}
+
+	schemaResp := getFileResourceSchema(t)
+
+	stateValue := createFileResourceModel("/mnt/storage/test.txt", nil, nil, "/mnt/storage/test.txt", "content", "0644", 0, 0, "checksum")
+
+	req := resource.DeleteRequest{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    stateValue,
+		},
+	}
+
+	resp := &resource.DeleteResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+		},
+	}
+
+	r.Delete(context.Background(), req, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error when delete needs SSH")
+	}
+	if resp.Diagnostics[0].Summary() != "Operation Requires SSH" {
+		t.Errorf("expected SSH-required diagnostic, got %q", resp.Diagnostics[0].Summary())
+	}
+}
+
 // Bug fix tests
 
 // TestFileResource_ValidateConfig_UnknownHostPath tests that validation passes
@@ -1131,6 +1233,11 @@ func createFileResourceModelWithUnknown(id, hostPath, relativePath, path, conten
 			"gid":           tftypes.Number,
 			"checksum":      tftypes.String,
 			"force_destroy": tftypes.Bool,
+
+			"content_wo":         tftypes.String,
+			"content_wo_version": tftypes.Number,
+
+			"timeouts": timeoutsType,
 		},
 	}, map[string]tftypes.Value{
 		"id":            newStringOrUnknown(id),
@@ -1143,6 +1250,11 @@ func createFileResourceModelWithUnknown(id, hostPath, relativePath, path, conten
 		"gid":           newNumberOrUnknown(gid),
 		"checksum":      newStringOrUnknown(checksum),
 		"force_destroy": tftypes.NewValue(tftypes.Bool, nil),
+
+		"content_wo":         tftypes.NewValue(tftypes.String, nil),
+		"content_wo_version": tftypes.NewValue(tftypes.Number, nil),
+
+		"timeouts": nullTimeouts(),
 	})
 }
 
@@ -1718,3 +1830,55 @@ func TestFileResource_Delete_ForceDestroy_ChownFailsContinues(t *testing.T) {
 		t.Error("expected DeleteFile to be called even when Chown fails")
 	}
 }
+
+func TestFileResource_Read_WriteOnlyContent(t *testing.T) {
+	r := &FileResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Filesystem: &truenas.MockFilesystemService{
+				ClientFunc: func() truenas.FileCaller {
+					return &client.MockClient{
+						FileExistsFunc: func(ctx context.Context, path string) (bool, error) {
+							return true, nil
+						},
+						ReadFileFunc: func(ctx context.Context, path string) ([]byte, error) {
+							t.Error("write-only content should not be read back")
+							return nil, nil
+						},
+					}
+				},
+			},
+		}},
+	}
+
+	schemaResp := getFileResourceSchema(t)
+
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    createFileResourceModel("/mnt/storage/test.txt", nil, nil, "/mnt/storage/test.txt", nil, "0644", 0, 0, nil),
+	}
+	if diags := state.SetAttribute(context.Background(), tfpath.Root("content_wo_version"), types.Int64Value(1)); diags.HasError() {
+		t.Fatalf("failed to set content_wo_version: %v", diags)
+	}
+
+	req := resource.ReadRequest{State: state}
+	resp := &resource.ReadResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+		},
+	}
+
+	r.Read(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+
+	var model FileResourceModel
+	resp.State.Get(context.Background(), &model)
+	if !model.Checksum.IsNull() {
+		t.Errorf("expected checksum to be null, got %q", model.Checksum.ValueString())
+	}
+	if model.ContentWOVersion.ValueInt64() != 1 {
+		t.Errorf("expected content_wo_version 1, got %d", model.ContentWOVersion.ValueInt64())
+	}
+}
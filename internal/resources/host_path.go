@@ -2,23 +2,31 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"path/filepath"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 var _ resource.Resource = &HostPathResource{}
 var _ resource.ResourceWithConfigure = &HostPathResource{}
 var _ resource.ResourceWithImportState = &HostPathResource{}
+var _ resource.ResourceWithModifyPlan = &HostPathResource{}
+var _ resource.ResourceWithIdentity = &HostPathResource{}
+var _ resource.ResourceWithMoveState = &HostPathResource{}
+var _ resource.ResourceWithUpgradeState = &HostPathResource{}
 
 // HostPathResource defines the resource implementation.
 type HostPathResource struct {
@@ -33,6 +41,8 @@ type HostPathResourceModel struct {
 	UID          types.Int64  `tfsdk:"uid"`
 	GID          types.Int64  `tfsdk:"gid"`
 	ForceDestroy types.Bool   `tfsdk:"force_destroy"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // NewHostPathResource creates a new HostPathResource.
@@ -44,8 +54,14 @@ func (r *HostPathResource) Metadata(ctx context.Context, req resource.MetadataRe
 	resp.TypeName = req.ProviderTypeName + "_host_path"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply.
+func (r *HostPathResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_host_path", req, resp)
+}
+
 func (r *HostPathResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version:            1,
 		Description:        "Manages a TrueNAS host path directory for app storage mounts.",
 		DeprecationMessage: "Use truenas_dataset with nested datasets instead. host_path relies on SFTP which may not work with non-root SSH users. Datasets are created via the TrueNAS API and provide better ZFS integration.",
 		Attributes: map[string]schema.Attribute{
@@ -67,6 +83,9 @@ func (r *HostPathResource) Schema(ctx context.Context, req resource.SchemaReques
 				Description: "Unix mode (e.g., '755').",
 				Optional:    true,
 				Computed:    true,
+				Validators: []validator.String{
+					modeValidator(),
+				},
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 				},
@@ -92,11 +111,21 @@ func (r *HostPathResource) Schema(ctx context.Context, req resource.SchemaReques
 				Optional:    true,
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.BlockAll(ctx),
+		},
 	}
 }
 
+func (r *HostPathResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = pathIdentitySchema("Absolute path of the directory.")
+}
 
 func (r *HostPathResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data HostPathResourceModel
 
 	// Read Terraform plan data into the model
@@ -105,6 +134,12 @@ func (r *HostPathResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	pathStr := data.Path.ValueString()
 
 	// Determine the mode for the directory
@@ -115,10 +150,8 @@ func (r *HostPathResource) Create(ctx context.Context, req resource.CreateReques
 
 	// Create the directory
 	if err := r.services.Filesystem.Client().MkdirAll(ctx, pathStr, mode); err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Host Path",
-			fmt.Sprintf("Cannot create directory %q: %s", pathStr, err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create Host Path",
+			fmt.Sprintf("Cannot create directory %q", pathStr), err, nil)
 		return
 	}
 
@@ -126,10 +159,8 @@ func (r *HostPathResource) Create(ctx context.Context, req resource.CreateReques
 	if r.hasUIDGID(&data) {
 		permOpts := r.buildPermOpts(&data)
 		if err := r.services.Filesystem.SetPermissions(ctx, permOpts); err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Set Permissions",
-				fmt.Sprintf("Cannot set permissions on %q: %s", pathStr, err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Set Permissions",
+				fmt.Sprintf("Cannot set permissions on %q", pathStr), err, nil)
 			return
 		}
 	}
@@ -139,6 +170,7 @@ func (r *HostPathResource) Create(ctx context.Context, req resource.CreateReques
 
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, pathIdentity{Path: types.StringValue(pathStr)}, &resp.Diagnostics)
 }
 
 func (r *HostPathResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -150,13 +182,25 @@ func (r *HostPathResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	path := data.Path.ValueString()
+	setIdentity(ctx, resp.Identity, pathIdentity{Path: types.StringValue(path)}, &resp.Diagnostics)
 
 	// Call filesystem.stat to verify the path exists
 	stat, err := r.services.Filesystem.Stat(ctx, path)
 	if err != nil {
-		// Path doesn't exist or API error - remove from state
-		resp.State.RemoveResource(ctx)
+		if isNotFoundError(err) {
+			// Path was deleted outside Terraform - remove from state
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Read Host Path",
+			fmt.Sprintf("Unable to stat %q", path), err, nil)
 		return
 	}
 
@@ -180,6 +224,10 @@ func (r *HostPathResource) Read(ctx context.Context, req resource.ReadRequest, r
 }
 
 func (r *HostPathResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var data HostPathResourceModel
 	var state HostPathResourceModel
 
@@ -189,6 +237,12 @@ func (r *HostPathResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Read current state
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
@@ -203,19 +257,22 @@ func (r *HostPathResource) Update(ctx context.Context, req resource.UpdateReques
 	if permChanged {
 		permOpts := r.buildPermOpts(&data)
 		if err := r.services.Filesystem.SetPermissions(ctx, permOpts); err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Update Permissions",
-				fmt.Sprintf("Cannot update permissions on %q: %s", data.Path.ValueString(), err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update Permissions",
+				fmt.Sprintf("Cannot update permissions on %q", data.Path.ValueString()), err, nil)
 			return
 		}
 	}
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, pathIdentity{Path: data.Path}, &resp.Diagnostics)
 }
 
 func (r *HostPathResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data HostPathResourceModel
 
 	// Read Terraform prior state data into the model
@@ -224,6 +281,12 @@ func (r *HostPathResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	p := data.Path.ValueString()
 
 	// Delete the directory using SFTP
@@ -236,10 +299,14 @@ func (r *HostPathResource) Delete(ctx context.Context, req resource.DeleteReques
 	}
 
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Delete Host Path",
-			fmt.Sprintf("Cannot delete directory %q: %s", p, err.Error()),
-		)
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
+		if addShellRequiredError(&resp.Diagnostics, "truenas_host_path", "delete directories", err) {
+			return
+		}
+		r.addAPIError(ctx, &resp.Diagnostics, req.State.Schema, "Unable to Delete Host Path",
+			fmt.Sprintf("Cannot delete directory %q", p), err, nil)
 		return
 	}
 }
@@ -325,11 +392,65 @@ func (r *HostPathResource) forceDestroyHostPath(ctx context.Context, p string, r
 }
 
 func (r *HostPathResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if !resolveImportID(ctx, &req, &pathIdentity{}, &resp.Diagnostics) {
+		return
+	}
 	// The import ID is the path - set it to both id and path attributes
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), req.ID)...)
 }
 
+// UpgradeState upgrades state written by earlier schema versions.
+func (r *HostPathResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		// Version 0 is the schema before versions were recorded.
+		0: {StateUpgrader: upgradeFromRaw},
+	}
+}
+
+// MoveState moves a truenas_dataset to a host path at its mountpoint, so
+// that the directory stays in place when the dataset is no longer managed.
+func (r *HostPathResource) MoveState(ctx context.Context) []resource.StateMover {
+	datasetSchema := resourceSchema(ctx, &DatasetResource{})
+	return []resource.StateMover{
+		renamedProviderMover(resourceSchema(ctx, r), "truenas_host_path"),
+		{
+			SourceSchema: &datasetSchema,
+			StateMover:   r.moveFromDataset,
+		},
+	}
+}
+
+func (r *HostPathResource) moveFromDataset(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+	if req.SourceTypeName != "truenas_dataset" || !isTrueNASProvider(req.SourceProviderAddress) || req.SourceState == nil {
+		return
+	}
+
+	var source DatasetResourceModel
+	resp.Diagnostics.Append(req.SourceState.Get(ctx, &source)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if source.MountPath.ValueString() == "" {
+		resp.Diagnostics.AddError(
+			"Unable to Move Dataset",
+			fmt.Sprintf("Dataset %q has no mount path in state. Refresh it before moving it to truenas_host_path.", source.ID.ValueString()),
+		)
+		return
+	}
+
+	data := HostPathResourceModel{
+		ID:       source.MountPath,
+		Path:     source.MountPath,
+		Mode:     source.Mode,
+		UID:      source.UID,
+		GID:      source.GID,
+		Timeouts: source.Timeouts,
+	}
+	resp.Diagnostics.Append(resp.TargetState.Set(ctx, &data)...)
+	setIdentity(ctx, resp.TargetIdentity, pathIdentity{Path: source.MountPath}, &resp.Diagnostics)
+}
+
 // hasUIDGID returns true if uid or gid are set (mode handled separately in mkdir).
 func (r *HostPathResource) hasUIDGID(data *HostPathResourceModel) bool {
 	return (!data.UID.IsNull() && !data.UID.IsUnknown()) ||
@@ -9,6 +9,8 @@ import (
 	truenas "github.com/deevus/truenas-go"
 	"github.com/deevus/truenas-go/client"
 	"github.com/deevus/terraform-provider-truenas/internal/services"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -148,6 +150,7 @@ func createHostPathResourceModelWithForceDestroy(id, path, mode, uid, gid, force
 			"uid":           tftypes.Number,
 			"gid":           tftypes.Number,
 			"force_destroy": tftypes.Bool,
+			"timeouts":      timeoutsType,
 		},
 	}, map[string]tftypes.Value{
 		"id":            tftypes.NewValue(tftypes.String, id),
@@ -156,6 +159,7 @@ func createHostPathResourceModelWithForceDestroy(id, path, mode, uid, gid, force
 		"uid":           tftypes.NewValue(tftypes.Number, uid),
 		"gid":           tftypes.NewValue(tftypes.Number, gid),
 		"force_destroy": tftypes.NewValue(tftypes.Bool, forceDestroy),
+		"timeouts":      nullTimeouts(),
 	})
 }
 
@@ -385,6 +389,45 @@ func TestHostPathResource_Create_SetPermError(t *testing.T) {
 	}
 }
 
+func TestHostPathResource_Create_SetPermValidationError(t *testing.T) {
+	r := &HostPathResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Filesystem: &truenas.MockFilesystemService{
+				ClientFunc: func() truenas.FileCaller {
+					return &client.MockClient{
+						MkdirAllFunc: func(ctx context.Context, path string, mode fs.FileMode) error {
+							return nil
+						},
+					}
+				},
+				SetPermissionsFunc: func(ctx context.Context, opts truenas.SetPermOpts) error {
+					return validationRPCError([]any{"filesystem_setperm.uid", "User does not exist", float64(22)})
+				},
+			},
+		}},
+	}
+
+	schemaResp := getHostPathResourceSchema(t)
+	planValue := createHostPathResourceModel(nil, "/mnt/tank/apps/myapp", "755", 1000, 1000)
+
+	req := resource.CreateRequest{
+		Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: planValue},
+	}
+	resp := &resource.CreateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema},
+	}
+
+	r.Create(context.Background(), req, resp)
+
+	if resp.Diagnostics.ErrorsCount() != 1 {
+		t.Fatalf("expected one error, got %v", resp.Diagnostics)
+	}
+	withPath, ok := resp.Diagnostics.Errors()[0].(diag.DiagnosticWithPath)
+	if !ok || !withPath.Path().Equal(path.Root("uid")) {
+		t.Errorf("expected the error on uid, got %v", resp.Diagnostics.Errors()[0])
+	}
+}
+
 func TestHostPathResource_Read_Success(t *testing.T) {
 	r := &HostPathResource{
 		BaseResource: BaseResource{services: &services.TrueNASServices{
@@ -436,7 +479,7 @@ func TestHostPathResource_Read_NotFound(t *testing.T) {
 		BaseResource: BaseResource{services: &services.TrueNASServices{
 			Filesystem: &truenas.MockFilesystemService{
 				StatFunc: func(ctx context.Context, path string) (*truenas.StatResult, error) {
-					return nil, errors.New("path not found")
+					return nil, &services.NotFoundError{Err: errors.New("[ENOENT] Path /mnt/tank/apps/myapp not found")}
 				},
 			},
 		}},
@@ -502,16 +545,9 @@ func TestHostPathResource_Read_APIError(t *testing.T) {
 
 	r.Read(context.Background(), req, resp)
 
-	// For a "not found" style error, we remove state. But we need to differentiate.
-	// Let's treat any error as "not found" for simplicity since TrueNAS returns error when path doesn't exist
-	// Actually let me reconsider - we should treat specific "not found" differently from connection errors
-	// For now, any API error during Read removes the resource from state (consistent with Terraform idioms)
-	if resp.Diagnostics.HasError() {
-		t.Fatalf("unexpected errors - read should remove from state on API error: %v", resp.Diagnostics)
-	}
-
-	if !resp.State.Raw.IsNull() {
-		t.Error("expected state to be removed (null) on API error")
+	// Only a not-found error removes the resource from state
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for API error")
 	}
 }
 
@@ -0,0 +1,186 @@
+package resources
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/identityschema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// -- Resource identities --
+//
+// A resource identity is the stable, structured key Terraform stores next to
+// the state of a resource, for example to report list results or to detect
+// that a refresh returned a different object. An import block can give the
+// identity instead of an import ID; resolveImportID turns it into the ID.
+
+// importableIdentity is a resource identity that can be written as the
+// import ID of the object it identifies.
+type importableIdentity interface {
+	importID() string
+}
+
+// numericIdentity identifies an object by the numeric ID the middleware
+// assigns, as for cron jobs, cloud sync tasks and VMs.
+type numericIdentity struct {
+	ID types.Int64 `tfsdk:"id"`
+}
+
+func (i numericIdentity) importID() string {
+	return strconv.FormatInt(i.ID.ValueInt64(), 10)
+}
+
+// numericIdentitySchema returns the identity schema of numericIdentity.
+// description describes the ID, for example "Cron job ID.".
+func numericIdentitySchema(description string) identityschema.Schema {
+	return identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"id": identityschema.Int64Attribute{
+				Description:       description,
+				RequiredForImport: true,
+			},
+		},
+	}
+}
+
+// nameIdentity identifies an object by its unique name, as for apps and
+// virt instances.
+type nameIdentity struct {
+	Name types.String `tfsdk:"name"`
+}
+
+func (i nameIdentity) importID() string {
+	return i.Name.ValueString()
+}
+
+// nameIdentitySchema returns the identity schema of nameIdentity.
+func nameIdentitySchema(description string) identityschema.Schema {
+	return identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"name": identityschema.StringAttribute{
+				Description:       description,
+				RequiredForImport: true,
+			},
+		},
+	}
+}
+
+// poolPathIdentity identifies a dataset or zvol by its pool and its path
+// within the pool.
+type poolPathIdentity struct {
+	Pool types.String `tfsdk:"pool"`
+	Path types.String `tfsdk:"path"`
+}
+
+// newPoolPathIdentity returns the identity of the dataset or zvol id, for
+// example "tank/vms/disk0".
+func newPoolPathIdentity(id string) poolPathIdentity {
+	pool, path := poolDatasetIDToParts(id)
+	return poolPathIdentity{Pool: types.StringValue(pool), Path: types.StringValue(path)}
+}
+
+func (i poolPathIdentity) importID() string {
+	return i.Pool.ValueString() + "/" + i.Path.ValueString()
+}
+
+// poolPathIdentitySchema returns the identity schema of poolPathIdentity.
+func poolPathIdentitySchema() identityschema.Schema {
+	return identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"pool": identityschema.StringAttribute{
+				Description:       "Pool name.",
+				RequiredForImport: true,
+			},
+			"path": identityschema.StringAttribute{
+				Description:       "Path within the pool (e.g., 'vms/disk0').",
+				RequiredForImport: true,
+			},
+		},
+	}
+}
+
+// snapshotIdentity identifies a snapshot by its dataset and name.
+type snapshotIdentity struct {
+	Dataset types.String `tfsdk:"dataset"`
+	Name    types.String `tfsdk:"name"`
+}
+
+// newSnapshotIdentity returns the identity of the snapshot id, for example
+// "tank/data@daily".
+func newSnapshotIdentity(id string) snapshotIdentity {
+	dataset, name, _ := strings.Cut(id, "@")
+	return snapshotIdentity{Dataset: types.StringValue(dataset), Name: types.StringValue(name)}
+}
+
+func (i snapshotIdentity) importID() string {
+	return i.Dataset.ValueString() + "@" + i.Name.ValueString()
+}
+
+// snapshotIdentitySchema returns the identity schema of snapshotIdentity.
+func snapshotIdentitySchema() identityschema.Schema {
+	return identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"dataset": identityschema.StringAttribute{
+				Description:       "Dataset ID (e.g., 'tank/data').",
+				RequiredForImport: true,
+			},
+			"name": identityschema.StringAttribute{
+				Description:       "Snapshot name.",
+				RequiredForImport: true,
+			},
+		},
+	}
+}
+
+// pathIdentity identifies a directory or file by its absolute path, as for
+// host paths and files.
+type pathIdentity struct {
+	Path types.String `tfsdk:"path"`
+}
+
+func (i pathIdentity) importID() string {
+	return i.Path.ValueString()
+}
+
+// pathIdentitySchema returns the identity schema of pathIdentity.
+func pathIdentitySchema(description string) identityschema.Schema {
+	return identityschema.Schema{
+		Attributes: map[string]identityschema.Attribute{
+			"path": identityschema.StringAttribute{
+				Description:       description,
+				RequiredForImport: true,
+			},
+		},
+	}
+}
+
+// resolveImportID sets req.ID from the identity of an import block that
+// gives one, so that ImportState handles both kinds of import alike.
+// identity is a pointer to the identity type of the resource. It reports
+// false when the identity could not be read.
+func resolveImportID(ctx context.Context, req *resource.ImportStateRequest, identity importableIdentity, diags *diag.Diagnostics) bool {
+	if req.ID != "" || req.Identity == nil || req.Identity.Raw.IsNull() {
+		return true
+	}
+	diags.Append(req.Identity.Get(ctx, identity)...)
+	if diags.HasError() {
+		return false
+	}
+	req.ID = identity.importID()
+	return true
+}
+
+// setIdentity sets the identity of a response to value. Terraform always
+// passes an identity to resources with an identity schema; it is nil when
+// a resource is called directly, as in unit tests.
+func setIdentity(ctx context.Context, identity *tfsdk.ResourceIdentity, value any, diags *diag.Diagnostics) {
+	if identity == nil {
+		return
+	}
+	diags.Append(identity.Set(ctx, value)...)
+}
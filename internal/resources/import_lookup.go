@@ -0,0 +1,78 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+// importLookup returns the IDs of the objects whose attribute equals value,
+// for an import identifier such as "name:backups".
+type importLookup func(ctx context.Context, value string) ([]int64, error)
+
+// importByLookup imports a resource with a numeric ID. The import identifier
+// is either the ID itself or "<key>:<value>", where key names one of lookups,
+// so that objects can be imported by the name shown in the TrueNAS UI. noun
+// names the object in errors, for example "cron job". A lookup that matches
+// no object, or more than one, is an error. An import by identity gives the
+// ID in a numericIdentity.
+func (b *BaseResource) importByLookup(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse, noun string, lookups map[string]importLookup) {
+	if !resolveImportID(ctx, &req, &numericIdentity{}, &resp.Diagnostics) {
+		return
+	}
+	if _, err := strconv.ParseInt(req.ID, 10, 64); err == nil {
+		resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+		return
+	}
+
+	keys := make([]string, 0, len(lookups))
+	for key := range lookups {
+		keys = append(keys, key+":<"+key+">")
+	}
+	slices.Sort(keys)
+
+	key, value, ok := strings.Cut(req.ID, ":")
+	lookup := lookups[key]
+	if !ok || lookup == nil || value == "" {
+		resp.Diagnostics.AddError(
+			"Invalid Import ID",
+			fmt.Sprintf("Expected a numeric %s ID or %s, got %q.", noun, strings.Join(keys, " or "), req.ID),
+		)
+		return
+	}
+
+	ids, err := lookup(ctx, value)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Look Up Import ID",
+			fmt.Sprintf("Unable to look up the %s with %s %q: %s", noun, key, value, err.Error()),
+		)
+		return
+	}
+
+	switch len(ids) {
+	case 0:
+		resp.Diagnostics.AddError(
+			"Import Target Not Found",
+			fmt.Sprintf("No %s has %s %q.", noun, key, value),
+		)
+	case 1:
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), strconv.FormatInt(ids[0], 10))...)
+	default:
+		slices.Sort(ids)
+		matches := make([]string, len(ids))
+		for i, id := range ids {
+			matches[i] = strconv.FormatInt(id, 10)
+		}
+		resp.Diagnostics.AddError(
+			"Ambiguous Import ID",
+			fmt.Sprintf("The %s %s %q is ambiguous: it matches IDs %s. Import one of them by its numeric ID.",
+				noun, key, value, strings.Join(matches, ", ")),
+		)
+	}
+}
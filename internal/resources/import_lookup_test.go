@@ -0,0 +1,79 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestBaseResource_importByLookup(t *testing.T) {
+	lookups := map[string]importLookup{
+		"name": func(ctx context.Context, name string) ([]int64, error) {
+			switch name {
+			case "backups":
+				return []int64{7}, nil
+			case "dup":
+				return []int64{9, 3}, nil
+			case "broken":
+				return nil, errors.New("connection refused")
+			}
+			return nil, nil
+		},
+	}
+
+	tests := []struct {
+		name string
+		id   string
+		// wantID is the imported ID, or "" when the import fails with an
+		// error whose detail contains wantErr.
+		wantID  string
+		wantErr string
+	}{
+		{name: "numeric ID", id: "12", wantID: "12"},
+		{name: "lookup", id: "name:backups", wantID: "7"},
+		{name: "value with colon", id: "name:dup:x", wantErr: `No widget has name "dup:x".`},
+		{name: "not found", id: "name:missing", wantErr: `No widget has name "missing".`},
+		{name: "ambiguous", id: "name:dup", wantErr: `The widget name "dup" is ambiguous: it matches IDs 3, 9.`},
+		{name: "lookup error", id: "name:broken", wantErr: "connection refused"},
+		{name: "unknown key", id: "description:backups", wantErr: `Expected a numeric widget ID or name:<name>, got "description:backups".`},
+		{name: "empty value", id: "name:", wantErr: "Expected a numeric widget ID"},
+		{name: "not numeric", id: "backups", wantErr: "Expected a numeric widget ID"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &BaseResource{}
+			resp := &resource.ImportStateResponse{
+				State: tfsdk.State{
+					Schema: importStateTestSchema(),
+					Raw:    tftypes.NewValue(importStateTestSchema().Type().TerraformType(context.Background()), nil),
+				},
+			}
+
+			b.importByLookup(context.Background(), resource.ImportStateRequest{ID: tc.id}, resp, "widget", lookups)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() {
+					t.Fatal("expected an error")
+				}
+				if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.Contains(detail, tc.wantErr) {
+					t.Errorf("expected error containing %q, got %q", tc.wantErr, detail)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+			}
+			var id string
+			resp.Diagnostics.Append(resp.State.GetAttribute(context.Background(), path.Root("id"), &id)...)
+			if id != tc.wantID {
+				t.Errorf("expected ID %q, got %q", tc.wantID, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package resources
+
+import (
+	"context"
+	"iter"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+)
+
+// listEntry is one object found by a list resource.
+type listEntry struct {
+	// ImportID is the ID "terraform import" takes for the object.
+	ImportID string
+	// DisplayName names the object in "terraform query" output.
+	DisplayName string
+	// Identity is the resource identity of the object, such as a
+	// numericIdentity.
+	Identity any
+}
+
+// displayName returns name, or fallback for objects without a name.
+func displayName(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// listError ends stream with an error, for a list that could not be made.
+func listError(stream *list.ListResultsStream, summary, detail string) {
+	var diags diag.Diagnostics
+	diags.AddError(summary, detail)
+	stream.Results = list.ListResultsStreamDiagnostics(diags)
+}
+
+// listResults returns the results of req for entries, stopping at the limit
+// of the request. When the request includes resource objects, as for
+// "terraform query -generate-config-out", each object is the state r has
+// after importing the entry, exactly as "terraform import" would produce.
+// Entries deleted since they were listed are skipped.
+func listResults(ctx context.Context, r resource.ResourceWithImportState, req list.ListRequest, entries []listEntry) iter.Seq[list.ListResult] {
+	return func(push func(list.ListResult) bool) {
+		var count int64
+		for _, e := range entries {
+			if req.Limit > 0 && count >= req.Limit {
+				return
+			}
+
+			result := req.NewListResult(ctx)
+			result.DisplayName = e.DisplayName
+			result.Diagnostics.Append(result.Identity.Set(ctx, e.Identity)...)
+			if req.IncludeResource && !result.Diagnostics.HasError() && !importListed(ctx, r, e.ImportID, &result) {
+				continue
+			}
+
+			count++
+			if !push(result) {
+				return
+			}
+		}
+	}
+}
+
+// importListed sets the resource object and identity of result to the state
+// of r after ImportState and Read of importID. It reports false when Read
+// found that the object no longer exists.
+func importListed(ctx context.Context, r resource.ResourceWithImportState, importID string, result *list.ListResult) bool {
+	importResp := resource.ImportStateResponse{
+		State:    tfsdk.State{Schema: result.Resource.Schema, Raw: result.Resource.Raw.Copy()},
+		Identity: &tfsdk.ResourceIdentity{Schema: result.Identity.Schema, Raw: result.Identity.Raw.Copy()},
+	}
+	r.ImportState(ctx, resource.ImportStateRequest{ID: importID}, &importResp)
+	result.Diagnostics.Append(importResp.Diagnostics...)
+	if result.Diagnostics.HasError() {
+		return true
+	}
+
+	readReq := resource.ReadRequest{
+		State:    importResp.State,
+		Identity: importResp.Identity,
+	}
+	readResp := resource.ReadResponse{
+		State:    tfsdk.State{Schema: importResp.State.Schema, Raw: importResp.State.Raw.Copy()},
+		Identity: &tfsdk.ResourceIdentity{Schema: importResp.Identity.Schema, Raw: importResp.Identity.Raw.Copy()},
+	}
+	r.Read(ctx, readReq, &readResp)
+	result.Diagnostics.Append(readResp.Diagnostics...)
+	if result.Diagnostics.HasError() {
+		return true
+	}
+	if readResp.State.Raw.IsNull() {
+		return false
+	}
+
+	result.Resource = &tfsdk.Resource{Schema: readResp.State.Schema, Raw: readResp.State.Raw}
+	result.Identity = readResp.Identity
+	return true
+}
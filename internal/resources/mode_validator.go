@@ -0,0 +1,32 @@
+package resources
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// modeValidator returns a validator for Unix mode attributes that rejects
+// anything ParseMode cannot parse.
+func modeValidator() validator.String {
+	return &unixModeValidator{}
+}
+
+type unixModeValidator struct{}
+
+func (v *unixModeValidator) Description(ctx context.Context) string {
+	return "value must be a Unix mode written in octal, such as 0644"
+}
+
+func (v *unixModeValidator) MarkdownDescription(ctx context.Context) string {
+	return "value must be a Unix mode written in octal, such as `0644`"
+}
+
+func (v *unixModeValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := ParseMode(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Mode", err.Error())
+	}
+}
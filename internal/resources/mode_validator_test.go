@@ -0,0 +1,37 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestModeValidator(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     types.String
+		expectErr bool
+	}{
+		{"octal with leading zero", types.StringValue("0644"), false},
+		{"octal without leading zero", types.StringValue("755"), false},
+		{"null", types.StringNull(), false},
+		{"unknown", types.StringUnknown(), false},
+		{"not a number", types.StringValue("rwxr-xr-x"), true},
+		{"non-octal digits", types.StringValue("0999"), true},
+		{"empty", types.StringValue(""), true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := validator.StringRequest{Path: path.Root("mode"), ConfigValue: tc.value}
+			resp := &validator.StringResponse{}
+			modeValidator().ValidateString(context.Background(), req, resp)
+			if resp.Diagnostics.HasError() != tc.expectErr {
+				t.Errorf("expected error %v, got %v", tc.expectErr, resp.Diagnostics)
+			}
+		})
+	}
+}
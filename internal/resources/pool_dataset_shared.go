@@ -1,9 +1,12 @@
 package resources
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
+	listschema "github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
@@ -41,6 +44,35 @@ func poolDatasetFullName(pool, path, parent, name types.String) string {
 	return ""
 }
 
+// requiresReplaceIfRenamed returns a plan modifier for the pool, path,
+// parent and name attributes that replaces the dataset or zvol only when its
+// full name changes. Switching between the pool + path and parent + path
+// forms of the same name, as an import or a moved block does, is not a
+// change.
+func requiresReplaceIfRenamed() planmodifier.String {
+	return stringplanmodifier.RequiresReplaceIf(
+		func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+			var priorID, pool, datasetPath, parent, name types.String
+			resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &priorID)...)
+			resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("pool"), &pool)...)
+			resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("path"), &datasetPath)...)
+			resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("parent"), &parent)...)
+			// Zvols have no name attribute
+			if _, ok := req.Plan.Schema.GetAttributes()["name"]; ok {
+				resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("name"), &name)...)
+			}
+			if resp.Diagnostics.HasError() {
+				return
+			}
+
+			fullName := poolDatasetFullName(pool, datasetPath, parent, name)
+			resp.RequiresReplace = fullName == "" || fullName != priorID.ValueString()
+		},
+		"Changing the full name of the dataset requires replacement.",
+		"Changing the full name of the dataset requires replacement.",
+	)
+}
+
 // poolDatasetIDToParts splits a dataset ID like "tank/vms/disk0" into pool="tank", path="vms/disk0".
 func poolDatasetIDToParts(id string) (pool, path string) {
 	parts := strings.SplitN(id, "/", 2)
@@ -67,21 +99,43 @@ func poolDatasetIdentitySchema() map[string]schema.Attribute {
 			Description: "Pool name. Use with 'path' attribute.",
 			Optional:    true,
 			PlanModifiers: []planmodifier.String{
-				stringplanmodifier.RequiresReplace(),
+				requiresReplaceIfRenamed(),
 			},
 		},
 		"path": schema.StringAttribute{
 			Description: "Path within the pool (e.g., 'vms/disk0').",
 			Optional:    true,
 			PlanModifiers: []planmodifier.String{
-				stringplanmodifier.RequiresReplace(),
+				requiresReplaceIfRenamed(),
 			},
 		},
 		"parent": schema.StringAttribute{
 			Description: "Parent dataset ID (e.g., 'tank/vms'). Use with 'path' attribute.",
 			Optional:    true,
 			PlanModifiers: []planmodifier.String{
-				stringplanmodifier.RequiresReplace(),
+				requiresReplaceIfRenamed(),
+			},
+		},
+	}
+}
+
+// -- Shared list configuration --
+
+// poolListConfig is the configuration of the dataset and zvol list
+// resources.
+type poolListConfig struct {
+	Pool types.String `tfsdk:"pool"`
+}
+
+// poolListSchema returns the list resource schema of poolListConfig. noun is
+// the plural of the listed objects, for example "datasets".
+func poolListSchema(noun string) listschema.Schema {
+	return listschema.Schema{
+		Description: fmt.Sprintf("Lists the %s on TrueNAS.", noun),
+		Attributes: map[string]listschema.Attribute{
+			"pool": listschema.StringAttribute{
+				Description: fmt.Sprintf("Only list the %s of this pool.", noun),
+				Optional:    true,
 			},
 		},
 	}
@@ -0,0 +1,167 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// -- Plan-time reference checks --
+//
+// Resources that refer to other objects on the NAS look them up in
+// ModifyPlan, so that a mistyped pool or credential ID fails the plan instead
+// of an apply that has already changed other resources. Objects Terraform
+// cannot create, such as pools, are reported as errors. Objects another
+// resource of the same configuration may create first, such as a parent
+// dataset, are reported as warnings. References are only looked up when
+// they are known and added or changed, and not at all when the provider is
+// configured with skip_reference_checks.
+
+// checksReferences reports whether ModifyPlan should look up the references
+// of req. Destroy plans and plans that already failed are not checked.
+func (b *BaseResource) checksReferences(req resource.ModifyPlanRequest, diags diag.Diagnostics) bool {
+	return b.services != nil && !b.services.SkipReferenceChecks && !req.Plan.Raw.IsNull() && !diags.HasError()
+}
+
+// plannedReference returns the planned value of the attribute at p, and
+// reports whether it is known and differs from the prior state.
+func plannedReference[T attr.Value](ctx context.Context, req resource.ModifyPlanRequest, p path.Path) (T, bool) {
+	var planned, prior T
+	if diags := req.Plan.GetAttribute(ctx, p, &planned); diags.HasError() || planned.IsNull() || planned.IsUnknown() {
+		return planned, false
+	}
+	if !req.State.Raw.IsNull() {
+		if diags := req.State.GetAttribute(ctx, p, &prior); !diags.HasError() && prior.Equal(planned) {
+			return planned, false
+		}
+	}
+	return planned, true
+}
+
+// addReferenceLookupWarning reports a failed lookup of the reference at p.
+// The plan goes ahead; the apply reports the reference if it is wrong.
+func addReferenceLookupWarning(diags *diag.Diagnostics, p path.Path, object string, err error) {
+	diags.AddAttributeWarning(
+		p,
+		"Unable to Check Reference",
+		fmt.Sprintf("Unable to look up %s while planning: %s. Set skip_reference_checks = true in the provider configuration to plan without these lookups.", object, err.Error()),
+	)
+}
+
+// checkPool adds an error on p when pool does not exist. It reports whether
+// the pool was found.
+func (b *BaseResource) checkPool(ctx context.Context, diags *diag.Diagnostics, p path.Path, pool string) bool {
+	pools, err := b.services.Dataset.ListPools(ctx)
+	if err != nil {
+		addReferenceLookupWarning(diags, p, fmt.Sprintf("pool %q", pool), err)
+		return false
+	}
+	names := make([]string, len(pools))
+	for i, candidate := range pools {
+		if candidate.Name == pool {
+			return true
+		}
+		names[i] = candidate.Name
+	}
+	diags.AddAttributeError(
+		p,
+		"Pool Not Found",
+		fmt.Sprintf("Pool %q does not exist. Existing pools: %s.", pool, listOrNone(names)),
+	)
+	return false
+}
+
+// checkPoolDatasetReferences checks the pool and the parent dataset of the
+// dataset or zvol fullName, as built by poolDatasetFullName. parent is the
+// configured parent attribute, which the diagnostics point at when it is set.
+// Nothing is looked up when fullName is unknown or is the ID in state.
+func (b *BaseResource) checkPoolDatasetReferences(ctx context.Context, req resource.ModifyPlanRequest, diags *diag.Diagnostics, fullName string, parent types.String) {
+	if fullName == "" {
+		return
+	}
+	if !req.State.Raw.IsNull() {
+		var priorID types.String
+		if d := req.State.GetAttribute(ctx, path.Root("id"), &priorID); !d.HasError() && priorID.ValueString() == fullName {
+			return
+		}
+	}
+
+	poolPath, parentPath := path.Root("pool"), path.Root("path")
+	if !parent.IsNull() {
+		poolPath, parentPath = path.Root("parent"), path.Root("parent")
+	}
+	pool, _ := poolDatasetIDToParts(fullName)
+	if !b.checkPool(ctx, diags, poolPath, pool) {
+		return
+	}
+
+	parentID := fullName[:strings.LastIndex(fullName, "/")]
+	if parentID == pool {
+		return
+	}
+	ds, err := b.services.Dataset.GetDataset(ctx, parentID)
+	if err != nil && !isNotFoundError(err) {
+		addReferenceLookupWarning(diags, parentPath, fmt.Sprintf("dataset %q", parentID), err)
+		return
+	}
+	if ds == nil {
+		diags.AddAttributeWarning(
+			parentPath,
+			"Parent Dataset Not Found",
+			fmt.Sprintf("Dataset %q does not exist, so %q cannot be created unless another resource creates its parent first. "+
+				"Refer to the parent's id, or add depends_on, so that Terraform creates it before this one.", parentID, fullName),
+		)
+	}
+}
+
+// checkCloudSyncCredentials adds an error on p when the cloud sync
+// credentials id do not exist.
+func (b *BaseResource) checkCloudSyncCredentials(ctx context.Context, diags *diag.Diagnostics, p path.Path, id int64) {
+	cred, err := b.services.CloudSync.GetCredential(ctx, id)
+	if err != nil && !isNotFoundError(err) {
+		addReferenceLookupWarning(diags, p, fmt.Sprintf("cloud sync credentials %d", id), err)
+		return
+	}
+	if cred == nil {
+		diags.AddAttributeError(
+			p,
+			"Cloud Sync Credentials Not Found",
+			fmt.Sprintf("Cloud sync credentials %d do not exist. Use the id of a truenas_cloudsync_credentials resource or data source.", id),
+		)
+	}
+}
+
+// checkVirtStoragePool adds a warning on p when pool is not one of the
+// storage pools of the virt configuration, which truenas_virt_config may
+// still change in the same apply.
+func (b *BaseResource) checkVirtStoragePool(ctx context.Context, diags *diag.Diagnostics, p path.Path, pool string) {
+	cfg, err := b.services.Virt.GetGlobalConfig(ctx)
+	if err != nil {
+		addReferenceLookupWarning(diags, p, "the virt configuration", err)
+		return
+	}
+	if cfg == nil || slices.Contains(cfg.StoragePools, pool) {
+		return
+	}
+	diags.AddAttributeWarning(
+		p,
+		"Storage Pool Not Configured",
+		fmt.Sprintf("Pool %q is not a storage pool of the virt configuration. Configured storage pools: %s. "+
+			"Creating the instance fails unless truenas_virt_config configures the pool first.", pool, listOrNone(cfg.StoragePools)),
+	)
+}
+
+// listOrNone joins names for a diagnostic, or returns "none".
+func listOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
@@ -2,9 +2,15 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	listschema "github.com/hashicorp/terraform-plugin-framework/list/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
@@ -18,6 +24,9 @@ import (
 var _ resource.Resource = &SnapshotResource{}
 var _ resource.ResourceWithConfigure = &SnapshotResource{}
 var _ resource.ResourceWithImportState = &SnapshotResource{}
+var _ resource.ResourceWithModifyPlan = &SnapshotResource{}
+var _ resource.ResourceWithIdentity = &SnapshotResource{}
+var _ list.ListResourceWithConfigure = &SnapshotResource{}
 
 // SnapshotResource defines the resource implementation.
 type SnapshotResource struct {
@@ -34,6 +43,8 @@ type SnapshotResourceModel struct {
 	CreateTXG       types.String `tfsdk:"createtxg"`
 	UsedBytes       types.Int64  `tfsdk:"used_bytes"`
 	ReferencedBytes types.Int64  `tfsdk:"referenced_bytes"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // NewSnapshotResource creates a new SnapshotResource.
@@ -41,10 +52,20 @@ func NewSnapshotResource() resource.Resource {
 	return &SnapshotResource{}
 }
 
+// NewSnapshotListResource creates a SnapshotResource for listing snapshots.
+func NewSnapshotListResource() list.ListResource {
+	return &SnapshotResource{}
+}
+
 func (r *SnapshotResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_snapshot"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply.
+func (r *SnapshotResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_snapshot", req, resp)
+}
+
 func (r *SnapshotResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages a ZFS snapshot. Use for pre-upgrade backups and point-in-time recovery.",
@@ -107,9 +128,16 @@ func (r *SnapshotResource) Schema(ctx context.Context, req resource.SchemaReques
 				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.BlockAll(ctx),
+		},
 	}
 }
 
+func (r *SnapshotResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = snapshotIdentitySchema()
+}
+
 // mapSnapshotToModel maps a typed Snapshot to the Terraform model.
 func mapSnapshotToModel(snap *truenas.Snapshot, data *SnapshotResourceModel) {
 	data.ID = types.StringValue(snap.ID)
@@ -122,6 +150,10 @@ func mapSnapshotToModel(snap *truenas.Snapshot, data *SnapshotResourceModel) {
 }
 
 func (r *SnapshotResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data SnapshotResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
@@ -129,6 +161,12 @@ func (r *SnapshotResource) Create(ctx context.Context, req resource.CreateReques
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Create the snapshot
 	snap, err := r.services.Snapshot.Create(ctx, truenas.CreateSnapshotOpts{
 		Dataset:   data.DatasetID.ValueString(),
@@ -136,10 +174,8 @@ func (r *SnapshotResource) Create(ctx context.Context, req resource.CreateReques
 		Recursive: !data.Recursive.IsNull() && data.Recursive.ValueBool(),
 	})
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Snapshot",
-			fmt.Sprintf("Unable to create snapshot: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create Snapshot",
+			"Unable to create snapshot", err, nil)
 		return
 	}
 
@@ -147,20 +183,16 @@ func (r *SnapshotResource) Create(ctx context.Context, req resource.CreateReques
 	if !data.Hold.IsNull() && data.Hold.ValueBool() {
 		err := r.services.Snapshot.Hold(ctx, snap.ID)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Hold Snapshot",
-				fmt.Sprintf("Snapshot created but failed to apply hold: %s", err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Hold Snapshot",
+				"Snapshot created but failed to apply hold", err, nil)
 			return
 		}
 
 		// Re-read snapshot to get updated hold state
 		snap, err = r.services.Snapshot.Get(ctx, snap.ID)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Read Snapshot",
-				fmt.Sprintf("Snapshot created but unable to read: %s", err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Read Snapshot",
+				"Snapshot created but unable to read", err, nil)
 			return
 		}
 	}
@@ -176,6 +208,7 @@ func (r *SnapshotResource) Create(ctx context.Context, req resource.CreateReques
 	mapSnapshotToModel(snap, &data)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, newSnapshotIdentity(snap.ID), &resp.Diagnostics)
 }
 
 func (r *SnapshotResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -186,12 +219,22 @@ func (r *SnapshotResource) Read(ctx context.Context, req resource.ReadRequest, r
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	setIdentity(ctx, resp.Identity, newSnapshotIdentity(data.ID.ValueString()), &resp.Diagnostics)
+
 	snap, err := r.services.Snapshot.Get(ctx, data.ID.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Read Snapshot",
-			fmt.Sprintf("Unable to read snapshot %q: %s", data.ID.ValueString(), err.Error()),
-		)
+		if isNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Read Snapshot",
+			fmt.Sprintf("Unable to read snapshot %q", data.ID.ValueString()), err, nil)
 		return
 	}
 
@@ -207,6 +250,10 @@ func (r *SnapshotResource) Read(ctx context.Context, req resource.ReadRequest, r
 }
 
 func (r *SnapshotResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var state SnapshotResourceModel
 	var plan SnapshotResourceModel
 
@@ -216,6 +263,12 @@ func (r *SnapshotResource) Update(ctx context.Context, req resource.UpdateReques
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, plan.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	snapshotID := state.ID.ValueString()
 
 	// Handle hold changes
@@ -226,20 +279,16 @@ func (r *SnapshotResource) Update(ctx context.Context, req resource.UpdateReques
 		// Release hold
 		err := r.services.Snapshot.Release(ctx, snapshotID)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Release Snapshot Hold",
-				fmt.Sprintf("Unable to release hold on snapshot %q: %s", snapshotID, err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Release Snapshot Hold",
+				fmt.Sprintf("Unable to release hold on snapshot %q", snapshotID), err, nil)
 			return
 		}
 	} else if !stateHold && planHold {
 		// Apply hold
 		err := r.services.Snapshot.Hold(ctx, snapshotID)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Hold Snapshot",
-				fmt.Sprintf("Unable to hold snapshot %q: %s", snapshotID, err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Hold Snapshot",
+				fmt.Sprintf("Unable to hold snapshot %q", snapshotID), err, nil)
 			return
 		}
 	}
@@ -247,10 +296,8 @@ func (r *SnapshotResource) Update(ctx context.Context, req resource.UpdateReques
 	// Refresh state from API
 	snap, err := r.services.Snapshot.Get(ctx, snapshotID)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Read Snapshot",
-			fmt.Sprintf("Unable to read snapshot %q: %s", snapshotID, err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Read Snapshot",
+			fmt.Sprintf("Unable to read snapshot %q", snapshotID), err, nil)
 		return
 	}
 
@@ -266,9 +313,14 @@ func (r *SnapshotResource) Update(ctx context.Context, req resource.UpdateReques
 	plan.Hold = types.BoolValue(planHold) // Preserve the planned hold value
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	setIdentity(ctx, resp.Identity, newSnapshotIdentity(snap.ID), &resp.Diagnostics)
 }
 
 func (r *SnapshotResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data SnapshotResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -276,16 +328,23 @@ func (r *SnapshotResource) Delete(ctx context.Context, req resource.DeleteReques
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	snapshotID := data.ID.ValueString()
 
 	// If held, release first
 	if data.Hold.ValueBool() {
 		err := r.services.Snapshot.Release(ctx, snapshotID)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Release Snapshot Hold",
-				fmt.Sprintf("Unable to release hold before delete: %s", err.Error()),
-			)
+			if errors.Is(err, services.ErrNotFound) {
+				return // Already deleted
+			}
+			r.addAPIError(ctx, &resp.Diagnostics, req.State.Schema, "Unable to Release Snapshot Hold",
+				"Unable to release hold before delete", err, nil)
 			return
 		}
 	}
@@ -293,10 +352,65 @@ func (r *SnapshotResource) Delete(ctx context.Context, req resource.DeleteReques
 	// Delete the snapshot
 	err := r.services.Snapshot.Delete(ctx, snapshotID)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Delete Snapshot",
-			fmt.Sprintf("Unable to delete snapshot %q: %s", snapshotID, err.Error()),
-		)
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
+		r.addAPIError(ctx, &resp.Diagnostics, req.State.Schema, "Unable to Delete Snapshot",
+			fmt.Sprintf("Unable to delete snapshot %q", snapshotID), err, nil)
 		return
 	}
 }
+
+// snapshotListConfig is the configuration of the snapshot list resource.
+type snapshotListConfig struct {
+	DatasetID types.String `tfsdk:"dataset_id"`
+}
+
+// ImportState imports by ID, such as "tank/data@daily", or by identity.
+func (r *SnapshotResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if !resolveImportID(ctx, &req, &snapshotIdentity{}, &resp.Diagnostics) {
+		return
+	}
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func (r *SnapshotResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = listschema.Schema{
+		Description: "Lists the snapshots on TrueNAS.",
+		Attributes: map[string]listschema.Attribute{
+			"dataset_id": listschema.StringAttribute{
+				Description: "Only list the snapshots of this dataset (e.g., 'tank/data').",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+// List lists every snapshot, or those of one dataset.
+func (r *SnapshotResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var config snapshotListConfig
+	if diags := req.Config.Get(ctx, &config); diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	var filters [][]any
+	if !config.DatasetID.IsNull() {
+		filters = [][]any{{"dataset", "=", config.DatasetID.ValueString()}}
+	}
+	snaps, err := r.services.Snapshot.Query(ctx, filters)
+	if err != nil {
+		listError(stream, "Unable to List Snapshots", fmt.Sprintf("Unable to list snapshots: %s", err.Error()))
+		return
+	}
+
+	entries := make([]listEntry, len(snaps))
+	for i, snap := range snaps {
+		entries[i] = listEntry{
+			ImportID:    snap.ID,
+			DisplayName: snap.ID,
+			Identity:    newSnapshotIdentity(snap.ID),
+		}
+	}
+	stream.Results = listResults(ctx, r, req, entries)
+}
@@ -189,6 +189,7 @@ func createSnapshotResourceModelValue(p snapshotModelParams) tftypes.Value {
 			"createtxg":        tftypes.String,
 			"used_bytes":       tftypes.Number,
 			"referenced_bytes": tftypes.Number,
+			"timeouts":         timeoutsType,
 		},
 	}, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, p.ID),
@@ -199,6 +200,7 @@ func createSnapshotResourceModelValue(p snapshotModelParams) tftypes.Value {
 		"createtxg":        tftypes.NewValue(tftypes.String, p.CreateTXG),
 		"used_bytes":       tftypes.NewValue(tftypes.Number, p.UsedBytes),
 		"referenced_bytes": tftypes.NewValue(tftypes.Number, p.ReferencedBytes),
+		"timeouts":         nullTimeouts(),
 	})
 }
 
@@ -0,0 +1,81 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// eventPollInterval is how often waitForStableState still queries the state
+// while change events are delivered, in case an event was missed.
+const eventPollInterval = 30 * time.Second
+
+// watchStates subscribes to the change events of collection (for example
+// "app.query") and delivers the stateField of every added or changed row
+// named name. When the client cannot subscribe, as over SSH, the returned
+// channel is nil and the caller polls instead. stop ends the subscription.
+func (b *BaseResource) watchStates(ctx context.Context, collection, name, stateField string) (<-chan string, func()) {
+	if b.services == nil || b.services.Client == nil {
+		return nil, func() {}
+	}
+	sub, err := b.services.Client.Subscribe(ctx, collection, nil)
+	if err != nil || sub == nil {
+		return nil, func() {}
+	}
+
+	states := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		defer close(states)
+		for {
+			var event json.RawMessage
+			var ok bool
+			select {
+			case <-done:
+				return
+			case event, ok = <-sub.C:
+				if !ok {
+					return
+				}
+			}
+
+			var row map[string]any
+			if err := json.Unmarshal(event, &row); err != nil {
+				continue
+			}
+			if row["name"] != name {
+				continue
+			}
+			state, ok := row[stateField].(string)
+			if !ok {
+				continue
+			}
+			select {
+			case <-done:
+				return
+			case states <- state:
+			}
+		}
+	}()
+
+	return states, func() {
+		close(done)
+		sub.Close()
+	}
+}
+
+// waitForAppState waits for the app to reach a stable state, using app.query
+// change events when the transport supports them.
+func (r *AppResource) waitForAppState(ctx context.Context, name string, timeout time.Duration) (string, error) {
+	states, stop := r.watchStates(ctx, "app.query", name, "state")
+	defer stop()
+	return waitForStableState(ctx, name, timeout, r.queryAppState, states)
+}
+
+// waitForVirtInstanceState waits for the instance to reach a stable state,
+// using virt.instance.query change events when the transport supports them.
+func (r *VirtInstanceResource) waitForVirtInstanceState(ctx context.Context, name string, timeout time.Duration) (string, error) {
+	states, stop := r.watchStates(ctx, "virt.instance.query", name, "status")
+	defer stop()
+	return waitForStableState(ctx, name, timeout, r.getVirtInstanceState, states)
+}
@@ -0,0 +1,117 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/deevus/terraform-provider-truenas/internal/services"
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+func TestWaitForStableState_EventDeliversStableState(t *testing.T) {
+	callCount := 0
+	queryFunc := func(ctx context.Context, name string) (string, error) {
+		callCount++
+		return AppStateDeploying, nil
+	}
+
+	states := make(chan string, 2)
+	states <- AppStateDeploying
+	states <- AppStateRunning
+
+	start := time.Now()
+	state, err := waitForStableState(context.Background(), "myapp", 30*time.Second, queryFunc, states)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != AppStateRunning {
+		t.Errorf("expected state %q, got %q", AppStateRunning, state)
+	}
+	if callCount != 1 {
+		t.Errorf("expected only the initial query, got %d calls", callCount)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the event to end the wait, took %v", elapsed)
+	}
+}
+
+func TestWaitForStableState_ClosedEventsFallsBackToPolling(t *testing.T) {
+	callCount := 0
+	queryFunc := func(ctx context.Context, name string) (string, error) {
+		callCount++
+		if callCount < 3 {
+			return AppStateStarting, nil
+		}
+		return AppStateRunning, nil
+	}
+
+	states := make(chan string)
+	close(states)
+
+	state, err := waitForStableState(context.Background(), "myapp", time.Second, queryFunc, states)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != AppStateRunning {
+		t.Errorf("expected state %q, got %q", AppStateRunning, state)
+	}
+	if callCount != 3 {
+		t.Errorf("expected 3 calls, got %d", callCount)
+	}
+}
+
+func TestWatchStates_FiltersByName(t *testing.T) {
+	events := make(chan json.RawMessage, 4)
+	closed := false
+	var collection string
+	r := &BaseResource{services: &services.TrueNASServices{
+		Client: &client.MockClient{
+			SubscribeFunc: func(ctx context.Context, c string, params any) (*truenas.Subscription[json.RawMessage], error) {
+				collection = c
+				return truenas.NewSubscription[json.RawMessage](events, func() { closed = true }), nil
+			},
+		},
+	}}
+
+	events <- json.RawMessage(`{"name": "other", "state": "RUNNING"}`)
+	events <- json.RawMessage(`not json`)
+	events <- json.RawMessage(`{"name": "myapp", "state": "STOPPED"}`)
+
+	states, stop := r.watchStates(context.Background(), "app.query", "myapp", "state")
+	if collection != "app.query" {
+		t.Errorf("expected a subscription to app.query, got %q", collection)
+	}
+
+	select {
+	case state := <-states:
+		if state != AppStateStopped {
+			t.Errorf("expected state %q, got %q", AppStateStopped, state)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a state for myapp")
+	}
+
+	stop()
+	if !closed {
+		t.Error("expected stop to close the subscription")
+	}
+}
+
+func TestWatchStates_Unsupported(t *testing.T) {
+	r := &BaseResource{services: &services.TrueNASServices{
+		Client: &client.MockClient{
+			SubscribeFunc: func(ctx context.Context, c string, params any) (*truenas.Subscription[json.RawMessage], error) {
+				return nil, client.ErrUnsupportedOperation
+			},
+		},
+	}}
+
+	states, stop := r.watchStates(context.Background(), "app.query", "myapp", "state")
+	defer stop()
+	if states != nil {
+		t.Error("expected no state channel when the client cannot subscribe")
+	}
+}
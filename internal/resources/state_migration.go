@@ -0,0 +1,68 @@
+package resources
+
+import (
+	"context"
+	"path"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// -- State moves --
+//
+// A moved block whose from and to differ in resource type or provider asks
+// the target resource to take over the state of the source. Sources are
+// accepted from any provider named truenas, so that state recorded under an
+// earlier or forked provider address can be moved to this one.
+
+// isTrueNASProvider reports whether address, such as
+// "registry.terraform.io/deevus/truenas", names a TrueNAS provider.
+func isTrueNASProvider(address string) bool {
+	return path.Base(address) == "truenas"
+}
+
+// resourceSchema returns the current schema of r.
+func resourceSchema(ctx context.Context, r resource.Resource) schema.Schema {
+	var resp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &resp)
+	return resp.Schema
+}
+
+// renamedProviderMover moves the state of typeName from another TrueNAS
+// provider address unchanged. s is the current schema of typeName; attributes
+// the source has and s does not are dropped, and attributes it lacks are null
+// until the next refresh.
+func renamedProviderMover(s schema.Schema, typeName string) resource.StateMover {
+	return resource.StateMover{
+		SourceSchema: &s,
+		StateMover: func(ctx context.Context, req resource.MoveStateRequest, resp *resource.MoveStateResponse) {
+			if req.SourceTypeName != typeName || !isTrueNASProvider(req.SourceProviderAddress) || req.SourceState == nil {
+				return
+			}
+			resp.TargetState.Raw = req.SourceState.Raw
+		},
+	}
+}
+
+// -- State upgrades --
+
+// upgradeFromRaw is a StateUpgrader for prior versions whose state decodes
+// into the current schema: attributes that were removed are dropped and
+// attributes that were added are null until the next refresh. Upgraders for
+// changes that rename or retype attributes need their own PriorSchema.
+func upgradeFromRaw(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	typ := resp.State.Schema.Type().TerraformType(ctx)
+	v, err := req.RawState.UnmarshalWithOpts(typ, tfprotov6.UnmarshalOpts{
+		ValueFromJSONOpts: tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true},
+	})
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to Upgrade Resource State",
+			"Unable to read the prior state of the resource: "+err.Error(),
+		)
+		return
+	}
+	resp.State.Raw = v
+}
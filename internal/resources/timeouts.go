@@ -0,0 +1,33 @@
+package resources
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// Default operation timeouts, used when the timeouts block of a resource does
+// not set one. Creates and updates can run middleware jobs such as image
+// pulls, so they get the most time.
+const (
+	defaultCreateTimeout = 30 * time.Minute
+	defaultReadTimeout   = 5 * time.Minute
+	defaultUpdateTimeout = 30 * time.Minute
+	defaultDeleteTimeout = 20 * time.Minute
+)
+
+// timeoutFunc is one of the Create, Read, Update and Delete methods of
+// timeouts.Value.
+type timeoutFunc func(ctx context.Context, defaultTimeout time.Duration) (time.Duration, diag.Diagnostics)
+
+// withTimeout returns a copy of ctx that expires after the timeout returned
+// by get, or after defaultTimeout when the timeouts block does not set it.
+// Every call the operation makes must use the returned context, so that
+// middleware jobs still running at expiry are aborted. The returned cancel
+// function must be called even when diags has errors.
+func withTimeout(ctx context.Context, get timeoutFunc, defaultTimeout time.Duration, diags *diag.Diagnostics) (context.Context, context.CancelFunc) {
+	timeout, d := get(ctx, defaultTimeout)
+	diags.Append(d...)
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,110 @@
+package resources
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// timeoutsType is the tftypes.Object of the timeouts block, for constructing
+// test values.
+var timeoutsType = tftypes.Object{
+	AttributeTypes: map[string]tftypes.Type{
+		"create": tftypes.String,
+		"read":   tftypes.String,
+		"update": tftypes.String,
+		"delete": tftypes.String,
+	},
+}
+
+// nullTimeouts returns an unset timeouts block.
+func nullTimeouts() tftypes.Value {
+	return tftypes.NewValue(timeoutsType, nil)
+}
+
+func TestWithTimeout_Default(t *testing.T) {
+	value := timeouts.Value{Object: types.ObjectNull(map[string]attr.Type{
+		"create": types.StringType,
+		"read":   types.StringType,
+		"update": types.StringType,
+		"delete": types.StringType,
+	})}
+
+	var diags diag.Diagnostics
+	ctx, cancel := withTimeout(context.Background(), value.Create, defaultCreateTimeout, &diags)
+	defer cancel()
+
+	if diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected a deadline")
+	}
+	if d := time.Until(deadline); d < defaultCreateTimeout-time.Minute || d > defaultCreateTimeout {
+		t.Errorf("expected a deadline in %v, got %v", defaultCreateTimeout, d)
+	}
+}
+
+func TestWithTimeout_Configured(t *testing.T) {
+	value := timeouts.Value{Object: types.ObjectValueMust(
+		map[string]attr.Type{
+			"create": types.StringType,
+			"read":   types.StringType,
+			"update": types.StringType,
+			"delete": types.StringType,
+		},
+		map[string]attr.Value{
+			"create": types.StringNull(),
+			"read":   types.StringNull(),
+			"update": types.StringNull(),
+			"delete": types.StringValue("90s"),
+		},
+	)}
+
+	var diags diag.Diagnostics
+	ctx, cancel := withTimeout(context.Background(), value.Delete, defaultDeleteTimeout, &diags)
+	defer cancel()
+
+	if diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected a deadline")
+	}
+	if d := time.Until(deadline); d < 80*time.Second || d > 90*time.Second {
+		t.Errorf("expected a deadline in 90s, got %v", d)
+	}
+}
+
+func TestWithTimeout_Invalid(t *testing.T) {
+	value := timeouts.Value{Object: types.ObjectValueMust(
+		map[string]attr.Type{
+			"create": types.StringType,
+			"read":   types.StringType,
+			"update": types.StringType,
+			"delete": types.StringType,
+		},
+		map[string]attr.Value{
+			"create": types.StringNull(),
+			"read":   types.StringNull(),
+			"update": types.StringValue("soon"),
+			"delete": types.StringNull(),
+		},
+	)}
+
+	var diags diag.Diagnostics
+	_, cancel := withTimeout(context.Background(), value.Update, defaultUpdateTimeout, &diags)
+	defer cancel()
+
+	if !diags.HasError() {
+		t.Fatal("expected an error for an invalid duration")
+	}
+}
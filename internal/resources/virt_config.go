@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -17,6 +18,7 @@ var (
 	_ resource.Resource                = &VirtConfigResource{}
 	_ resource.ResourceWithConfigure   = &VirtConfigResource{}
 	_ resource.ResourceWithImportState = &VirtConfigResource{}
+	_ resource.ResourceWithModifyPlan  = &VirtConfigResource{}
 )
 
 // VirtConfigResourceModel describes the resource data model.
@@ -26,6 +28,8 @@ type VirtConfigResourceModel struct {
 	V4Network     types.String `tfsdk:"v4_network"`
 	V6Network     types.String `tfsdk:"v6_network"`
 	Pool types.String `tfsdk:"pool"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // VirtConfigResource defines the resource implementation.
@@ -42,6 +46,11 @@ func (r *VirtConfigResource) Metadata(ctx context.Context, req resource.Metadata
 	resp.TypeName = req.ProviderTypeName + "_virt_config"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply.
+func (r *VirtConfigResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_virt_config", req, resp)
+}
+
 func (r *VirtConfigResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages the global virtualization configuration on TrueNAS.",
@@ -70,11 +79,18 @@ func (r *VirtConfigResource) Schema(ctx context.Context, req resource.SchemaRequ
 				Optional:    true,
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.BlockAll(ctx),
+		},
 	}
 }
 
 
 func (r *VirtConfigResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data VirtConfigResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
@@ -82,14 +98,18 @@ func (r *VirtConfigResource) Create(ctx context.Context, req resource.CreateRequ
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Build opts and call API
 	opts := r.buildConfigOpts(&data)
 	config, err := r.services.Virt.UpdateGlobalConfig(ctx, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Update LXC Config",
-			fmt.Sprintf("Unable to update virtualization configuration: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update LXC Config",
+			"Unable to update virtualization configuration", err, nil)
 		return
 	}
 
@@ -110,6 +130,12 @@ func (r *VirtConfigResource) Read(ctx context.Context, req resource.ReadRequest,
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	config, err := r.services.Virt.GetGlobalConfig(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError(
@@ -128,6 +154,10 @@ func (r *VirtConfigResource) Read(ctx context.Context, req resource.ReadRequest,
 }
 
 func (r *VirtConfigResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var plan VirtConfigResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
@@ -135,14 +165,18 @@ func (r *VirtConfigResource) Update(ctx context.Context, req resource.UpdateRequ
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, plan.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Build opts and call API
 	opts := r.buildConfigOpts(&plan)
 	config, err := r.services.Virt.UpdateGlobalConfig(ctx, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Update LXC Config",
-			fmt.Sprintf("Unable to update virtualization configuration: %s", err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update LXC Config",
+			"Unable to update virtualization configuration", err, nil)
 		return
 	}
 
@@ -156,6 +190,23 @@ func (r *VirtConfigResource) Update(ctx context.Context, req resource.UpdateRequ
 }
 
 func (r *VirtConfigResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
+	var data VirtConfigResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Reset to defaults by setting all fields to empty strings
 	empty := ""
 	opts := truenas.UpdateVirtGlobalConfigOpts{
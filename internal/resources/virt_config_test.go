@@ -148,8 +148,11 @@ func createVirtConfigModelValue(p virtConfigModelParams) tftypes.Value {
 			"v4_network":     tftypes.String,
 			"v6_network":     tftypes.String,
 			"pool": tftypes.String,
+
+			"timeouts": timeoutsType,
 		},
 	}
+	values["timeouts"] = nullTimeouts()
 
 	return tftypes.NewValue(objectType, values)
 }
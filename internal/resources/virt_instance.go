@@ -2,14 +2,20 @@ package resources
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
+	"github.com/deevus/terraform-provider-truenas/internal/capabilities"
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	listschema "github.com/hashicorp/terraform-plugin-framework/list/schema"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -34,6 +40,9 @@ var (
 	_ resource.Resource                = &VirtInstanceResource{}
 	_ resource.ResourceWithConfigure   = &VirtInstanceResource{}
 	_ resource.ResourceWithImportState = &VirtInstanceResource{}
+	_ resource.ResourceWithModifyPlan  = &VirtInstanceResource{}
+	_ resource.ResourceWithIdentity    = &VirtInstanceResource{}
+	_ list.ListResourceWithConfigure   = &VirtInstanceResource{}
 )
 
 // VirtInstanceResourceModel describes the resource data model.
@@ -53,6 +62,8 @@ type VirtInstanceResourceModel struct {
 	Disks           []DiskModel  `tfsdk:"disk"`
 	NICs            []NICModel   `tfsdk:"nic"`
 	Proxies         []ProxyModel `tfsdk:"proxy"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 // DiskModel represents a disk device attachment.
@@ -92,15 +103,39 @@ type VirtInstanceResource struct {
 	BaseResource
 }
 
+// virtInstanceAPIFields maps virt.instance.create attributes to the schema.
+var virtInstanceAPIFields = map[string]string{
+	"image": "image_name",
+}
+
 // NewVirtInstanceResource creates a new VirtInstanceResource.
 func NewVirtInstanceResource() resource.Resource {
 	return &VirtInstanceResource{}
 }
 
+// NewVirtInstanceListResource creates a VirtInstanceResource for listing
+// containers.
+func NewVirtInstanceListResource() list.ListResource {
+	return &VirtInstanceResource{}
+}
+
 func (r *VirtInstanceResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_virt_instance"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply
+// and references to objects that do not exist.
+func (r *VirtInstanceResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_virt_instance", req, resp)
+	if !r.checksReferences(req, resp.Diagnostics) {
+		return
+	}
+
+	if pool, changed := plannedReference[types.String](ctx, req, path.Root("storage_pool")); changed {
+		r.checkVirtStoragePool(ctx, &resp.Diagnostics, path.Root("storage_pool"), pool.ValueString())
+	}
+}
+
 func (r *VirtInstanceResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages an Incus/LXC container on TrueNAS 25.0+.",
@@ -294,12 +329,20 @@ func (r *VirtInstanceResource) Schema(ctx context.Context, req resource.SchemaRe
 					},
 				},
 			},
+			"timeouts": timeouts.BlockAll(ctx),
 		},
 	}
 }
 
+func (r *VirtInstanceResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = nameIdentitySchema("Container name.")
+}
 
 func (r *VirtInstanceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data VirtInstanceResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
@@ -307,13 +350,14 @@ func (r *VirtInstanceResource) Create(ctx context.Context, req resource.CreateRe
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Check version requirement
-	version := r.services.Client.Version()
-	if !version.AtLeast(25, 0) {
-		resp.Diagnostics.AddError(
-			"Unsupported TrueNAS Version",
-			fmt.Sprintf("Container resources require TrueNAS 25.0 or later. Detected version: %s", version.String()),
-		)
+	if !capabilities.Check("truenas_virt_instance", r.services.Version(), &resp.Diagnostics) {
 		return
 	}
 
@@ -324,10 +368,8 @@ func (r *VirtInstanceResource) Create(ctx context.Context, req resource.CreateRe
 	// Call VirtService.CreateInstance (does CallAndWait + GetInstance internally)
 	container, err := r.services.Virt.CreateInstance(ctx, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Container",
-			fmt.Sprintf("Unable to create container %q: %s", containerName, err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create Container",
+			fmt.Sprintf("Unable to create container %q", containerName), err, virtInstanceAPIFields)
 		return
 	}
 
@@ -379,11 +421,7 @@ func (r *VirtInstanceResource) Create(ctx context.Context, req resource.CreateRe
 		}
 
 		// Wait for stable state
-		queryFunc := func(ctx context.Context, n string) (string, error) {
-			return r.getVirtInstanceState(ctx, n)
-		}
-
-		finalState, err := waitForStableState(ctx, containerName, timeout, queryFunc)
+		finalState, err := r.waitForVirtInstanceState(ctx, containerName, timeout)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Timeout Waiting for Container State",
@@ -404,6 +442,7 @@ func (r *VirtInstanceResource) Create(ctx context.Context, req resource.CreateRe
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, nameIdentity{Name: data.Name}, &resp.Diagnostics)
 }
 
 func (r *VirtInstanceResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -414,13 +453,14 @@ func (r *VirtInstanceResource) Read(ctx context.Context, req resource.ReadReques
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Check version requirement
-	version := r.services.Client.Version()
-	if !version.AtLeast(25, 0) {
-		resp.Diagnostics.AddError(
-			"Unsupported TrueNAS Version",
-			fmt.Sprintf("Container resources require TrueNAS 25.0 or later. Detected version: %s", version.String()),
-		)
+	if !capabilities.Check("truenas_virt_instance", r.services.Version(), &resp.Diagnostics) {
 		return
 	}
 
@@ -429,9 +469,14 @@ func (r *VirtInstanceResource) Read(ctx context.Context, req resource.ReadReques
 	priorStateTimeout := data.StateTimeout
 
 	containerName := data.Name.ValueString()
+	setIdentity(ctx, resp.Identity, nameIdentity{Name: data.Name}, &resp.Diagnostics)
 
 	container, err := r.services.Virt.GetInstance(ctx, containerName)
 	if err != nil {
+		if isNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Read Container",
 			fmt.Sprintf("Unable to read container %q: %s", containerName, err.Error()),
@@ -482,6 +527,10 @@ func (r *VirtInstanceResource) Read(ctx context.Context, req resource.ReadReques
 }
 
 func (r *VirtInstanceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var data VirtInstanceResourceModel
 	var stateData VirtInstanceResourceModel
 
@@ -490,18 +539,19 @@ func (r *VirtInstanceResource) Update(ctx context.Context, req resource.UpdateRe
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	resp.Diagnostics.Append(req.State.Get(ctx, &stateData)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	// Check version requirement
-	version := r.services.Client.Version()
-	if !version.AtLeast(25, 0) {
-		resp.Diagnostics.AddError(
-			"Unsupported TrueNAS Version",
-			fmt.Sprintf("Container resources require TrueNAS 25.0 or later. Detected version: %s", version.String()),
-		)
+	if !capabilities.Check("truenas_virt_instance", r.services.Version(), &resp.Diagnostics) {
 		return
 	}
 
@@ -513,10 +563,8 @@ func (r *VirtInstanceResource) Update(ctx context.Context, req resource.UpdateRe
 	if updateOpts.Autostart != nil {
 		_, err := r.services.Virt.UpdateInstance(ctx, containerID, updateOpts)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Update Container",
-				fmt.Sprintf("Unable to update container %q: %s", containerName, err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update Container",
+				fmt.Sprintf("Unable to update container %q", containerName), err, virtInstanceAPIFields)
 			return
 		}
 	}
@@ -548,11 +596,7 @@ func (r *VirtInstanceResource) Update(ctx context.Context, req resource.UpdateRe
 
 	// Wait for transitional states to complete
 	if !isVirtInstanceStableState(currentState) {
-		queryFunc := func(ctx context.Context, n string) (string, error) {
-			return r.getVirtInstanceState(ctx, n)
-		}
-
-		stableState, err := waitForStableState(ctx, containerName, timeout, queryFunc)
+		stableState, err := r.waitForVirtInstanceState(ctx, containerName, timeout)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Timeout Waiting for Container State",
@@ -611,9 +655,14 @@ func (r *VirtInstanceResource) Update(ctx context.Context, req resource.UpdateRe
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, nameIdentity{Name: data.Name}, &resp.Diagnostics)
 }
 
 func (r *VirtInstanceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data VirtInstanceResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -621,12 +670,21 @@ func (r *VirtInstanceResource) Delete(ctx context.Context, req resource.DeleteRe
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	containerName := data.Name.ValueString()
 	containerID := data.ID.ValueString()
 
 	// Check current state - if running, stop first
 	currentState, err := r.getVirtInstanceState(ctx, containerName)
 	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Query Container State",
 			fmt.Sprintf("Unable to query container %q state: %s", containerName, err.Error()),
@@ -652,6 +710,9 @@ func (r *VirtInstanceResource) Delete(ctx context.Context, req resource.DeleteRe
 	// Delete the container
 	err = r.services.Virt.DeleteInstance(ctx, containerID)
 	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Delete Container",
 			fmt.Sprintf("Unable to delete container %q: %s", containerName, err.Error()),
@@ -661,10 +722,45 @@ func (r *VirtInstanceResource) Delete(ctx context.Context, req resource.DeleteRe
 }
 
 func (r *VirtInstanceResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if !resolveImportID(ctx, &req, &nameIdentity{}, &resp.Diagnostics) {
+		return
+	}
 	// Import by container name
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), req.ID)...)
 }
 
+func (r *VirtInstanceResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = listschema.Schema{
+		Description: "Lists the containers on TrueNAS.",
+	}
+}
+
+// List lists every container, named by its name. Virtual machines of the
+// virt service are not managed by this resource and are left out.
+func (r *VirtInstanceResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var diags diag.Diagnostics
+	if !capabilities.Check("truenas_virt_instance", r.services.Version(), &diags) {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	instances, err := r.services.Virt.ListInstances(ctx, [][]any{{"type", "=", "CONTAINER"}})
+	if err != nil {
+		listError(stream, "Unable to List Containers", fmt.Sprintf("Unable to list containers: %s", err.Error()))
+		return
+	}
+
+	entries := make([]listEntry, len(instances))
+	for i, instance := range instances {
+		entries[i] = listEntry{
+			ImportID:    instance.Name,
+			DisplayName: instance.Name,
+			Identity:    nameIdentity{Name: types.StringValue(instance.Name)},
+		}
+	}
+	stream.Results = listResults(ctx, r, req, entries)
+}
+
 // buildCreateOpts builds the typed opts from the resource model for create.
 func (r *VirtInstanceResource) buildCreateOpts(ctx context.Context, data *VirtInstanceResourceModel) truenas.CreateVirtInstanceOpts {
 	// Build image string in format "name/version" (e.g., "alpine/3.20")
@@ -758,18 +854,6 @@ func (r *VirtInstanceResource) buildUpdateOpts(plan, state *VirtInstanceResource
 	return opts
 }
 
-// isNotFoundError checks if an error indicates the resource was not found.
-func isNotFoundError(err error) bool {
-	if err == nil {
-		return false
-	}
-	// TrueNAS API returns errors containing "does not exist" or "not found" for missing resources
-	errLower := strings.ToLower(err.Error())
-	return strings.Contains(errLower, "does not exist") ||
-		strings.Contains(errLower, "not found") ||
-		strings.Contains(errLower, "no such instance")
-}
-
 // getVirtInstanceState queries the current state of a container.
 func (r *VirtInstanceResource) getVirtInstanceState(ctx context.Context, name string) (string, error) {
 	container, err := r.services.Virt.GetInstance(ctx, name)
@@ -778,7 +862,7 @@ func (r *VirtInstanceResource) getVirtInstanceState(ctx context.Context, name st
 	}
 
 	if container == nil {
-		return "", fmt.Errorf("container %q not found", name)
+		return "", fmt.Errorf("container %q %w", name, services.ErrNotFound)
 	}
 
 	return container.Status, nil
@@ -1159,11 +1243,7 @@ func (r *VirtInstanceResource) reconcileDesiredState(
 	}
 
 	// Wait for stable state
-	queryFunc := func(ctx context.Context, n string) (string, error) {
-		return r.getVirtInstanceState(ctx, n)
-	}
-
-	finalState, err := waitForStableState(ctx, name, timeout, queryFunc)
+	finalState, err := r.waitForVirtInstanceState(ctx, name, timeout)
 	if err != nil {
 		return err
 	}
@@ -318,6 +318,7 @@ func createVirtInstanceModelValue(p virtInstanceModelParams) tftypes.Value {
 		"disk":             diskListValue,
 		"nic":              nicListValue,
 		"proxy":            proxyListValue,
+		"timeouts":         nullTimeouts(),
 	}
 
 	objectType := tftypes.Object{
@@ -337,6 +338,7 @@ func createVirtInstanceModelValue(p virtInstanceModelParams) tftypes.Value {
 			"disk":             tftypes.List{ElementType: diskBlockType()},
 			"nic":              tftypes.List{ElementType: nicBlockType()},
 			"proxy":            tftypes.List{ElementType: proxyBlockType()},
+			"timeouts":         timeoutsType,
 		},
 	}
 
@@ -506,6 +508,62 @@ func TestVirtInstanceResource_Update_VersionCheck(t *testing.T) {
 
 // Create tests
 
+func TestVirtInstanceResource_ModifyPlan_VersionCheck(t *testing.T) {
+	schemaResp := getVirtInstanceResourceSchema(t)
+	planValue := createVirtInstanceModelValue(virtInstanceModelParams{
+		Name:         "test-container",
+		StoragePool:  "tank",
+		ImageName:    "ubuntu",
+		ImageVersion: "24.04",
+		DesiredState: "RUNNING",
+		StateTimeout: float64(90),
+	})
+	nullValue := tftypes.NewValue(planValue.Type(), nil)
+
+	tests := []struct {
+		name    string
+		version truenas.Version
+		plan    tftypes.Value
+		wantErr bool
+	}{
+		{"unsupported", truenas.Version{Major: 24, Minor: 10, Patch: 2, Build: 4}, planValue, true},
+		{"supported", truenas.Version{Major: 25, Minor: 4}, planValue, false},
+		{"not yet detected", truenas.Version{}, planValue, false},
+		{"destroy on unsupported", truenas.Version{Major: 24, Minor: 10}, nullValue, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &VirtInstanceResource{
+				BaseResource: BaseResource{services: &services.TrueNASServices{
+					Client: &client.MockClient{VersionVal: tt.version},
+					Virt: &truenas.MockVirtService{
+						GetGlobalConfigFunc: func(ctx context.Context) (*truenas.VirtGlobalConfig, error) {
+							return &truenas.VirtGlobalConfig{StoragePools: []string{"tank"}}, nil
+						},
+					},
+				}},
+			}
+
+			req := resource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tt.plan},
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: tt.plan},
+			}
+			resp := &resource.ModifyPlanResponse{
+				Plan: req.Plan,
+			}
+
+			r.ModifyPlan(context.Background(), req, resp)
+
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, resp.Diagnostics)
+			}
+			if tt.wantErr && resp.Diagnostics.Errors()[0].Summary() != "Unsupported TrueNAS Version" {
+				t.Errorf("unexpected error: %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
 func TestVirtInstanceResource_Create_Success(t *testing.T) {
 	var capturedOpts truenas.CreateVirtInstanceOpts
 
@@ -1355,8 +1413,8 @@ func TestVirtInstanceResource_getVirtInstanceState_NotFound(t *testing.T) {
 	})
 
 	_, err := r.getVirtInstanceState(context.Background(), "test-container")
-	if err == nil {
-		t.Fatal("expected error for container not found")
+	if !errors.Is(err, services.ErrNotFound) {
+		t.Fatalf("expected a not-found error, got %v", err)
 	}
 }
 
@@ -2596,10 +2654,10 @@ func TestIsNotFoundError(t *testing.T) {
 		expected bool
 	}{
 		{"nil error", nil, false},
-		{"does not exist", errors.New("Instance does not exist"), true},
-		{"not found", errors.New("Container not found"), true},
-		{"no such instance", errors.New("No such instance: test"), true},
-		{"case insensitive", errors.New("DOES NOT EXIST"), true},
+		{"typed", &services.NotFoundError{Err: errors.New("[ENOENT] Instance test does not exist")}, true},
+		{"errno", errors.New("[ENOENT] Instance test does not exist"), true},
+		{"message without errno", errors.New("Instance does not exist"), false},
+		{"unrelated not found", errors.New("[EINVAL] pool: tank not found"), false},
 		{"unrelated error", errors.New("connection failed"), false},
 		{"api error", errors.New("API returned 500"), false},
 	}
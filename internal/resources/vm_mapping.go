@@ -93,6 +93,33 @@ func preserveRawExists(mapped, prior []VMRawModel) {
 	}
 }
 
+// preserveDisplayWriteOnly copies password_wo_version from prior DISPLAY
+// devices to mapped ones. A device with a write-only password keeps a null
+// password instead of the one TrueNAS returns, so it never reaches state.
+func preserveDisplayWriteOnly(mapped, prior []VMDisplayModel) {
+	priorByID := make(map[int64]VMDisplayModel)
+	for _, p := range prior {
+		if !p.DeviceID.IsNull() && !p.DeviceID.IsUnknown() {
+			priorByID[p.DeviceID.ValueInt64()] = p
+		}
+	}
+
+	for i := range mapped {
+		p, ok := priorByID[mapped[i].DeviceID.ValueInt64()]
+		if !ok && i < len(prior) {
+			// Fallback: match by index for newly created devices
+			p, ok = prior[i], true
+		}
+		if !ok {
+			continue
+		}
+		mapped[i].PasswordWOVersion = p.PasswordWOVersion
+		if !p.PasswordWOVersion.IsNull() {
+			mapped[i].Password = types.StringNull()
+		}
+	}
+}
+
 func mapDiskDevice(dev truenas.VMDevice) VMDiskModel {
 	m := VMDiskModel{
 		DeviceID: types.Int64Value(dev.ID),
@@ -1,7 +1,12 @@
 package resources
 
 import (
+	"context"
+
 	truenas "github.com/deevus/truenas-go"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 )
 
 func (r *VMResource) buildCreateOpts(data *VMResourceModel) truenas.CreateVMOpts {
@@ -202,8 +207,8 @@ func buildDisplayDeviceOpts(display *VMDisplayModel, vmID int64) truenas.CreateV
 	if !display.Wait.IsNull() && !display.Wait.IsUnknown() {
 		d.Wait = display.Wait.ValueBool()
 	}
-	if !display.Password.IsNull() && !display.Password.IsUnknown() {
-		d.Password = display.Password.ValueString()
+	if password := secretValue(display.Password, display.PasswordWO); password != "" {
+		d.Password = password
 	}
 	if !display.Web.IsNull() && !display.Web.IsUnknown() {
 		d.Web = display.Web.ValueBool()
@@ -259,3 +264,11 @@ func buildUSBDeviceOpts(usb *VMUSBModel, vmID int64) truenas.CreateVMDeviceOpts
 	}
 	return opts
 }
+
+// readDisplayWriteOnly copies the write-only password of each display device
+// from config into displays.
+func readDisplayWriteOnly(ctx context.Context, config tfsdk.Config, displays []VMDisplayModel, diags *diag.Diagnostics) {
+	for i := range displays {
+		displays[i].PasswordWO = writeOnlyValue(ctx, config, path.Root("display").AtListIndex(i).AtName("password_wo"), diags)
+	}
+}
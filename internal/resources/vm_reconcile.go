@@ -248,7 +248,8 @@ func (r *VMResource) reconcileDisplayDevices(ctx context.Context, vmID int64, pl
 
 func displayEqual(a, b VMDisplayModel) bool {
 	return a.Type.Equal(b.Type) && a.Resolution.Equal(b.Resolution) && a.Bind.Equal(b.Bind) &&
-		a.Web.Equal(b.Web) && a.Wait.Equal(b.Wait) && a.Port.Equal(b.Port) && a.WebPort.Equal(b.WebPort)
+		a.Web.Equal(b.Web) && a.Wait.Equal(b.Wait) && a.Port.Equal(b.Port) && a.WebPort.Equal(b.WebPort) &&
+		a.PasswordWOVersion.Equal(b.PasswordWOVersion)
 }
 
 func (r *VMResource) reconcilePCIDevices(ctx context.Context, vmID int64, plan, state []VMPCIModel) error {
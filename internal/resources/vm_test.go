@@ -2,11 +2,16 @@ package resources
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -89,6 +94,9 @@ func vmDisplayBlockType() tftypes.Object {
 		"password":   tftypes.String,
 		"web":        tftypes.Bool,
 		"order":      tftypes.Number,
+
+		"password_wo":         tftypes.String,
+		"password_wo_version": tftypes.Number,
 	}}
 }
 
@@ -113,31 +121,33 @@ func vmUSBBlockType() tftypes.Object {
 func vmObjectType() tftypes.Object {
 	return tftypes.Object{
 		AttributeTypes: map[string]tftypes.Type{
-			"id":                tftypes.String,
-			"name":              tftypes.String,
-			"description":       tftypes.String,
-			"vcpus":             tftypes.Number,
-			"cores":             tftypes.Number,
-			"threads":           tftypes.Number,
-			"memory":            tftypes.Number,
-			"min_memory":        tftypes.Number,
-			"autostart":         tftypes.Bool,
-			"time":              tftypes.String,
-			"bootloader":        tftypes.String,
-			"bootloader_ovmf":   tftypes.String,
-			"cpu_mode":          tftypes.String,
-			"cpu_model":         tftypes.String,
-			"shutdown_timeout":  tftypes.Number,
-			"command_line_args": tftypes.String,
-			"state":             tftypes.String,
-			"display_available": tftypes.Bool,
-			"disk":              tftypes.List{ElementType: vmDiskBlockType()},
-			"raw":               tftypes.List{ElementType: vmRawBlockType()},
-			"cdrom":             tftypes.List{ElementType: vmCDROMBlockType()},
-			"nic":               tftypes.List{ElementType: vmNICBlockType()},
-			"display":           tftypes.List{ElementType: vmDisplayBlockType()},
-			"pci":               tftypes.List{ElementType: vmPCIBlockType()},
-			"usb":               tftypes.List{ElementType: vmUSBBlockType()},
+			"id":                 tftypes.String,
+			"name":               tftypes.String,
+			"description":        tftypes.String,
+			"vcpus":              tftypes.Number,
+			"cores":              tftypes.Number,
+			"threads":            tftypes.Number,
+			"memory":             tftypes.Number,
+			"min_memory":         tftypes.Number,
+			"autostart":          tftypes.Bool,
+			"time":               tftypes.String,
+			"bootloader":         tftypes.String,
+			"bootloader_ovmf":    tftypes.String,
+			"cpu_mode":           tftypes.String,
+			"cpu_model":          tftypes.String,
+			"shutdown_timeout":   tftypes.Number,
+			"command_line_args":  tftypes.String,
+			"enable_secure_boot": tftypes.Bool,
+			"state":              tftypes.String,
+			"display_available":  tftypes.Bool,
+			"disk":               tftypes.List{ElementType: vmDiskBlockType()},
+			"raw":                tftypes.List{ElementType: vmRawBlockType()},
+			"cdrom":              tftypes.List{ElementType: vmCDROMBlockType()},
+			"nic":                tftypes.List{ElementType: vmNICBlockType()},
+			"display":            tftypes.List{ElementType: vmDisplayBlockType()},
+			"pci":                tftypes.List{ElementType: vmPCIBlockType()},
+			"usb":                tftypes.List{ElementType: vmUSBBlockType()},
+			"timeouts":           timeoutsType,
 		},
 	}
 }
@@ -159,6 +169,7 @@ type vmModelParams struct {
 	CPUModel         interface{}
 	ShutdownTimeout  interface{}
 	CommandLineArgs  interface{}
+	EnableSecureBoot interface{}
 	State            interface{}
 	DisplayAvailable interface{}
 	Disks            []vmDiskParams
@@ -204,6 +215,9 @@ type vmDisplayParams struct {
 	Password   interface{}
 	Web        interface{}
 	Order      interface{}
+
+	PasswordWO        interface{}
+	PasswordWOVersion interface{}
 }
 
 func emptyBlockList(elemType tftypes.Object) tftypes.Value {
@@ -275,6 +289,9 @@ func createVMModelValue(p vmModelParams) tftypes.Value {
 			"password":   tftypes.NewValue(tftypes.String, d.Password),
 			"web":        tftypes.NewValue(tftypes.Bool, d.Web),
 			"order":      tftypes.NewValue(tftypes.Number, d.Order),
+
+			"password_wo":         tftypes.NewValue(tftypes.String, d.PasswordWO),
+			"password_wo_version": tftypes.NewValue(tftypes.Number, d.PasswordWOVersion),
 		}))
 	}
 	displayList := emptyBlockList(vmDisplayBlockType())
@@ -283,31 +300,33 @@ func createVMModelValue(p vmModelParams) tftypes.Value {
 	}
 
 	values := map[string]tftypes.Value{
-		"id":                tftypes.NewValue(tftypes.String, p.ID),
-		"name":              tftypes.NewValue(tftypes.String, p.Name),
-		"description":       tftypes.NewValue(tftypes.String, p.Description),
-		"vcpus":             tftypes.NewValue(tftypes.Number, p.VCPUs),
-		"cores":             tftypes.NewValue(tftypes.Number, p.Cores),
-		"threads":           tftypes.NewValue(tftypes.Number, p.Threads),
-		"memory":            tftypes.NewValue(tftypes.Number, p.Memory),
-		"min_memory":        tftypes.NewValue(tftypes.Number, p.MinMemory),
-		"autostart":         tftypes.NewValue(tftypes.Bool, p.Autostart),
-		"time":              tftypes.NewValue(tftypes.String, p.Time),
-		"bootloader":        tftypes.NewValue(tftypes.String, p.Bootloader),
-		"bootloader_ovmf":   tftypes.NewValue(tftypes.String, p.BootloaderOVMF),
-		"cpu_mode":          tftypes.NewValue(tftypes.String, p.CPUMode),
-		"cpu_model":         tftypes.NewValue(tftypes.String, p.CPUModel),
-		"shutdown_timeout":  tftypes.NewValue(tftypes.Number, p.ShutdownTimeout),
-		"command_line_args": tftypes.NewValue(tftypes.String, p.CommandLineArgs),
-		"state":             tftypes.NewValue(tftypes.String, p.State),
-		"display_available": tftypes.NewValue(tftypes.Bool, p.DisplayAvailable),
-		"disk":              diskList,
-		"raw":               emptyBlockList(vmRawBlockType()),
-		"cdrom":             cdromList,
-		"nic":               nicList,
-		"display":           displayList,
-		"pci":               emptyBlockList(vmPCIBlockType()),
-		"usb":               emptyBlockList(vmUSBBlockType()),
+		"id":                 tftypes.NewValue(tftypes.String, p.ID),
+		"name":               tftypes.NewValue(tftypes.String, p.Name),
+		"description":        tftypes.NewValue(tftypes.String, p.Description),
+		"vcpus":              tftypes.NewValue(tftypes.Number, p.VCPUs),
+		"cores":              tftypes.NewValue(tftypes.Number, p.Cores),
+		"threads":            tftypes.NewValue(tftypes.Number, p.Threads),
+		"memory":             tftypes.NewValue(tftypes.Number, p.Memory),
+		"min_memory":         tftypes.NewValue(tftypes.Number, p.MinMemory),
+		"autostart":          tftypes.NewValue(tftypes.Bool, p.Autostart),
+		"time":               tftypes.NewValue(tftypes.String, p.Time),
+		"bootloader":         tftypes.NewValue(tftypes.String, p.Bootloader),
+		"bootloader_ovmf":    tftypes.NewValue(tftypes.String, p.BootloaderOVMF),
+		"cpu_mode":           tftypes.NewValue(tftypes.String, p.CPUMode),
+		"cpu_model":          tftypes.NewValue(tftypes.String, p.CPUModel),
+		"shutdown_timeout":   tftypes.NewValue(tftypes.Number, p.ShutdownTimeout),
+		"command_line_args":  tftypes.NewValue(tftypes.String, p.CommandLineArgs),
+		"enable_secure_boot": tftypes.NewValue(tftypes.Bool, p.EnableSecureBoot),
+		"state":              tftypes.NewValue(tftypes.String, p.State),
+		"display_available":  tftypes.NewValue(tftypes.Bool, p.DisplayAvailable),
+		"disk":               diskList,
+		"raw":                emptyBlockList(vmRawBlockType()),
+		"cdrom":              cdromList,
+		"nic":                nicList,
+		"display":            displayList,
+		"pci":                emptyBlockList(vmPCIBlockType()),
+		"usb":                emptyBlockList(vmUSBBlockType()),
+		"timeouts":           nullTimeouts(),
 	}
 
 	return tftypes.NewValue(vmObjectType(), values)
@@ -354,6 +373,7 @@ func defaultVMPlanParams() vmModelParams {
 		CPUModel:         nil,
 		ShutdownTimeout:  float64(90),
 		CommandLineArgs:  "",
+		EnableSecureBoot: false,
 		State:            "STOPPED",
 		DisplayAvailable: nil,
 	}
@@ -424,7 +444,7 @@ func TestVMResource_Schema(t *testing.T) {
 	for _, name := range []string{
 		"description", "vcpus", "cores", "threads", "autostart", "time",
 		"bootloader", "bootloader_ovmf", "cpu_mode", "cpu_model",
-		"shutdown_timeout", "command_line_args", "state",
+		"shutdown_timeout", "command_line_args", "enable_secure_boot", "state",
 	} {
 		attr, ok := attrs[name]
 		if !ok {
@@ -634,6 +654,120 @@ func TestVMResource_Create_APIError(t *testing.T) {
 	}
 }
 
+func TestVMResource_ModifyPlan_SecureBootVersionCheck(t *testing.T) {
+	schemaResp := getVMResourceSchema(t)
+	secureBoot := defaultVMPlanParams()
+	secureBoot.EnableSecureBoot = true
+	unset := defaultVMPlanParams()
+	unset.EnableSecureBoot = nil
+
+	tests := []struct {
+		name    string
+		version truenas.Version
+		config  vmModelParams
+		wantErr bool
+	}{
+		{"secure boot on 24.10", truenas.Version{Major: 24, Minor: 10, Patch: 2}, secureBoot, true},
+		{"secure boot on 25.04", truenas.Version{Major: 25, Minor: 4}, secureBoot, false},
+		{"unset on 24.10", truenas.Version{Major: 24, Minor: 10, Patch: 2}, unset, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &VMResource{
+				BaseResource: BaseResource{services: &services.TrueNASServices{
+					Client: &client.MockClient{VersionVal: tt.version},
+				}},
+			}
+
+			planValue := createVMModelValue(tt.config)
+			req := resource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: planValue},
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: planValue},
+			}
+			resp := &resource.ModifyPlanResponse{
+				Plan: req.Plan,
+			}
+
+			r.ModifyPlan(context.Background(), req, resp)
+
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, resp.Diagnostics)
+			}
+			if !tt.wantErr {
+				return
+			}
+			diags := resp.Diagnostics.Errors()
+			if diags[0].Summary() != "Unsupported TrueNAS Version" {
+				t.Errorf("unexpected error: %v", diags)
+			}
+			withPath, ok := diags[0].(diag.DiagnosticWithPath)
+			if !ok || !withPath.Path().Equal(path.Root("enable_secure_boot")) {
+				t.Errorf("expected error on enable_secure_boot, got %v", diags[0])
+			}
+		})
+	}
+}
+
+func TestVMResource_Create_SecureBoot(t *testing.T) {
+	var calls []string
+	var updateParams any
+
+	r := &VMResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Client: &client.MockClient{
+				VersionVal: truenas.Version{Major: 25, Minor: 4},
+				CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+					calls = append(calls, method)
+					if method == "vm.update" {
+						updateParams = params
+					}
+					return json.RawMessage(`{"id": 1, "enable_secure_boot": true}`), nil
+				},
+			},
+			VM: &truenas.MockVMService{
+				CreateVMFunc: func(ctx context.Context, opts truenas.CreateVMOpts) (*truenas.VM, error) {
+					return mockVM(1, "test-vm", 2048, "STOPPED"), nil
+				},
+				GetVMFunc: func(ctx context.Context, id int64) (*truenas.VM, error) {
+					return mockVM(1, "test-vm", 2048, "STOPPED"), nil
+				},
+				ListDevicesFunc: func(ctx context.Context, vmID int64) ([]truenas.VMDevice, error) {
+					return nil, nil
+				},
+			},
+		}},
+	}
+
+	schemaResp := getVMResourceSchema(t)
+	p := defaultVMPlanParams()
+	p.EnableSecureBoot = true
+	req := resource.CreateRequest{
+		Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: createVMModelValue(p)},
+	}
+	resp := &resource.CreateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema},
+	}
+
+	r.Create(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+	if !reflect.DeepEqual(calls, []string{"vm.update", "vm.get_instance"}) {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	wantParams := []any{int64(1), map[string]any{"enable_secure_boot": true}}
+	if !reflect.DeepEqual(updateParams, wantParams) {
+		t.Errorf("expected vm.update params %v, got %v", wantParams, updateParams)
+	}
+
+	var model VMResourceModel
+	resp.State.Get(context.Background(), &model)
+	if !model.EnableSecureBoot.ValueBool() {
+		t.Error("expected enable_secure_boot to be true")
+	}
+}
+
 func TestVMResource_Create_WithDevices(t *testing.T) {
 	var deviceCreateCalls []truenas.CreateVMDeviceOpts
 
@@ -735,7 +869,7 @@ func TestVMResource_Read_NotFound(t *testing.T) {
 	r := &VMResource{
 		BaseResource: BaseResource{services: &services.TrueNASServices{VM: &truenas.MockVMService{
 			GetVMFunc: func(ctx context.Context, id int64) (*truenas.VM, error) {
-				return nil, errors.New("does not exist")
+				return nil, &services.NotFoundError{Err: errors.New("[ENOENT] VM 999 does not exist")}
 			},
 		}}},
 	}
@@ -763,6 +897,36 @@ func TestVMResource_Read_NotFound(t *testing.T) {
 	}
 }
 
+func TestVMResource_Read_OtherNotFoundMessage(t *testing.T) {
+	r := &VMResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{VM: &truenas.MockVMService{
+			GetVMFunc: func(ctx context.Context, id int64) (*truenas.VM, error) {
+				return nil, errors.New("[EFAULT] libvirt connection not found")
+			},
+		}}},
+	}
+
+	schemaResp := getVMResourceSchema(t)
+	p := defaultVMPlanParams()
+	p.ID = "999"
+	stateValue := createVMModelValue(p)
+	req := resource.ReadRequest{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue},
+	}
+	resp := &resource.ReadResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue},
+	}
+
+	r.Read(context.Background(), req, resp)
+
+	// A failure that merely mentions "not found" must not drop the VM from state
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected an error")
+	}
+	if resp.State.Raw.IsNull() {
+		t.Error("expected state to be kept")
+	}
+}
 func TestVMResource_Read_WithDevices(t *testing.T) {
 	r := &VMResource{
 		BaseResource: BaseResource{services: &services.TrueNASServices{VM: &truenas.MockVMService{
@@ -983,6 +1147,48 @@ func TestVMResource_ImportState(t *testing.T) {
 	}
 }
 
+func TestVMResource_ImportState_ByName(t *testing.T) {
+	var capturedParams any
+	r := &VMResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Client: &client.MockClient{
+				CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+					if method != "vm.query" {
+						t.Fatalf("unexpected call %s", method)
+					}
+					capturedParams = params
+					return json.RawMessage(`[{"id": 7, "name": "web"}]`), nil
+				},
+			},
+		}},
+	}
+
+	schemaResp := getVMResourceSchema(t)
+	emptyState := createVMModelValue(defaultVMPlanParams())
+
+	req := resource.ImportStateRequest{ID: "name:web"}
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: emptyState},
+	}
+
+	r.ImportState(context.Background(), req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+
+	expected := [][]any{{"name", "=", "web"}}
+	if !reflect.DeepEqual(capturedParams, expected) {
+		t.Errorf("expected vm.query filters %v, got %v", expected, capturedParams)
+	}
+
+	var model VMResourceModel
+	resp.State.Get(context.Background(), &model)
+	if model.ID.ValueString() != "7" {
+		t.Errorf("expected ID '7', got %q", model.ID.ValueString())
+	}
+}
+
 // -- Device mapping tests --
 
 func TestVMResource_mapDevicesToModel(t *testing.T) {
@@ -1445,6 +1651,9 @@ func createVMModelValueFull(p vmModelParams, raws []vmRawParams, pcis []vmPCIPar
 			"password":   tftypes.NewValue(tftypes.String, d.Password),
 			"web":        tftypes.NewValue(tftypes.Bool, d.Web),
 			"order":      tftypes.NewValue(tftypes.Number, d.Order),
+
+			"password_wo":         tftypes.NewValue(tftypes.String, d.PasswordWO),
+			"password_wo_version": tftypes.NewValue(tftypes.Number, d.PasswordWOVersion),
 		}))
 	}
 	displayList := emptyBlockList(vmDisplayBlockType())
@@ -1504,31 +1713,33 @@ func createVMModelValueFull(p vmModelParams, raws []vmRawParams, pcis []vmPCIPar
 	}
 
 	values := map[string]tftypes.Value{
-		"id":                tftypes.NewValue(tftypes.String, p.ID),
-		"name":              tftypes.NewValue(tftypes.String, p.Name),
-		"description":       tftypes.NewValue(tftypes.String, p.Description),
-		"vcpus":             tftypes.NewValue(tftypes.Number, p.VCPUs),
-		"cores":             tftypes.NewValue(tftypes.Number, p.Cores),
-		"threads":           tftypes.NewValue(tftypes.Number, p.Threads),
-		"memory":            tftypes.NewValue(tftypes.Number, p.Memory),
-		"min_memory":        tftypes.NewValue(tftypes.Number, p.MinMemory),
-		"autostart":         tftypes.NewValue(tftypes.Bool, p.Autostart),
-		"time":              tftypes.NewValue(tftypes.String, p.Time),
-		"bootloader":        tftypes.NewValue(tftypes.String, p.Bootloader),
-		"bootloader_ovmf":   tftypes.NewValue(tftypes.String, p.BootloaderOVMF),
-		"cpu_mode":          tftypes.NewValue(tftypes.String, p.CPUMode),
-		"cpu_model":         tftypes.NewValue(tftypes.String, p.CPUModel),
-		"shutdown_timeout":  tftypes.NewValue(tftypes.Number, p.ShutdownTimeout),
-		"command_line_args": tftypes.NewValue(tftypes.String, p.CommandLineArgs),
-		"state":             tftypes.NewValue(tftypes.String, p.State),
-		"display_available": tftypes.NewValue(tftypes.Bool, p.DisplayAvailable),
-		"disk":              diskList,
-		"raw":               rawList,
-		"cdrom":             cdromList,
-		"nic":               nicList,
-		"display":           displayList,
-		"pci":               pciList,
-		"usb":               usbList,
+		"id":                 tftypes.NewValue(tftypes.String, p.ID),
+		"name":               tftypes.NewValue(tftypes.String, p.Name),
+		"description":        tftypes.NewValue(tftypes.String, p.Description),
+		"vcpus":              tftypes.NewValue(tftypes.Number, p.VCPUs),
+		"cores":              tftypes.NewValue(tftypes.Number, p.Cores),
+		"threads":            tftypes.NewValue(tftypes.Number, p.Threads),
+		"memory":             tftypes.NewValue(tftypes.Number, p.Memory),
+		"min_memory":         tftypes.NewValue(tftypes.Number, p.MinMemory),
+		"autostart":          tftypes.NewValue(tftypes.Bool, p.Autostart),
+		"time":               tftypes.NewValue(tftypes.String, p.Time),
+		"bootloader":         tftypes.NewValue(tftypes.String, p.Bootloader),
+		"bootloader_ovmf":    tftypes.NewValue(tftypes.String, p.BootloaderOVMF),
+		"cpu_mode":           tftypes.NewValue(tftypes.String, p.CPUMode),
+		"cpu_model":          tftypes.NewValue(tftypes.String, p.CPUModel),
+		"shutdown_timeout":   tftypes.NewValue(tftypes.Number, p.ShutdownTimeout),
+		"command_line_args":  tftypes.NewValue(tftypes.String, p.CommandLineArgs),
+		"enable_secure_boot": tftypes.NewValue(tftypes.Bool, p.EnableSecureBoot),
+		"state":              tftypes.NewValue(tftypes.String, p.State),
+		"display_available":  tftypes.NewValue(tftypes.Bool, p.DisplayAvailable),
+		"disk":               diskList,
+		"raw":                rawList,
+		"cdrom":              cdromList,
+		"nic":                nicList,
+		"display":            displayList,
+		"pci":                pciList,
+		"usb":                usbList,
+		"timeouts":           nullTimeouts(),
 	}
 
 	return tftypes.NewValue(vmObjectType(), values)
@@ -2572,7 +2783,7 @@ func TestVMResource_Delete_AlreadyDeleted(t *testing.T) {
 	r := &VMResource{
 		BaseResource: BaseResource{services: &services.TrueNASServices{VM: &truenas.MockVMService{
 			GetVMFunc: func(ctx context.Context, id int64) (*truenas.VM, error) {
-				return nil, errors.New("does not exist")
+				return nil, &services.NotFoundError{Err: errors.New("[ENOENT] VM 1 does not exist")}
 			},
 		}}},
 	}
@@ -2592,6 +2803,34 @@ func TestVMResource_Delete_AlreadyDeleted(t *testing.T) {
 	}
 }
 
+func TestVMResource_Delete_UntypedNotFoundMessage(t *testing.T) {
+	// Only a typed not-found error means the VM is gone; a message that
+	// merely mentions "not found" is reported
+	r := &VMResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{VM: &truenas.MockVMService{
+			GetVMFunc: func(ctx context.Context, id int64) (*truenas.VM, error) {
+				return &truenas.VM{ID: 1, State: "STOPPED"}, nil
+			},
+			DeleteVMFunc: func(ctx context.Context, id int64) error {
+				return errors.New("[EFAULT] zvol tank/vm-disk not found while detaching devices")
+			},
+		}}},
+	}
+
+	schemaResp := getVMResourceSchema(t)
+	p := defaultVMPlanParams()
+	p.ID = "1"
+	req := resource.DeleteRequest{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: createVMModelValue(p)},
+	}
+	resp := &resource.DeleteResponse{}
+
+	r.Delete(context.Background(), req, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected the failed delete to be reported")
+	}
+}
+
 // -- buildUpdateOpts tests --
 
 func TestVMResource_buildUpdateOpts(t *testing.T) {
@@ -4559,3 +4798,29 @@ func TestVMResource_rawEqual_IOType(t *testing.T) {
 		})
 	}
 }
+
+func TestPreserveDisplayWriteOnly(t *testing.T) {
+	mapped := []VMDisplayModel{
+		{DeviceID: types.Int64Value(20), Password: types.StringValue("secret")},
+		{DeviceID: types.Int64Value(21), Password: types.StringValue("plain")},
+	}
+	prior := []VMDisplayModel{
+		{DeviceID: types.Int64Value(21), Password: types.StringValue("plain"), PasswordWOVersion: types.Int64Null()},
+		{DeviceID: types.Int64Value(20), PasswordWOVersion: types.Int64Value(3)},
+	}
+
+	preserveDisplayWriteOnly(mapped, prior)
+
+	if !mapped[0].Password.IsNull() {
+		t.Errorf("expected write-only password to be null, got %q", mapped[0].Password.ValueString())
+	}
+	if mapped[0].PasswordWOVersion.ValueInt64() != 3 {
+		t.Errorf("expected password_wo_version 3, got %v", mapped[0].PasswordWOVersion)
+	}
+	if mapped[1].Password.ValueString() != "plain" {
+		t.Errorf("expected password 'plain', got %q", mapped[1].Password.ValueString())
+	}
+	if !mapped[1].PasswordWOVersion.IsNull() {
+		t.Errorf("expected password_wo_version to be null, got %v", mapped[1].PasswordWOVersion)
+	}
+}
@@ -0,0 +1,69 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Secrets the provider sends to TrueNAS have a write-only variant, name_wo,
+// which Terraform 1.11 and later never store in plan or state. Because a
+// value that is not stored cannot be diffed, each has a companion
+// name_wo_version: changing it plans an update that sends the current value
+// of name_wo. The secret is sent on create and on every update.
+
+// writeOnlyAttribute returns the schema of name_wo, the write-only variant
+// of the secret attribute name. description describes the secret.
+func writeOnlyAttribute(name, description string) schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: fmt.Sprintf("%s Write-only alternative to %s that is never stored in state; "+
+			"requires Terraform 1.11 or later. Change %s_wo_version to send a new value.", description, name, name),
+		Optional:  true,
+		Sensitive: true,
+		WriteOnly: true,
+		Validators: []validator.String{
+			stringvalidator.ConflictsWith(path.MatchRelative().AtParent().AtName(name)),
+			stringvalidator.AlsoRequires(path.MatchRelative().AtParent().AtName(name + "_wo_version")),
+		},
+	}
+}
+
+// writeOnlyVersionAttribute returns the schema of name_wo_version.
+func writeOnlyVersionAttribute(name string) schema.Int64Attribute {
+	return schema.Int64Attribute{
+		Description: fmt.Sprintf("Version of %[1]s_wo. Change it, for example by incrementing it, "+
+			"to send the current value of %[1]s_wo to TrueNAS.", name),
+		Optional: true,
+		Validators: []validator.Int64{
+			int64validator.AlsoRequires(path.MatchRelative().AtParent().AtName(name + "_wo")),
+		},
+	}
+}
+
+// writeOnlyValue returns the write-only attribute at p. Write-only values
+// are always null in plans, so Create and Update read them from config.
+func writeOnlyValue(ctx context.Context, config tfsdk.Config, p path.Path, diags *diag.Diagnostics) types.String {
+	var v types.String
+	if config.Raw.IsNull() {
+		return types.StringNull()
+	}
+	diags.Append(config.GetAttribute(ctx, p, &v)...)
+	return v
+}
+
+// secretValue returns writeOnly if it is set and value otherwise. It returns
+// "" when neither is known.
+func secretValue(value, writeOnly types.String) string {
+	if !writeOnly.IsNull() && !writeOnly.IsUnknown() {
+		return writeOnly.ValueString()
+	}
+	return value.ValueString()
+}
@@ -2,10 +2,16 @@ package resources
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/deevus/terraform-provider-truenas/internal/services"
 	truenas "github.com/deevus/truenas-go"
 	customtypes "github.com/deevus/terraform-provider-truenas/internal/types"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/list"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
@@ -16,6 +22,9 @@ import (
 var _ resource.Resource = &ZvolResource{}
 var _ resource.ResourceWithConfigure = &ZvolResource{}
 var _ resource.ResourceWithImportState = &ZvolResource{}
+var _ resource.ResourceWithModifyPlan = &ZvolResource{}
+var _ resource.ResourceWithIdentity = &ZvolResource{}
+var _ list.ListResourceWithConfigure = &ZvolResource{}
 
 type ZvolResource struct {
 	BaseResource
@@ -33,16 +42,40 @@ type ZvolResourceModel struct {
 	Compression  types.String                `tfsdk:"compression"`
 	Comments     types.String                `tfsdk:"comments"`
 	ForceDestroy types.Bool                  `tfsdk:"force_destroy"`
+
+	Timeouts timeouts.Value `tfsdk:"timeouts"`
 }
 
 func NewZvolResource() resource.Resource {
 	return &ZvolResource{}
 }
 
+// NewZvolListResource creates a ZvolResource for listing zvols.
+func NewZvolListResource() list.ListResource {
+	return &ZvolResource{}
+}
+
 func (r *ZvolResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_zvol"
 }
 
+// ModifyPlan reports configurations the detected TrueNAS version cannot apply
+// and references to objects that do not exist.
+func (r *ZvolResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.checkCapabilities("truenas_zvol", req, resp)
+	if !r.checksReferences(req, resp.Diagnostics) {
+		return
+	}
+
+	var plan ZvolResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	fullName := poolDatasetFullName(plan.Pool, plan.Path, plan.Parent, types.StringNull())
+	r.checkPoolDatasetReferences(ctx, req, &resp.Diagnostics, fullName, plan.Parent)
+}
+
 func (r *ZvolResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	attrs := poolDatasetIdentitySchema()
 
@@ -93,16 +126,33 @@ func (r *ZvolResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 	resp.Schema = schema.Schema{
 		Description: "Manages a ZFS volume (zvol) on TrueNAS. Zvols are block devices backed by ZFS, commonly used as VM disks or iSCSI targets.",
 		Attributes:  attrs,
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.BlockAll(ctx),
+		},
 	}
 }
 
+func (r *ZvolResource) IdentitySchema(ctx context.Context, req resource.IdentitySchemaRequest, resp *resource.IdentitySchemaResponse) {
+	resp.IdentitySchema = poolPathIdentitySchema()
+}
+
 func (r *ZvolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "create") {
+		return
+	}
+
 	var data ZvolResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, defaultCreateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	fullName := poolDatasetFullName(data.Pool, data.Path, data.Parent, types.StringNull())
 	if fullName == "" {
 		resp.Diagnostics.AddError(
@@ -142,10 +192,8 @@ func (r *ZvolResource) Create(ctx context.Context, req resource.CreateRequest, r
 
 	zvol, err := r.services.Dataset.CreateZvol(ctx, opts)
 	if err != nil {
-		resp.Diagnostics.AddError(
-			"Unable to Create Zvol",
-			fmt.Sprintf("Unable to create zvol %q: %s", fullName, err.Error()),
-		)
+		r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Create Zvol",
+			fmt.Sprintf("Unable to create zvol %q", fullName), err, nil)
 		return
 	}
 
@@ -160,6 +208,7 @@ func (r *ZvolResource) Create(ctx context.Context, req resource.CreateRequest, r
 	mapZvolToModel(zvol, &data)
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	setIdentity(ctx, resp.Identity, newPoolPathIdentity(data.ID.ValueString()), &resp.Diagnostics)
 }
 
 func (r *ZvolResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -169,10 +218,21 @@ func (r *ZvolResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, defaultReadTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	zvolID := data.ID.ValueString()
+	setIdentity(ctx, resp.Identity, newPoolPathIdentity(zvolID), &resp.Diagnostics)
 
 	zvol, err := r.services.Dataset.GetZvol(ctx, zvolID)
 	if err != nil {
+		if isNotFoundError(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
 		resp.Diagnostics.AddError("Unable to Read Zvol", fmt.Sprintf("Unable to read zvol %q: %s", zvolID, err.Error()))
 		return
 	}
@@ -195,6 +255,10 @@ func (r *ZvolResource) Read(ctx context.Context, req resource.ReadRequest, resp
 }
 
 func (r *ZvolResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "update") {
+		return
+	}
+
 	var plan, state ZvolResourceModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
@@ -202,6 +266,12 @@ func (r *ZvolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, plan.Timeouts.Update, defaultUpdateTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	updateOpts := truenas.UpdateZvolOpts{}
 	hasChanges := false
 
@@ -239,10 +309,8 @@ func (r *ZvolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	if hasChanges {
 		zvol, err := r.services.Dataset.UpdateZvol(ctx, zvolID, updateOpts)
 		if err != nil {
-			resp.Diagnostics.AddError(
-				"Unable to Update Zvol",
-				fmt.Sprintf("Unable to update zvol %q: %s", zvolID, err.Error()),
-			)
+			r.addAPIError(ctx, &resp.Diagnostics, resp.State.Schema, "Unable to Update Zvol",
+				fmt.Sprintf("Unable to update zvol %q", zvolID), err, nil)
 			return
 		}
 
@@ -267,15 +335,26 @@ func (r *ZvolResource) Update(ctx context.Context, req resource.UpdateRequest, r
 	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	setIdentity(ctx, resp.Identity, newPoolPathIdentity(zvolID), &resp.Diagnostics)
 }
 
 func (r *ZvolResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !r.checkWritable(&resp.Diagnostics, "delete") {
+		return
+	}
+
 	var data ZvolResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, defaultDeleteTimeout, &resp.Diagnostics)
+	defer cancel()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	zvolID := data.ID.ValueString()
 	recursive := !data.ForceDestroy.IsNull() && data.ForceDestroy.ValueBool()
 
@@ -287,6 +366,9 @@ func (r *ZvolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 	}
 
 	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			return // Already deleted
+		}
 		resp.Diagnostics.AddError(
 			"Unable to Delete Zvol",
 			fmt.Sprintf("Unable to delete zvol %q: %s", zvolID, err.Error()),
@@ -294,6 +376,54 @@ func (r *ZvolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 	}
 }
 
+// ImportState imports by ID, such as "tank/vms/disk0", or by identity.
+func (r *ZvolResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if !resolveImportID(ctx, &req, &poolPathIdentity{}, &resp.Diagnostics) {
+		return
+	}
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func (r *ZvolResource) ListResourceConfigSchema(ctx context.Context, req list.ListResourceSchemaRequest, resp *list.ListResourceSchemaResponse) {
+	resp.Schema = poolListSchema("zvols")
+}
+
+// List lists every zvol. The dataset service has no method listing zvols, so
+// they are queried with pool.dataset.query.
+func (r *ZvolResource) List(ctx context.Context, req list.ListRequest, stream *list.ListResultsStream) {
+	var config poolListConfig
+	if diags := req.Config.Get(ctx, &config); diags.HasError() {
+		stream.Results = list.ListResultsStreamDiagnostics(diags)
+		return
+	}
+
+	filters := [][]any{{"type", "=", "VOLUME"}}
+	if !config.Pool.IsNull() {
+		filters = append(filters, []any{"pool", "=", config.Pool.ValueString()})
+	}
+	result, err := r.services.Client.Call(ctx, "pool.dataset.query", filters)
+	var zvols []struct {
+		ID string `json:"id"`
+	}
+	if err == nil {
+		err = json.Unmarshal(result, &zvols)
+	}
+	if err != nil {
+		listError(stream, "Unable to List Zvols", fmt.Sprintf("Unable to list zvols: %s", err.Error()))
+		return
+	}
+
+	entries := make([]listEntry, len(zvols))
+	for i, zvol := range zvols {
+		entries[i] = listEntry{
+			ImportID:    zvol.ID,
+			DisplayName: zvol.ID,
+			Identity:    newPoolPathIdentity(zvol.ID),
+		}
+	}
+	stream.Results = listResults(ctx, r, req, entries)
+}
+
 // mapZvolToModel maps a Zvol to the resource model.
 func mapZvolToModel(zvol *truenas.Zvol, data *ZvolResourceModel) {
 	data.ID = types.StringValue(zvol.ID)
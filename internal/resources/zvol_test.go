@@ -585,6 +585,35 @@ func TestZvolResource_Delete_APIError(t *testing.T) {
 	}
 }
 
+func TestZvolResource_Delete_NotFound(t *testing.T) {
+	r := &ZvolResource{
+		BaseResource: BaseResource{services: &services.TrueNASServices{
+			Dataset: &truenas.MockDatasetService{
+				DeleteZvolFunc: func(ctx context.Context, id string) error {
+					return &services.NotFoundError{Err: errors.New("[ENOENT] tank/myvol is gone")}
+				},
+			},
+		}},
+	}
+
+	schemaResp := getZvolResourceSchema(t)
+	p := defaultZvolPlanParams()
+	p.ID = strPtr("tank/myvol")
+	stateValue := createZvolModelValue(p)
+
+	req := resource.DeleteRequest{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue},
+	}
+	resp := &resource.DeleteResponse{}
+
+	r.Delete(context.Background(), req, resp)
+
+	// An object deleted outside Terraform is already gone
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected errors: %v", resp.Diagnostics)
+	}
+}
+
 func TestZvolResource_Update_CompressionAndComments(t *testing.T) {
 	var capturedOpts truenas.UpdateZvolOpts
 
@@ -1122,6 +1151,7 @@ func zvolObjectType() tftypes.Object {
 			"compression":   tftypes.String,
 			"comments":      tftypes.String,
 			"force_destroy": tftypes.Bool,
+			"timeouts":      timeoutsType,
 		},
 	}
 }
@@ -1166,6 +1196,7 @@ func createZvolModelValue(p zvolModelParams) tftypes.Value {
 		"compression":   strVal(p.Compression),
 		"comments":      strVal(p.Comments),
 		"force_destroy": boolVal(p.ForceDestroy),
+		"timeouts":      nullTimeouts(),
 	})
 }
 
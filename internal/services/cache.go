@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	"github.com/deevus/truenas-go/client"
+)
+
+const (
+	// batchWindow is how long a lookup waits for concurrent lookups of the
+	// same collection to join its query.
+	batchWindow = 10 * time.Millisecond
+
+	// prefetchThreshold is the number of lookups of a collection after
+	// which it is fetched whole instead of by ID.
+	prefetchThreshold = 20
+)
+
+// cachedQueries are the query methods whose lookups by ID are batched and
+// cached.
+var cachedQueries = map[string]bool{
+	"pool.dataset.query":  true,
+	"pool.snapshot.query": true,
+	"zfs.snapshot.query":  true,
+}
+
+// Compile-time check that QueryCache implements client.Client.
+var _ client.Client = (*QueryCache)(nil)
+
+// QueryCache wraps a client.Client for the lifetime of one Terraform
+// operation, so that refreshing hundreds of datasets or snapshots does not
+// make a call per resource. Lookups of a single row by ID, as made by
+// DatasetService.GetDataset or SnapshotService.Get, are:
+//
+//   - batched: concurrent lookups of a collection are made with one query
+//     filtered on the IDs;
+//   - prefetched: after prefetchThreshold lookups the whole collection is
+//     fetched with one query;
+//   - cached: rows, and the absence of rows, are remembered.
+//
+// Every call that is not a query empties the cache, before and after the
+// call, so that reads after a write see its effect. Other calls are passed
+// through unchanged.
+type QueryCache struct {
+	client.Client
+
+	mu sync.Mutex
+	// generation counts writes, so that a query that overlapped one is not
+	// cached.
+	generation int
+	tables     map[string]*queryTable
+}
+
+// queryTable holds the cached rows of one query method.
+type queryTable struct {
+	// rows maps IDs to rows. A nil row records that the ID does not exist.
+	rows map[string]json.RawMessage
+	// complete is set when rows holds the whole collection.
+	complete bool
+	lookups  int
+	pending  *queryBatch
+}
+
+// queryBatch is a query shared by concurrent lookups.
+type queryBatch struct {
+	ids  []string
+	done chan struct{}
+
+	rows     map[string]json.RawMessage
+	complete bool
+	err      error
+}
+
+// NewQueryCache wraps c.
+func NewQueryCache(c client.Client) *QueryCache {
+	return &QueryCache{Client: c, tables: make(map[string]*queryTable)}
+}
+
+// Invalidate empties the cache.
+func (c *QueryCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.tables)
+}
+
+// Call serves lookups by ID from the cache and invalidates it around every
+// call that is not a query.
+func (c *QueryCache) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if !transport.IsQueryMethod(method) {
+		c.Invalidate()
+		defer c.Invalidate()
+		return c.Client.Call(ctx, method, params)
+	}
+	if !cachedQueries[method] {
+		return c.Client.Call(ctx, method, params)
+	}
+	if id, ok := idLookup(params); ok {
+		return c.lookup(ctx, method, id)
+	}
+	return c.Client.Call(ctx, method, params)
+}
+
+// CallAndWait invalidates the cache around the job.
+func (c *QueryCache) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	c.Invalidate()
+	defer c.Invalidate()
+	return c.Client.CallAndWait(ctx, method, params)
+}
+
+// idLookup returns the ID of params when they are a single id filter, as in
+// [][]any{{"id", "=", "tank/data"}}.
+func idLookup(params any) (string, bool) {
+	filters, ok := params.([][]any)
+	if !ok || len(filters) != 1 || len(filters[0]) != 3 {
+		return "", false
+	}
+	if field, _ := filters[0][0].(string); field != "id" {
+		return "", false
+	}
+	if op, _ := filters[0][1].(string); op != "=" {
+		return "", false
+	}
+	id, ok := filters[0][2].(string)
+	return id, ok
+}
+
+// lookup returns the query result for the row id of method, joining or
+// starting a batch when it is not cached. A lookup whose batch failed
+// because the context of the lookup that ran it ended tries again.
+func (c *QueryCache) lookup(ctx context.Context, method, id string) (json.RawMessage, error) {
+	for {
+		b, result, err := c.lookupOnce(ctx, method, id)
+		if b != nil && b.err != nil && ctx.Err() == nil &&
+			(errors.Is(b.err, context.Canceled) || errors.Is(b.err, context.DeadlineExceeded)) {
+			continue
+		}
+		return result, err
+	}
+}
+
+// lookupOnce makes one attempt of lookup, and returns the batch it waited
+// for, if any.
+func (c *QueryCache) lookupOnce(ctx context.Context, method, id string) (*queryBatch, json.RawMessage, error) {
+	c.mu.Lock()
+	t := c.tables[method]
+	if t == nil {
+		t = &queryTable{rows: make(map[string]json.RawMessage)}
+		c.tables[method] = t
+	}
+	if row, ok := t.rows[id]; ok || t.complete {
+		c.mu.Unlock()
+		return nil, lookupResult(row), nil
+	}
+
+	t.lookups++
+	b := t.pending
+	leader := b == nil
+	if leader {
+		b = &queryBatch{done: make(chan struct{})}
+		t.pending = b
+	}
+	if !slices.Contains(b.ids, id) {
+		b.ids = append(b.ids, id)
+	}
+	c.mu.Unlock()
+
+	if leader {
+		c.runBatch(ctx, method, t, b)
+	} else {
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	if b.err != nil {
+		return b, nil, b.err
+	}
+	return b, lookupResult(b.rows[id]), nil
+}
+
+// runBatch waits for other lookups to join b, then queries its rows and
+// caches them unless a write happened meanwhile.
+func (c *QueryCache) runBatch(ctx context.Context, method string, t *queryTable, b *queryBatch) {
+	defer close(b.done)
+
+	select {
+	case <-time.After(batchWindow):
+	case <-ctx.Done():
+	}
+
+	c.mu.Lock()
+	if t.pending == b {
+		t.pending = nil
+	}
+	ids := b.ids
+	b.complete = t.lookups > prefetchThreshold
+	generation := c.generation
+	c.mu.Unlock()
+
+	var params any
+	if !b.complete {
+		params = [][]any{{"id", "in", ids}}
+	}
+	result, err := c.Client.Call(ctx, method, params)
+	if err != nil {
+		b.err = err
+		return
+	}
+	var rows []json.RawMessage
+	if err := json.Unmarshal(result, &rows); err != nil {
+		b.err = fmt.Errorf("parse query response: %w", err)
+		return
+	}
+	b.rows = make(map[string]json.RawMessage, len(rows))
+	for _, row := range rows {
+		var key struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(row, &key); err != nil {
+			b.err = fmt.Errorf("parse query response: %w", err)
+			return
+		}
+		b.rows[key.ID] = row
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation || c.tables[method] != t {
+		return
+	}
+	for _, id := range ids {
+		t.rows[id] = b.rows[id]
+	}
+	if b.complete {
+		for id, row := range b.rows {
+			t.rows[id] = row
+		}
+		t.complete = true
+	}
+}
+
+// lookupResult returns the query result for a cached row.
+func lookupResult(row json.RawMessage) json.RawMessage {
+	if row == nil {
+		return json.RawMessage("[]")
+	}
+	return json.RawMessage("[" + string(row) + "]")
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/deevus/terraform-provider-truenas/internal/fakenas"
+	"github.com/deevus/terraform-provider-truenas/internal/transport"
+	truenas "github.com/deevus/truenas-go"
+)
+
+// newCachedServer returns a QueryCache on a fake NAS with the datasets
+// tank/ds0 to tank/ds<n-1>.
+func newCachedServer(t *testing.T, n int) (*fakenas.Server, *QueryCache) {
+	t.Helper()
+	srv := fakenas.NewServer(t)
+	c, err := transport.NewWebSocketClient(transport.WebSocketConfig{
+		Host:               srv.Host,
+		Port:               srv.Port,
+		Username:           srv.Username(),
+		APIKey:             srv.APIKey(),
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatalf("NewWebSocketClient: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+
+	ctx := context.Background()
+	for i := range n {
+		if _, err := c.Call(ctx, "pool.dataset.create", map[string]any{"name": fmt.Sprintf("tank/ds%d", i)}); err != nil {
+			t.Fatalf("pool.dataset.create: %v", err)
+		}
+	}
+	return srv, NewQueryCache(c)
+}
+
+func TestQueryCache_BatchesConcurrentLookups(t *testing.T) {
+	srv, cache := newCachedServer(t, 5)
+	datasets := truenas.NewDatasetService(cache, truenas.Version{Major: 25, Minor: 4})
+	before := srv.CallCount("pool.dataset.query")
+
+	var wg sync.WaitGroup
+	found := make([]*truenas.Dataset, 6)
+	errs := make([]error, 6)
+	for i := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found[i], errs[i] = datasets.GetDataset(context.Background(), fmt.Sprintf("tank/ds%d", i))
+		}()
+	}
+	wg.Wait()
+
+	for i := range 5 {
+		if errs[i] != nil {
+			t.Fatalf("GetDataset: %v", errs[i])
+		}
+		if found[i] == nil || found[i].ID != fmt.Sprintf("tank/ds%d", i) {
+			t.Errorf("expected tank/ds%d, got %+v", i, found[i])
+		}
+	}
+	if errs[5] != nil || found[5] != nil {
+		t.Errorf("expected tank/ds5 not to exist, got %+v, %v", found[5], errs[5])
+	}
+	if n := srv.CallCount("pool.dataset.query") - before; n != 1 {
+		t.Errorf("expected one batched query, got %d", n)
+	}
+
+	// Repeated lookups, including of missing datasets, are cached
+	if _, err := datasets.GetDataset(context.Background(), "tank/ds5"); err != nil {
+		t.Fatalf("GetDataset: %v", err)
+	}
+	if n := srv.CallCount("pool.dataset.query") - before; n != 1 {
+		t.Errorf("expected lookups to be cached, got %d queries", n)
+	}
+}
+
+func TestQueryCache_InvalidatedByWrites(t *testing.T) {
+	srv, cache := newCachedServer(t, 1)
+	datasets := truenas.NewDatasetService(cache, truenas.Version{Major: 25, Minor: 4})
+	ctx := context.Background()
+
+	ds, err := datasets.GetDataset(ctx, "tank/new")
+	if err != nil || ds != nil {
+		t.Fatalf("expected tank/new not to exist, got %+v, %v", ds, err)
+	}
+	if _, err := datasets.CreateDataset(ctx, truenas.CreateDatasetOpts{Name: "tank/new"}); err != nil {
+		t.Fatalf("CreateDataset: %v", err)
+	}
+	ds, err = datasets.GetDataset(ctx, "tank/new")
+	if err != nil || ds == nil {
+		t.Fatalf("expected tank/new after the write, got %+v, %v", ds, err)
+	}
+
+	before := srv.CallCount("pool.dataset.query")
+	if _, err := datasets.GetDataset(ctx, "tank/new"); err != nil {
+		t.Fatalf("GetDataset: %v", err)
+	}
+	if n := srv.CallCount("pool.dataset.query") - before; n != 0 {
+		t.Errorf("expected the lookup to be cached, got %d queries", n)
+	}
+}
+
+func TestQueryCache_PrefetchesLargeCollections(t *testing.T) {
+	srv, cache := newCachedServer(t, prefetchThreshold+5)
+	datasets := truenas.NewDatasetService(cache, truenas.Version{Major: 25, Minor: 4})
+	ctx := context.Background()
+
+	for i := range prefetchThreshold + 1 {
+		if _, err := datasets.GetDataset(ctx, fmt.Sprintf("tank/ds%d", i)); err != nil {
+			t.Fatalf("GetDataset: %v", err)
+		}
+	}
+
+	// The whole collection was fetched, so later lookups are served from
+	// the cache
+	before := srv.CallCount("pool.dataset.query")
+	for i := prefetchThreshold + 1; i < prefetchThreshold+5; i++ {
+		ds, err := datasets.GetDataset(ctx, fmt.Sprintf("tank/ds%d", i))
+		if err != nil || ds == nil {
+			t.Fatalf("expected tank/ds%d, got %+v, %v", i, ds, err)
+		}
+	}
+	if _, err := datasets.GetDataset(ctx, "tank/missing"); err != nil {
+		t.Fatalf("GetDataset: %v", err)
+	}
+	if n := srv.CallCount("pool.dataset.query") - before; n != 0 {
+		t.Errorf("expected prefetched lookups, got %d queries", n)
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/deevus/truenas-go/client"
+)
+
+// ErrNotFound matches, with errors.Is, the errors of calls on objects that
+// do not exist, for example a cron job that was deleted in the TrueNAS UI.
+var ErrNotFound = errors.New("not found")
+
+// NotFoundError is the error of a call on an object that does not exist. It
+// wraps the middleware error, whose message it keeps.
+type NotFoundError struct {
+	Err error
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrNotFound.
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// errnoNames maps the errno values the middleware reports in call errors to
+// their names.
+var errnoNames = map[int]string{
+	1:  "EPERM",
+	2:  "ENOENT",
+	5:  "EIO",
+	13: "EACCES",
+	14: "EFAULT",
+	16: "EBUSY",
+	17: "EEXIST",
+	20: "ENOTDIR",
+	21: "EISDIR",
+	22: "EINVAL",
+	28: "ENOSPC",
+	39: "ENOTEMPTY",
+	95: "EOPNOTSUPP",
+}
+
+// ErrnoString returns the name of errno, for example "ENOENT" for 2, or ""
+// when it is not known.
+func ErrnoString(errno int) string {
+	return errnoNames[errno]
+}
+
+// ErrnoName returns the errno name of a middleware error, for example
+// "ENOENT", or "" when err does not carry one. It understands the JSON-RPC
+// errors of the WebSocket transport as well as the parsed errors of SSH
+// calls and jobs.
+func ErrnoName(err error) string {
+	var rpcErr *client.JSONRPCError
+	if errors.As(err, &rpcErr) && rpcErr.Data != nil {
+		if name := ErrnoString(rpcErr.Data.Error); name != "" {
+			return name
+		}
+	}
+	var tnErr *client.TrueNASError
+	if errors.As(err, &tnErr) && tnErr.Code != "" && tnErr.Code != "UNKNOWN" {
+		return tnErr.Code
+	}
+	if code := client.ParseTrueNASError(err.Error()).Code; code != "UNKNOWN" {
+		return code
+	}
+	return ""
+}
+
+// Compile-time check that NotFoundClient implements client.Client.
+var _ client.Client = (*NotFoundClient)(nil)
+
+// NotFoundClient wraps a client.Client so that calls failing with ENOENT,
+// as the middleware does for objects that do not exist, return a
+// *NotFoundError. Services built on it pass the error on, so resources can
+// tell an object deleted outside Terraform from other failures with
+// errors.Is(err, ErrNotFound).
+type NotFoundClient struct {
+	client.Client
+}
+
+// NewNotFoundClient wraps c.
+func NewNotFoundClient(c client.Client) *NotFoundClient {
+	return &NotFoundClient{Client: c}
+}
+
+// Call calls method, typing not-found errors.
+func (c *NotFoundClient) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := c.Client.Call(ctx, method, params)
+	return result, notFound(err)
+}
+
+// CallAndWait calls method and waits for its job, typing not-found errors.
+func (c *NotFoundClient) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := c.Client.CallAndWait(ctx, method, params)
+	return result, notFound(err)
+}
+
+// DeleteFile removes the file at path, typing the error of a missing file.
+func (c *NotFoundClient) DeleteFile(ctx context.Context, path string) error {
+	return fileNotFound(c.Client.DeleteFile(ctx, path))
+}
+
+// RemoveDir removes the empty directory at path, typing the error of a
+// missing directory.
+func (c *NotFoundClient) RemoveDir(ctx context.Context, path string) error {
+	return fileNotFound(c.Client.RemoveDir(ctx, path))
+}
+
+// noSuchFile is the message of ENOENT that rm and rmdir print.
+const noSuchFile = "No such file or directory"
+
+// fileNotFound returns err as a *NotFoundError when it is an ENOENT error
+// of a middleware call or a shell command.
+func fileNotFound(err error) error {
+	if err != nil && !errors.Is(err, ErrNotFound) && strings.Contains(err.Error(), noSuchFile) {
+		return &NotFoundError{Err: err}
+	}
+	return notFound(err)
+}
+
+// notFound returns err as a *NotFoundError when it is an ENOENT error.
+func notFound(err error) error {
+	if err == nil || errors.Is(err, ErrNotFound) || ErrnoName(err) != "ENOENT" {
+		return err
+	}
+	return &NotFoundError{Err: err}
+}
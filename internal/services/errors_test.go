@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/deevus/truenas-go/client"
+)
+
+func TestErrnoName(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"websocket errno", &client.JSONRPCError{Data: &client.JSONRPCData{Error: 2, Reason: "[ENOENT] tank/data"}}, "ENOENT"},
+		{"websocket reason only", &client.JSONRPCError{Data: &client.JSONRPCData{Reason: "[EBUSY] dataset is busy"}}, "EBUSY"},
+		{"ssh job error", fmt.Errorf("query: %w", client.ParseTrueNASError("[EEXIST] Already exists")), "EEXIST"},
+		{"ssh call error", errors.New("Process exited with status 1: [ENOENT] CronJob 3 does not exist"), "ENOENT"},
+		{"no errno", errors.New("connection refused"), ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ErrnoName(tc.err); got != tc.expected {
+				t.Errorf("ErrnoName(%v) = %q, expected %q", tc.err, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNotFoundClient(t *testing.T) {
+	_, cache := newCachedServer(t, 1)
+	c := NewNotFoundClient(cache)
+	ctx := context.Background()
+
+	_, err := c.Call(ctx, "cronjob.delete", 3)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a not-found error deleting a missing cron job, got %v", err)
+	}
+	var rpcErr *client.JSONRPCError
+	if !errors.As(err, &rpcErr) {
+		t.Errorf("expected the middleware error to be wrapped, got %T", err)
+	}
+	if err.Error() != "[ENOENT] CronJob 3 does not exist" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+
+	_, err = c.Call(ctx, "pool.dataset.create", map[string]any{"name": "tank/ds0"})
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected an error that is not a not-found error, got %v", err)
+	}
+
+	_, err = c.CallAndWait(ctx, "app.delete", []any{"missing"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a not-found error deleting a missing app, got %v", err)
+	}
+}
+
+func TestNotFoundClient_FileErrors(t *testing.T) {
+	ctx := context.Background()
+	c := NewNotFoundClient(&client.MockClient{
+		DeleteFileFunc: func(ctx context.Context, path string) error {
+			return fmt.Errorf("failed to delete file %q: rm: cannot remove '%s': No such file or directory", path, path)
+		},
+		RemoveDirFunc: func(ctx context.Context, path string) error {
+			return fmt.Errorf("failed to remove directory %q: rmdir: failed to remove '%s': Directory not empty", path, path)
+		},
+	})
+
+	if err := c.DeleteFile(ctx, "/mnt/tank/gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a not-found error deleting a missing file, got %v", err)
+	}
+	if err := c.RemoveDir(ctx, "/mnt/tank/full"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected an error that is not a not-found error, got %v", err)
+	}
+}
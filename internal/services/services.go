@@ -6,13 +6,23 @@ import (
 )
 
 // TrueNASServices holds typed service instances for all TrueNAS API namespaces.
-// Resources and datasources access services through this registry.
+// Resources and datasources access services through this registry. The
+// provider builds the services on a QueryCache.
 type TrueNASServices struct {
 	// Client provides backward-compatible access to the raw client.Client
 	// for resources that haven't been migrated to typed services yet.
 	// Remove this field once all resources use typed service methods.
 	Client client.Client
 
+	// ReadOnly is set when the provider is configured with read_only. Client
+	// then rejects every call that is not a query.
+	ReadOnly bool
+
+	// SkipReferenceChecks is set when the provider is configured with
+	// skip_reference_checks. Resources then do not look up the objects they
+	// refer to while planning.
+	SkipReferenceChecks bool
+
 	App        truenas.AppServiceAPI
 	CloudSync  truenas.CloudSyncServiceAPI
 	Cron       truenas.CronServiceAPI
@@ -22,3 +32,12 @@ type TrueNASServices struct {
 	Virt       truenas.VirtServiceAPI
 	VM         truenas.VMServiceAPI
 }
+
+// Version returns the TrueNAS version detected by Client, or the zero Version
+// when no client is configured.
+func (s *TrueNASServices) Version() truenas.Version {
+	if s == nil || s.Client == nil {
+		return truenas.Version{}
+	}
+	return s.Client.Version()
+}
@@ -0,0 +1,234 @@
+// Package transport contains the client.Client implementations used by the
+// provider. SSHClient and WebSocketClient talk to the NAS as the truenas-go
+// clients do, adding the connection and login options those lack. The other
+// clients add rate limiting, read-only plans, cassettes and a fallback for
+// API-only deployments.
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+// ErrShellRequired is returned by APIFallbackClient for operations that have
+// no middleware equivalent and can only be performed over SSH.
+var ErrShellRequired = fmt.Errorf("requires shell access over SSH: %w", client.ErrUnsupportedOperation)
+
+// Caller is the subset of client.Client used to issue middleware calls.
+type Caller interface {
+	Call(ctx context.Context, method string, params any) (json.RawMessage, error)
+}
+
+// APIFallbackConfig configures an APIFallbackClient.
+type APIFallbackConfig struct {
+	Host               string
+	Port               int
+	InsecureSkipVerify bool
+	// TLSConfig, when set, configures TLS instead of InsecureSkipVerify.
+	TLSConfig *tls.Config
+
+	// HTTPClient is used for file downloads. Defaults to a client honouring
+	// TLSConfig or InsecureSkipVerify.
+	HTTPClient *http.Client
+}
+
+// Compile-time check that APIFallbackClient implements client.Client.
+var _ client.Client = (*APIFallbackClient)(nil)
+
+// APIFallbackClient is a WebSocketConfig.Fallback for deployments without SSH.
+// It detects the TrueNAS version with system.info and reads files with
+// core.download/filesystem.get over the WebSocket connection it is attached to.
+// Operations that genuinely need a shell return ErrShellRequired.
+type APIFallbackClient struct {
+	config APIFallbackConfig
+
+	mu      sync.RWMutex
+	caller  Caller
+	version truenas.Version
+}
+
+// NewAPIFallbackClient creates an APIFallbackClient. Attach must be called
+// with the WebSocket client before Connect.
+func NewAPIFallbackClient(cfg APIFallbackConfig) *APIFallbackClient {
+	if cfg.Port == 0 {
+		cfg.Port = 443
+	}
+	if cfg.HTTPClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = cfg.TLSConfig
+		if cfg.TLSConfig == nil && cfg.InsecureSkipVerify {
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+		}
+		cfg.HTTPClient = &http.Client{Transport: transport}
+	}
+	return &APIFallbackClient{config: cfg}
+}
+
+// Attach sets the client used for middleware calls. The WebSocket client owns
+// its fallback, so the two are created in order and then linked here.
+func (c *APIFallbackClient) Attach(caller Caller) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.caller = caller
+}
+
+func (c *APIFallbackClient) getCaller() (Caller, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.caller == nil {
+		return nil, errors.New("api fallback client is not attached to a WebSocket client")
+	}
+	return c.caller, nil
+}
+
+// Connect detects the TrueNAS version with system.info.
+func (c *APIFallbackClient) Connect(ctx context.Context) error {
+	caller, err := c.getCaller()
+	if err != nil {
+		return err
+	}
+
+	result, err := caller.Call(ctx, "system.info", nil)
+	if err != nil {
+		return fmt.Errorf("failed to detect TrueNAS version: %w", err)
+	}
+
+	var info struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(result, &info); err != nil {
+		return fmt.Errorf("failed to parse system.info response: %w", err)
+	}
+
+	version, err := truenas.ParseVersion(strings.TrimSpace(info.Version))
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.version = version
+	c.mu.Unlock()
+	return nil
+}
+
+// Version returns the version detected by Connect, or the zero Version.
+func (c *APIFallbackClient) Version() truenas.Version {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// ReadFile downloads a file through core.download and filesystem.get.
+func (c *APIFallbackClient) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	caller, err := c.getCaller()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := caller.Call(ctx, "core.download", []any{"filesystem.get", []any{path}, filepath.Base(path)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+
+	// core.download returns [job_id, "/_download/<id>?auth_token=..."]
+	var download []json.RawMessage
+	if err := json.Unmarshal(result, &download); err != nil || len(download) != 2 {
+		return nil, fmt.Errorf("failed to read file %q: unexpected core.download response: %s", path, string(result))
+	}
+	var url string
+	if err := json.Unmarshal(download[1], &url); err != nil {
+		return nil, fmt.Errorf("failed to read file %q: unexpected download URL: %w", path, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.downloadURL(url), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read file %q: download returned HTTP %d", path, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+	return data, nil
+}
+
+// downloadURL resolves a path returned by core.download against the server.
+func (c *APIFallbackClient) downloadURL(url string) string {
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		return url
+	}
+	return fmt.Sprintf("https://%s:%d%s", c.config.Host, c.config.Port, url)
+}
+
+// DeleteFile has no middleware equivalent.
+func (c *APIFallbackClient) DeleteFile(ctx context.Context, path string) error {
+	return fmt.Errorf("delete file %q %w", path, ErrShellRequired)
+}
+
+// RemoveDir has no middleware equivalent.
+func (c *APIFallbackClient) RemoveDir(ctx context.Context, path string) error {
+	return fmt.Errorf("remove directory %q %w", path, ErrShellRequired)
+}
+
+// RemoveAll has no middleware equivalent.
+func (c *APIFallbackClient) RemoveAll(ctx context.Context, path string) error {
+	return fmt.Errorf("remove directory tree %q %w", path, ErrShellRequired)
+}
+
+// The WebSocket client performs the remaining operations natively and never
+// delegates them to its fallback.
+
+func (c *APIFallbackClient) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return nil, client.ErrUnsupportedOperation
+}
+
+func (c *APIFallbackClient) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return nil, client.ErrUnsupportedOperation
+}
+
+func (c *APIFallbackClient) WriteFile(ctx context.Context, path string, params truenas.WriteFileParams) error {
+	return client.ErrUnsupportedOperation
+}
+
+func (c *APIFallbackClient) FileExists(ctx context.Context, path string) (bool, error) {
+	return false, client.ErrUnsupportedOperation
+}
+
+func (c *APIFallbackClient) Chown(ctx context.Context, path string, uid, gid int) error {
+	return client.ErrUnsupportedOperation
+}
+
+func (c *APIFallbackClient) ChmodRecursive(ctx context.Context, path string, mode fs.FileMode) error {
+	return client.ErrUnsupportedOperation
+}
+
+func (c *APIFallbackClient) MkdirAll(ctx context.Context, path string, mode fs.FileMode) error {
+	return client.ErrUnsupportedOperation
+}
+
+func (c *APIFallbackClient) Subscribe(ctx context.Context, collection string, params any) (*truenas.Subscription[json.RawMessage], error) {
+	return nil, client.ErrUnsupportedOperation
+}
+
+func (c *APIFallbackClient) Close() error { return nil }
@@ -0,0 +1,153 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deevus/truenas-go/client"
+)
+
+// stubCaller answers middleware calls from a function.
+type stubCaller struct {
+	callFunc func(ctx context.Context, method string, params any) (json.RawMessage, error)
+}
+
+func (s *stubCaller) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return s.callFunc(ctx, method, params)
+}
+
+func TestAPIFallbackClient_Connect_DetectsVersionFromSystemInfo(t *testing.T) {
+	c := NewAPIFallbackClient(APIFallbackConfig{Host: "nas"})
+	c.Attach(&stubCaller{callFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+		if method != "system.info" {
+			t.Errorf("expected system.info, got %s", method)
+		}
+		return json.RawMessage(`{"version": "TrueNAS-25.04.2.4", "hostname": "nas"}`), nil
+	}})
+
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v := c.Version()
+	if v.Major != 25 || v.Minor != 4 || v.Patch != 2 || v.Build != 4 {
+		t.Errorf("unexpected version %v", v)
+	}
+}
+
+func TestAPIFallbackClient_Connect_NotAttached(t *testing.T) {
+	c := NewAPIFallbackClient(APIFallbackConfig{Host: "nas"})
+
+	if err := c.Connect(context.Background()); err == nil {
+		t.Fatal("expected error when not attached")
+	}
+}
+
+func TestAPIFallbackClient_Connect_CallError(t *testing.T) {
+	c := NewAPIFallbackClient(APIFallbackConfig{Host: "nas"})
+	c.Attach(&stubCaller{callFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+		return nil, errors.New("connection refused")
+	}})
+
+	if err := c.Connect(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if !c.Version().IsZero() {
+		t.Error("expected version to stay zero after failed detection")
+	}
+}
+
+func TestAPIFallbackClient_ReadFile_DownloadsThroughCoreDownload(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_download/42" || r.URL.Query().Get("auth_token") != "tok" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("file content"))
+	}))
+	defer server.Close()
+
+	c := NewAPIFallbackClient(APIFallbackConfig{Host: "nas", HTTPClient: server.Client()})
+	c.Attach(&stubCaller{callFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+		if method != "core.download" {
+			t.Errorf("expected core.download, got %s", method)
+		}
+		args := params.([]any)
+		if args[0] != "filesystem.get" {
+			t.Errorf("expected filesystem.get job, got %v", args[0])
+		}
+		if p := args[1].([]any)[0]; p != "/mnt/tank/app/config.yaml" {
+			t.Errorf("unexpected path %v", p)
+		}
+		return json.RawMessage(`[42, "` + server.URL + `/_download/42?auth_token=tok"]`), nil
+	}})
+
+	data, err := c.ReadFile(context.Background(), "/mnt/tank/app/config.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "file content" {
+		t.Errorf("unexpected content %q", string(data))
+	}
+}
+
+func TestAPIFallbackClient_ReadFile_HTTPError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := NewAPIFallbackClient(APIFallbackConfig{Host: "nas", HTTPClient: server.Client()})
+	c.Attach(&stubCaller{callFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+		return json.RawMessage(`[1, "` + server.URL + `/_download/1"]`), nil
+	}})
+
+	if _, err := c.ReadFile(context.Background(), "/mnt/tank/missing"); err == nil {
+		t.Fatal("expected error for HTTP 404")
+	}
+}
+
+func TestAPIFallbackClient_ReadFile_UnexpectedResponse(t *testing.T) {
+	c := NewAPIFallbackClient(APIFallbackConfig{Host: "nas"})
+	c.Attach(&stubCaller{callFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+		return json.RawMessage(`42`), nil
+	}})
+
+	if _, err := c.ReadFile(context.Background(), "/mnt/tank/file"); err == nil {
+		t.Fatal("expected error for malformed core.download response")
+	}
+}
+
+func TestAPIFallbackClient_DownloadURL(t *testing.T) {
+	c := NewAPIFallbackClient(APIFallbackConfig{Host: "nas.local", Port: 8443})
+
+	if got := c.downloadURL("/_download/1?auth_token=x"); got != "https://nas.local:8443/_download/1?auth_token=x" {
+		t.Errorf("unexpected relative URL resolution: %s", got)
+	}
+	if got := c.downloadURL("https://other/_download/1"); got != "https://other/_download/1" {
+		t.Errorf("expected absolute URL to pass through, got %s", got)
+	}
+}
+
+func TestAPIFallbackClient_ShellOperations(t *testing.T) {
+	c := NewAPIFallbackClient(APIFallbackConfig{Host: "nas"})
+	ctx := context.Background()
+
+	errs := map[string]error{
+		"DeleteFile": c.DeleteFile(ctx, "/mnt/tank/file"),
+		"RemoveDir":  c.RemoveDir(ctx, "/mnt/tank/dir"),
+		"RemoveAll":  c.RemoveAll(ctx, "/mnt/tank/dir"),
+	}
+	for name, err := range errs {
+		if !errors.Is(err, ErrShellRequired) {
+			t.Errorf("%s: expected ErrShellRequired, got %v", name, err)
+		}
+		if !errors.Is(err, client.ErrUnsupportedOperation) {
+			t.Errorf("%s: expected error to wrap client.ErrUnsupportedOperation", name)
+		}
+	}
+}
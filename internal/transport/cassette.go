@@ -0,0 +1,230 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Operations recorded in a cassette. Each corresponds to a client.Client method.
+const (
+	OpConnect        = "connect"
+	OpCall           = "call"
+	OpCallAndWait    = "call_and_wait"
+	OpWriteFile      = "write_file"
+	OpReadFile       = "read_file"
+	OpDeleteFile     = "delete_file"
+	OpRemoveDir      = "remove_dir"
+	OpRemoveAll      = "remove_all"
+	OpFileExists     = "file_exists"
+	OpChown          = "chown"
+	OpChmodRecursive = "chmod_recursive"
+	OpMkdirAll       = "mkdir_all"
+)
+
+// Redacted replaces secret values in cassettes.
+const Redacted = "REDACTED"
+
+// Interaction is a single recorded client operation.
+type Interaction struct {
+	// Transport distinguishes the SSH client from the WebSocket client when
+	// both are recorded into the same cassette.
+	Transport string `json:"transport"`
+	Operation string `json:"operation"`
+
+	// Method is the middleware method for call operations, or the remote
+	// path for file operations.
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// key identifies interactions that should replay the same response.
+func (i Interaction) key() string {
+	return i.Transport + "\x00" + i.Operation + "\x00" + i.Method + "\x00" + string(i.Params)
+}
+
+// sensitiveKeys lists JSON object keys whose values are never written to a
+// cassette. Matching is case-insensitive on the full key or a key suffix.
+// A bare "key" only matches exactly, so flags such as generate_key survive.
+var sensitiveKeys = []string{
+	"password",
+	"passphrase",
+	"salt",
+	"secret",
+	"secret_key",
+	"secret_access_key",
+	"api_key",
+	"private_key",
+	"token",
+	"auth_token",
+	"otp_token",
+	"account_key",
+	"service_account_credentials",
+}
+
+// isSensitiveKey reports whether values stored under key must be redacted.
+func isSensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	if k == "key" {
+		return true
+	}
+	for _, s := range sensitiveKeys {
+		if k == s || strings.HasSuffix(k, "_"+s) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretResultMethods lists methods whose whole result is a secret, such
+// as a bare token string, which has no key to be redacted by.
+var secretResultMethods = map[string]bool{
+	"auth.generate_token":            true,
+	"auth.generate_onetime_password": true,
+}
+
+// redactResult returns the result of a call to method in the form it is
+// written to a cassette.
+func redactResult(method string, result []byte) json.RawMessage {
+	if secretResultMethods[method] && len(result) > 0 {
+		return json.RawMessage(`"` + Redacted + `"`)
+	}
+	return redactJSON(result)
+}
+
+// redactJSON returns data with every sensitive value replaced by Redacted.
+// Object keys are emitted in sorted order, so the output is also a canonical
+// form suitable for matching.
+func redactJSON(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return json.RawMessage(data)
+	}
+
+	// Download URLs contain '&', which the default encoder would escape
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redactValue(v)); err != nil {
+		return json.RawMessage(data)
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if isSensitiveKey(k) {
+				if _, isObject := val.(map[string]any); !isObject && val != nil {
+					t[k] = Redacted
+					continue
+				}
+			}
+			t[k] = redactValue(val)
+		}
+		return t
+	case []any:
+		for i, val := range t {
+			t[i] = redactValue(val)
+		}
+		return t
+	case string:
+		return authTokenParam.ReplaceAllString(t, "${1}"+Redacted)
+	default:
+		return v
+	}
+}
+
+// authTokenParam matches the token embedded in core.download URLs.
+var authTokenParam = regexp.MustCompile(`(auth_token=)[^&"\s]+`)
+
+// marshalParams converts call parameters into their redacted JSON form.
+func marshalParams(params any) json.RawMessage {
+	if params == nil {
+		return nil
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return json.RawMessage(fmt.Sprintf("%q", fmt.Sprint(params)))
+	}
+	return redactJSON(data)
+}
+
+// CassetteWriter appends interactions to a JSON Lines cassette file.
+// Recorders sharing a writer, such as the SSH and WebSocket recorders of one
+// provider, never interleave partial lines.
+type CassetteWriter struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewCassetteWriter creates a CassetteWriter appending to the cassette at
+// path. The file is created on the first write.
+func NewCassetteWriter(path string) *CassetteWriter {
+	return &CassetteWriter{path: path}
+}
+
+func (w *CassetteWriter) append(i Interaction) error {
+	line, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// LoadCassette reads every interaction from a JSON Lines cassette file.
+func LoadCassette(path string) ([]Interaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette %q: %w", path, err)
+	}
+
+	var interactions []Interaction
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var i Interaction
+		if err := json.Unmarshal(text, &i); err != nil {
+			return nil, fmt.Errorf("cassette %q line %d: %w", path, line, err)
+		}
+		// Normalise so hand-edited cassettes match recorded parameters
+		i.Params = redactJSON(i.Params)
+		interactions = append(interactions, i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette %q: %w", path, err)
+	}
+
+	return interactions, nil
+}
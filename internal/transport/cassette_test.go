@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactJSON_SensitiveKeys(t *testing.T) {
+	input := `{
+		"name": "backup",
+		"attributes": {"access_key_id": "AKIA", "secret_access_key": "s3cr3t", "key": "b2key"},
+		"password": "hunter2",
+		"encryption_options": {"passphrase": "pp", "generate_key": true, "key": "hex"},
+		"nested": [{"api_key": "1-abc"}, {"private_key": "-----BEGIN"}],
+		"encryption": true,
+		"encryption_password": "cloudpw",
+		"encryption_salt": "cloudsalt"
+	}`
+
+	out := string(redactJSON([]byte(input)))
+
+	for _, secret := range []string{"s3cr3t", "b2key", "hunter2", "\"pp\"", "hex", "1-abc", "BEGIN", "cloudpw", "cloudsalt"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %s to be redacted, got %s", secret, out)
+		}
+	}
+	for _, kept := range []string{"backup", "AKIA", "\"generate_key\":true", "\"encryption\":true"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("expected %s to be kept, got %s", kept, out)
+		}
+	}
+}
+
+func TestRedactJSON_DownloadToken(t *testing.T) {
+	out := string(redactJSON([]byte(`[12, "/_download/12?auth_token=abcdef&x=1"]`)))
+
+	if strings.Contains(out, "abcdef") {
+		t.Errorf("expected auth_token to be redacted, got %s", out)
+	}
+	if !strings.Contains(out, "auth_token=REDACTED&x=1") {
+		t.Errorf("unexpected redaction result %s", out)
+	}
+}
+
+func TestRedactJSON_CanonicalKeyOrder(t *testing.T) {
+	a := redactJSON([]byte(`{"b": 1, "a": 2}`))
+	b := redactJSON([]byte(`{"a": 2, "b": 1}`))
+
+	if string(a) != string(b) {
+		t.Errorf("expected canonical output, got %s and %s", a, b)
+	}
+}
+
+func TestRedactJSON_PreservesLargeIntegers(t *testing.T) {
+	out := redactJSON([]byte(`{"size": 18446744073709551615}`))
+
+	var v map[string]json.Number
+	if err := json.Unmarshal(out, &v); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v["size"].String() != "18446744073709551615" {
+		t.Errorf("expected integer to survive redaction, got %s", v["size"])
+	}
+}
+
+func TestRedactJSON_InvalidJSONPassesThrough(t *testing.T) {
+	if got := string(redactJSON([]byte("not json"))); got != "not json" {
+		t.Errorf("expected passthrough, got %q", got)
+	}
+	if redactJSON(nil) != nil {
+		t.Error("expected nil for empty input")
+	}
+}
+
+func TestLoadCassette_SkipsBlankLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	content := `{"transport":"ssh","operation":"connect","result":"\"TrueNAS-25.04.0\""}
+
+{"transport":"ssh","operation":"call","method":"cronjob.query","params":{"b":1,"a":2},"result":[]}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	interactions, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(interactions) != 2 {
+		t.Fatalf("expected 2 interactions, got %d", len(interactions))
+	}
+	if string(interactions[1].Params) != `{"a":2,"b":1}` {
+		t.Errorf("expected params to be normalised, got %s", interactions[1].Params)
+	}
+}
+
+func TestLoadCassette_InvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	if err := os.WriteFile(path, []byte("{not json}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadCassette(path); err == nil {
+		t.Fatal("expected error for malformed cassette")
+	}
+}
+
+func TestLoadCassette_MissingFile(t *testing.T) {
+	if _, err := LoadCassette(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Fatal("expected error for missing cassette")
+	}
+}
@@ -0,0 +1,140 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deevus/truenas-go/client"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// jobAbortTimeout bounds the core.job_abort call made for a job whose
+// context ended.
+const jobAbortTimeout = 30 * time.Second
+
+// jobPollInterval is how often core.get_jobs is polled while waiting for a
+// job.
+const jobPollInterval = 500 * time.Millisecond
+
+// jobStateAborted is the terminal state of aborted jobs, which truenas-go
+// does not define.
+const jobStateAborted client.JobState = "ABORTED"
+
+// callFunc is the Call method of a client.
+type callFunc func(ctx context.Context, method string, params any) (json.RawMessage, error)
+
+// jobEvent is the part of a core.get_jobs row needed to follow a job.
+type jobEvent struct {
+	ID          int64           `json:"id"`
+	Method      string          `json:"method"`
+	State       client.JobState `json:"state"`
+	Progress    jobProgress     `json:"progress"`
+	Result      json.RawMessage `json:"result"`
+	Error       string          `json:"error"`
+	LogsExcerpt string          `json:"logs_excerpt"`
+}
+
+func (e jobEvent) terminal() bool {
+	return e.State == client.JobStateSuccess || e.State == client.JobStateFailed || e.State == jobStateAborted
+}
+
+// jobProgress is the progress of a job as reported by core.get_jobs.
+type jobProgress struct {
+	Percent     float64 `json:"percent"`
+	Description string  `json:"description"`
+}
+
+// progressLogger logs the progress of a job at info level whenever it
+// changes, so that long jobs such as image pulls show activity during an
+// apply.
+type progressLogger struct {
+	last jobProgress
+}
+
+func (l *progressLogger) log(ctx context.Context, job jobEvent) {
+	if job.Progress == l.last {
+		return
+	}
+	l.last = job.Progress
+
+	msg := fmt.Sprintf("Job %d (%s) is %.0f%% complete", job.ID, job.Method, job.Progress.Percent)
+	if job.Progress.Description != "" {
+		msg += ": " + job.Progress.Description
+	}
+	tflog.Info(ctx, msg, map[string]any{
+		"job_id":      job.ID,
+		"method":      job.Method,
+		"percent":     job.Progress.Percent,
+		"description": job.Progress.Description,
+	})
+}
+
+// abortJob handles a job whose context ended before the job finished, either
+// because its deadline passed, for example a resource timeout, or because it
+// was cancelled, for example by Ctrl-C. The job is aborted with core.job_abort
+// so that it does not keep running on the NAS. The returned error wraps
+// ctx.Err().
+func abortJob(ctx context.Context, call callFunc, id int64) error {
+	reason := "was cancelled"
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		reason = "did not finish in time"
+	}
+
+	abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobAbortTimeout)
+	defer cancel()
+	if _, err := call(abortCtx, "core.job_abort", []any{id}); err != nil {
+		return fmt.Errorf("job %d %s and could not be aborted, so it may still be running: %v: %w",
+			id, reason, err, ctx.Err())
+	}
+	return fmt.Errorf("job %d %s and was aborted: %w", id, reason, ctx.Err())
+}
+
+// waitJob polls core.get_jobs until job id finishes, logging its progress,
+// and returns the job's final state. A job still running when ctx is
+// cancelled or its deadline passes is aborted.
+func waitJob(ctx context.Context, call callFunc, id int64) (jobEvent, error) {
+	var progress progressLogger
+	params := []any{[]any{[]any{"id", "=", id}}, map[string]any{"get": true}}
+	for {
+		result, err := call(ctx, "core.get_jobs", params)
+		if err != nil {
+			if ctx.Err() != nil {
+				return jobEvent{}, abortJob(ctx, call, id)
+			}
+			return jobEvent{}, fmt.Errorf("failed to poll job %d: %w", id, err)
+		}
+		var job jobEvent
+		if err := json.Unmarshal(result, &job); err != nil {
+			return jobEvent{}, fmt.Errorf("failed to parse job status: %w", err)
+		}
+		if job.terminal() {
+			return job, nil
+		}
+		progress.log(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return jobEvent{}, abortJob(ctx, call, id)
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+// jobError converts a job that did not succeed into a *client.TrueNASError,
+// attaching the app lifecycle log when there is one.
+func jobError(ctx context.Context, job jobEvent, readFile func(ctx context.Context, path string) ([]byte, error)) error {
+	if job.Error == "" {
+		return fmt.Errorf("job %d %s", job.ID, strings.ToLower(string(job.State)))
+	}
+	tnErr := client.ParseTrueNASError(job.Error)
+	tnErr.LogsExcerpt = job.LogsExcerpt
+	client.EnrichAppLifecycleError(ctx, tnErr, func(ctx context.Context, path string) (string, error) {
+		content, err := readFile(ctx, path)
+		return string(content), err
+	})
+	return tnErr
+}
@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestProgressLogger_LogsChanges(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	var progress progressLogger
+	job := jobEvent{ID: 7, Method: "app.create"}
+	progress.log(ctx, job)
+	job.Progress = jobProgress{Percent: 40, Description: "Pulling images"}
+	progress.log(ctx, job)
+	progress.log(ctx, job)
+	job.Progress = jobProgress{Percent: 80}
+	progress.log(ctx, job)
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatalf("decode log: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 progress entries, got %d: %v", len(entries), entries)
+	}
+	if entries[0]["@level"] != "info" {
+		t.Errorf("expected info level, got %v", entries[0]["@level"])
+	}
+	if msg := entries[0]["@message"]; msg != "Job 7 (app.create) is 40% complete: Pulling images" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if msg := entries[1]["@message"]; msg != "Job 7 (app.create) is 80% complete" {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestAbortJob_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var methods []string
+	err := abortJob(ctx, func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+		if ctx.Err() != nil {
+			t.Error("expected the abort to outlive the cancelled context")
+		}
+		methods = append(methods, method)
+		return nil, nil
+	}, 12)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "job 12 was cancelled and was aborted") {
+		t.Errorf("unexpected error %q", err)
+	}
+	if len(methods) != 1 || methods[0] != "core.job_abort" {
+		t.Errorf("expected core.job_abort, got %v", methods)
+	}
+}
@@ -0,0 +1,206 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"time"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+	"golang.org/x/time/rate"
+)
+
+// DefaultRateLimit is the default number of API calls per minute.
+const DefaultRateLimit = 300
+
+// unlimitedCallsPerMinute turns off the rate limit of
+// client.RateLimitedClient, which has no way to turn it off and cannot weigh
+// calls. RateLimitedClient limits the rate itself.
+const unlimitedCallsPerMinute = math.MaxInt32
+
+// RateLimitConfig configures a RateLimitedClient.
+type RateLimitConfig struct {
+	// CallsPerMinute is the rate limit. 0 disables rate limiting.
+	CallsPerMinute int
+	// Weights are the number of calls a call to a method counts as, for
+	// expensive methods such as pool.dataset.query. Other methods, and
+	// file operations, count as one call.
+	Weights map[string]int
+	// MaxRetries is the number of times a call failing with an error
+	// Classifier reports as retriable is retried. 0 disables retries.
+	MaxRetries int
+	// Classifier decides which errors are retried. Defaults to
+	// RetryClassifier.
+	Classifier client.RetryClassifier
+}
+
+// Compile-time check that RateLimitedClient implements client.Client.
+var _ client.Client = (*RateLimitedClient)(nil)
+
+// RateLimitedClient is a client.RateLimitedClient whose calls are weighted
+// by method. Calls of methods that change state are only retried when the
+// NAS cannot have run them, so that a create or a job is never run twice.
+type RateLimitedClient struct {
+	*client.RateLimitedClient
+	// limiter is nil when rate limiting is disabled.
+	limiter *rate.Limiter
+	weights map[string]int
+}
+
+// NewRateLimitedClient wraps c.
+func NewRateLimitedClient(c client.Client, cfg RateLimitConfig) *RateLimitedClient {
+	if cfg.Classifier == nil {
+		cfg.Classifier = &RetryClassifier{}
+	}
+	r := &RateLimitedClient{
+		RateLimitedClient: client.NewRateLimitedClient(&retryGuard{Client: c}, unlimitedCallsPerMinute, max(cfg.MaxRetries, 0), cfg.Classifier),
+		weights:           cfg.Weights,
+	}
+
+	// A call takes as many tokens as its weight, so the burst must fit the
+	// heaviest call
+	if cfg.CallsPerMinute > 0 {
+		burst := 1
+		for _, w := range cfg.Weights {
+			burst = max(burst, w)
+		}
+		r.limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(cfg.CallsPerMinute)), burst)
+	}
+	return r
+}
+
+// weight returns the number of calls a call to method counts as.
+func (r *RateLimitedClient) weight(method string) int {
+	return max(r.weights[method], 1)
+}
+
+// wait blocks until the limiter allows n calls.
+func (r *RateLimitedClient) wait(ctx context.Context, n int) error {
+	if r.limiter == nil {
+		return nil
+	}
+	if err := r.limiter.WaitN(ctx, n); err != nil {
+		return fmt.Errorf("rate limiter: %w", err)
+	}
+	return nil
+}
+
+// Call executes a method with rate limiting and retries.
+func (r *RateLimitedClient) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if err := r.wait(ctx, r.weight(method)); err != nil {
+		return nil, err
+	}
+	return r.RateLimitedClient.Call(ctx, method, params)
+}
+
+// CallAndWait executes a job method with rate limiting. A job that the NAS
+// rejected as busy is started again.
+func (r *RateLimitedClient) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if err := r.wait(ctx, r.weight(method)); err != nil {
+		return nil, err
+	}
+	return r.RateLimitedClient.CallAndWait(ctx, method, params)
+}
+
+// WriteFile writes a file with rate limiting.
+func (r *RateLimitedClient) WriteFile(ctx context.Context, path string, params truenas.WriteFileParams) error {
+	if err := r.wait(ctx, 1); err != nil {
+		return err
+	}
+	return r.RateLimitedClient.WriteFile(ctx, path, params)
+}
+
+// ReadFile reads a file with rate limiting.
+func (r *RateLimitedClient) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	if err := r.wait(ctx, 1); err != nil {
+		return nil, err
+	}
+	return r.RateLimitedClient.ReadFile(ctx, path)
+}
+
+// DeleteFile deletes a file with rate limiting.
+func (r *RateLimitedClient) DeleteFile(ctx context.Context, path string) error {
+	if err := r.wait(ctx, 1); err != nil {
+		return err
+	}
+	return r.RateLimitedClient.DeleteFile(ctx, path)
+}
+
+// RemoveDir removes an empty directory with rate limiting.
+func (r *RateLimitedClient) RemoveDir(ctx context.Context, path string) error {
+	if err := r.wait(ctx, 1); err != nil {
+		return err
+	}
+	return r.RateLimitedClient.RemoveDir(ctx, path)
+}
+
+// RemoveAll removes a directory tree with rate limiting.
+func (r *RateLimitedClient) RemoveAll(ctx context.Context, path string) error {
+	if err := r.wait(ctx, 1); err != nil {
+		return err
+	}
+	return r.RateLimitedClient.RemoveAll(ctx, path)
+}
+
+// FileExists checks if a file exists with rate limiting.
+func (r *RateLimitedClient) FileExists(ctx context.Context, path string) (bool, error) {
+	if err := r.wait(ctx, 1); err != nil {
+		return false, err
+	}
+	return r.RateLimitedClient.FileExists(ctx, path)
+}
+
+// Chown changes ownership with rate limiting.
+func (r *RateLimitedClient) Chown(ctx context.Context, path string, uid, gid int) error {
+	if err := r.wait(ctx, 1); err != nil {
+		return err
+	}
+	return r.RateLimitedClient.Chown(ctx, path, uid, gid)
+}
+
+// ChmodRecursive changes permissions with rate limiting.
+func (r *RateLimitedClient) ChmodRecursive(ctx context.Context, path string, mode fs.FileMode) error {
+	if err := r.wait(ctx, 1); err != nil {
+		return err
+	}
+	return r.RateLimitedClient.ChmodRecursive(ctx, path, mode)
+}
+
+// MkdirAll creates a directory with rate limiting.
+func (r *RateLimitedClient) MkdirAll(ctx context.Context, path string, mode fs.FileMode) error {
+	if err := r.wait(ctx, 1); err != nil {
+		return err
+	}
+	return r.RateLimitedClient.MkdirAll(ctx, path, mode)
+}
+
+// retryGuard wraps the client under client.RateLimitedClient so that errors
+// of methods that change state, other than those proving the call was not
+// run, are not retried.
+type retryGuard struct {
+	client.Client
+}
+
+// guard returns err as a *sentCallError unless method only reads state or
+// err proves the NAS did not run the call.
+func guard(method string, err error) error {
+	if err == nil || IsQueryMethod(method) || notSent(err) {
+		return err
+	}
+	return &sentCallError{err: err}
+}
+
+// Call calls method, guarding its error.
+func (g *retryGuard) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := g.Client.Call(ctx, method, params)
+	return result, guard(method, err)
+}
+
+// CallAndWait calls method and waits for its job, guarding its error.
+func (g *retryGuard) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := g.Client.CallAndWait(ctx, method, params)
+	return result, guard(method, err)
+}
@@ -0,0 +1,191 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/deevus/truenas-go/client"
+)
+
+func TestRateLimitedClient_RetriesTransientErrors(t *testing.T) {
+	calls := 0
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("cannot destroy tank/data: dataset is busy")
+			}
+			return json.RawMessage(`true`), nil
+		},
+	}
+	c := NewRateLimitedClient(mock, RateLimitConfig{MaxRetries: 3})
+
+	result, err := c.Call(context.Background(), "pool.dataset.delete", "tank/data")
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if string(result) != "true" || calls != 2 {
+		t.Errorf("expected success on the second call, got %s after %d calls", result, calls)
+	}
+}
+
+func TestRateLimitedClient_DoesNotRetryPermanentErrors(t *testing.T) {
+	calls := 0
+	mock := &client.MockClient{
+		CallAndWaitFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			calls++
+			return nil, &client.TrueNASError{Code: "EINVAL", Message: "[EINVAL] name: invalid"}
+		},
+	}
+	c := NewRateLimitedClient(mock, RateLimitConfig{MaxRetries: 3})
+
+	if _, err := c.CallAndWait(context.Background(), "app.create", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Errorf("expected one call, got %d", calls)
+	}
+}
+
+func TestRateLimitedClient_RetriesDisabled(t *testing.T) {
+	calls := 0
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			calls++
+			return nil, errors.New("connection reset by peer")
+		},
+	}
+	c := NewRateLimitedClient(mock, RateLimitConfig{})
+
+	if _, err := c.Call(context.Background(), "pool.dataset.query", nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls != 1 {
+		t.Errorf("expected one call, got %d", calls)
+	}
+}
+
+func TestRateLimitedClient_Weights(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return json.RawMessage(`[]`), nil
+		},
+	}
+	// 600 calls per minute is one every 100ms
+	c := NewRateLimitedClient(mock, RateLimitConfig{
+		CallsPerMinute: 600,
+		Weights:        map[string]int{"pool.dataset.query": 3},
+	})
+	ctx := context.Background()
+
+	// The burst fits one heavy call, after which it takes 300ms to refill
+	start := time.Now()
+	for range 2 {
+		if _, err := c.Call(ctx, "pool.dataset.query", nil); err != nil {
+			t.Fatalf("Call: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("expected the second heavy call to wait for 3 tokens, took %v", elapsed)
+	}
+
+	start = time.Now()
+	if _, err := c.Call(ctx, "app.query", nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("expected a light call to wait for one token, took %v", elapsed)
+	}
+}
+
+func TestRateLimitedClient_WeightUsesCallsOfRateLimit(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return json.RawMessage(`[]`), nil
+		},
+	}
+	// 600 calls per minute is one every 100ms
+	c := NewRateLimitedClient(mock, RateLimitConfig{
+		CallsPerMinute: 600,
+		Weights:        map[string]int{"pool.dataset.query": 5},
+	})
+	ctx := context.Background()
+
+	if _, err := c.Call(ctx, "pool.dataset.query", nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if tokens := c.limiter.Tokens(); tokens > 0.5 {
+		t.Errorf("expected a weight-5 call to take the 5 calls of the burst, %.1f left", tokens)
+	}
+
+	// The light calls that follow share the limiter and wait for the 5
+	// calls to refill, one every 100ms
+	start := time.Now()
+	for range 5 {
+		if _, err := c.Call(ctx, "app.query", nil); err != nil {
+			t.Fatalf("Call: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected 5 light calls after a weight-5 call to take 500ms, took %v", elapsed)
+	}
+}
+
+func TestRateLimitedClient_Unlimited(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return json.RawMessage(`[]`), nil
+		},
+	}
+	c := NewRateLimitedClient(mock, RateLimitConfig{Weights: map[string]int{"pool.dataset.query": 5}})
+
+	start := time.Now()
+	for range 100 {
+		if _, err := c.Call(context.Background(), "pool.dataset.query", nil); err != nil {
+			t.Fatalf("Call: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected no rate limit, took %v", elapsed)
+	}
+}
+
+func TestRateLimitedClient_RetriesOnlyUnsentMutations(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		err    error
+		calls  int
+	}{
+		{"query after reset", "pool.dataset.query", io.EOF, 2},
+		{"create after reset", "pool.dataset.create", io.EOF, 1},
+		{"job after lost connection", "app.create", &client.JSONRPCError{Code: client.ErrCodeInternal, Message: "connection lost: EOF"}, 1},
+		{"create before connecting", "pool.dataset.create", errors.New("dial tcp 10.0.0.1:443: connect: connection refused"), 2},
+		{"job rejected as busy", "app.create", &client.TrueNASError{Code: "EBUSY", Message: "[EBUSY] resource is busy"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			fail := func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+				calls++
+				if calls == 1 {
+					return nil, tt.err
+				}
+				return json.RawMessage(`1`), nil
+			}
+			c := NewRateLimitedClient(&client.MockClient{CallFunc: fail, CallAndWaitFunc: fail}, RateLimitConfig{MaxRetries: 3})
+
+			_, err := c.CallAndWait(context.Background(), tt.method, nil)
+			if calls != tt.calls {
+				t.Errorf("expected %d calls, got %d", tt.calls, calls)
+			}
+			if tt.calls == 1 && !errors.Is(err, tt.err) {
+				t.Errorf("expected the call's error, got %v", err)
+			}
+		})
+	}
+}
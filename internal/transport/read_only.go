@@ -0,0 +1,169 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+// ErrReadOnly is returned by ReadOnlyClient for operations that could change
+// the state of the NAS.
+var ErrReadOnly = errors.New("the provider is configured with read_only = true")
+
+// queryMethodSuffixes are the final segments of middleware methods that only
+// read state, such as pool.dataset.query or virt.global.config.
+var queryMethodSuffixes = map[string]bool{
+	"query":           true,
+	"get_instance":    true,
+	"config":          true,
+	"status":          true,
+	"summary":         true,
+	"stats":           true,
+	"available_space": true,
+	"upgrade_summary": true,
+	"device_list":     true,
+}
+
+// queryMethods are read-only methods whose names do not follow the suffixes
+// in queryMethodSuffixes.
+var queryMethods = map[string]bool{
+	"core.ping":          true,
+	"core.get_jobs":      true,
+	"system.info":        true,
+	"system.version":     true,
+	"filesystem.stat":    true,
+	"filesystem.listdir": true,
+	"filesystem.get":     true,
+}
+
+// IsQueryMethod reports whether method only reads state. Unknown methods are
+// treated as mutations.
+func IsQueryMethod(method string) bool {
+	if queryMethods[method] {
+		return true
+	}
+	i := strings.LastIndexByte(method, '.')
+	return i >= 0 && queryMethodSuffixes[method[i+1:]]
+}
+
+// Compile-time check that ReadOnlyClient implements client.Client.
+var _ client.Client = (*ReadOnlyClient)(nil)
+
+// ReadOnlyClient wraps a client.Client and rejects every operation that is
+// not a query with ErrReadOnly, before it reaches the wire. Reads, file
+// reads and event subscriptions are passed through.
+type ReadOnlyClient struct {
+	client client.Client
+}
+
+// NewReadOnlyClient wraps c.
+func NewReadOnlyClient(c client.Client) *ReadOnlyClient {
+	return &ReadOnlyClient{client: c}
+}
+
+// reject returns the error for a refused operation.
+func reject(operation string) error {
+	return fmt.Errorf("refusing to %s: %w, which only allows queries", operation, ErrReadOnly)
+}
+
+// checkCall rejects method unless it is a query. core.download runs the
+// method it is given, so that method is checked instead.
+func checkCall(method string, params any) error {
+	if method == "core.download" {
+		if args, ok := params.([]any); ok && len(args) > 0 {
+			if inner, ok := args[0].(string); ok && IsQueryMethod(inner) {
+				return nil
+			}
+		}
+	} else if IsQueryMethod(method) {
+		return nil
+	}
+	return reject("call " + method)
+}
+
+// Connect delegates to the underlying client.
+func (c *ReadOnlyClient) Connect(ctx context.Context) error {
+	return c.client.Connect(ctx)
+}
+
+// Version delegates to the underlying client.
+func (c *ReadOnlyClient) Version() truenas.Version {
+	return c.client.Version()
+}
+
+// Call delegates query methods to the underlying client.
+func (c *ReadOnlyClient) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if err := checkCall(method, params); err != nil {
+		return nil, err
+	}
+	return c.client.Call(ctx, method, params)
+}
+
+// CallAndWait delegates query methods to the underlying client.
+func (c *ReadOnlyClient) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if err := checkCall(method, params); err != nil {
+		return nil, err
+	}
+	return c.client.CallAndWait(ctx, method, params)
+}
+
+// WriteFile is rejected.
+func (c *ReadOnlyClient) WriteFile(ctx context.Context, path string, params truenas.WriteFileParams) error {
+	return reject(fmt.Sprintf("write file %q", path))
+}
+
+// ReadFile delegates to the underlying client.
+func (c *ReadOnlyClient) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	return c.client.ReadFile(ctx, path)
+}
+
+// DeleteFile is rejected.
+func (c *ReadOnlyClient) DeleteFile(ctx context.Context, path string) error {
+	return reject(fmt.Sprintf("delete file %q", path))
+}
+
+// RemoveDir is rejected.
+func (c *ReadOnlyClient) RemoveDir(ctx context.Context, path string) error {
+	return reject(fmt.Sprintf("remove directory %q", path))
+}
+
+// RemoveAll is rejected.
+func (c *ReadOnlyClient) RemoveAll(ctx context.Context, path string) error {
+	return reject(fmt.Sprintf("remove directory %q", path))
+}
+
+// FileExists delegates to the underlying client.
+func (c *ReadOnlyClient) FileExists(ctx context.Context, path string) (bool, error) {
+	return c.client.FileExists(ctx, path)
+}
+
+// Chown is rejected.
+func (c *ReadOnlyClient) Chown(ctx context.Context, path string, uid, gid int) error {
+	return reject(fmt.Sprintf("change ownership of %q", path))
+}
+
+// ChmodRecursive is rejected.
+func (c *ReadOnlyClient) ChmodRecursive(ctx context.Context, path string, mode fs.FileMode) error {
+	return reject(fmt.Sprintf("chmod %q", path))
+}
+
+// MkdirAll is rejected.
+func (c *ReadOnlyClient) MkdirAll(ctx context.Context, path string, mode fs.FileMode) error {
+	return reject(fmt.Sprintf("create directory %q", path))
+}
+
+// Subscribe delegates to the underlying client; events only report state.
+func (c *ReadOnlyClient) Subscribe(ctx context.Context, collection string, params any) (*truenas.Subscription[json.RawMessage], error) {
+	return c.client.Subscribe(ctx, collection, params)
+}
+
+// Close delegates to the underlying client.
+func (c *ReadOnlyClient) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"testing"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+func TestIsQueryMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"pool.dataset.query", true},
+		{"cronjob.get_instance", true},
+		{"virt.global.config", true},
+		{"system.info", true},
+		{"filesystem.stat", true},
+		{"core.get_jobs", true},
+		{"pool.dataset.create", false},
+		{"virt.global.update", false},
+		{"filesystem.file_receive", false},
+		{"core.job_abort", false},
+		// Credentials are created on the NAS, so ephemeral resources are
+		// unavailable in read-only mode
+		{"api_key.create", false},
+		{"auth.generate_token", false},
+		{"query", false},
+	}
+	for _, tt := range tests {
+		if got := IsQueryMethod(tt.method); got != tt.want {
+			t.Errorf("IsQueryMethod(%q) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestReadOnlyClient_Call(t *testing.T) {
+	var called []string
+	inner := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			called = append(called, method)
+			return json.RawMessage(`[]`), nil
+		},
+		CallAndWaitFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			called = append(called, method)
+			return nil, nil
+		},
+	}
+	c := NewReadOnlyClient(inner)
+	ctx := context.Background()
+
+	if _, err := c.Call(ctx, "pool.dataset.query", nil); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if _, err := c.Call(ctx, "core.download", []any{"filesystem.get", []any{"/mnt/tank/f"}, "f"}); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+
+	_, err := c.Call(ctx, "pool.dataset.create", map[string]any{"name": "tank/x"})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly, got %v", err)
+	}
+	if _, err := c.CallAndWait(ctx, "pool.dataset.delete", "tank/x"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly from CallAndWait, got %v", err)
+	}
+	if _, err := c.Call(ctx, "core.download", []any{"config.save", []any{}, "config.db"}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected download of a mutating method to be rejected, got %v", err)
+	}
+
+	if len(called) != 2 || called[0] != "pool.dataset.query" || called[1] != "core.download" {
+		t.Errorf("unexpected calls reached the client: %v", called)
+	}
+}
+
+func TestReadOnlyClient_FileOperations(t *testing.T) {
+	reached := false
+	mutate := func() error { reached = true; return nil }
+	inner := &client.MockClient{
+		ReadFileFunc:       func(ctx context.Context, path string) ([]byte, error) { return []byte("x"), nil },
+		FileExistsFunc:     func(ctx context.Context, path string) (bool, error) { return true, nil },
+		WriteFileFunc:      func(context.Context, string, truenas.WriteFileParams) error { return mutate() },
+		DeleteFileFunc:     func(context.Context, string) error { return mutate() },
+		RemoveAllFunc:      func(context.Context, string) error { return mutate() },
+		MkdirAllFunc:       func(ctx context.Context, path string, mode fs.FileMode) error { return mutate() },
+		ChownFunc:          func(context.Context, string, int, int) error { return mutate() },
+		ChmodRecursiveFunc: func(ctx context.Context, path string, mode fs.FileMode) error { return mutate() },
+	}
+	c := NewReadOnlyClient(inner)
+	ctx := context.Background()
+
+	if data, err := c.ReadFile(ctx, "/mnt/tank/f"); err != nil || string(data) != "x" {
+		t.Fatalf("ReadFile: %q, %v", data, err)
+	}
+	if ok, err := c.FileExists(ctx, "/mnt/tank/f"); err != nil || !ok {
+		t.Fatalf("FileExists: %v, %v", ok, err)
+	}
+
+	for name, op := range map[string]func() error{
+		"WriteFile":      func() error { return c.WriteFile(ctx, "/mnt/tank/f", truenas.WriteFileParams{}) },
+		"DeleteFile":     func() error { return c.DeleteFile(ctx, "/mnt/tank/f") },
+		"RemoveDir":      func() error { return c.RemoveDir(ctx, "/mnt/tank/d") },
+		"RemoveAll":      func() error { return c.RemoveAll(ctx, "/mnt/tank/d") },
+		"MkdirAll":       func() error { return c.MkdirAll(ctx, "/mnt/tank/d", 0o755) },
+		"Chown":          func() error { return c.Chown(ctx, "/mnt/tank/d", 0, 0) },
+		"ChmodRecursive": func() error { return c.ChmodRecursive(ctx, "/mnt/tank/d", 0o755) },
+	} {
+		if err := op(); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+	if reached {
+		t.Error("a mutating file operation reached the client")
+	}
+}
@@ -0,0 +1,201 @@
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+// Compile-time check that Recorder implements client.Client.
+var _ client.Client = (*Recorder)(nil)
+
+// Recorder wraps a client.Client and appends every operation, its parameters
+// and its result or error to a cassette file. Secrets are redacted before
+// anything is written, and file contents, which may hold secrets of their
+// own, are recorded as their SHA-256 digest. Subscriptions pass through unrecorded; replayed
+// clients report them as unsupported so callers fall back to polling.
+type Recorder struct {
+	inner     client.Client
+	transport string
+	writer    *CassetteWriter
+}
+
+// NewRecorder creates a Recorder that appends to the cassette of writer.
+// transport labels the interactions, e.g. "ssh" or "websocket".
+func NewRecorder(inner client.Client, transport string, writer *CassetteWriter) *Recorder {
+	return &Recorder{
+		inner:     inner,
+		transport: transport,
+		writer:    writer,
+	}
+}
+
+// record writes an interaction. Recording failures must not change the
+// behaviour of the client being recorded, so they are ignored.
+func (r *Recorder) record(op, method string, params any, result json.RawMessage, err error) {
+	i := Interaction{
+		Transport: r.transport,
+		Operation: op,
+		Method:    method,
+		Params:    marshalParams(params),
+		Result:    redactResult(method, result),
+	}
+	if err != nil {
+		i.Error = err.Error()
+	}
+	_ = r.writer.append(i)
+}
+
+func marshalResult(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func (r *Recorder) Connect(ctx context.Context) error {
+	err := r.inner.Connect(ctx)
+	var result json.RawMessage
+	if err == nil {
+		result = marshalResult(r.inner.Version().Raw)
+	}
+	r.record(OpConnect, "", nil, result, err)
+	return err
+}
+
+func (r *Recorder) Version() truenas.Version {
+	return r.inner.Version()
+}
+
+func (r *Recorder) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := r.inner.Call(ctx, method, params)
+	recorded, _ := callParams(method, params)
+	r.record(OpCall, method, recorded, result, err)
+	return result, err
+}
+
+func (r *Recorder) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := r.inner.CallAndWait(ctx, method, params)
+	recorded, _ := callParams(method, params)
+	r.record(OpCallAndWait, method, recorded, result, err)
+	return result, err
+}
+
+// callParams returns the recorded form of the parameters of a call. The
+// base64 content truenas-go writes files with is replaced by its digest, and
+// returned decoded.
+func callParams(method string, params any) (any, []byte) {
+	if method != "filesystem.file_receive" {
+		return params, nil
+	}
+	args, ok := params.([]any)
+	if !ok || len(args) < 2 {
+		return params, nil
+	}
+	encoded, ok := args[1].(string)
+	if !ok {
+		return params, nil
+	}
+	content, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return params, nil
+	}
+	recorded := append([]any(nil), args...)
+	recorded[1] = map[string]string{"content_sha256": contentDigest(content)}
+	return recorded, content
+}
+
+func (r *Recorder) WriteFile(ctx context.Context, path string, params truenas.WriteFileParams) error {
+	err := r.inner.WriteFile(ctx, path, params)
+	r.record(OpWriteFile, path, writeFileParams(params), nil, err)
+	return err
+}
+
+// contentDigest is the recorded form of file contents.
+func contentDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileContent is the recorded result of a read_file operation.
+type fileContent struct {
+	SHA256 string `json:"sha256"`
+}
+
+// writeFileParams is the recorded form of truenas.WriteFileParams.
+func writeFileParams(params truenas.WriteFileParams) map[string]any {
+	return map[string]any{
+		"content_sha256": contentDigest(params.Content),
+		"mode":           fmt.Sprintf("%04o", params.Mode),
+		"uid":            params.UID,
+		"gid":            params.GID,
+	}
+}
+
+func (r *Recorder) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	data, err := r.inner.ReadFile(ctx, path)
+	var result json.RawMessage
+	if err == nil {
+		result = marshalResult(fileContent{SHA256: contentDigest(data)})
+	}
+	r.record(OpReadFile, path, nil, result, err)
+	return data, err
+}
+
+func (r *Recorder) DeleteFile(ctx context.Context, path string) error {
+	err := r.inner.DeleteFile(ctx, path)
+	r.record(OpDeleteFile, path, nil, nil, err)
+	return err
+}
+
+func (r *Recorder) RemoveDir(ctx context.Context, path string) error {
+	err := r.inner.RemoveDir(ctx, path)
+	r.record(OpRemoveDir, path, nil, nil, err)
+	return err
+}
+
+func (r *Recorder) RemoveAll(ctx context.Context, path string) error {
+	err := r.inner.RemoveAll(ctx, path)
+	r.record(OpRemoveAll, path, nil, nil, err)
+	return err
+}
+
+func (r *Recorder) FileExists(ctx context.Context, path string) (bool, error) {
+	exists, err := r.inner.FileExists(ctx, path)
+	r.record(OpFileExists, path, nil, marshalResult(exists), err)
+	return exists, err
+}
+
+func (r *Recorder) Chown(ctx context.Context, path string, uid, gid int) error {
+	err := r.inner.Chown(ctx, path, uid, gid)
+	r.record(OpChown, path, map[string]int{"uid": uid, "gid": gid}, nil, err)
+	return err
+}
+
+func (r *Recorder) ChmodRecursive(ctx context.Context, path string, mode fs.FileMode) error {
+	err := r.inner.ChmodRecursive(ctx, path, mode)
+	r.record(OpChmodRecursive, path, fmt.Sprintf("%04o", mode), nil, err)
+	return err
+}
+
+func (r *Recorder) MkdirAll(ctx context.Context, path string, mode fs.FileMode) error {
+	err := r.inner.MkdirAll(ctx, path, mode)
+	r.record(OpMkdirAll, path, fmt.Sprintf("%04o", mode), nil, err)
+	return err
+}
+
+func (r *Recorder) Subscribe(ctx context.Context, collection string, params any) (*truenas.Subscription[json.RawMessage], error) {
+	return r.inner.Subscribe(ctx, collection, params)
+}
+
+func (r *Recorder) Close() error {
+	return r.inner.Close()
+}
@@ -0,0 +1,192 @@
+package transport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+func TestRecorder_RecordsCallsAndRedactsSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	inner := &client.MockClient{
+		VersionVal: truenas.Version{Major: 25, Minor: 4, Raw: "TrueNAS-25.04.0"},
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return json.RawMessage(`{"id": 7, "attributes": {"secret_access_key": "from-server"}}`), nil
+		},
+	}
+
+	r := NewRecorder(inner, "ssh", NewCassetteWriter(path))
+	ctx := context.Background()
+
+	if err := r.Connect(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := r.Call(ctx, "cloudsync.credentials.create", map[string]any{
+		"name":       "s3",
+		"provider":   map[string]any{"type": "S3", "secret_access_key": "from-config"},
+		"unrelated":  1,
+		"zzz_ignore": nil,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The caller still sees the real result
+	if !strings.Contains(string(result), "from-server") {
+		t.Error("expected recorder to return the unredacted result to the caller")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "from-config") || strings.Contains(string(data), "from-server") {
+		t.Errorf("expected secrets to be redacted in cassette, got %s", data)
+	}
+
+	interactions, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(interactions) != 2 {
+		t.Fatalf("expected 2 interactions, got %d", len(interactions))
+	}
+	if interactions[0].Operation != OpConnect || string(interactions[0].Result) != `"TrueNAS-25.04.0"` {
+		t.Errorf("unexpected connect interaction %+v", interactions[0])
+	}
+	if interactions[1].Method != "cloudsync.credentials.create" || interactions[1].Transport != "ssh" {
+		t.Errorf("unexpected call interaction %+v", interactions[1])
+	}
+}
+
+func TestRecorder_RedactsTokenResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	inner := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return json.RawMessage(`"tok-from-server"`), nil
+		},
+	}
+
+	r := NewRecorder(inner, "websocket", NewCassetteWriter(path))
+	result, err := r.Call(context.Background(), "auth.generate_token", []any{300, map[string]any{}, false})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(result) != `"tok-from-server"` {
+		t.Errorf("expected recorder to return the token to the caller, got %s", result)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "tok-from-server") {
+		t.Errorf("expected the token to be redacted in cassette, got %s", data)
+	}
+
+	interactions, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(interactions) != 1 || string(interactions[0].Result) != `"REDACTED"` {
+		t.Fatalf("expected the recorded result to be redacted, got %+v", interactions)
+	}
+}
+
+func TestRecorder_RecordsErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	inner := &client.MockClient{
+		DeleteFileFunc: func(ctx context.Context, path string) error {
+			return errors.New("permission denied")
+		},
+	}
+
+	r := NewRecorder(inner, "ssh", NewCassetteWriter(path))
+	if err := r.DeleteFile(context.Background(), "/mnt/tank/file"); err == nil {
+		t.Fatal("expected error to pass through")
+	}
+
+	interactions, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(interactions) != 1 || interactions[0].Error != "permission denied" {
+		t.Fatalf("expected recorded error, got %+v", interactions)
+	}
+	if interactions[0].Method != "/mnt/tank/file" {
+		t.Errorf("expected path to be recorded, got %q", interactions[0].Method)
+	}
+}
+
+func TestRecorder_RecordsFileContentsAsDigests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	inner := &client.MockClient{
+		ReadFileFunc: func(ctx context.Context, path string) ([]byte, error) {
+			return []byte("password=from-server"), nil
+		},
+	}
+
+	r := NewRecorder(inner, "ssh", NewCassetteWriter(path))
+	ctx := context.Background()
+	if err := r.WriteFile(ctx, "/mnt/tank/app.env", truenas.WriteFileParams{Content: []byte("password=from-config"), Mode: 0o600}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.ReadFile(ctx, "/mnt/tank/app.env"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "from-config") || strings.Contains(string(data), "from-server") {
+		t.Errorf("expected file contents to be left out of the cassette, got %s", data)
+	}
+	if !strings.Contains(string(data), contentDigest([]byte("password=from-config"))) {
+		t.Errorf("expected the digest of the written contents in the cassette, got %s", data)
+	}
+}
+
+func TestRecorder_RecordsFileReceiveContentAsDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	inner := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return json.RawMessage(`true`), nil
+		},
+	}
+
+	ctx := context.Background()
+	params := truenas.WriteFileParams{Content: []byte("password=from-config"), Mode: 0o600}
+	if err := truenas.NewFilesystemService(NewRecorder(inner, "websocket", NewCassetteWriter(path)), truenas.Version{}).WriteFile(ctx, "/mnt/tank/app.env", params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), base64.StdEncoding.EncodeToString(params.Content)) {
+		t.Errorf("expected file contents to be left out of the cassette, got %s", data)
+	}
+
+	// Replaying the write makes its contents readable
+	state, err := LoadReplayState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := NewReplayer("websocket", state)
+	if err := truenas.NewFilesystemService(replayer, truenas.Version{}).WriteFile(ctx, "/mnt/tank/app.env", params); err != nil {
+		t.Fatalf("unexpected error replaying: %v", err)
+	}
+	if content, ok := replayer.state.content(contentDigest(params.Content)); !ok || string(content) != string(params.Content) {
+		t.Errorf("expected the replayed write to store its contents, got %q", content)
+	}
+}
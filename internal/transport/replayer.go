@@ -0,0 +1,241 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+// ErrNoInteraction is returned when a replayed client receives an operation
+// that is not in its cassette.
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// ReplayState holds the interactions of one cassette and the position of
+// every request key. Replayers sharing a state continue where the previous
+// one stopped, so a provider configured repeatedly in one process (once per
+// Terraform command) replays the session in order.
+//
+// Cassettes hold only the digest of file contents, so contents holds the
+// contents replayed writes were given, by digest, for reads to return.
+type ReplayState struct {
+	mu       sync.Mutex
+	queues   map[string][]Interaction
+	cursors  map[string]int
+	contents map[string][]byte
+}
+
+// LoadReplayState reads the cassette at path and returns a ReplayState
+// positioned at its first interaction.
+func LoadReplayState(path string) (*ReplayState, error) {
+	interactions, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ReplayState{
+		queues:   make(map[string][]Interaction),
+		cursors:  make(map[string]int),
+		contents: make(map[string][]byte),
+	}
+	for _, i := range interactions {
+		k := i.key()
+		s.queues[k] = append(s.queues[k], i)
+	}
+	return s, nil
+}
+
+// next returns the next interaction recorded for req. Identical requests
+// are answered in recorded order; once exhausted, the last response repeats,
+// which keeps extra refreshes deterministic.
+func (s *ReplayState) next(req Interaction) (Interaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := req.key()
+	queue := s.queues[k]
+	if len(queue) == 0 {
+		return Interaction{}, fmt.Errorf("%w for %s %s %s (params %s)",
+			ErrNoInteraction, req.Transport, req.Operation, req.Method, string(req.Params))
+	}
+
+	pos := s.cursors[k]
+	if pos >= len(queue) {
+		pos = len(queue) - 1
+	} else {
+		s.cursors[k] = pos + 1
+	}
+	return queue[pos], nil
+}
+
+// storeContent remembers content written during replay.
+func (s *ReplayState) storeContent(content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contents[contentDigest(content)] = content
+}
+
+// content returns the content written during replay with digest.
+func (s *ReplayState) content(digest string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.contents[digest]
+	return content, ok
+}
+
+// Compile-time check that Replayer implements client.Client.
+var _ client.Client = (*Replayer)(nil)
+
+// Replayer implements client.Client from a cassette written by Recorder.
+// Requests are matched on transport, operation, method and redacted
+// parameters, with file contents matched by digest, so replay makes no
+// network connections. A read returns contents only when an earlier replayed
+// write supplied contents with the recorded digest.
+type Replayer struct {
+	transport string
+	state     *ReplayState
+	version   truenas.Version
+}
+
+// NewReplayer creates a Replayer serving the interactions recorded for
+// transport in the cassette of state.
+func NewReplayer(transport string, state *ReplayState) *Replayer {
+	return &Replayer{transport: transport, state: state}
+}
+
+func (r *Replayer) replay(op, method string, params any) (json.RawMessage, error) {
+	i, err := r.state.next(Interaction{
+		Transport: r.transport,
+		Operation: op,
+		Method:    method,
+		Params:    marshalParams(params),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if i.Error != "" {
+		return nil, errors.New(i.Error)
+	}
+	return i.Result, nil
+}
+
+func (r *Replayer) Connect(ctx context.Context) error {
+	result, err := r.replay(OpConnect, "", nil)
+	if err != nil {
+		return err
+	}
+
+	var raw string
+	if err := json.Unmarshal(result, &raw); err != nil {
+		return fmt.Errorf("cassette connect result is not a version string: %w", err)
+	}
+	version, err := truenas.ParseVersion(raw)
+	if err != nil {
+		return err
+	}
+	r.version = version
+	return nil
+}
+
+func (r *Replayer) Version() truenas.Version {
+	return r.version
+}
+
+func (r *Replayer) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return r.replayCall(OpCall, method, params)
+}
+
+func (r *Replayer) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	return r.replayCall(OpCallAndWait, method, params)
+}
+
+// replayCall replays a call, remembering the contents of files it writes.
+func (r *Replayer) replayCall(op, method string, params any) (json.RawMessage, error) {
+	recorded, content := callParams(method, params)
+	result, err := r.replay(op, method, recorded)
+	if err == nil && content != nil {
+		r.state.storeContent(content)
+	}
+	return result, err
+}
+
+func (r *Replayer) WriteFile(ctx context.Context, path string, params truenas.WriteFileParams) error {
+	if _, err := r.replay(OpWriteFile, path, writeFileParams(params)); err != nil {
+		return err
+	}
+	r.state.storeContent(params.Content)
+	return nil
+}
+
+func (r *Replayer) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	result, err := r.replay(OpReadFile, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	var recorded fileContent
+	if err := json.Unmarshal(result, &recorded); err != nil {
+		return nil, fmt.Errorf("cassette read_file result for %q: %w", path, err)
+	}
+	data, ok := r.state.content(recorded.SHA256)
+	if !ok {
+		return nil, fmt.Errorf("%w for the content of %q: the cassette only holds its digest, and no replayed write supplied it",
+			ErrNoInteraction, path)
+	}
+	return data, nil
+}
+
+func (r *Replayer) DeleteFile(ctx context.Context, path string) error {
+	_, err := r.replay(OpDeleteFile, path, nil)
+	return err
+}
+
+func (r *Replayer) RemoveDir(ctx context.Context, path string) error {
+	_, err := r.replay(OpRemoveDir, path, nil)
+	return err
+}
+
+func (r *Replayer) RemoveAll(ctx context.Context, path string) error {
+	_, err := r.replay(OpRemoveAll, path, nil)
+	return err
+}
+
+func (r *Replayer) FileExists(ctx context.Context, path string) (bool, error) {
+	result, err := r.replay(OpFileExists, path, nil)
+	if err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := json.Unmarshal(result, &exists); err != nil {
+		return false, fmt.Errorf("cassette file_exists result for %q: %w", path, err)
+	}
+	return exists, nil
+}
+
+func (r *Replayer) Chown(ctx context.Context, path string, uid, gid int) error {
+	_, err := r.replay(OpChown, path, map[string]int{"uid": uid, "gid": gid})
+	return err
+}
+
+func (r *Replayer) ChmodRecursive(ctx context.Context, path string, mode fs.FileMode) error {
+	_, err := r.replay(OpChmodRecursive, path, fmt.Sprintf("%04o", mode))
+	return err
+}
+
+func (r *Replayer) MkdirAll(ctx context.Context, path string, mode fs.FileMode) error {
+	_, err := r.replay(OpMkdirAll, path, fmt.Sprintf("%04o", mode))
+	return err
+}
+
+// Subscribe is not replayable; callers fall back to polling as they do over SSH.
+func (r *Replayer) Subscribe(ctx context.Context, collection string, params any) (*truenas.Subscription[json.RawMessage], error) {
+	return nil, client.ErrUnsupportedOperation
+}
+
+func (r *Replayer) Close() error {
+	return nil
+}
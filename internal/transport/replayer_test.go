@@ -0,0 +1,248 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+// writeCassette writes lines to a cassette and loads it for replay.
+func writeCassette(t *testing.T, lines ...string) *ReplayState {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	content := ""
+	for _, l := range lines {
+		content += l + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return loadReplayState(t, path)
+}
+
+func loadReplayState(t *testing.T, path string) *ReplayState {
+	t.Helper()
+	state, err := LoadReplayState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestReplayer_RoundTripThroughTypedService(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	ctx := context.Background()
+
+	// Record against a mock standing in for a real server
+	live := &client.MockClient{
+		VersionVal: truenas.Version{Major: 25, Minor: 4, Raw: "TrueNAS-25.04.0"},
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			switch method {
+			case "cronjob.create":
+				return json.RawMessage(`{"id": 5}`), nil
+			case "cronjob.get_instance":
+				return json.RawMessage(`{"id": 5, "user": "root", "command": "echo hi", "enabled": true,
+					"schedule": {"minute": "0", "hour": "1", "dom": "*", "month": "*", "dow": "*"}}`), nil
+			case "cronjob.query":
+				return json.RawMessage(`[{"id": 5, "user": "root", "command": "echo hi", "enabled": true,
+					"schedule": {"minute": "0", "hour": "1", "dom": "*", "month": "*", "dow": "*"}}]`), nil
+			}
+			return nil, errors.New("unexpected method " + method)
+		},
+	}
+	recorder := NewRecorder(live, "ssh", NewCassetteWriter(path))
+	if err := recorder.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := truenas.CreateCronJobOpts{
+		User:    "root",
+		Command: "echo hi",
+		Enabled: true,
+		Schedule: truenas.Schedule{
+			Minute: "0", Hour: "1", Dom: "*", Month: "*", Dow: "*",
+		},
+	}
+	recorded, err := truenas.NewCronService(recorder, recorder.Version()).Create(ctx, opts)
+	if err != nil {
+		t.Fatalf("unexpected error recording: %v", err)
+	}
+
+	// Replay without any server
+	state := loadReplayState(t, path)
+	replayer := NewReplayer("ssh", state)
+	if err := replayer.Connect(ctx); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	if !replayer.Version().AtLeast(25, 4) {
+		t.Errorf("expected replayed version 25.04, got %v", replayer.Version())
+	}
+
+	replayed, err := truenas.NewCronService(replayer, replayer.Version()).Create(ctx, opts)
+	if err != nil {
+		t.Fatalf("unexpected error replaying: %v", err)
+	}
+	if replayed.ID != recorded.ID || replayed.Command != recorded.Command {
+		t.Errorf("expected replayed job %+v to equal recorded %+v", replayed, recorded)
+	}
+}
+
+func TestReplayer_IdenticalRequestsReplayInOrderThenRepeat(t *testing.T) {
+	state := writeCassette(t,
+		`{"transport":"ssh","operation":"call","method":"cronjob.query","params":[[["id","=",1]]],"result":[{"id":1,"enabled":true}]}`,
+		`{"transport":"ssh","operation":"call","method":"cronjob.query","params":[[["id","=",1]]],"result":[{"id":1,"enabled":false}]}`,
+	)
+
+	r := NewReplayer("ssh", state)
+	params := []any{[]any{[]any{"id", "=", 1}}}
+
+	want := []string{`[{"id":1,"enabled":true}]`, `[{"id":1,"enabled":false}]`, `[{"id":1,"enabled":false}]`}
+	for i, w := range want {
+		got, err := r.Call(context.Background(), "cronjob.query", params)
+		if err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+		if string(got) != w {
+			t.Errorf("call %d: expected %s, got %s", i, w, got)
+		}
+	}
+}
+
+func TestReplayer_PositionSharedAcrossInstances(t *testing.T) {
+	state := writeCassette(t,
+		`{"transport":"ssh","operation":"file_exists","method":"/mnt/tank/f","result":true}`,
+		`{"transport":"ssh","operation":"file_exists","method":"/mnt/tank/f","result":false}`,
+	)
+
+	first := NewReplayer("ssh", state)
+	if exists, _ := first.FileExists(context.Background(), "/mnt/tank/f"); !exists {
+		t.Error("expected first answer to be true")
+	}
+
+	// A second provider configuration continues the sequence
+	second := NewReplayer("ssh", state)
+	if exists, _ := second.FileExists(context.Background(), "/mnt/tank/f"); exists {
+		t.Error("expected second answer to be false")
+	}
+}
+
+func TestReplayer_ReadsContentsOfReplayedWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	ctx := context.Background()
+
+	var stored []byte
+	live := &client.MockClient{
+		WriteFileFunc: func(ctx context.Context, path string, params truenas.WriteFileParams) error {
+			stored = params.Content
+			return nil
+		},
+		ReadFileFunc: func(ctx context.Context, path string) ([]byte, error) {
+			return stored, nil
+		},
+	}
+	recorder := NewRecorder(live, "ssh", NewCassetteWriter(path))
+	params := truenas.WriteFileParams{Content: []byte("hello"), Mode: 0o644}
+	if err := recorder.WriteFile(ctx, "/mnt/tank/f", params); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recorder.ReadFile(ctx, "/mnt/tank/f"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reads before the write have no contents to return
+	state := loadReplayState(t, path)
+	first := NewReplayer("ssh", state)
+	if _, err := first.ReadFile(ctx, "/mnt/tank/f"); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction, got %v", err)
+	}
+
+	if err := first.WriteFile(ctx, "/mnt/tank/f", truenas.WriteFileParams{Content: []byte("other"), Mode: 0o644}); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected a write of other contents not to match, got %v", err)
+	}
+	if err := first.WriteFile(ctx, "/mnt/tank/f", params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Contents written by one provider configuration are read by the next
+	second := NewReplayer("ssh", state)
+	data, err := second.ReadFile(ctx, "/mnt/tank/f")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("expected replayed contents %q, got %q", "hello", data)
+	}
+}
+
+func TestReplayer_TransportsAreSeparate(t *testing.T) {
+	state := writeCassette(t,
+		`{"transport":"ssh","operation":"connect","result":"TrueNAS-25.04.0"}`,
+		`{"transport":"websocket","operation":"connect","result":"TrueNAS-25.10.1"}`,
+	)
+
+	ws := NewReplayer("websocket", state)
+	if err := ws.Connect(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ws.Version().Minor != 10 {
+		t.Errorf("expected websocket version 25.10, got %v", ws.Version())
+	}
+}
+
+func TestReplayer_RecordedError(t *testing.T) {
+	state := writeCassette(t,
+		`{"transport":"ssh","operation":"remove_dir","method":"/mnt/tank/d","error":"directory not empty"}`,
+	)
+
+	r := NewReplayer("ssh", state)
+	err := r.RemoveDir(context.Background(), "/mnt/tank/d")
+	if err == nil || err.Error() != "directory not empty" {
+		t.Errorf("expected recorded error, got %v", err)
+	}
+}
+
+func TestReplayer_UnknownInteraction(t *testing.T) {
+	state := writeCassette(t,
+		`{"transport":"ssh","operation":"mkdir_all","method":"/mnt/tank/a","params":"0755"}`,
+	)
+
+	r := NewReplayer("ssh", state)
+	if err := r.MkdirAll(context.Background(), "/mnt/tank/a", fs.FileMode(0o755)); err != nil {
+		t.Errorf("unexpected error for recorded mkdir: %v", err)
+	}
+	err := r.MkdirAll(context.Background(), "/mnt/tank/a", fs.FileMode(0o700))
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction for different mode, got %v", err)
+	}
+}
+
+func TestReplayer_RedactedParamsMatchLiveSecrets(t *testing.T) {
+	state := writeCassette(t,
+		`{"transport":"websocket","operation":"call","method":"app.registry.create","params":[{"password":"REDACTED","uri":"ghcr.io"}],"result":{"id":3}}`,
+	)
+
+	r := NewReplayer("websocket", state)
+	got, err := r.Call(context.Background(), "app.registry.create", []any{map[string]any{"uri": "ghcr.io", "password": "live-secret"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != `{"id":3}` {
+		t.Errorf("unexpected result %s", got)
+	}
+}
+
+func TestReplayer_SubscribeUnsupported(t *testing.T) {
+	state := writeCassette(t, `{"transport":"ssh","operation":"connect","result":"TrueNAS-25.04.0"}`)
+
+	r := NewReplayer("ssh", state)
+	if _, err := r.Subscribe(context.Background(), "app.query", nil); !errors.Is(err, client.ErrUnsupportedOperation) {
+		t.Errorf("expected ErrUnsupportedOperation, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/deevus/truenas-go/client"
+	"github.com/gorilla/websocket"
+)
+
+// Compile-time check that RetryClassifier implements client.RetryClassifier.
+var _ client.RetryClassifier = (*RetryClassifier)(nil)
+
+// busyPatterns are error substrings of middleware calls and jobs that fail
+// because the NAS is briefly busy with another operation.
+var busyPatterns = []string{
+	"dataset is busy",
+	"job already running",
+}
+
+// RetryClassifier classifies the errors of both transports. On top of the
+// connection failures recognised by truenas-go it retries socket resets and
+// calls that failed because a dataset or job was busy. Errors of the caller's
+// context, of a closed client and of calls that may have changed the NAS
+// before failing are never retried.
+type RetryClassifier struct{}
+
+// IsRetriable reports whether err is a transient failure.
+func (c *RetryClassifier) IsRetriable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrClientClosed) {
+		return false
+	}
+	var sentErr *sentCallError
+	if errors.As(err, &sentErr) {
+		return false
+	}
+
+	if (&client.WebSocketRetryClassifier{}).IsRetriable(err) || (&client.SSHRetryClassifier{}).IsRetriable(err) {
+		return true
+	}
+	if isSocketReset(err) {
+		return true
+	}
+
+	return isBusy(err)
+}
+
+// isBusy reports whether err means the NAS rejected a call because a dataset
+// or job was busy.
+func isBusy(err error) bool {
+	var tnErr *client.TrueNASError
+	if errors.As(err, &tnErr) && tnErr.Code == "EBUSY" {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range busyPatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSocketReset reports whether err means the connection was reset or closed
+// under a call.
+func isSocketReset(err error) bool {
+	var closeErr *websocket.CloseError
+	return errors.As(err, &closeErr) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, websocket.ErrCloseSent)
+}
+
+// notSentPatterns are error substrings of connections that failed before a
+// call could be sent.
+var notSentPatterns = []string{
+	"connection refused",
+	"no route to host",
+	"network is unreachable",
+	"failed connection handshake",
+}
+
+// notSent reports whether err proves that the NAS did not run the call: the
+// connection could not be made, or the middleware rejected the call before
+// running it because it was busy, over its concurrency limit or needed the
+// session to log in again.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH) {
+		return true
+	}
+
+	var rpcErr *client.JSONRPCError
+	if errors.As(err, &rpcErr) {
+		if rpcErr.Code == client.ErrCodeTooManyConcurrent {
+			return true
+		}
+		if rpcErr.Data != nil && strings.Contains(rpcErr.Data.Reason, "ENOTAUTHENTICATED") {
+			return true
+		}
+	}
+	if isBusy(err) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range notSentPatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// sentCallError is the error of a call that changes state and failed after
+// it may have reached the NAS, for example when the connection was reset
+// while waiting for the result. Running the call again could create an
+// object or start a job twice, so it is never retried.
+type sentCallError struct {
+	err error
+}
+
+func (e *sentCallError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sentCallError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/deevus/truenas-go/client"
+	"github.com/gorilla/websocket"
+)
+
+func TestRetryClassifier_IsRetriable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retriable bool
+	}{
+		{"nil", nil, false},
+		{"connection lost", &client.JSONRPCError{Code: client.ErrCodeInternal, Message: "connection lost: EOF"}, true},
+		{"connection refused", errors.New("dial tcp 10.0.0.1:443: connect: connection refused"), true},
+		{"socket reset", &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.ECONNRESET)}, true},
+		{"broken pipe", fmt.Errorf("write: %w", syscall.EPIPE), true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"websocket closed", &websocket.CloseError{Code: websocket.CloseGoingAway}, true},
+		{"EBUSY job", &client.TrueNASError{Code: "EBUSY", Message: "[EBUSY] resource is busy"}, true},
+		{"dataset is busy", errors.New("cannot destroy tank/data: dataset is busy"), true},
+		{"job already running", errors.New("[EFAULT] Job already running"), true},
+		{"validation error", &client.TrueNASError{Code: "EINVAL", Message: "[EINVAL] name: invalid"}, false},
+		{"not found", &client.TrueNASError{Code: "ENOENT", Message: "[ENOENT] does not exist"}, false},
+		{"cancelled", fmt.Errorf("job 3 was cancelled and was aborted: %w", context.Canceled), false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"client closed", ErrClientClosed, false},
+	}
+	classifier := &RetryClassifier{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifier.IsRetriable(tt.err); got != tt.retriable {
+				t.Errorf("IsRetriable(%v) = %v, want %v", tt.err, got, tt.retriable)
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"al.essio.dev/pkg/shellescape"
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+)
+
+// Compile-time check that SSHClient implements client.Client.
+var _ client.Client = (*SSHClient)(nil)
+
+// SSHClient runs midclt and the shell commands of file operations over SSH,
+// as the SSH client of truenas-go does, with the connection options of
+// SSHConfig: an ssh-agent, a bastion host, several host key fingerprints or
+// a known_hosts file, and sudo for users other than root. Every command runs
+// in a session of its own, which is closed when the context of its call
+// ends.
+type SSHClient struct {
+	config   SSHConfig
+	dialer   *sshDialer
+	sessions chan struct{}
+
+	mu        sync.Mutex
+	conn      *sshConn
+	closed    bool
+	connected bool
+	version   truenas.Version
+}
+
+// NewSSHClient creates an SSHClient. Keys and the known_hosts file are read
+// immediately, but no connection to the NAS is made until Connect or the
+// first call.
+func NewSSHClient(cfg SSHConfig) (*SSHClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	dialer, err := newSSHDialer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &SSHClient{
+		config:   cfg,
+		dialer:   dialer,
+		sessions: make(chan struct{}, cfg.MaxSessions),
+	}, nil
+}
+
+// ensureConn returns the connection to the NAS, dialing it if needed.
+func (c *SSHClient) ensureConn() (*sshConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	if c.conn != nil {
+		return c.conn, nil
+	}
+	conn, err := c.dialer.dial()
+	if err != nil {
+		return nil, client.NewConnectionError(c.config.Host, c.config.Port, err)
+	}
+	c.conn = conn
+	return conn, nil
+}
+
+// dropConn discards conn after it failed, so that the next session dials
+// again.
+func (c *SSHClient) dropConn(conn *sshConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == conn {
+		_ = conn.Close()
+		c.conn = nil
+	}
+}
+
+// run runs cmd in a new session on the NAS and returns its standard output.
+// The session is closed when ctx ends. A connection that was lost while
+// idle is dialed again, since the command cannot have run on it.
+func (c *SSHClient) run(ctx context.Context, cmd string) ([]byte, error) {
+	select {
+	case c.sessions <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-c.sessions }()
+
+	conn, err := c.ensureConn()
+	if err != nil {
+		return nil, err
+	}
+	session, err := conn.client.NewSession()
+	if err != nil {
+		c.dropConn(conn)
+		if conn, err = c.ensureConn(); err != nil {
+			return nil, err
+		}
+		if session, err = conn.client.NewSession(); err != nil {
+			c.dropConn(conn)
+			return nil, client.NewConnectionError(c.config.Host, c.config.Port, err)
+		}
+	}
+	defer func() { _ = session.Close() }()
+	stop := context.AfterFunc(ctx, func() { _ = session.Close() })
+	defer stop()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout, session.Stderr = &stdout, &stderr
+	if c.config.SudoPassword != "" {
+		session.Stdin = strings.NewReader(c.config.SudoPassword + "\n")
+	}
+	if err := session.Run(c.elevate(cmd)); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// Include stderr, which holds the middleware's error message
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// elevate prefixes cmd with sudo when configured. Without a password sudo
+// runs non-interactively, so that a missing NOPASSWD rule fails instead of
+// waiting for a password. With one, sudo reads it from stdin without
+// printing a prompt.
+func (c *SSHClient) elevate(cmd string) string {
+	switch {
+	case !c.config.Sudo:
+		return cmd
+	case c.config.SudoPassword == "":
+		return "sudo -n " + cmd
+	default:
+		return "sudo -S -p '' " + cmd
+	}
+}
+
+// runShell runs a shell command whose arguments are quoted for the shell.
+func (c *SSHClient) runShell(ctx context.Context, args ...string) ([]byte, error) {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellescape.Quote(arg)
+	}
+	return c.run(ctx, strings.Join(quoted, " "))
+}
+
+// Connect connects to the NAS and detects the TrueNAS version.
+func (c *SSHClient) Connect(ctx context.Context) error {
+	result, err := c.Call(ctx, "system.version", nil)
+	if err != nil {
+		return fmt.Errorf("failed to detect TrueNAS version: %w", err)
+	}
+
+	// midclt prints string results without JSON quotes
+	version, err := truenas.ParseVersion(strings.TrimSpace(string(result)))
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version = version
+	c.connected = true
+	return nil
+}
+
+// Version returns the TrueNAS version detected by Connect. As with
+// truenas-go, calling it before Connect panics.
+func (c *SSHClient) Version() truenas.Version {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.connected {
+		panic("client.Version() called before Connect()")
+	}
+	return c.version
+}
+
+// Close closes the connection to the NAS. Later calls fail with
+// ErrClientClosed.
+func (c *SSHClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// Call runs a middleware method with midclt and returns its output.
+func (c *SSHClient) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	c.config.Logger.Debug(ctx, "API request", map[string]any{"method": method})
+	output, err := c.run(ctx, client.BuildCommand(method, params))
+	c.config.Logger.Debug(ctx, "API response", map[string]any{
+		"method": method,
+		"output": string(output),
+		"error":  err,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(output), nil
+}
+
+// CallAndWait runs a job method and polls core.get_jobs until the job
+// finishes, logging its progress, and aborts it if ctx ends first. midclt's
+// -j flag is not used, because a job midclt waits for cannot be aborted or
+// report its progress. As with truenas-go, no result is returned: callers
+// query the state afterwards.
+func (c *SSHClient) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := c.Call(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobID int64
+	if err := json.Unmarshal(result, &jobID); err != nil {
+		// Not a job ID: the method completed synchronously
+		return result, nil
+	}
+
+	job, err := waitJob(ctx, c.Call, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.State != client.JobStateSuccess {
+		return nil, jobError(ctx, job, c.ReadFile)
+	}
+	return nil, nil
+}
+
+// WriteFile writes a file using filesystem.file_receive, which runs with the
+// privileges of the middleware.
+func (c *SSHClient) WriteFile(ctx context.Context, path string, params truenas.WriteFileParams) error {
+	// -1 leaves the owner unchanged
+	uid, gid := -1, -1
+	if params.UID != nil {
+		uid = *params.UID
+	}
+	if params.GID != nil {
+		gid = *params.GID
+	}
+	args := []any{
+		path,
+		base64.StdEncoding.EncodeToString(params.Content),
+		map[string]any{
+			"mode": int(params.Mode),
+			"uid":  uid,
+			"gid":  gid,
+		},
+	}
+	if _, err := c.Call(ctx, "filesystem.file_receive", args); err != nil {
+		return fmt.Errorf("failed to write file %q: %w", path, err)
+	}
+	return nil
+}
+
+// ReadFile reads a file with cat.
+func (c *SSHClient) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	data, err := c.runShell(ctx, "cat", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q: %w", path, err)
+	}
+	return data, nil
+}
+
+// DeleteFile removes a file with rm.
+func (c *SSHClient) DeleteFile(ctx context.Context, path string) error {
+	if _, err := c.runShell(ctx, "rm", path); err != nil {
+		return fmt.Errorf("failed to delete file %q: %w", path, err)
+	}
+	return nil
+}
+
+// RemoveDir removes an empty directory with rmdir.
+func (c *SSHClient) RemoveDir(ctx context.Context, path string) error {
+	if _, err := c.runShell(ctx, "rmdir", path); err != nil {
+		return fmt.Errorf("failed to remove directory %q: %w", path, err)
+	}
+	return nil
+}
+
+// RemoveAll recursively removes a directory with rm -rf.
+func (c *SSHClient) RemoveAll(ctx context.Context, path string) error {
+	if _, err := c.runShell(ctx, "rm", "-rf", path); err != nil {
+		return fmt.Errorf("failed to remove directory %q: %w", path, err)
+	}
+	return nil
+}
+
+// FileExists checks if a file exists using filesystem.stat.
+func (c *SSHClient) FileExists(ctx context.Context, path string) (bool, error) {
+	if _, err := c.Call(ctx, "filesystem.stat", path); err != nil {
+		if client.ParseTrueNASError(err.Error()).Code == "ENOENT" {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat file %q: %w", path, err)
+	}
+	return true, nil
+}
+
+// MkdirAll creates a directory using filesystem.mkdir.
+func (c *SSHClient) MkdirAll(ctx context.Context, path string, mode fs.FileMode) error {
+	params := map[string]any{
+		"path": path,
+		"options": map[string]any{
+			"mode": fmt.Sprintf("%04o", mode),
+		},
+	}
+	if _, err := c.Call(ctx, "filesystem.mkdir", params); err != nil {
+		return fmt.Errorf("failed to create directory %q: %w", path, err)
+	}
+	return nil
+}
+
+// Chown changes ownership using filesystem.chown.
+func (c *SSHClient) Chown(ctx context.Context, path string, uid, gid int) error {
+	params := map[string]any{
+		"path": path,
+		"uid":  uid,
+		"gid":  gid,
+	}
+	if _, err := c.CallAndWait(ctx, "filesystem.chown", params); err != nil {
+		return fmt.Errorf("failed to change ownership of %q: %w", path, err)
+	}
+	return nil
+}
+
+// ChmodRecursive changes permissions using filesystem.setperm.
+func (c *SSHClient) ChmodRecursive(ctx context.Context, path string, mode fs.FileMode) error {
+	params := map[string]any{
+		"path": path,
+		"mode": fmt.Sprintf("%04o", mode),
+		"options": map[string]any{
+			"recursive": true,
+		},
+	}
+	if _, err := c.CallAndWait(ctx, "filesystem.setperm", params); err != nil {
+		return fmt.Errorf("failed to chmod %q: %w", path, err)
+	}
+	return nil
+}
+
+// Subscribe is not supported over SSH.
+func (c *SSHClient) Subscribe(ctx context.Context, collection string, params any) (*truenas.Subscription[json.RawMessage], error) {
+	return nil, client.ErrUnsupportedOperation
+}
@@ -0,0 +1,311 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deevus/truenas-go/client"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshConnectTimeout bounds the TCP connection and handshake of each hop.
+const sshConnectTimeout = 30 * time.Second
+
+// SSHConfig configures an SSHClient.
+type SSHConfig struct {
+	Host string
+	Port int
+	User string
+
+	// PrivateKey is an OpenSSH private key. At least one of PrivateKey and
+	// UseAgent must be set; with both, the key is offered first.
+	PrivateKey string
+	// UseAgent authenticates with the keys held by ssh-agent, such as
+	// hardware keys that cannot be exported.
+	UseAgent bool
+	// AgentSocket is the agent's socket. Defaults to $SSH_AUTH_SOCK.
+	AgentSocket string
+
+	// HostKeyFingerprints are the accepted SHA256 fingerprints of the host
+	// key, as printed by ssh-keygen -lf. Listing both the old and the new
+	// key lets a host key be rotated without interruption.
+	HostKeyFingerprints []string
+	// KnownHostsFile is an OpenSSH known_hosts file. Keys it lists for the
+	// host are accepted in addition to HostKeyFingerprints. At least one of
+	// the two must be set.
+	KnownHostsFile string
+
+	// Bastion, when set, is a jump host the connection is tunnelled through.
+	Bastion *SSHBastionConfig
+
+	// Sudo runs midclt and the shell commands of file operations with sudo,
+	// for users other than root. Without SudoPassword, sudo must not ask
+	// for a password (NOPASSWD in sudoers).
+	Sudo bool
+	// SudoPassword is written to sudo's standard input when it asks for a
+	// password. Requires Sudo.
+	SudoPassword string
+
+	MaxSessions int // Maximum concurrent sessions (default: 5)
+
+	// Logger receives request and response debug logs. Defaults to
+	// client.NopLogger.
+	Logger client.Logger
+}
+
+// SSHBastionConfig configures the jump host of an SSH connection.
+type SSHBastionConfig struct {
+	Host string
+	Port int
+	// User defaults to the user of the TrueNAS connection.
+	User string
+	// PrivateKey defaults to the key of the TrueNAS connection. The agent is
+	// offered as well when UseAgent is set.
+	PrivateKey string
+	// HostKeyFingerprints are the accepted fingerprints of the bastion's
+	// host key. KnownHostsFile applies to the bastion too, and at least one
+	// of the two must be set.
+	HostKeyFingerprints []string
+}
+
+// Validate validates the SSHConfig and sets defaults.
+func (c *SSHConfig) Validate() error {
+	if c.Host == "" {
+		return errors.New("host is required")
+	}
+	if c.PrivateKey == "" && !c.UseAgent {
+		return errors.New("one of private_key or use_agent is required")
+	}
+	if c.UseAgent && c.AgentSocket == "" {
+		c.AgentSocket = os.Getenv("SSH_AUTH_SOCK")
+		if c.AgentSocket == "" {
+			return errors.New("use_agent is set but SSH_AUTH_SOCK is empty: no ssh-agent is running")
+		}
+	}
+	if len(c.HostKeyFingerprints) == 0 && c.KnownHostsFile == "" {
+		return errors.New("one of host_key_fingerprint, host_key_fingerprints or known_hosts_file is required")
+	}
+	if c.SudoPassword != "" && !c.Sudo {
+		return errors.New("sudo_password requires sudo")
+	}
+	if b := c.Bastion; b != nil {
+		if b.Host == "" {
+			return errors.New("bastion host is required")
+		}
+		if len(b.HostKeyFingerprints) == 0 && c.KnownHostsFile == "" {
+			return errors.New("bastion host_key_fingerprint or known_hosts_file is required")
+		}
+		if b.Port == 0 {
+			b.Port = 22
+		}
+	}
+
+	if c.Port == 0 {
+		c.Port = 22
+	}
+	if c.User == "" {
+		c.User = "root"
+	}
+	if c.Bastion != nil && c.Bastion.User == "" {
+		c.Bastion.User = c.User
+	}
+	if c.MaxSessions <= 0 {
+		c.MaxSessions = 5
+	}
+	if c.Logger == nil {
+		c.Logger = client.NopLogger{}
+	}
+	return nil
+}
+
+// sshDialer opens SSH connections, through the bastion when there is one.
+type sshDialer struct {
+	config     SSHConfig
+	signer     ssh.Signer
+	bastionKey ssh.Signer
+	knownHosts ssh.HostKeyCallback
+}
+
+func newSSHDialer(cfg SSHConfig) (*sshDialer, error) {
+	d := &sshDialer{config: cfg}
+
+	var err error
+	if cfg.PrivateKey != "" {
+		if d.signer, err = ssh.ParsePrivateKey([]byte(cfg.PrivateKey)); err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+	}
+	if cfg.Bastion != nil && cfg.Bastion.PrivateKey != "" {
+		if d.bastionKey, err = ssh.ParsePrivateKey([]byte(cfg.Bastion.PrivateKey)); err != nil {
+			return nil, fmt.Errorf("failed to parse bastion private key: %w", err)
+		}
+	}
+	if cfg.KnownHostsFile != "" {
+		if d.knownHosts, err = knownhosts.New(cfg.KnownHostsFile); err != nil {
+			return nil, fmt.Errorf("failed to read known_hosts file: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// dial connects to the TrueNAS host.
+func (d *sshDialer) dial() (*sshConn, error) {
+	var agentSigners func() ([]ssh.Signer, error)
+	if d.config.UseAgent {
+		sock, err := net.DialTimeout("unix", d.config.AgentSocket, sshConnectTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to ssh-agent: %w", err)
+		}
+		// The agent is only needed while authenticating
+		defer func() { _ = sock.Close() }()
+		agentSigners = agent.NewClient(sock).Signers
+	}
+
+	addr := net.JoinHostPort(d.config.Host, strconv.Itoa(d.config.Port))
+	config := d.clientConfig(d.config.User, d.signer, agentSigners, d.config.HostKeyFingerprints, addr)
+
+	b := d.config.Bastion
+	if b == nil {
+		c, err := ssh.Dial("tcp", addr, config)
+		if err != nil {
+			return nil, err
+		}
+		return &sshConn{client: c}, nil
+	}
+
+	bastionKey := d.bastionKey
+	if bastionKey == nil {
+		bastionKey = d.signer
+	}
+	bastionAddr := net.JoinHostPort(b.Host, strconv.Itoa(b.Port))
+	bastion, err := ssh.Dial("tcp", bastionAddr,
+		d.clientConfig(b.User, bastionKey, agentSigners, b.HostKeyFingerprints, bastionAddr))
+	if err != nil {
+		return nil, fmt.Errorf("bastion %s: %w", bastionAddr, err)
+	}
+
+	tunnel, err := bastion.Dial("tcp", addr)
+	if err != nil {
+		_ = bastion.Close()
+		return nil, fmt.Errorf("bastion %s could not reach %s: %w", bastionAddr, addr, err)
+	}
+	conn, chans, reqs, err := ssh.NewClientConn(tunnel, addr, config)
+	if err != nil {
+		_ = tunnel.Close()
+		_ = bastion.Close()
+		return nil, err
+	}
+	return &sshConn{client: ssh.NewClient(conn, chans, reqs), bastion: bastion}, nil
+}
+
+// clientConfig returns the configuration of one hop.
+func (d *sshDialer) clientConfig(user string, signer ssh.Signer, agentSigners func() ([]ssh.Signer, error), fingerprints []string, addr string) *ssh.ClientConfig {
+	var auth []ssh.AuthMethod
+	if signer != nil {
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if agentSigners != nil {
+		auth = append(auth, ssh.PublicKeysCallback(agentSigners))
+	}
+
+	config := &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: verifyHostKey(fingerprints, d.knownHosts, d.config.KnownHostsFile),
+		Timeout:         sshConnectTimeout,
+	}
+	if len(fingerprints) == 0 {
+		// Ask for a key type known_hosts has, rather than whichever the
+		// server prefers
+		config.HostKeyAlgorithms = knownHostKeyAlgorithms(d.knownHosts, addr)
+	}
+	return config
+}
+
+// verifyHostKey accepts a host key that matches one of fingerprints or, when
+// knownHosts is set, that the known_hosts file lists for the host.
+func verifyHostKey(fingerprints []string, knownHosts ssh.HostKeyCallback, knownHostsFile string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		actual := ssh.FingerprintSHA256(key)
+		for _, fp := range fingerprints {
+			if normalizeHostKeyFingerprint(fp) == actual {
+				return nil
+			}
+		}
+		if knownHosts != nil && knownHosts(hostname, remote, key) == nil {
+			return nil
+		}
+
+		expected := strings.Join(fingerprints, " or ")
+		if knownHosts != nil {
+			if expected != "" {
+				expected += " or "
+			}
+			expected += "a key listed in " + knownHostsFile
+		}
+		return client.NewHostKeyError(hostname, expected, actual)
+	}
+}
+
+// normalizeHostKeyFingerprint adds the "SHA256:" prefix and removes the
+// base64 padding that some tools print.
+func normalizeHostKeyFingerprint(fp string) string {
+	fp = strings.TrimRight(strings.TrimSpace(fp), "=")
+	if !strings.HasPrefix(fp, "SHA256:") {
+		fp = "SHA256:" + fp
+	}
+	return fp
+}
+
+// knownHostKeyAlgorithms returns the host key algorithms of the keys
+// known_hosts lists for addr, or nil to use the defaults.
+func knownHostKeyAlgorithms(knownHosts ssh.HostKeyCallback, addr string) []string {
+	if knownHosts == nil {
+		return nil
+	}
+	// A key that is certainly unknown makes the callback list the known ones
+	err := knownHosts(addr, &net.TCPAddr{}, unknownHostKey{})
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return nil
+	}
+
+	var algorithms []string
+	for _, known := range keyErr.Want {
+		if known.Key.Type() == ssh.KeyAlgoRSA {
+			algorithms = append(algorithms, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256)
+		}
+		algorithms = append(algorithms, known.Key.Type())
+	}
+	return algorithms
+}
+
+// unknownHostKey is a public key no known_hosts file lists.
+type unknownHostKey struct{}
+
+func (unknownHostKey) Type() string                        { return "unknown" }
+func (unknownHostKey) Marshal() []byte                     { return []byte("unknown") }
+func (unknownHostKey) Verify([]byte, *ssh.Signature) error { return errors.New("unknown key") }
+
+// sshConn is an SSH connection, possibly tunnelled through a bastion.
+type sshConn struct {
+	client  *ssh.Client
+	bastion *ssh.Client
+}
+
+func (c *sshConn) Close() error {
+	err := c.client.Close()
+	if c.bastion != nil {
+		if bErr := c.bastion.Close(); err == nil {
+			err = bErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,450 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deevus/terraform-provider-truenas/internal/fakenas"
+	truenas "github.com/deevus/truenas-go"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// otherFingerprint is the fingerprint of a key no test server uses.
+const otherFingerprint = "SHA256:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// newSSHServer starts a fake NAS behind SSH and returns a key it accepts.
+func newSSHServer(t *testing.T) (*fakenas.Server, *fakenas.SSHServer, string) {
+	t.Helper()
+	key, signer := fakenas.GenerateSSHKey(t)
+	srv := fakenas.NewServer(t)
+	return srv, srv.ServeSSH(t, fakenas.WithAuthorizedKey(signer.PublicKey())), key
+}
+
+// connectSSH creates an SSHClient for cfg and connects it.
+func connectSSH(t *testing.T, cfg SSHConfig) (*SSHClient, error) {
+	t.Helper()
+	c, err := NewSSHClient(cfg)
+	if err != nil {
+		t.Fatalf("NewSSHClient: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c, c.Connect(context.Background())
+}
+
+// startAgent serves an ssh-agent holding key and returns its socket.
+func startAgent(t *testing.T, key string) string {
+	t.Helper()
+	keyring := agent.NewKeyring()
+	priv, err := ssh.ParseRawPrivateKey([]byte(key))
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	if err := keyring.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+		t.Fatalf("add key: %v", err)
+	}
+
+	// Unix socket paths are short; t.TempDir can exceed the limit
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	sock := filepath.Join(dir, "sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				_ = agent.ServeAgent(keyring, c)
+			}()
+		}
+	}()
+	return sock
+}
+
+// mustPublicKey returns the public half of an OpenSSH private key.
+func mustPublicKey(t *testing.T, key string) ssh.PublicKey {
+	t.Helper()
+	signer, err := ssh.ParsePrivateKey([]byte(key))
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	return signer.PublicKey()
+}
+
+func TestSSHConfig_Validate(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	tests := []struct {
+		name    string
+		cfg     SSHConfig
+		wantErr string
+	}{
+		{"no host", SSHConfig{}, "host is required"},
+		{"no credentials", SSHConfig{Host: "nas", HostKeyFingerprints: []string{otherFingerprint}}, "one of private_key or use_agent"},
+		{"agent without socket", SSHConfig{Host: "nas", UseAgent: true, HostKeyFingerprints: []string{otherFingerprint}}, "SSH_AUTH_SOCK"},
+		{"no host key", SSHConfig{Host: "nas", PrivateKey: "key"}, "known_hosts_file is required"},
+		{"sudo password without sudo", SSHConfig{Host: "nas", PrivateKey: "key", HostKeyFingerprints: []string{otherFingerprint}, SudoPassword: "secret"}, "sudo_password requires sudo"},
+		{"bastion without host", SSHConfig{Host: "nas", PrivateKey: "key", HostKeyFingerprints: []string{otherFingerprint}, Bastion: &SSHBastionConfig{}}, "bastion host is required"},
+		{"bastion without host key", SSHConfig{Host: "nas", PrivateKey: "key", HostKeyFingerprints: []string{otherFingerprint}, Bastion: &SSHBastionConfig{Host: "jump"}}, "bastion host_key_fingerprint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSSHConfig_Validate_Defaults(t *testing.T) {
+	cfg := SSHConfig{
+		Host:                "nas",
+		PrivateKey:          "key",
+		User:                "admin",
+		HostKeyFingerprints: []string{otherFingerprint},
+		Bastion:             &SSHBastionConfig{Host: "jump", HostKeyFingerprints: []string{otherFingerprint}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if cfg.Port != 22 || cfg.MaxSessions != 5 || cfg.Logger == nil {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if cfg.Bastion.Port != 22 || cfg.Bastion.User != "admin" {
+		t.Errorf("unexpected bastion defaults: %+v", cfg.Bastion)
+	}
+}
+
+func TestNewSSHClient_InvalidKey(t *testing.T) {
+	_, err := NewSSHClient(SSHConfig{Host: "nas", PrivateKey: "not a key", HostKeyFingerprints: []string{otherFingerprint}})
+	if err == nil || !strings.Contains(err.Error(), "failed to parse private key") {
+		t.Fatalf("expected key error, got %v", err)
+	}
+}
+
+func TestSSHClient_Operations(t *testing.T) {
+	srv, sshd, key := newSSHServer(t)
+	c, err := connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		PrivateKey:          key,
+		HostKeyFingerprints: []string{sshd.Fingerprint()},
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if !c.Version().AtLeast(25, 0) {
+		t.Errorf("unexpected version %v", c.Version())
+	}
+
+	ctx := context.Background()
+	path := "/mnt/" + fakenas.DefaultPool + "/hello.txt"
+	if err := c.WriteFile(ctx, path, truenas.WriteFileParams{Content: []byte("hello"), Mode: 0o644}); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	data, err := c.ReadFile(ctx, path)
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("ReadFile: %q, %v", data, err)
+	}
+	if err := c.DeleteFile(ctx, path); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	if exists, err := c.FileExists(ctx, path); err != nil || exists {
+		t.Fatalf("FileExists after delete: %v, %v", exists, err)
+	}
+
+	if _, err := c.CallAndWait(ctx, "pool.dataset.create", map[string]any{"name": fakenas.DefaultPool + "/apps"}); err != nil {
+		t.Fatalf("CallAndWait: %v", err)
+	}
+	_, err = c.CallAndWait(ctx, "pool.dataset.create", map[string]any{"name": fakenas.DefaultPool + "/apps"})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected duplicate dataset error, got %v", err)
+	}
+	if srv.CallCount("pool.dataset.create") != 2 {
+		t.Errorf("expected two dataset creations, got %v", srv.Calls())
+	}
+}
+
+func TestSSHClient_CallAndWait_AbortsJobAtDeadline(t *testing.T) {
+	key, signer := fakenas.GenerateSSHKey(t)
+	srv := fakenas.NewServer(t, fakenas.WithJobDelay(time.Second))
+	sshd := srv.ServeSSH(t, fakenas.WithAuthorizedKey(signer.PublicKey()))
+	c, err := connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		PrivateKey:          key,
+		HostKeyFingerprints: []string{sshd.Fingerprint()},
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = c.CallAndWait(ctx, "app.start", "web")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if srv.CallCount("core.job_abort") != 1 {
+		t.Errorf("expected the job to be aborted, got calls %v", srv.Calls())
+	}
+}
+
+func TestSSHClient_ClosesSessionAtDeadline(t *testing.T) {
+	key, signer := fakenas.GenerateSSHKey(t)
+	srv := fakenas.NewServer(t, fakenas.WithJobDelay(2*time.Second))
+	sshd := srv.ServeSSH(t, fakenas.WithAuthorizedKey(signer.PublicKey()))
+	c, err := connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		PrivateKey:          key,
+		HostKeyFingerprints: []string{sshd.Fingerprint()},
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	// midclt -j blocks until the job finishes
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.run(ctx, `midclt call -j app.start '"web"'`); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the session to be closed at the deadline, took %v", elapsed)
+	}
+}
+
+func TestSSHClient_RedialsLostConnection(t *testing.T) {
+	_, sshd, key := newSSHServer(t)
+	c, err := connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		PrivateKey:          key,
+		HostKeyFingerprints: []string{sshd.Fingerprint()},
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	_ = c.conn.client.Close()
+	if _, err := c.Call(context.Background(), "system.info", nil); err != nil {
+		t.Fatalf("Call after the connection was lost: %v", err)
+	}
+
+	_ = c.Close()
+	if _, err := c.Call(context.Background(), "system.info", nil); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+}
+
+func TestSSHClient_HostKeyRotation(t *testing.T) {
+	_, sshd, key := newSSHServer(t)
+
+	// Both the retired and the new key are accepted during a rotation
+	_, err := connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		PrivateKey:          key,
+		HostKeyFingerprints: []string{otherFingerprint, strings.TrimPrefix(sshd.Fingerprint(), "SHA256:")},
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	_, err = connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		PrivateKey:          key,
+		HostKeyFingerprints: []string{otherFingerprint},
+	})
+	if err == nil || !strings.Contains(err.Error(), "host key verification failed") {
+		t.Fatalf("expected host key error, got %v", err)
+	}
+}
+
+func TestSSHClient_KnownHostsFile(t *testing.T) {
+	_, sshd, key := newSSHServer(t)
+	addr := net.JoinHostPort(sshd.Host, strconv.Itoa(sshd.Port))
+
+	known := filepath.Join(t.TempDir(), "known_hosts")
+	if err := os.WriteFile(known, []byte(knownhosts.Line([]string{addr}, sshd.HostKey())+"\n"), 0o600); err != nil {
+		t.Fatalf("write known_hosts: %v", err)
+	}
+	if _, err := connectSSH(t, SSHConfig{Host: sshd.Host, Port: sshd.Port, PrivateKey: key, KnownHostsFile: known}); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	// A host key that changed is rejected
+	_, other := fakenas.GenerateSSHKey(t)
+	if err := os.WriteFile(known, []byte(knownhosts.Line([]string{addr}, other.PublicKey())+"\n"), 0o600); err != nil {
+		t.Fatalf("write known_hosts: %v", err)
+	}
+	_, err := connectSSH(t, SSHConfig{Host: sshd.Host, Port: sshd.Port, PrivateKey: key, KnownHostsFile: known})
+	if err == nil || !strings.Contains(err.Error(), "a key listed in "+known) {
+		t.Fatalf("expected host key error, got %v", err)
+	}
+}
+
+func TestSSHClient_Agent(t *testing.T) {
+	_, sshd, key := newSSHServer(t)
+	sock := startAgent(t, key)
+
+	_, err := connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		UseAgent:            true,
+		AgentSocket:         sock,
+		HostKeyFingerprints: []string{sshd.Fingerprint()},
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+}
+
+func TestSSHClient_Bastion(t *testing.T) {
+	_, sshd, key := newSSHServer(t)
+	bastionKey, bastionSigner := fakenas.GenerateSSHKey(t)
+	bastion := fakenas.NewBastion(t, fakenas.WithSSHUser("jump"), fakenas.WithAuthorizedKey(bastionSigner.PublicKey()))
+
+	c, err := connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		PrivateKey:          key,
+		HostKeyFingerprints: []string{sshd.Fingerprint()},
+		Bastion: &SSHBastionConfig{
+			Host:                bastion.Host,
+			Port:                bastion.Port,
+			User:                "jump",
+			PrivateKey:          bastionKey,
+			HostKeyFingerprints: []string{bastion.Fingerprint()},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if _, err := c.Call(context.Background(), "system.info", nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if bastion.Tunnels() != 1 {
+		t.Errorf("expected one tunnel through the bastion, got %d", bastion.Tunnels())
+	}
+}
+
+func TestSSHClient_BastionWithAgent(t *testing.T) {
+	_, sshd, key := newSSHServer(t)
+	// The same hardware key, held by the agent, opens both hops
+	bastion := fakenas.NewBastion(t, fakenas.WithAuthorizedKey(mustPublicKey(t, key)))
+	sock := startAgent(t, key)
+
+	_, err := connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		UseAgent:            true,
+		AgentSocket:         sock,
+		HostKeyFingerprints: []string{sshd.Fingerprint()},
+		Bastion:             &SSHBastionConfig{Host: bastion.Host, Port: bastion.Port, HostKeyFingerprints: []string{bastion.Fingerprint()}},
+	})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	// The bastion's host key is verified too
+	_, err = connectSSH(t, SSHConfig{
+		Host:                sshd.Host,
+		Port:                sshd.Port,
+		UseAgent:            true,
+		AgentSocket:         sock,
+		HostKeyFingerprints: []string{sshd.Fingerprint()},
+		Bastion:             &SSHBastionConfig{Host: bastion.Host, Port: bastion.Port, HostKeyFingerprints: []string{sshd.Fingerprint()}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "bastion") {
+		t.Fatalf("expected bastion host key error, got %v", err)
+	}
+}
+
+func TestSSHClient_Sudo(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverPass   string
+		sudo         bool
+		sudoPassword string
+		wantErr      string
+		wantCommand  string
+	}{
+		{name: "without sudo", wantErr: "Not authorized"},
+		{name: "passwordless", sudo: true, wantCommand: "sudo -n midclt call system.version"},
+		{name: "password", serverPass: "secret", sudo: true, sudoPassword: "secret", wantCommand: "sudo -S -p '' midclt call system.version"},
+		{name: "missing password", serverPass: "secret", sudo: true, wantErr: "a password is required"},
+		{name: "wrong password", serverPass: "secret", sudo: true, sudoPassword: "wrong", wantErr: "incorrect password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, signer := fakenas.GenerateSSHKey(t)
+			srv := fakenas.NewServer(t)
+			sshd := srv.ServeSSH(t,
+				fakenas.WithAuthorizedKey(signer.PublicKey()),
+				fakenas.WithSSHUser("terraform"),
+				fakenas.WithSudoPassword(tt.serverPass),
+			)
+
+			c, err := connectSSH(t, SSHConfig{
+				Host:                sshd.Host,
+				Port:                sshd.Port,
+				User:                "terraform",
+				PrivateKey:          key,
+				HostKeyFingerprints: []string{sshd.Fingerprint()},
+				Sudo:                tt.sudo,
+				SudoPassword:        tt.sudoPassword,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Connect: %v", err)
+			}
+			if got := sshd.Commands()[0]; got != tt.wantCommand {
+				t.Errorf("expected command %q, got %q", tt.wantCommand, got)
+			}
+
+			// File operations are elevated too
+			ctx := context.Background()
+			if err := c.MkdirAll(ctx, "/mnt/tank/app", 0o755); err != nil {
+				t.Fatalf("MkdirAll: %v", err)
+			}
+			if err := c.WriteFile(ctx, "/mnt/tank/app/config", truenas.WriteFileParams{Content: []byte("x"), Mode: 0o644}); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			if err := c.RemoveAll(ctx, "/mnt/tank/app"); err != nil {
+				t.Fatalf("RemoveAll: %v", err)
+			}
+			for _, cmd := range sshd.Commands() {
+				if !strings.HasPrefix(cmd, "sudo ") {
+					t.Errorf("command not run with sudo: %q", cmd)
+				}
+			}
+		})
+	}
+}
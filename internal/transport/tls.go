@@ -0,0 +1,110 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TLSOptions describes how connections to TrueNAS verify the server and,
+// optionally, authenticate the client.
+type TLSOptions struct {
+	// InsecureSkipVerify disables server verification entirely.
+	InsecureSkipVerify bool
+
+	// CACertPEM holds the PEM certificates trusted instead of the system
+	// roots, typically the self-signed certificate of the NAS or the CA that
+	// issued it.
+	CACertPEM []byte
+
+	// Fingerprint pins the SHA-256 fingerprint of the server's leaf
+	// certificate, in hex as printed by `openssl x509 -fingerprint -sha256`.
+	// Without CACertPEM a matching certificate is accepted even if it is
+	// self-signed or does not name the host; with it, both must hold.
+	Fingerprint string
+
+	// ClientCertPEM and ClientKeyPEM are a certificate presented to servers
+	// or reverse proxies that require mutual TLS.
+	ClientCertPEM []byte
+	ClientKeyPEM  []byte
+}
+
+// NewTLSConfig builds the tls.Config described by opts.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	verifies := len(opts.CACertPEM) > 0 || opts.Fingerprint != ""
+	if opts.InsecureSkipVerify && verifies {
+		return nil, errors.New("insecure_skip_verify cannot be combined with a CA certificate or fingerprint")
+	}
+
+	cfg := &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipVerify, //nolint:gosec // explicitly requested
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if len(opts.CACertPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(opts.CACertPEM) {
+			return nil, errors.New("CA certificate contains no PEM encoded certificates")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if opts.Fingerprint != "" {
+		pin, err := ParseFingerprint(opts.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.RootCAs == nil {
+			// The pin replaces chain verification
+			cfg.InsecureSkipVerify = true //nolint:gosec // verified by VerifyConnection
+		}
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+			got := sha256.Sum256(cs.PeerCertificates[0].Raw)
+			if subtle.ConstantTimeCompare(got[:], pin) != 1 {
+				return fmt.Errorf("server certificate fingerprint %s does not match the pinned fingerprint", FormatFingerprint(got[:]))
+			}
+			return nil
+		}
+	}
+
+	if len(opts.ClientCertPEM) > 0 || len(opts.ClientKeyPEM) > 0 {
+		cert, err := tls.X509KeyPair(opts.ClientCertPEM, opts.ClientKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// ParseFingerprint parses a hex SHA-256 certificate fingerprint. Colons,
+// spaces, case and a leading "sha256:" are ignored.
+func ParseFingerprint(s string) ([]byte, error) {
+	v := strings.ToLower(strings.TrimSpace(s))
+	v = strings.TrimPrefix(v, "sha256:")
+	v = strings.NewReplacer(":", "", " ", "").Replace(v)
+	pin, err := hex.DecodeString(v)
+	if err != nil || len(pin) != sha256.Size {
+		return nil, fmt.Errorf("tls_fingerprint %q is not a hex SHA-256 fingerprint", s)
+	}
+	return pin, nil
+}
+
+// FormatFingerprint formats a SHA-256 fingerprint as colon separated upper
+// case hex, the form printed by openssl.
+func FormatFingerprint(sum []byte) string {
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,203 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deevus/terraform-provider-truenas/internal/fakenas"
+)
+
+// serverFingerprint returns the fingerprint of srv's certificate.
+func serverFingerprint(t *testing.T, srv *fakenas.Server) string {
+	t.Helper()
+	block, _ := pem.Decode(srv.CertificatePEM())
+	sum := sha256.Sum256(block.Bytes)
+	return FormatFingerprint(sum[:])
+}
+
+// newClientCertificate returns a self-signed client certificate and key.
+func newClientCertificate(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "terraform"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// connectWithTLS connects a WebSocketClient to srv using opts.
+func connectWithTLS(t *testing.T, srv *fakenas.Server, opts TLSOptions) error {
+	t.Helper()
+	tlsConfig, err := NewTLSConfig(opts)
+	if err != nil {
+		t.Fatalf("NewTLSConfig: %v", err)
+	}
+	c, err := NewWebSocketClient(WebSocketConfig{
+		Host:       srv.Host,
+		Port:       srv.Port,
+		Username:   srv.Username(),
+		APIKey:     srv.APIKey(),
+		TLSConfig:  tlsConfig,
+		MaxRetries: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewWebSocketClient: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c.Connect(context.Background())
+}
+
+func TestParseFingerprint(t *testing.T) {
+	want := strings.Repeat("ab", 32)
+	for _, s := range []string{
+		want,
+		strings.ToUpper(want),
+		"sha256:" + want,
+		strings.TrimSuffix(strings.Repeat("AB:", 32), ":"),
+	} {
+		pin, err := ParseFingerprint(s)
+		if err != nil {
+			t.Fatalf("%q: %v", s, err)
+		}
+		if len(pin) != 32 || pin[0] != 0xab {
+			t.Errorf("%q: unexpected pin %x", s, pin)
+		}
+	}
+
+	for _, s := range []string{"", "abcd", strings.Repeat("zz", 32)} {
+		if _, err := ParseFingerprint(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestNewTLSConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr string
+	}{
+		{"insecure with pin", TLSOptions{InsecureSkipVerify: true, Fingerprint: strings.Repeat("ab", 32)}, "cannot be combined"},
+		{"insecure with ca", TLSOptions{InsecureSkipVerify: true, CACertPEM: []byte("x")}, "cannot be combined"},
+		{"ca without certificates", TLSOptions{CACertPEM: []byte("not pem")}, "no PEM encoded certificates"},
+		{"bad fingerprint", TLSOptions{Fingerprint: "abc"}, "not a hex SHA-256 fingerprint"},
+		{"key without certificate", TLSOptions{ClientKeyPEM: []byte("x")}, "invalid client certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTLSConfig(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_TLS_DefaultRejectsSelfSigned(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	err := connectWithTLS(t, srv, TLSOptions{})
+	if err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("expected certificate verification error, got %v", err)
+	}
+}
+
+func TestWebSocketClient_TLS_CACertificate(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	if err := connectWithTLS(t, srv, TLSOptions{CACertPEM: srv.CertificatePEM()}); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+}
+
+func TestWebSocketClient_TLS_Fingerprint(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	if err := connectWithTLS(t, srv, TLSOptions{Fingerprint: serverFingerprint(t, srv)}); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+}
+
+func TestWebSocketClient_TLS_FingerprintMismatch(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	err := connectWithTLS(t, srv, TLSOptions{Fingerprint: strings.Repeat("00", 32)})
+	if err == nil || !strings.Contains(err.Error(), "does not match the pinned fingerprint") {
+		t.Fatalf("expected fingerprint mismatch, got %v", err)
+	}
+}
+
+func TestWebSocketClient_TLS_FingerprintAndCA(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	opts := TLSOptions{CACertPEM: srv.CertificatePEM(), Fingerprint: serverFingerprint(t, srv)}
+	if err := connectWithTLS(t, srv, opts); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+}
+
+func TestWebSocketClient_TLS_ClientCertificate(t *testing.T) {
+	certPEM, keyPEM := newClientCertificate(t)
+	srv := fakenas.NewServer(t, fakenas.WithClientCA(certPEM))
+
+	if err := connectWithTLS(t, srv, TLSOptions{CACertPEM: srv.CertificatePEM()}); err == nil {
+		t.Fatal("expected connection without a client certificate to fail")
+	}
+
+	opts := TLSOptions{CACertPEM: srv.CertificatePEM(), ClientCertPEM: certPEM, ClientKeyPEM: keyPEM}
+	if err := connectWithTLS(t, srv, opts); err != nil {
+		t.Fatalf("Connect with client certificate: %v", err)
+	}
+}
+
+func TestWebSocketClient_TLS_AppliesToCallConnection(t *testing.T) {
+	certPEM, keyPEM := newClientCertificate(t)
+	srv := fakenas.NewServer(t, fakenas.WithClientCA(certPEM))
+	tlsConfig, err := NewTLSConfig(TLSOptions{Fingerprint: serverFingerprint(t, srv), ClientCertPEM: certPEM, ClientKeyPEM: keyPEM})
+	if err != nil {
+		t.Fatalf("NewTLSConfig: %v", err)
+	}
+	c := newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey(), TLSConfig: tlsConfig})
+	if _, err := c.Call(context.Background(), "system.info", nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	// Calls go over a connection to the NAS itself, verified by the pin
+	tlsConn, ok := c.conn.ws.NetConn().(*tls.Conn)
+	if !ok {
+		t.Fatalf("expected a TLS connection, got %T", c.conn.ws.NetConn())
+	}
+	if port := tlsConn.RemoteAddr().(*net.TCPAddr).Port; port != srv.Port {
+		t.Errorf("expected a connection to port %d, got %d", srv.Port, port)
+	}
+	sum := sha256.Sum256(tlsConn.ConnectionState().PeerCertificates[0].Raw)
+	if got := FormatFingerprint(sum[:]); got != serverFingerprint(t, srv) {
+		t.Errorf("unexpected server certificate %s", got)
+	}
+}
@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // RFC 6238 TOTP is defined over HMAC-SHA1
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// totpPeriod is the lifetime of a one-time password, as used by TrueNAS.
+const totpPeriod = 30 * time.Second
+
+// ValidateOTPSecret reports whether secret is a usable base32 TOTP secret.
+func ValidateOTPSecret(secret string) error {
+	_, err := decodeOTPSecret(secret)
+	return err
+}
+
+// decodeOTPSecret decodes a base32 TOTP secret as shown by TrueNAS when
+// two-factor authentication is set up. Case, spaces and padding are ignored.
+func decodeOTPSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, errors.New("otp_secret is empty")
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("otp_secret is not valid base32: %w", err)
+	}
+	return key, nil
+}
+
+// totpCode returns the six digit RFC 6238 code for key at time t.
+func totpCode(key []byte, t time.Time) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix()/int64(totpPeriod/time.Second)))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1_000_000)
+}
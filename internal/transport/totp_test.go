@@ -0,0 +1,51 @@
+package transport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1, truncated to six digits
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, time.Unix(tt.unix, 0)); got != tt.want {
+			t.Errorf("at %d: expected %s, got %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestDecodeOTPSecret_Normalises(t *testing.T) {
+	// base32 of "12345678901234567890"
+	for _, secret := range []string{
+		"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		"gezd gnbv gy3t qojq gezd gnbv gy3t qojq",
+		"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ====",
+	} {
+		key, err := decodeOTPSecret(secret)
+		if err != nil {
+			t.Fatalf("%q: %v", secret, err)
+		}
+		if string(key) != "12345678901234567890" {
+			t.Errorf("%q: unexpected key %q", secret, key)
+		}
+	}
+}
+
+func TestValidateOTPSecret_Invalid(t *testing.T) {
+	for _, secret := range []string{"", "not-base32!", "   "} {
+		if err := ValidateOTPSecret(secret); err == nil {
+			t.Errorf("%q: expected error", secret)
+		}
+	}
+}
@@ -0,0 +1,881 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	truenas "github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+	"github.com/gorilla/websocket"
+)
+
+// Authentication mechanisms and responses of auth.login_ex.
+const (
+	mechanismAPIKey   = "API_KEY_PLAIN"
+	mechanismPassword = "PASSWORD_PLAIN"
+	mechanismOTP      = "OTP_TOKEN"
+
+	loginSuccess     = "SUCCESS"
+	loginOTPRequired = "OTP_REQUIRED"
+)
+
+// ErrClientClosed is returned by calls made after Close.
+var ErrClientClosed = errors.New("websocket client is closed")
+
+// WebSocketConfig configures a WebSocketClient.
+type WebSocketConfig struct {
+	Host     string
+	Port     int
+	Username string
+
+	// APIKey authenticates with the API_KEY_PLAIN mechanism. Exactly one of
+	// APIKey and Password must be set.
+	APIKey string
+	// Password authenticates with the PASSWORD_PLAIN mechanism.
+	Password string
+	// OTPSecret is the base32 TOTP secret of the user. It is used to answer
+	// the one-time password challenge of accounts with two-factor
+	// authentication, and requires Password.
+	OTPSecret string
+
+	InsecureSkipVerify bool
+	// TLSConfig, when set, configures TLS instead of InsecureSkipVerify:
+	// its CA bundle, pinned fingerprint and client certificate apply to the
+	// connection to the NAS. See NewTLSConfig.
+	TLSConfig *tls.Config
+
+	MaxConcurrent  int
+	ConnectTimeout time.Duration
+	MaxRetries     int           // Resends of a query whose connection dropped (default: 3, negative: none)
+	PingInterval   time.Duration // Interval between pings (default: 30s)
+	PingTimeout    time.Duration // Time to wait for pong (default: 10s)
+
+	// Fallback serves the operations the middleware cannot, such as reading
+	// and deleting files. Defaults to client.UnsupportedClient.
+	Fallback client.Client
+}
+
+// Validate validates the WebSocketConfig and sets defaults.
+func (c *WebSocketConfig) Validate() error {
+	if c.Host == "" {
+		return errors.New("host is required")
+	}
+	if c.Username == "" {
+		return errors.New("username is required")
+	}
+	switch {
+	case c.APIKey == "" && c.Password == "":
+		return errors.New("one of api_key or password is required")
+	case c.APIKey != "" && c.Password != "":
+		return errors.New("only one of api_key or password can be set")
+	case c.OTPSecret != "" && c.Password == "":
+		return errors.New("otp_secret requires password authentication")
+	}
+	if c.OTPSecret != "" {
+		if err := ValidateOTPSecret(c.OTPSecret); err != nil {
+			return err
+		}
+	}
+	if c.Fallback == nil {
+		c.Fallback = &client.UnsupportedClient{}
+	}
+
+	if c.Port == 0 {
+		c.Port = 443
+	}
+	if c.MaxConcurrent == 0 {
+		c.MaxConcurrent = 20
+	}
+	if c.ConnectTimeout == 0 {
+		c.ConnectTimeout = 30 * time.Second
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	} else if c.MaxRetries < 0 {
+		c.MaxRetries = 0
+	}
+	if c.PingInterval == 0 {
+		c.PingInterval = 30 * time.Second
+	}
+	if c.PingTimeout == 0 {
+		c.PingTimeout = 10 * time.Second
+	}
+	return nil
+}
+
+// Compile-time check that WebSocketClient implements client.Client.
+var _ client.Client = (*WebSocketClient)(nil)
+
+// WebSocketClient calls the middleware with JSON-RPC 2.0 over a WebSocket at
+// /api/current, as the WebSocket client of truenas-go does, adding what
+// truenas-go lacks: password and one-time password logins, the TLS settings
+// of NewTLSConfig, and logging in again on the same connection when a
+// session expires. A dropped connection is dialed again by the next call, so
+// an expired session or a dropped socket in the middle of an apply is
+// recovered transparently. Jobs are polled so that their progress is logged
+// and they can be aborted.
+type WebSocketClient struct {
+	config WebSocketConfig
+	dialer *websocket.Dialer
+	otpKey []byte
+	slots  chan struct{}
+	nextID atomic.Int64
+
+	// dialMu serialises dialing, so that calls waiting for a connection
+	// share the new one.
+	dialMu sync.Mutex
+
+	mu        sync.Mutex
+	conn      *wsConn
+	subs      map[*wsSubscription]struct{}
+	version   truenas.Version
+	connected bool
+	closed    bool
+}
+
+// NewWebSocketClient creates a WebSocketClient. No connection is made until
+// the first call.
+func NewWebSocketClient(cfg WebSocketConfig) (*WebSocketClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	dialer := &websocket.Dialer{HandshakeTimeout: cfg.ConnectTimeout, TLSClientConfig: cfg.TLSConfig}
+	if cfg.TLSConfig == nil && cfg.InsecureSkipVerify {
+		dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+	c := &WebSocketClient{
+		config: cfg,
+		dialer: dialer,
+		slots:  make(chan struct{}, cfg.MaxConcurrent),
+		subs:   make(map[*wsSubscription]struct{}),
+	}
+	if cfg.OTPSecret != "" {
+		var err error
+		if c.otpKey, err = decodeOTPSecret(cfg.OTPSecret); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// acquire waits for one of the MaxConcurrent call slots. The returned
+// function releases it.
+func (c *WebSocketClient) acquire(ctx context.Context) (func(), error) {
+	if c.isClosed() {
+		return nil, ErrClientClosed
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *WebSocketClient) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// closedErr returns ErrClientClosed for calls failed by Close.
+func (c *WebSocketClient) closedErr(err error) error {
+	if err != nil && c.isClosed() {
+		return ErrClientClosed
+	}
+	return err
+}
+
+// ensureConn returns the connection to the middleware, dialing and logging
+// in if there is none or it was lost. The subscriptions of a lost connection
+// are made again on the new one.
+func (c *WebSocketClient) ensureConn(ctx context.Context) (*wsConn, error) {
+	c.dialMu.Lock()
+	defer c.dialMu.Unlock()
+
+	c.mu.Lock()
+	conn, closed := c.conn, c.closed
+	c.mu.Unlock()
+	switch {
+	case closed:
+		return nil, ErrClientClosed
+	case conn != nil && conn.alive():
+		return conn, nil
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		conn.fail(ErrClientClosed)
+		return nil, ErrClientClosed
+	}
+	c.conn = conn
+	subs := make([]*wsSubscription, 0, len(c.subs))
+	for sub := range c.subs {
+		subs = append(subs, sub)
+	}
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		if _, err := conn.call(ctx, "core.subscribe", []any{sub.name}); err != nil {
+			// Dial again next time, restoring the subscriptions then
+			conn.fail(err)
+			return nil, fmt.Errorf("failed to restore subscription to %s: %w", sub.name, err)
+		}
+	}
+	return conn, nil
+}
+
+// dial connects to /api/current and logs in.
+func (c *WebSocketClient) dial(ctx context.Context) (*wsConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.config.ConnectTimeout)
+	defer cancel()
+
+	url := "wss://" + net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port)) + "/api/current"
+	ws, _, err := c.dialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("websocket connect failed: %w", err)
+	}
+	conn := newWSConn(c, ws)
+	if err := conn.login(ctx); err != nil {
+		conn.fail(err)
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Connect connects the fallback client and detects the TrueNAS version. The
+// version the fallback detected, as SSHClient and APIFallbackClient do, is
+// used when there is one. Otherwise it is read with system.version. Versions
+// before 25.0 are rejected, as they do not serve JSON-RPC 2.0 at
+// /api/current. Connect must be called before Version.
+func (c *WebSocketClient) Connect(ctx context.Context) error {
+	if c.isClosed() {
+		return ErrClientClosed
+	}
+	if err := c.config.Fallback.Connect(ctx); err != nil {
+		return err
+	}
+
+	version := c.config.Fallback.Version()
+	if version.IsZero() {
+		result, err := c.Call(ctx, "system.version", nil)
+		if err != nil {
+			return fmt.Errorf("failed to detect TrueNAS version: %w", err)
+		}
+		var raw string
+		if err := json.Unmarshal(result, &raw); err != nil {
+			return fmt.Errorf("failed to parse version response: %w", err)
+		}
+		if version, err = truenas.ParseVersion(strings.TrimSpace(raw)); err != nil {
+			return err
+		}
+		if !version.AtLeast(25, 0) {
+			return fmt.Errorf("%w (detected version: %s)", client.ErrUnsupportedVersion, version.Raw)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version = version
+	c.connected = true
+	return nil
+}
+
+// Version returns the TrueNAS version detected by Connect. As with
+// truenas-go, calling it before Connect panics.
+func (c *WebSocketClient) Version() truenas.Version {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.connected {
+		panic("client.Version() called before Connect()")
+	}
+	return c.version
+}
+
+// Close closes the connection, failing calls in flight and ending all
+// subscriptions. The fallback client is left open.
+func (c *WebSocketClient) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	conn := c.conn
+	c.conn = nil
+	for sub := range c.subs {
+		close(sub.ch)
+	}
+	c.subs = nil
+	c.mu.Unlock()
+
+	if conn != nil {
+		conn.fail(ErrClientClosed)
+	}
+	return nil
+}
+
+// Call executes a method. A call rejected because the session expired is
+// sent again after logging in again. When the connection drops before the
+// answer arrives, a query is sent again on a new connection, up to
+// MaxRetries times, but a call that changes state fails, as the NAS may have
+// run it.
+func (c *WebSocketClient) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	for attempt := 0; ; attempt++ {
+		conn, err := c.ensureConn(ctx)
+		if err != nil {
+			return nil, c.closedErr(err)
+		}
+		result, err := conn.callAuthenticated(ctx, method, params)
+
+		var lost *lostCallError
+		if !errors.As(err, &lost) {
+			return result, c.closedErr(err)
+		}
+		switch {
+		case c.isClosed():
+			return nil, ErrClientClosed
+		case lost.sent && !IsQueryMethod(method):
+			return nil, fmt.Errorf("connection to the NAS was lost after %s was sent, so it may have run: %v", method, lost.err)
+		case attempt >= c.config.MaxRetries:
+			return nil, err
+		}
+	}
+}
+
+// CallAndWait executes a method and, if it starts a job, polls the job until
+// it finishes. Progress is logged at info level. A job still running when
+// ctx is cancelled or its deadline passes is aborted. Jobs that fail because
+// the NAS was busy are started again by RateLimitedClient, as with SSH.
+func (c *WebSocketClient) CallAndWait(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	result, err := c.Call(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobID int64
+	if err := json.Unmarshal(result, &jobID); err != nil {
+		return result, nil // Not a job ID, return directly
+	}
+	job, err := waitJob(ctx, c.Call, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.State != client.JobStateSuccess {
+		return nil, jobError(ctx, job, c.ReadFile)
+	}
+	return job.Result, nil
+}
+
+// Subscribe subscribes to collection_update events of a collection, passing
+// the fields of each event to the subscription. A parameterised event source
+// is subscribed to as "collection:params". The subscription is made again
+// when the client reconnects. Events a subscriber is too slow to take are
+// dropped rather than holding up the connection.
+func (c *WebSocketClient) Subscribe(ctx context.Context, collection string, params any) (*truenas.Subscription[json.RawMessage], error) {
+	name := collection
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		name += ":" + string(data)
+	}
+	sub := &wsSubscription{name: name, ch: make(chan json.RawMessage, 100)}
+
+	conn, err := c.ensureConn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe to %s: %w", collection, c.closedErr(err))
+	}
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClientClosed
+	}
+	c.subs[sub] = struct{}{}
+	c.mu.Unlock()
+
+	if _, err := conn.callAuthenticated(ctx, "core.subscribe", []any{name}); err != nil {
+		c.unsubscribe(sub)
+		return nil, fmt.Errorf("subscribe to %s: %w", collection, c.closedErr(err))
+	}
+	return truenas.NewSubscription[json.RawMessage](sub.ch, func() { c.unsubscribe(sub) }), nil
+}
+
+// unsubscribe ends sub, closing its channel.
+func (c *WebSocketClient) unsubscribe(sub *wsSubscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.subs[sub]; ok {
+		delete(c.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// publish passes the fields of a collection_update event to the
+// subscriptions of its collection.
+func (c *WebSocketClient) publish(params json.RawMessage) {
+	var event struct {
+		Collection string          `json:"collection"`
+		Fields     json.RawMessage `json:"fields"`
+	}
+	if json.Unmarshal(params, &event) != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for sub := range c.subs {
+		if sub.name != event.Collection && !strings.HasPrefix(sub.name, event.Collection+":") {
+			continue
+		}
+		select {
+		case sub.ch <- event.Fields:
+		default:
+		}
+	}
+}
+
+// WriteFile writes content to a file using filesystem.file_receive.
+func (c *WebSocketClient) WriteFile(ctx context.Context, path string, params truenas.WriteFileParams) error {
+	// -1 leaves the owner unchanged
+	uid, gid := -1, -1
+	if params.UID != nil {
+		uid = *params.UID
+	}
+	if params.GID != nil {
+		gid = *params.GID
+	}
+	args := []any{
+		path,
+		base64.StdEncoding.EncodeToString(params.Content),
+		map[string]any{
+			"mode": int(params.Mode),
+			"uid":  uid,
+			"gid":  gid,
+		},
+	}
+	if _, err := c.Call(ctx, "filesystem.file_receive", args); err != nil {
+		return fmt.Errorf("failed to write file %q: %w", path, err)
+	}
+	return nil
+}
+
+// fallbackErr explains an operation the fallback client does not support.
+func fallbackErr(op string, err error) error {
+	if errors.Is(err, client.ErrUnsupportedOperation) {
+		return fmt.Errorf("%s requires SSH fallback client: configure Fallback in WebSocketConfig: %w", op, err)
+	}
+	return err
+}
+
+// ReadFile delegates to the fallback client.
+func (c *WebSocketClient) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	data, err := c.config.Fallback.ReadFile(ctx, path)
+	return data, fallbackErr("ReadFile", err)
+}
+
+// DeleteFile delegates to the fallback client.
+func (c *WebSocketClient) DeleteFile(ctx context.Context, path string) error {
+	return fallbackErr("DeleteFile", c.config.Fallback.DeleteFile(ctx, path))
+}
+
+// RemoveDir delegates to the fallback client.
+func (c *WebSocketClient) RemoveDir(ctx context.Context, path string) error {
+	return fallbackErr("RemoveDir", c.config.Fallback.RemoveDir(ctx, path))
+}
+
+// RemoveAll delegates to the fallback client.
+func (c *WebSocketClient) RemoveAll(ctx context.Context, path string) error {
+	return fallbackErr("RemoveAll", c.config.Fallback.RemoveAll(ctx, path))
+}
+
+// FileExists checks if a file exists using filesystem.stat.
+func (c *WebSocketClient) FileExists(ctx context.Context, path string) (bool, error) {
+	if _, err := c.Call(ctx, "filesystem.stat", path); err != nil {
+		var rpcErr *client.JSONRPCError
+		if errors.As(err, &rpcErr) && rpcErr.Data != nil && rpcErr.Data.Error == 2 {
+			return false, nil // ENOENT
+		}
+		return false, fmt.Errorf("failed to stat file %q: %w", path, err)
+	}
+	return true, nil
+}
+
+// Chown changes ownership using filesystem.chown, waiting for the job with
+// CallAndWait.
+func (c *WebSocketClient) Chown(ctx context.Context, path string, uid, gid int) error {
+	params := map[string]any{
+		"path": path,
+		"uid":  uid,
+		"gid":  gid,
+	}
+	if _, err := c.CallAndWait(ctx, "filesystem.chown", params); err != nil {
+		return fmt.Errorf("failed to chown %q: %w", path, err)
+	}
+	return nil
+}
+
+// ChmodRecursive changes permissions using filesystem.setperm, waiting for
+// the job with CallAndWait.
+func (c *WebSocketClient) ChmodRecursive(ctx context.Context, path string, mode fs.FileMode) error {
+	params := map[string]any{
+		"path": path,
+		"mode": fmt.Sprintf("%04o", mode),
+		"options": map[string]any{
+			"recursive": true,
+		},
+	}
+	if _, err := c.CallAndWait(ctx, "filesystem.setperm", params); err != nil {
+		return fmt.Errorf("failed to chmod %q: %w", path, err)
+	}
+	return nil
+}
+
+// MkdirAll creates a directory using filesystem.mkdir.
+func (c *WebSocketClient) MkdirAll(ctx context.Context, path string, mode fs.FileMode) error {
+	params := map[string]any{
+		"path": path,
+		"options": map[string]any{
+			"mode": fmt.Sprintf("%04o", mode),
+		},
+	}
+	if _, err := c.Call(ctx, "filesystem.mkdir", params); err != nil {
+		return fmt.Errorf("failed to mkdir %q: %w", path, err)
+	}
+	return nil
+}
+
+// wsSubscription is a subscription made with Subscribe.
+type wsSubscription struct {
+	name string
+	ch   chan json.RawMessage
+}
+
+// wsMessage is a JSON-RPC response or notification.
+type wsMessage struct {
+	ID     string               `json:"id"`
+	Method string               `json:"method"`
+	Params json.RawMessage      `json:"params"`
+	Result json.RawMessage      `json:"result"`
+	Error  *client.JSONRPCError `json:"error"`
+}
+
+// lostCallError is the error of a call whose connection was lost before it
+// was answered. sent reports whether the request had been written.
+type lostCallError struct {
+	err  error
+	sent bool
+}
+
+func (e *lostCallError) Error() string {
+	return "connection to the NAS was lost: " + e.err.Error()
+}
+
+func (e *lostCallError) Unwrap() error {
+	return e.err
+}
+
+// wsConn is one connection to the middleware. A goroutine reads its
+// responses and events until the connection is lost, and another pings the
+// NAS to notice a connection that went away silently.
+type wsConn struct {
+	client *WebSocketClient
+	ws     *websocket.Conn
+	pongs  chan struct{}
+
+	// writeMu serialises writes.
+	writeMu sync.Mutex
+
+	// loginMu serialises logins. session counts the logins after the first.
+	loginMu sync.Mutex
+	session int
+
+	mu      sync.Mutex
+	pending map[string]chan wsMessage
+	err     error
+	done    chan struct{}
+}
+
+func newWSConn(c *WebSocketClient, ws *websocket.Conn) *wsConn {
+	conn := &wsConn{
+		client:  c,
+		ws:      ws,
+		pongs:   make(chan struct{}, 1),
+		pending: make(map[string]chan wsMessage),
+		done:    make(chan struct{}),
+	}
+	ws.SetPongHandler(func(string) error {
+		select {
+		case conn.pongs <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	go conn.read()
+	go conn.keepAlive()
+	return conn
+}
+
+// alive reports whether the connection has not been lost.
+func (c *wsConn) alive() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// fail marks the connection as lost because of err and closes it. Calls
+// waiting for an answer fail with a *lostCallError.
+func (c *wsConn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+	_ = c.ws.Close()
+}
+
+// read passes responses to the calls waiting for them and events to the
+// subscriptions.
+func (c *wsConn) read() {
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		var msg wsMessage
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+		if msg.ID == "" {
+			if msg.Method == "collection_update" {
+				c.client.publish(msg.Params)
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[msg.ID]
+		delete(c.pending, msg.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+}
+
+// keepAlive pings the NAS every PingInterval and drops the connection when
+// no pong arrives within PingTimeout.
+func (c *wsConn) keepAlive() {
+	interval, timeout := c.client.config.PingInterval, c.client.config.PingTimeout
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		// Forget a pong that arrived after the last ping timed out
+		select {
+		case <-c.pongs:
+		default:
+		}
+		if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout)); err != nil {
+			c.fail(fmt.Errorf("ping failed: %w", err))
+			return
+		}
+		select {
+		case <-c.pongs:
+		case <-c.done:
+			return
+		case <-time.After(timeout):
+			c.fail(errors.New("pong timeout"))
+			return
+		}
+	}
+}
+
+// call sends a request and waits for its response.
+func (c *wsConn) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := strconv.FormatInt(c.client.nextID.Add(1), 10)
+	ch := make(chan wsMessage, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return nil, &lostCallError{err: err}
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	req := client.JSONRPCRequest{JSONRPC: "2.0", Method: method, Params: wrapParams(params), ID: id}
+	c.writeMu.Lock()
+	err := c.ws.WriteJSON(req)
+	c.writeMu.Unlock()
+	if err != nil {
+		// A frame that was cut short is discarded by the server
+		c.fail(err)
+		return nil, &lostCallError{err: err}
+	}
+
+	select {
+	case msg := <-ch:
+		return msg.result()
+	case <-c.done:
+		// The response may have arrived just before the connection closed
+		select {
+		case msg := <-ch:
+			return msg.result()
+		default:
+			return nil, &lostCallError{err: c.err, sent: true}
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// result returns the result or the error of a response.
+func (m wsMessage) result() (json.RawMessage, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Result, nil
+}
+
+// callAuthenticated makes a call, logging in again and sending it once more
+// when the middleware rejected it because the session expired. Such calls
+// are rejected before they run.
+func (c *wsConn) callAuthenticated(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	c.loginMu.Lock()
+	session := c.session
+	c.loginMu.Unlock()
+
+	result, err := c.call(ctx, method, params)
+	var rpcErr *client.JSONRPCError
+	if !errors.As(err, &rpcErr) || !isAuthenticationError(rpcErr) {
+		return result, err
+	}
+
+	c.loginMu.Lock()
+	// Calls rejected together log in once
+	if c.session == session {
+		if err := c.login(ctx); err != nil {
+			c.loginMu.Unlock()
+			return nil, err
+		}
+		c.session++
+	}
+	c.loginMu.Unlock()
+	return c.call(ctx, method, params)
+}
+
+// login logs in with the API key, or with the password followed by a
+// one-time password generated from OTPSecret when the account asks for one.
+func (c *wsConn) login(ctx context.Context) error {
+	cfg := c.client.config
+	var responseType string
+	var err error
+	if cfg.APIKey != "" {
+		responseType, err = c.loginEx(ctx, map[string]string{
+			"mechanism": mechanismAPIKey,
+			"username":  cfg.Username,
+			"api_key":   cfg.APIKey,
+		})
+	} else {
+		responseType, err = c.loginEx(ctx, map[string]string{
+			"mechanism": mechanismPassword,
+			"username":  cfg.Username,
+			"password":  cfg.Password,
+		})
+		if err == nil && responseType == loginOTPRequired {
+			if c.client.otpKey == nil {
+				return fmt.Errorf("user %q requires a one-time password, set otp_secret", cfg.Username)
+			}
+			responseType, err = c.loginEx(ctx, map[string]string{
+				"mechanism": mechanismOTP,
+				"otp_token": totpCode(c.client.otpKey, time.Now()),
+			})
+			if err == nil && responseType == loginOTPRequired {
+				return errors.New("one-time password was rejected")
+			}
+		}
+	}
+
+	switch {
+	case err != nil:
+		return fmt.Errorf("authentication failed: %w", err)
+	case responseType != loginSuccess:
+		return fmt.Errorf("authentication failed: %s", responseType)
+	}
+	return nil
+}
+
+// loginEx sends one auth.login_ex request and returns its response type.
+func (c *wsConn) loginEx(ctx context.Context, params map[string]string) (string, error) {
+	result, err := c.call(ctx, "auth.login_ex", []any{params})
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		ResponseType string `json:"response_type"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return "", fmt.Errorf("auth response parse failed: %w", err)
+	}
+	return resp.ResponseType, nil
+}
+
+// isAuthenticationError reports whether err means the session has expired.
+func isAuthenticationError(err *client.JSONRPCError) bool {
+	return err.Data != nil && strings.Contains(err.Data.Reason, "ENOTAUTHENTICATED")
+}
+
+// wrapParams wraps params in the positional argument list of JSON-RPC. A
+// []any holds the arguments of methods taking several, such as the ID and
+// the data of an update.
+func wrapParams(params any) any {
+	if params == nil {
+		return nil
+	}
+	if _, ok := params.([]any); ok {
+		return params
+	}
+	return []any{params}
+}
@@ -0,0 +1,441 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deevus/terraform-provider-truenas/internal/fakenas"
+	"github.com/deevus/truenas-go/client"
+)
+
+// testOTPSecret is the base32 form of the RFC 6238 test key.
+const testOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// newTestClient returns a WebSocketClient for srv using cfg's credentials.
+func newTestClient(t *testing.T, srv *fakenas.Server, cfg WebSocketConfig) *WebSocketClient {
+	t.Helper()
+	cfg.Host = srv.Host
+	cfg.Port = srv.Port
+	cfg.Username = srv.Username()
+	cfg.InsecureSkipVerify = true
+	cfg.MaxRetries = 1
+	c, err := NewWebSocketClient(cfg)
+	if err != nil {
+		t.Fatalf("NewWebSocketClient: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestWebSocketConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     WebSocketConfig
+		wantErr string
+	}{
+		{"api key", WebSocketConfig{Host: "nas", Username: "root", APIKey: "key"}, ""},
+		{"password", WebSocketConfig{Host: "nas", Username: "admin", Password: "secret"}, ""},
+		{"password and otp", WebSocketConfig{Host: "nas", Username: "admin", Password: "secret", OTPSecret: testOTPSecret}, ""},
+		{"no host", WebSocketConfig{Username: "root", APIKey: "key"}, "host is required"},
+		{"no username", WebSocketConfig{Host: "nas", APIKey: "key"}, "username is required"},
+		{"no credentials", WebSocketConfig{Host: "nas", Username: "root"}, "one of api_key or password"},
+		{"both credentials", WebSocketConfig{Host: "nas", Username: "root", APIKey: "key", Password: "secret"}, "only one of"},
+		{"otp without password", WebSocketConfig{Host: "nas", Username: "root", APIKey: "key", OTPSecret: testOTPSecret}, "requires password"},
+		{"invalid otp", WebSocketConfig{Host: "nas", Username: "root", Password: "secret", OTPSecret: "!!"}, "not valid base32"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if tt.cfg.Port != 443 || tt.cfg.MaxRetries != 3 || tt.cfg.Fallback == nil {
+					t.Errorf("defaults not applied: %+v", tt.cfg)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWebSocketClient_Connect_APIKey(t *testing.T) {
+	srv := fakenas.NewServer(t, fakenas.WithVersion("TrueNAS-25.04.2.4"))
+	c := newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()})
+
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if v := c.Version(); v.Major != 25 || v.Minor != 4 {
+		t.Errorf("unexpected version %v", v)
+	}
+}
+
+func TestWebSocketClient_Connect_Password(t *testing.T) {
+	srv := fakenas.NewServer(t, fakenas.WithPassword("breakglass", "hunter2"))
+	c := newTestClient(t, srv, WebSocketConfig{Password: "hunter2"})
+
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("expected 1 login, got %d", srv.Logins())
+	}
+}
+
+func TestWebSocketClient_Connect_WrongPassword(t *testing.T) {
+	srv := fakenas.NewServer(t, fakenas.WithPassword("breakglass", "hunter2"))
+	c := newTestClient(t, srv, WebSocketConfig{Password: "wrong"})
+
+	err := c.Connect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "authentication failed: AUTH_ERR") {
+		t.Fatalf("expected authentication error, got %v", err)
+	}
+}
+
+func TestWebSocketClient_Connect_PasswordWithOTP(t *testing.T) {
+	srv := fakenas.NewServer(t,
+		fakenas.WithPassword("breakglass", "hunter2"),
+		fakenas.WithOTPSecret(testOTPSecret),
+	)
+	c := newTestClient(t, srv, WebSocketConfig{Password: "hunter2", OTPSecret: testOTPSecret})
+
+	if err := c.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+}
+
+func TestWebSocketClient_Connect_OTPRequiredWithoutSecret(t *testing.T) {
+	srv := fakenas.NewServer(t,
+		fakenas.WithPassword("breakglass", "hunter2"),
+		fakenas.WithOTPSecret(testOTPSecret),
+	)
+	c := newTestClient(t, srv, WebSocketConfig{Password: "hunter2"})
+
+	err := c.Connect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "set otp_secret") {
+		t.Fatalf("expected one-time password error, got %v", err)
+	}
+}
+
+func TestWebSocketClient_Connect_WrongOTPSecret(t *testing.T) {
+	srv := fakenas.NewServer(t,
+		fakenas.WithPassword("breakglass", "hunter2"),
+		fakenas.WithOTPSecret(testOTPSecret),
+	)
+	c := newTestClient(t, srv, WebSocketConfig{Password: "hunter2", OTPSecret: "JBSWY3DPEHPK3PXP"})
+
+	err := c.Connect(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "AUTH_ERR") {
+		t.Fatalf("expected authentication error, got %v", err)
+	}
+}
+
+func TestWebSocketClient_Call_ReauthenticatesExpiredSession(t *testing.T) {
+	srv := fakenas.NewServer(t,
+		fakenas.WithPassword("breakglass", "hunter2"),
+		fakenas.WithOTPSecret(testOTPSecret),
+	)
+	c := newTestClient(t, srv, WebSocketConfig{Password: "hunter2", OTPSecret: testOTPSecret})
+	ctx := context.Background()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	srv.ExpireSessions()
+	start := time.Now()
+	if _, err := c.Call(ctx, "system.info", nil); err != nil {
+		t.Fatalf("Call after session expiry: %v", err)
+	}
+	if srv.Logins() != 2 {
+		t.Errorf("expected a second login, got %d logins", srv.Logins())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("re-authentication should not back off, took %v", elapsed)
+	}
+}
+
+func TestWebSocketClient_Call_ReconnectsAfterDrop(t *testing.T) {
+	srv := fakenas.NewServer(t, fakenas.WithPassword("breakglass", "hunter2"))
+	c := newTestClient(t, srv, WebSocketConfig{Password: "hunter2"})
+	ctx := context.Background()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+
+	srv.DropConnections()
+	waitFor(t, func() bool { return !c.connAlive() })
+	if _, err := c.Call(ctx, "system.info", nil); err != nil {
+		t.Fatalf("Call after drop: %v", err)
+	}
+	if srv.Logins() != 2 {
+		t.Errorf("expected a second login, got %d logins", srv.Logins())
+	}
+}
+
+func TestWebSocketClient_PingsKeepIdleConnection(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	c := newTestClient(t, srv, WebSocketConfig{
+		APIKey:       srv.APIKey(),
+		PingInterval: 20 * time.Millisecond,
+		PingTimeout:  100 * time.Millisecond,
+	})
+	ctx := context.Background()
+	if _, err := c.Call(ctx, "system.info", nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if !c.connAlive() {
+		t.Fatal("expected the idle connection to stay open")
+	}
+	if _, err := c.Call(ctx, "system.info", nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("expected a single login, got %d", srv.Logins())
+	}
+}
+
+func TestWebSocketClient_Call_DoesNotRepeatLostMutation(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	srv.Handle("test.create", func(r *fakenas.Request) (any, error) {
+		srv.DropConnections()
+		return "created", nil
+	})
+	c := newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()})
+
+	_, err := c.Call(context.Background(), "test.create", nil)
+	if err == nil || !strings.Contains(err.Error(), "may have run") {
+		t.Fatalf("expected a lost call error, got %v", err)
+	}
+	if n := srv.CallCount("test.create"); n != 1 {
+		t.Errorf("expected the call to be sent once, got %d", n)
+	}
+}
+
+func TestWebSocketClient_CallAndWait_Job(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	srv.HandleJob("test.job", func(r *fakenas.Request) (any, error) {
+		var n int
+		if err := r.Arg(0, &n); err != nil {
+			return nil, err
+		}
+		return n * 2, nil
+	})
+	c := newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()})
+
+	result, err := c.CallAndWait(context.Background(), "test.job", 21)
+	if err != nil {
+		t.Fatalf("CallAndWait: %v", err)
+	}
+	if string(result) != "42" {
+		t.Errorf("unexpected result %s", result)
+	}
+}
+
+func TestWebSocketClient_CallAndWait_JobFailure(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	srv.HandleJob("test.job", func(r *fakenas.Request) (any, error) {
+		return nil, fakenas.Errorf(fakenas.EINVAL, "bad input")
+	})
+	c := newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()})
+
+	_, err := c.CallAndWait(context.Background(), "test.job", nil)
+	var tnErr *client.TrueNASError
+	if !errors.As(err, &tnErr) {
+		t.Fatalf("expected TrueNASError, got %T: %v", err, err)
+	}
+	if tnErr.Code != "EINVAL" {
+		t.Errorf("expected code EINVAL, got %q", tnErr.Code)
+	}
+}
+
+func TestWebSocketClient_CallAndWait_RetriesBusyJob(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	attempts := 0
+	srv.HandleJob("test.job", func(r *fakenas.Request) (any, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, fakenas.Errorf(fakenas.EBUSY, "dataset is busy")
+		}
+		return "done", nil
+	})
+	c := NewRateLimitedClient(newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()}), RateLimitConfig{MaxRetries: 1})
+
+	result, err := c.CallAndWait(context.Background(), "test.job", nil)
+	if err != nil {
+		t.Fatalf("CallAndWait: %v", err)
+	}
+	if string(result) != `"done"` || attempts != 2 {
+		t.Errorf("expected the job to succeed on the second attempt, got %s after %d attempts", result, attempts)
+	}
+}
+
+func TestWebSocketClient_Call_RetriesBusy(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	attempts := 0
+	srv.Handle("test.update", func(r *fakenas.Request) (any, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, fakenas.Errorf(fakenas.EBUSY, "dataset is busy")
+		}
+		return "updated", nil
+	})
+	c := NewRateLimitedClient(newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()}), RateLimitConfig{MaxRetries: 1})
+
+	result, err := c.Call(context.Background(), "test.update", nil)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if string(result) != `"updated"` || attempts != 2 {
+		t.Errorf("expected the call to succeed on the second attempt, got %s after %d attempts", result, attempts)
+	}
+}
+
+func TestWebSocketClient_CallAndWait_AbortsJobAtDeadline(t *testing.T) {
+	srv := fakenas.NewServer(t, fakenas.WithJobDelay(300*time.Millisecond))
+	ran := make(chan struct{}, 1)
+	srv.HandleJob("test.job", func(r *fakenas.Request) (any, error) {
+		ran <- struct{}{}
+		return "done", nil
+	})
+	c := newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.CallAndWait(ctx, "test.job", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if srv.CallCount("core.job_abort") != 1 {
+		t.Fatalf("expected the job to be aborted, got calls %v", srv.Calls())
+	}
+
+	select {
+	case <-ran:
+		t.Error("expected the aborted job not to run")
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestWebSocketClient_CallAndWait_AbortsJobOnCancel(t *testing.T) {
+	srv := fakenas.NewServer(t, fakenas.WithJobDelay(300*time.Millisecond))
+	srv.HandleJob("test.job", func(r *fakenas.Request) (any, error) {
+		return "done", nil
+	})
+	c := newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err := c.CallAndWait(ctx, "test.job", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation error, got %v", err)
+	}
+	if srv.CallCount("core.job_abort") != 1 {
+		t.Errorf("expected the job to be aborted, got calls %v", srv.Calls())
+	}
+}
+
+func TestWebSocketClient_CallAndWait_SurvivesReconnectMidJob(t *testing.T) {
+	srv := fakenas.NewServer(t,
+		fakenas.WithPassword("breakglass", "hunter2"),
+		fakenas.WithOTPSecret(testOTPSecret),
+		fakenas.WithJobDelay(300*time.Millisecond),
+	)
+	srv.HandleJob("test.job", func(r *fakenas.Request) (any, error) {
+		return "done", nil
+	})
+	c := newTestClient(t, srv, WebSocketConfig{Password: "hunter2", OTPSecret: testOTPSecret})
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		srv.DropConnections()
+	}()
+	result, err := c.CallAndWait(context.Background(), "test.job", nil)
+	if err != nil {
+		t.Fatalf("CallAndWait: %v", err)
+	}
+	if string(result) != `"done"` {
+		t.Errorf("unexpected result %s", result)
+	}
+	if srv.Logins() != 2 {
+		t.Errorf("expected a second login, got %d logins", srv.Logins())
+	}
+}
+
+func TestWebSocketClient_Subscribe_RestoredAfterReconnect(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	c := newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()})
+	ctx := context.Background()
+
+	sub, err := c.Subscribe(ctx, "cronjob.query", nil)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	srv.DropConnections()
+	waitFor(t, func() bool { return !c.connAlive() })
+	_, err = c.Call(ctx, "cronjob.create", map[string]any{
+		"user":     "root",
+		"command":  "true",
+		"schedule": map[string]any{"minute": "0", "hour": "*", "dom": "*", "month": "*", "dow": "*"},
+	})
+	if err != nil {
+		t.Fatalf("cronjob.create: %v", err)
+	}
+
+	select {
+	case event := <-sub.C:
+		var fields struct {
+			Command string `json:"command"`
+		}
+		if err := json.Unmarshal(event, &fields); err != nil || fields.Command != "true" {
+			t.Errorf("unexpected event %s", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received after reconnect")
+	}
+}
+
+func TestWebSocketClient_Close_FailsLaterCalls(t *testing.T) {
+	srv := fakenas.NewServer(t)
+	c := newTestClient(t, srv, WebSocketConfig{APIKey: srv.APIKey()})
+	if _, err := c.Call(context.Background(), "system.info", nil); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := c.Call(context.Background(), "system.info", nil); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+}
+
+// connAlive reports whether c holds a connection that was not lost.
+func (c *WebSocketClient) connAlive() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil && c.conn.alive()
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}